@@ -6,8 +6,10 @@ import (
 	"os"
 	"strings"
 
+	"github.com/ikadar/loom-cli/internal/analysis"
 	"github.com/ikadar/loom-cli/internal/claude"
 	"github.com/ikadar/loom-cli/internal/config"
+	"github.com/ikadar/loom-cli/internal/decisions"
 	"github.com/ikadar/loom-cli/internal/domain"
 	"github.com/ikadar/loom-cli/prompts"
 )
@@ -19,6 +21,7 @@ type AnalyzeResult struct {
 	Decisions     []domain.Decision   `json:"existing_decisions"`
 	InputFiles    []string            `json:"input_files"`
 	InputContent  string              `json:"input_content"`
+	Incremental   *analysis.Summary   `json:"incremental,omitempty"`
 }
 
 func runAnalyze() error {
@@ -44,13 +47,107 @@ func runAnalyze() error {
 	existingDecisions := loadDecisions(cfg.DecisionsFile)
 	fmt.Fprintf(os.Stderr, "  Loaded %d existing decisions\n", len(existingDecisions))
 
+	// Split input into sections and compare with the previous run
+	sections, err := analysis.SplitFiles(inputFiles)
+	if err != nil {
+		return err
+	}
+	cachePath := analysis.CachePath(cfg.InputFile, cfg.InputDir)
+	cache, err := analysis.LoadCache(cachePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: %v (running full analysis)\n", err)
+		cache = nil
+	}
+
+	var domainModel *domain.Domain
+	var allAmbiguities []domain.Ambiguity
+	var contributions []analysis.Contribution
+	var summary *analysis.Summary
+
+	switch {
+	case cfg.Full || cache == nil:
+		summary = &analysis.Summary{Mode: analysis.ModeFull, Reason: "no previous analysis"}
+		if cfg.Full {
+			summary.Reason = "--full"
+		}
+	default:
+		changes := analysis.DiffSections(cache.Sections, sections)
+		summary = &analysis.Summary{Mode: analysis.ModeIncremental, Changes: changes}
+		fmt.Fprintf(os.Stderr, "  Sections: %d (%s since last analysis)\n", len(sections), changes)
+		if changes.Empty() {
+			summary.Mode = analysis.ModeUnchanged
+		}
+	}
+
+	switch summary.Mode {
+	case analysis.ModeUnchanged:
+		fmt.Fprintln(os.Stderr, "\nPhase 1-2: Input unchanged, reusing previous analysis")
+		domainModel = cache.DomainModel
+		allAmbiguities = cache.Ambiguities
+		contributions = cache.Contributions
+
+	case analysis.ModeIncremental:
+		domainModel, allAmbiguities, contributions, err = analyzeIncremental(client, cache, sections, summary, existingDecisions)
+		if err != nil {
+			return err
+		}
+
+	default:
+		domainModel, allAmbiguities, contributions, err = analyzeFull(client, sections)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remember this run for the next incremental analysis
+	cache = &analysis.Cache{
+		Sections:      analysis.SectionHashes(sections),
+		DomainModel:   domainModel,
+		Contributions: contributions,
+		Ambiguities:   allAmbiguities,
+	}
+	if err := cache.Save(cachePath); err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: %v\n", err)
+	}
+
+	// === PHASE 3: Filter Already Resolved ===
+	fmt.Fprintln(os.Stderr, "\nPhase 3: Filtering resolved ambiguities...")
+
+	unresolvedAmbiguities := filterResolved(allAmbiguities, existingDecisions)
+	fmt.Fprintf(os.Stderr, "  Unresolved: %d (of %d total)\n", len(unresolvedAmbiguities), len(allAmbiguities))
+
+	// Output result as JSON to stdout
+	result := AnalyzeResult{
+		DomainModel:   domainModel,
+		Ambiguities:   unresolvedAmbiguities,
+		Decisions:     existingDecisions,
+		InputFiles:    inputFiles,
+		InputContent:  inputContent,
+		Incremental:   summary,
+	}
+
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	fmt.Println(string(output))
+	return nil
+}
+
+// analyzeFull runs domain discovery on each section and completeness
+// analysis over the merged model. Each section's model is recorded as its
+// own contribution, so a later incremental run only rediscovers the
+// sections that changed.
+func analyzeFull(client *claude.Client, sections []analysis.Section) (*domain.Domain, []domain.Ambiguity, []analysis.Contribution, error) {
 	// === PHASE 1: Domain Discovery ===
 	fmt.Fprintln(os.Stderr, "\nPhase 1: Discovering domain model...")
 
-	domainModel, err := discoverDomain(client, inputContent)
+	contributions, err := discoverSections(client, sections)
 	if err != nil {
-		return fmt.Errorf("domain discovery failed: %w", err)
+		return nil, nil, nil, err
 	}
+	domainModel := analysis.BuildDomain(contributions)
 
 	fmt.Fprintf(os.Stderr, "  Found: %d entities, %d operations, %d relationships\n",
 		len(domainModel.Entities),
@@ -62,41 +159,96 @@ func runAnalyze() error {
 
 	entityAmbiguities, err := analyzeEntities(client, domainModel.Entities)
 	if err != nil {
-		return fmt.Errorf("entity analysis failed: %w", err)
+		return nil, nil, nil, fmt.Errorf("entity analysis failed: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Entity ambiguities: %d\n", len(entityAmbiguities))
 
 	operationAmbiguities, err := analyzeOperations(client, domainModel.Operations)
 	if err != nil {
-		return fmt.Errorf("operation analysis failed: %w", err)
+		return nil, nil, nil, fmt.Errorf("operation analysis failed: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Operation ambiguities: %d\n", len(operationAmbiguities))
 
-	// Combine all ambiguities
-	allAmbiguities := append(entityAmbiguities, operationAmbiguities...)
+	return domainModel, append(entityAmbiguities, operationAmbiguities...), contributions, nil
+}
 
-	// === PHASE 3: Filter Already Resolved ===
-	fmt.Fprintln(os.Stderr, "\nPhase 3: Filtering resolved ambiguities...")
+// analyzeIncremental withdraws what changed and removed sections
+// contributed to the cached model, discovers the domain model of the added
+// and changed sections one by one and analyzes the entities and operations
+// that are new or changed. Ambiguities of untouched subjects are carried
+// over from the cache.
+func analyzeIncremental(client *claude.Client, cache *analysis.Cache, sections []analysis.Section, summary *analysis.Summary, existing []domain.Decision) (*domain.Domain, []domain.Ambiguity, []analysis.Contribution, error) {
+	// === PHASE 1: Domain Discovery (delta) ===
+	fmt.Fprintln(os.Stderr, "\nPhase 1: Discovering domain model of changed sections...")
+
+	contributions, rediscover := analysis.Withdraw(cache.Contributions, summary.Changes)
+	if len(rediscover) > 0 {
+		fmt.Fprintf(os.Stderr, "  Rediscovering %d unchanged section(s) analyzed together with changed ones\n", len(rediscover))
+	}
+	discovered, err := discoverSections(client, analysis.Select(sections, summary.Added, summary.Changed, rediscover))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	contributions = append(contributions, discovered...)
 
-	unresolvedAmbiguities := filterResolved(allAmbiguities, existingDecisions)
-	fmt.Fprintf(os.Stderr, "  Unresolved: %d (of %d total)\n", len(unresolvedAmbiguities), len(allAmbiguities))
+	domainModel := analysis.BuildDomain(contributions)
+	entities, operations := analysis.ChangedSubjects(cache.DomainModel, domainModel)
 
-	// Output result as JSON to stdout
-	result := AnalyzeResult{
-		DomainModel:   domainModel,
-		Ambiguities:   unresolvedAmbiguities,
-		Decisions:     existingDecisions,
-		InputFiles:    inputFiles,
-		InputContent:  inputContent,
+	fmt.Fprintf(os.Stderr, "  Found: %d entities, %d operations, %d relationships (%d/%d new or changed)\n",
+		len(domainModel.Entities),
+		len(domainModel.Operations),
+		len(domainModel.Relationships),
+		len(entities), len(operations))
+
+	// === PHASE 2: Completeness Analysis (delta) ===
+	fmt.Fprintln(os.Stderr, "\nPhase 2: Analyzing completeness of new and changed subjects...")
+
+	entityAmbiguities, err := analyzeEntities(client, entities)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("entity analysis failed: %w", err)
 	}
+	fmt.Fprintf(os.Stderr, "  Entity ambiguities: %d\n", len(entityAmbiguities))
 
-	output, err := json.MarshalIndent(result, "", "  ")
+	operationAmbiguities, err := analyzeOperations(client, operations)
 	if err != nil {
-		return fmt.Errorf("failed to marshal result: %w", err)
+		return nil, nil, nil, fmt.Errorf("operation analysis failed: %w", err)
 	}
+	fmt.Fprintf(os.Stderr, "  Operation ambiguities: %d\n", len(operationAmbiguities))
 
-	fmt.Println(string(output))
-	return nil
+	kept := analysis.RetainAmbiguities(cache.Ambiguities, domainModel, entities, operations)
+	fmt.Fprintf(os.Stderr, "  Carried over: %d\n", len(kept))
+
+	// Avoid ID clashes with carried-over questions and recorded decisions
+	var usedIDs []string
+	for _, a := range kept {
+		usedIDs = append(usedIDs, a.ID)
+	}
+	for _, d := range existing {
+		usedIDs = append(usedIDs, d.ID)
+	}
+	fresh := analysis.RenumberAmbiguities(append(entityAmbiguities, operationAmbiguities...), usedIDs)
+
+	for _, e := range entities {
+		summary.ReanalyzedEntities = append(summary.ReanalyzedEntities, e.Name)
+	}
+	for _, op := range operations {
+		summary.ReanalyzedOperations = append(summary.ReanalyzedOperations, op.Name)
+	}
+
+	return domainModel, append(kept, fresh...), contributions, nil
+}
+
+// discoverSections discovers the domain model of each section separately
+func discoverSections(client *claude.Client, sections []analysis.Section) ([]analysis.Contribution, error) {
+	contributions := make([]analysis.Contribution, 0, len(sections))
+	for _, s := range sections {
+		model, err := discoverDomain(client, analysis.JoinSections([]analysis.Section{s}))
+		if err != nil {
+			return nil, fmt.Errorf("domain discovery failed: %w", err)
+		}
+		contributions = append(contributions, analysis.Contribution{Sections: []string{s.ID}, DomainModel: model})
+	}
+	return contributions, nil
 }
 
 // Phase 1: Domain Discovery
@@ -171,43 +323,12 @@ func filterResolved(ambiguities []domain.Ambiguity, decisions []domain.Decision)
 	return unresolved
 }
 
-// Load existing decisions from decisions.md
+// Load existing decisions from decisions.md (via its decision store)
 func loadDecisions(path string) []domain.Decision {
-	content, err := os.ReadFile(path)
+	ds, err := decisions.Load(path)
 	if err != nil {
-		return nil // File doesn't exist, return empty
-	}
-
-	var decisions []domain.Decision
-	lines := strings.Split(string(content), "\n")
-
-	var currentDecision *domain.Decision
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if strings.HasPrefix(line, "- **AMB-") {
-			if currentDecision != nil && currentDecision.Question != "" {
-				decisions = append(decisions, *currentDecision)
-			}
-			currentDecision = &domain.Decision{
-				Source: "existing",
-			}
-			end := strings.Index(line, ":")
-			if end > 6 {
-				currentDecision.ID = line[4:end]
-			}
-		} else if currentDecision != nil {
-			if strings.HasPrefix(line, "- Q:") {
-				currentDecision.Question = strings.TrimSpace(line[4:])
-			} else if strings.HasPrefix(line, "- A:") {
-				currentDecision.Answer = strings.TrimSpace(line[4:])
-			}
-		}
+		fmt.Fprintf(os.Stderr, "  Warning: %v\n", err)
+		return nil
 	}
-
-	if currentDecision != nil && currentDecision.Question != "" {
-		decisions = append(decisions, *currentDecision)
-	}
-
-	return decisions
+	return ds.ToDomain()
 }
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+)
+
+// ArtifactConfig holds configuration for the artifact command
+type ArtifactConfig struct {
+	Action     string // deprecate, split, merge, delete
+	ProjectDir string
+	IDs        []string // artifacts acted on (positional args)
+	Into       []string // split: new IDs; merge: target ID
+	ReplacedBy []string // deprecate: replacement IDs
+	Reason     string   // deprecate: note left in the document
+	Cascade    bool     // delete: also delete orphaned downstream artifacts
+	Format     string   // output format: text, json
+}
+
+func runArtifact() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: loom-cli artifact <deprecate|split|merge|delete> <ID> [options]")
+	}
+	action := os.Args[2]
+	switch action {
+	case "deprecate", "split", "merge", "delete":
+	default:
+		return fmt.Errorf("unknown artifact action: %s (expected deprecate, split, merge or delete)", action)
+	}
+
+	artifactFlags := flag.NewFlagSet("artifact "+action, flag.ExitOnError)
+	projectDir := artifactFlags.String("project-dir", ".", "Project root directory")
+	into := artifactFlags.String("into", "", "New IDs to split into, or the ID to merge into (comma-separated)")
+	replacedBy := artifactFlags.String("replaced-by", "", "IDs replacing the deprecated artifact (comma-separated)")
+	reason := artifactFlags.String("reason", "", "Reason noted in the document")
+	cascade := artifactFlags.Bool("cascade", false, "Also delete downstream artifacts left without upstream")
+	format := artifactFlags.String("format", "text", "Output format (text, json)")
+
+	ids := parseArgs(artifactFlags, os.Args[3:])
+
+	return executeArtifact(&ArtifactConfig{
+		Action:     action,
+		ProjectDir: *projectDir,
+		IDs:        ids,
+		Into:       splitIDs(*into),
+		ReplacedBy: splitIDs(*replacedBy),
+		Reason:     *reason,
+		Cascade:    *cascade,
+		Format:     *format,
+	})
+}
+
+func executeArtifact(cfg *ArtifactConfig) error {
+	sm := derivation.NewStateManager(cfg.ProjectDir)
+	if !sm.Store.Exists() {
+		return fmt.Errorf("no derivation state found in %s (run 'loom-cli init' first)", sm.LoomDir)
+	}
+
+	var result *derivation.LifecycleResult
+	var err error
+	switch cfg.Action {
+	case "deprecate":
+		if len(cfg.IDs) != 1 {
+			return fmt.Errorf("usage: loom-cli artifact deprecate <ID> [--replaced-by IDs] [--reason text]")
+		}
+		result, err = sm.Deprecate(cfg.IDs[0], cfg.ReplacedBy, cfg.Reason)
+	case "split":
+		if len(cfg.IDs) != 1 || len(cfg.Into) < 2 {
+			return fmt.Errorf("usage: loom-cli artifact split <ID> --into <ID>,<ID>[,...]")
+		}
+		result, err = sm.Split(cfg.IDs[0], cfg.Into)
+	case "merge":
+		if len(cfg.IDs) == 0 || len(cfg.Into) != 1 {
+			return fmt.Errorf("usage: loom-cli artifact merge <ID>... --into <ID>")
+		}
+		result, err = sm.Merge(cfg.IDs, cfg.Into[0])
+	case "delete":
+		if len(cfg.IDs) != 1 {
+			return fmt.Errorf("usage: loom-cli artifact delete <ID> [--cascade]")
+		}
+		result, err = sm.Delete(cfg.IDs[0], cfg.Cascade)
+	}
+	if err != nil {
+		return fmt.Errorf("artifact %s failed: %w", cfg.Action, err)
+	}
+
+	if cfg.Format == "json" {
+		outputJSON(result)
+		return nil
+	}
+
+	printIDs := func(label string, ids []string) {
+		if len(ids) > 0 {
+			fmt.Printf("%-12s %s\n", label+":", strings.Join(ids, ", "))
+		}
+	}
+	printIDs("Deprecated", result.Deprecated)
+	printIDs("Created", result.Created)
+	printIDs("Deleted", result.Deleted)
+	printIDs("Stale", result.Stale)
+	printIDs("Orphaned", result.Orphaned)
+	printIDs("Affected", result.Affected)
+	for _, path := range result.Files {
+		fmt.Printf("  Updated: %s\n", path)
+	}
+	if len(result.Stale) > 0 {
+		fmt.Println("\nRun 'loom-cli rederive --all' to derive the stale artifacts again.")
+	}
+	fmt.Printf("Recorded as run %s.\n", result.Run.ID)
+	return nil
+}
+
+// splitIDs splits a comma-separated list of IDs
+func splitIDs(list string) []string {
+	var ids []string
+	for _, id := range strings.Split(list, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
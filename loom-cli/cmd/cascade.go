@@ -5,9 +5,15 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/ikadar/loom-cli/internal/analysis"
+	"github.com/ikadar/loom-cli/internal/config"
 )
 
 // CascadeConfig holds configuration for the cascade command
@@ -20,12 +26,14 @@ type CascadeConfig struct {
 	Interactive   bool
 	Resume        bool
 	FromLevel     string
+	Full          bool
 }
 
 // CascadeState tracks the progress of cascade derivation
 type CascadeState struct {
 	Version   string                  `json:"version"`
 	InputHash string                  `json:"input_hash"`
+	InputSections map[string]string   `json:"input_sections,omitempty"`
 	Phases    map[string]*PhaseState  `json:"phases"`
 	Config    CascadeStateConfig      `json:"config"`
 	Timestamps struct {
@@ -38,6 +46,7 @@ type PhaseState struct {
 	Status    string    `json:"status"` // pending, running, completed, failed
 	Timestamp time.Time `json:"timestamp,omitempty"`
 	Error     string    `json:"error,omitempty"`
+	InputHash string    `json:"input_hash,omitempty"` // what the phase last ran on
 }
 
 type CascadeStateConfig struct {
@@ -81,6 +90,17 @@ func runCascade() error {
 		state = newCascadeState(cfg)
 	}
 
+	// A resumed run re-runs the phases whose input changed since they
+	// last ran (see refreshPhase). Analyze itself only re-analyzes the
+	// changed sections.
+	sections := cascadeInputSections(cfg)
+	if cfg.Resume && state.InputHash != computeSectionsHash(sections) {
+		changes := analysis.DiffSections(state.InputSections, sections)
+		fmt.Fprintf(os.Stderr, "Input changed since last run (%s sections), re-running the phases it affects\n", changes)
+	}
+	state.InputHash = computeSectionsHash(sections)
+	state.InputSections = analysis.SectionHashes(sections)
+
 	// Check if we should skip to a specific level
 	if cfg.FromLevel != "" {
 		resetFromLevel(state, cfg.FromLevel)
@@ -91,6 +111,7 @@ func runCascade() error {
 	fmt.Fprintf(os.Stderr, "╚══════════════════════════════════════════════════════════════╝\n\n")
 
 	// Phase 1: Analyze
+	refreshPhase(cfg, state, "analyze", sections)
 	if shouldRunPhase(state, "analyze", cfg) {
 		fmt.Fprintf(os.Stderr, "━━━ Phase 1/5: Analyze ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 		if err := runCascadeAnalyze(cfg, state); err != nil {
@@ -101,6 +122,7 @@ func runCascade() error {
 	}
 
 	// Phase 2: Interview (optional)
+	refreshPhase(cfg, state, "interview", sections)
 	if !cfg.SkipInterview && shouldRunPhase(state, "interview", cfg) {
 		fmt.Fprintf(os.Stderr, "\n━━━ Phase 2/5: Interview ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 		if err := runCascadeInterview(cfg, state); err != nil {
@@ -115,6 +137,7 @@ func runCascade() error {
 	}
 
 	// Phase 3: Derive L1
+	refreshPhase(cfg, state, "derive-l1", sections)
 	if shouldRunPhase(state, "derive-l1", cfg) {
 		fmt.Fprintf(os.Stderr, "\n━━━ Phase 3/5: Derive L1 (Strategic Design) ━━━━━━━━━━━━━━━━━━\n")
 		if err := runCascadeDeriveL1(cfg, state); err != nil {
@@ -125,6 +148,7 @@ func runCascade() error {
 	}
 
 	// Phase 4: Derive L2
+	refreshPhase(cfg, state, "derive-l2", sections)
 	if shouldRunPhase(state, "derive-l2", cfg) {
 		fmt.Fprintf(os.Stderr, "\n━━━ Phase 4/5: Derive L2 (Tactical Design) ━━━━━━━━━━━━━━━━━━━\n")
 		if err := runCascadeDeriveL2(cfg, state); err != nil {
@@ -135,6 +159,7 @@ func runCascade() error {
 	}
 
 	// Phase 5: Derive L3
+	refreshPhase(cfg, state, "derive-l3", sections)
 	if shouldRunPhase(state, "derive-l3", cfg) {
 		fmt.Fprintf(os.Stderr, "\n━━━ Phase 5/5: Derive L3 (Operational Design) ━━━━━━━━━━━━━━━━\n")
 		if err := runCascadeDeriveL3(cfg, state); err != nil {
@@ -199,6 +224,8 @@ func parseCascadeArgs() (*CascadeConfig, error) {
 				cfg.FromLevel = args[i+1]
 				i++
 			}
+		case "--full":
+			cfg.Full = true
 		}
 	}
 
@@ -230,14 +257,29 @@ func newCascadeState(cfg *CascadeConfig) *CascadeState {
 }
 
 func computeInputHash(cfg *CascadeConfig) string {
-	h := sha256.New()
-	if cfg.InputFile != "" {
-		if content, err := os.ReadFile(cfg.InputFile); err == nil {
-			h.Write(content)
-		}
+	return computeSectionsHash(cascadeInputSections(cfg))
+}
+
+// cascadeInputSections splits the L0 input into hashed sections. Unreadable
+// input yields no sections; analyze reports the actual error.
+func cascadeInputSections(cfg *CascadeConfig) []analysis.Section {
+	inputCfg := &config.Config{InputFile: cfg.InputFile, InputDir: cfg.InputDir}
+	_, files, err := inputCfg.ReadInputFiles()
+	if err != nil {
+		return nil
 	}
-	if cfg.InputDir != "" {
-		h.Write([]byte(cfg.InputDir))
+	sections, err := analysis.SplitFiles(files)
+	if err != nil {
+		return nil
+	}
+	return sections
+}
+
+// computeSectionsHash combines the section hashes into one input hash
+func computeSectionsHash(sections []analysis.Section) string {
+	h := sha256.New()
+	for _, s := range sections {
+		h.Write([]byte(s.ID + "=" + s.Hash + "\n"))
 	}
 	return hex.EncodeToString(h.Sum(nil))[:16]
 }
@@ -278,6 +320,101 @@ func shouldRunPhase(state *CascadeState, phase string, cfg *CascadeConfig) bool
 	return ps == nil || ps.Status != "completed"
 }
 
+// refreshPhase records the hash of a phase's input and, on a resumed run,
+// marks the phase pending when that input changed since it last ran
+func refreshPhase(cfg *CascadeConfig, state *CascadeState, phase string, sections []analysis.Section) {
+	ps := state.Phases[phase]
+	if ps == nil {
+		return
+	}
+	hash := phaseInputHash(cfg, phase, sections)
+	if cfg.Resume && ps.Status == "completed" && ps.InputHash != hash {
+		fmt.Fprintf(os.Stderr, "Input of %s changed since it last ran\n", phase)
+		ps.Status = "pending"
+		ps.Error = ""
+	}
+	ps.InputHash = hash
+}
+
+// phaseInputHash hashes what a phase reads: the L0 sections and decisions
+// for analyze, the analysis findings for the interview, the interview
+// state and L0 for derive-l1, and the previous layer for derive-l2 and
+// derive-l3
+func phaseInputHash(cfg *CascadeConfig, phase string, sections []analysis.Section) string {
+	h := sha256.New()
+	switch phase {
+	case "analyze":
+		h.Write([]byte(computeSectionsHash(sections)))
+		hashFile(h, cfg.DecisionsFile)
+	case "interview":
+		hashFindings(h, filepath.Join(cfg.OutputDir, ".analysis.json"))
+	case "derive-l1":
+		h.Write([]byte(computeSectionsHash(sections)))
+		hashFile(h, cfg.DecisionsFile)
+		stateFile := filepath.Join(cfg.OutputDir, ".interview-state.json")
+		if _, err := os.Stat(stateFile); os.IsNotExist(err) {
+			stateFile = filepath.Join(cfg.OutputDir, ".analysis.json")
+		}
+		hashFile(h, stateFile)
+	case "derive-l2":
+		hashDir(h, filepath.Join(cfg.OutputDir, "l1"))
+	case "derive-l3":
+		hashDir(h, filepath.Join(cfg.OutputDir, "l2"))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// hashFindings hashes the domain model and ambiguities of an analysis,
+// leaving out the input text and run summary that change on every edit
+func hashFindings(h io.Writer, path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var result AnalyzeResult
+	if err := json.Unmarshal(content, &result); err != nil {
+		h.Write(content)
+		return
+	}
+	findings, _ := json.Marshal(struct {
+		DomainModel interface{}
+		Ambiguities interface{}
+		Decisions   interface{}
+	}{result.DomainModel, result.Ambiguities, result.Decisions})
+	h.Write(findings)
+}
+
+func hashFile(h io.Writer, path string) {
+	if path == "" {
+		return
+	}
+	if content, err := os.ReadFile(path); err == nil {
+		h.Write(content)
+	}
+}
+
+// hashDir hashes the files of a layer directory, skipping hidden ones
+func hashDir(h io.Writer, dir string) {
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		h.Write([]byte(filepath.ToSlash(rel) + "\n"))
+		hashFile(h, path)
+		return nil
+	})
+}
+
 func resetFromLevel(state *CascadeState, level string) {
 	levels := []string{"l1", "l2", "l3"}
 	phases := []string{"derive-l1", "derive-l2", "derive-l3"}
@@ -314,6 +451,9 @@ func runCascadeAnalyze(cfg *CascadeConfig, state *CascadeState) error {
 	if cfg.DecisionsFile != "" {
 		analyzeArgs = append(analyzeArgs, "--decisions", cfg.DecisionsFile)
 	}
+	if cfg.Full {
+		analyzeArgs = append(analyzeArgs, "--full")
+	}
 
 	// Save original args and restore after
 	origArgs := os.Args
@@ -402,6 +542,12 @@ func runCascadeDeriveL1(cfg *CascadeConfig, state *CascadeState) error {
 	if cfg.DecisionsFile != "" {
 		os.Args = append(os.Args, "--decisions", cfg.DecisionsFile)
 	}
+	if cfg.InputFile != "" {
+		os.Args = append(os.Args, "--input-file", cfg.InputFile)
+	}
+	if cfg.InputDir != "" {
+		os.Args = append(os.Args, "--input-dir", cfg.InputDir)
+	}
 
 	err := runDeriveNew()
 	os.Args = origArgs
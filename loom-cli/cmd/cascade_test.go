@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/analysis"
+)
+
+func TestRefreshPhase_OnlyChangedInputs(t *testing.T) {
+	outputDir := t.TempDir()
+	for _, dir := range []string{"l1", "l2"} {
+		if err := os.MkdirAll(filepath.Join(outputDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(outputDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".analysis.json", `{"domain_model": {"entities": [{"name": "Order"}]}, "input_content": "v1"}`)
+	write("l1/acceptance-criteria.md", "## AC-ORD-001\n")
+	write("l2/tech-specs.md", "## TS-ORD-001\n")
+
+	cfg := &CascadeConfig{OutputDir: outputDir, Resume: true}
+	sections := []analysis.Section{{ID: "stories.md#us-001", Hash: "sha256:a"}}
+	state := newCascadeState(cfg)
+	for phase, ps := range state.Phases {
+		refreshPhase(cfg, state, phase, sections)
+		ps.Status = "completed"
+	}
+
+	// A changed story, an analysis differing only in its input text and
+	// an edited L1 document
+	sections[0].Hash = "sha256:b"
+	write(".analysis.json", `{"domain_model": {"entities": [{"name": "Order"}]}, "input_content": "v2"}`)
+	write("l1/acceptance-criteria.md", "## AC-ORD-001\n\nEdited\n")
+
+	want := map[string]string{
+		"analyze":   "pending",
+		"interview": "completed",
+		"derive-l1": "pending",
+		"derive-l2": "pending",
+		"derive-l3": "completed",
+	}
+	for _, phase := range []string{"analyze", "interview", "derive-l1", "derive-l2", "derive-l3"} {
+		refreshPhase(cfg, state, phase, sections)
+		if got := state.Phases[phase].Status; got != want[phase] {
+			t.Errorf("Phase %s: expected %s, got %s", phase, want[phase], got)
+		}
+	}
+}
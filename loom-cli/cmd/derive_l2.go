@@ -10,6 +10,7 @@ import (
 
 	"github.com/ikadar/loom-cli/internal/checkpoint"
 	"github.com/ikadar/loom-cli/internal/claude"
+	"github.com/ikadar/loom-cli/internal/derivation"
 	"github.com/ikadar/loom-cli/internal/formatter"
 	"github.com/ikadar/loom-cli/internal/generator"
 	"github.com/ikadar/loom-cli/internal/workflow"
@@ -594,15 +595,22 @@ func runDeriveL2() error {
 	// Track which files were written (for interactive mode skips)
 	writtenFiles := make(map[string]bool)
 
+	// The documents and their derivation state are written together
+	tx, err := beginDerivation(outputDir)
+	if err != nil {
+		return err
+	}
+	defer tx.Abort()
+
 	// Write Tech Specs
 	fmt.Fprintln(os.Stderr, "\nPhase L2-W1: Writing Tech Specs...")
 
 	tsPath := filepath.Join(outputDir, "tech-specs.md")
-	if err := writeTechSpecs(tsPath, result.TechSpecs); err != nil {
+	if err := writeTechSpecs(tx, tsPath, result.TechSpecs); err != nil {
 		return fmt.Errorf("failed to write tech specs: %w", err)
 	}
 
-	tsWriteResult, _, err := workflow.HandleFileApproval(tsPath, "Tech Specs", len(result.TechSpecs), "tech specs", "", interactive)
+	tsWriteResult, _, err := workflow.HandleFileApproval(tx, tsPath, "Tech Specs", len(result.TechSpecs), "tech specs", "", interactive)
 	if err != nil {
 		return err
 	}
@@ -614,12 +622,12 @@ func runDeriveL2() error {
 	fmt.Fprintln(os.Stderr, "\nPhase L2-W2: Writing Interface Contracts...")
 
 	icPath := filepath.Join(outputDir, "interface-contracts.md")
-	if err := writeInterfaceContracts(icPath, icResult.InterfaceContracts, icResult.SharedTypes); err != nil {
+	if err := writeInterfaceContracts(tx, icPath, icResult.InterfaceContracts, icResult.SharedTypes); err != nil {
 		return fmt.Errorf("failed to write interface contracts: %w", err)
 	}
 
 	icSummary := fmt.Sprintf("(%d operations)", icResult.Summary.TotalOperations)
-	icWriteResult, _, err := workflow.HandleFileApproval(icPath, "Interface Contracts", len(icResult.InterfaceContracts), "contracts", icSummary, interactive)
+	icWriteResult, _, err := workflow.HandleFileApproval(tx, icPath, "Interface Contracts", len(icResult.InterfaceContracts), "contracts", icSummary, interactive)
 	if err != nil {
 		return err
 	}
@@ -631,12 +639,12 @@ func runDeriveL2() error {
 	fmt.Fprintln(os.Stderr, "\nPhase L2-W3: Writing Aggregate Design...")
 
 	aggPath := filepath.Join(outputDir, "aggregate-design.md")
-	if err := writeAggregateDesign(aggPath, aggResult.Aggregates); err != nil {
+	if err := writeAggregateDesign(tx, aggPath, aggResult.Aggregates); err != nil {
 		return fmt.Errorf("failed to write aggregate design: %w", err)
 	}
 
 	aggSummary := fmt.Sprintf("(%d behaviors)", aggResult.Summary.TotalBehaviors)
-	aggWriteResult, _, err := workflow.HandleFileApproval(aggPath, "Aggregate Design", len(aggResult.Aggregates), "aggregates", aggSummary, interactive)
+	aggWriteResult, _, err := workflow.HandleFileApproval(tx, aggPath, "Aggregate Design", len(aggResult.Aggregates), "aggregates", aggSummary, interactive)
 	if err != nil {
 		return err
 	}
@@ -648,11 +656,11 @@ func runDeriveL2() error {
 	fmt.Fprintln(os.Stderr, "\nPhase L2-W4: Writing Sequence Design...")
 
 	seqPath := filepath.Join(outputDir, "sequence-design.md")
-	if err := writeSequenceDesign(seqPath, seqResult.Sequences); err != nil {
+	if err := writeSequenceDesign(tx, seqPath, seqResult.Sequences); err != nil {
 		return fmt.Errorf("failed to write sequence design: %w", err)
 	}
 
-	seqWriteResult, _, err := workflow.HandleFileApproval(seqPath, "Sequence Design", len(seqResult.Sequences), "sequences", "", interactive)
+	seqWriteResult, _, err := workflow.HandleFileApproval(tx, seqPath, "Sequence Design", len(seqResult.Sequences), "sequences", "", interactive)
 	if err != nil {
 		return err
 	}
@@ -664,12 +672,12 @@ func runDeriveL2() error {
 	fmt.Fprintln(os.Stderr, "\nPhase L2-W5: Writing Data Model...")
 
 	dataPath := filepath.Join(outputDir, "initial-data-model.md")
-	if err := writeDataModel(dataPath, dataResult.Tables, dataResult.Enums); err != nil {
+	if err := writeDataModel(tx, dataPath, dataResult.Tables, dataResult.Enums); err != nil {
 		return fmt.Errorf("failed to write data model: %w", err)
 	}
 
 	dataSummary := fmt.Sprintf("(%d indexes)", dataResult.Summary.TotalIndexes)
-	dataWriteResult, _, err := workflow.HandleFileApproval(dataPath, "Initial Data Model", len(dataResult.Tables), "tables", dataSummary, interactive)
+	dataWriteResult, _, err := workflow.HandleFileApproval(tx, dataPath, "Initial Data Model", len(dataResult.Tables), "tables", dataSummary, interactive)
 	if err != nil {
 		return err
 	}
@@ -689,11 +697,26 @@ func runDeriveL2() error {
 		"enums":               dataResult.Enums,
 	}
 	jsonContent, _ := json.MarshalIndent(l2Output, "", "  ")
-	if err := os.WriteFile(jsonPath, jsonContent, 0644); err != nil {
+	if err := tx.WriteFile(jsonPath, jsonContent); err != nil {
 		return fmt.Errorf("failed to write JSON output: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", jsonPath)
 
+	// Mark the written documents and record them in the derivation state
+	rec := derivation.NewRecorder(stateProjectDir(outputDir), "l2")
+	rec.Tx = tx
+	for _, path := range []string{tsPath, icPath, aggPath, seqPath, dataPath} {
+		if !writtenFiles[path] {
+			continue
+		}
+		if err := rec.Record(path); err != nil {
+			return err
+		}
+	}
+	if err := commitRecorder(rec); err != nil {
+		return err
+	}
+
 	// Print summary
 	fmt.Fprintln(os.Stderr, "\n========================================")
 	fmt.Fprintln(os.Stderr, "   L2 DERIVATION COMPLETE")
@@ -722,11 +745,11 @@ func runDeriveL2() error {
 	return nil
 }
 
-func writeTechSpecs(path string, techSpecs []TechSpec) error {
+func writeTechSpecs(tx *derivation.Transaction, path string, techSpecs []TechSpec) error {
 	timestamp := time.Now().Format(time.RFC3339)
 	fmtSpecs := convertTechSpecsToFormatter(techSpecs)
 	content := formatter.FormatTechSpecs(fmtSpecs, timestamp)
-	return os.WriteFile(path, []byte(content), 0644)
+	return tx.WriteFile(path, []byte(content))
 }
 
 // convertTechSpecsToFormatter converts local TechSpec slice to formatter types
@@ -766,32 +789,32 @@ func convertTechSpecsToFormatter(techSpecs []TechSpec) []formatter.TechSpec {
 	return result
 }
 
-func writeInterfaceContracts(path string, contracts []InterfaceContract, sharedTypes []SharedType) error {
+func writeInterfaceContracts(tx *derivation.Transaction, path string, contracts []InterfaceContract, sharedTypes []SharedType) error {
 	timestamp := time.Now().Format(time.RFC3339)
 	fmtContracts := convertContractsToFormatter(contracts)
 	fmtSharedTypes := convertSharedTypesToFormatter(sharedTypes)
 	content := formatter.FormatInterfaceContracts(fmtContracts, fmtSharedTypes, timestamp)
-	return os.WriteFile(path, []byte(content), 0644)
+	return tx.WriteFile(path, []byte(content))
 }
 
-func writeAggregateDesign(path string, aggregates []AggregateDesign) error {
+func writeAggregateDesign(tx *derivation.Transaction, path string, aggregates []AggregateDesign) error {
 	timestamp := time.Now().Format(time.RFC3339)
 	fmtAggs := convertAggregatesToFormatter(aggregates)
 	content := formatter.FormatAggregateDesign(fmtAggs, timestamp)
-	return os.WriteFile(path, []byte(content), 0644)
+	return tx.WriteFile(path, []byte(content))
 }
 
-func writeSequenceDesign(path string, sequences []SequenceDesign) error {
+func writeSequenceDesign(tx *derivation.Transaction, path string, sequences []SequenceDesign) error {
 	timestamp := time.Now().Format(time.RFC3339)
 	fmtSeqs := convertSequencesToFormatter(sequences)
 	content := formatter.FormatSequenceDesign(fmtSeqs, timestamp)
-	return os.WriteFile(path, []byte(content), 0644)
+	return tx.WriteFile(path, []byte(content))
 }
 
-func writeDataModel(path string, tables []DataTable, enums []DataEnum) error {
+func writeDataModel(tx *derivation.Transaction, path string, tables []DataTable, enums []DataEnum) error {
 	timestamp := time.Now().Format(time.RFC3339)
 	fmtTables := convertTablesToFormatter(tables)
 	fmtEnums := convertEnumsToFormatter(enums)
 	content := formatter.FormatDataModel(fmtTables, fmtEnums, timestamp)
-	return os.WriteFile(path, []byte(content), 0644)
+	return tx.WriteFile(path, []byte(content))
 }
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -8,17 +9,28 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ikadar/loom-cli/internal/apispec"
 	"github.com/ikadar/loom-cli/internal/claude"
+	"github.com/ikadar/loom-cli/internal/derivation"
 	"github.com/ikadar/loom-cli/internal/formatter"
 	"github.com/ikadar/loom-cli/internal/generator"
+	"github.com/ikadar/loom-cli/internal/spec"
 	"github.com/ikadar/loom-cli/prompts"
 )
 
 // L3Result is the output of the derive-l3 command
 type L3Result struct {
-	APISpec                 APISpec                  `json:"api_spec"`
+	APISpec                 interface{}              `json:"api_spec"` // *apispec.Document, or APISpec from the LLM fallback
 	ImplementationSkeletons []ImplementationSkeleton `json:"implementation_skeletons"`
 	Summary                 L3Summary                `json:"summary"`
+
+	// Event design, kept machine-readable for gen-asyncapi
+	DomainEvents      []formatter.DomainEvent      `json:"domain_events,omitempty"`
+	Commands          []formatter.Command          `json:"commands,omitempty"`
+	IntegrationEvents []formatter.IntegrationEvent `json:"integration_events,omitempty"`
+
+	// FeatureTickets are kept machine-readable for tickets export/sync
+	FeatureTickets []formatter.FeatureTicket `json:"feature_tickets,omitempty"`
 }
 
 type L3Summary struct {
@@ -27,6 +39,8 @@ type L3Summary struct {
 	FunctionsCount int `json:"functions_count"`
 }
 
+// APISpec is the free-form OpenAPI document returned by the LLM when no
+// structured interface contracts are available
 type APISpec struct {
 	OpenAPI string                 `json:"openapi"`
 	Info    map[string]interface{} `json:"info"`
@@ -49,22 +63,6 @@ type FunctionSpec struct {
 	ErrorCases  []string `json:"error_cases"`
 }
 
-// Feature Ticket types
-type FeatureTicket struct {
-	ID                    string   `json:"id"`
-	Title                 string   `json:"title"`
-	Status                string   `json:"status"`
-	BusinessGoal          string   `json:"business_goal"`
-	UserStory             string   `json:"user_story"`
-	AcceptanceCriteriaRefs []string `json:"acceptance_criteria_refs"`
-	NFR                   []string `json:"nfr"`
-	Dependencies          []string `json:"dependencies"`
-	ImpactAreas           []string `json:"impact_areas"`
-	OutOfScope            []string `json:"out_of_scope"`
-	Priority              string   `json:"priority"`
-	EstimatedComplexity   string   `json:"estimated_complexity"`
-}
-
 // Service Boundary types
 type ServiceBoundary struct {
 	ID               string              `json:"id"`
@@ -90,42 +88,6 @@ type ServiceDependency struct {
 	Reason  string `json:"reason"`
 }
 
-// Event Design types
-type DomainEvent struct {
-	ID                  string         `json:"id"`
-	Name                string         `json:"name"`
-	Purpose             string         `json:"purpose"`
-	Trigger             string         `json:"trigger"`
-	Aggregate           string         `json:"aggregate"`
-	Payload             []EventField   `json:"payload"`
-	InvariantsReflected []string       `json:"invariants_reflected"`
-	Consumers           []string       `json:"consumers"`
-	Version             string         `json:"version"`
-}
-
-type EventField struct {
-	Field string `json:"field"`
-	Type  string `json:"type"`
-}
-
-type Command struct {
-	ID                string       `json:"id"`
-	Name              string       `json:"name"`
-	Intent            string       `json:"intent"`
-	RequiredData      []EventField `json:"required_data"`
-	ExpectedOutcome   string       `json:"expected_outcome"`
-	FailureConditions []string     `json:"failure_conditions"`
-}
-
-type IntegrationEvent struct {
-	ID        string   `json:"id"`
-	Name      string   `json:"name"`
-	Purpose   string   `json:"purpose"`
-	Source    string   `json:"source"`
-	Consumers []string `json:"consumers"`
-	Payload   []string `json:"payload"`
-}
-
 // Dependency Graph types
 type GraphComponent struct {
 	ID          string `json:"id"`
@@ -241,14 +203,12 @@ func runDeriveL3() error {
 	// Phase L3-2a: Generate API Spec
 	fmt.Fprintln(os.Stderr, "\nPhase L3-2a: Generating API Specification...")
 
-	apiPrompt := prompts.DeriveL3API + "\n\n" + string(tsContent)
-
-	var apiResult APISpec
-	if err := client.CallJSON(apiPrompt, &apiResult); err != nil {
-		return fmt.Errorf("failed to generate API spec: %w", err)
+	apiDoc, endpointCount, err := deriveL3APISpec(client, inputDir, tsContent)
+	if err != nil {
+		return err
 	}
 
-	fmt.Fprintf(os.Stderr, "  Generated: %d endpoints\n", len(apiResult.Paths))
+	fmt.Fprintf(os.Stderr, "  Generated: %d endpoints\n", endpointCount)
 
 	// Phase L3-2b: Generate Implementation Skeletons
 	fmt.Fprintln(os.Stderr, "\nPhase L3-2b: Generating Implementation Skeletons...")
@@ -270,9 +230,9 @@ func runDeriveL3() error {
 
 	// Combine into L3Result
 	var result L3Result
-	result.APISpec = apiResult
+	result.APISpec = apiDoc
 	result.ImplementationSkeletons = skelResult.ImplementationSkeletons
-	result.Summary.EndpointsCount = len(apiResult.Paths)
+	result.Summary.EndpointsCount = endpointCount
 	result.Summary.ServicesCount = len(skelResult.ImplementationSkeletons)
 
 	// Phase 3: Generate Feature Tickets
@@ -283,7 +243,7 @@ func runDeriveL3() error {
 	ftPrompt := prompts.DeriveFeatureTickets + ftInput
 
 	var ftResult struct {
-		FeatureTickets []FeatureTicket `json:"feature_tickets"`
+		FeatureTickets []formatter.FeatureTicket `json:"feature_tickets"`
 		Summary        struct {
 			TotalTickets int            `json:"total_tickets"`
 			ByPriority   map[string]int `json:"by_priority"`
@@ -293,6 +253,7 @@ func runDeriveL3() error {
 		return fmt.Errorf("failed to generate feature tickets: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Generated: %d Feature Tickets\n", len(ftResult.FeatureTickets))
+	result.FeatureTickets = ftResult.FeatureTickets
 
 	// Phase 4: Generate Service Boundaries
 	fmt.Fprintln(os.Stderr, "\nPhase L3-4: Generating Service Boundaries...")
@@ -319,9 +280,9 @@ func runDeriveL3() error {
 	evPrompt := prompts.DeriveEventDesign + evInput
 
 	var evResult struct {
-		DomainEvents      []DomainEvent      `json:"domain_events"`
-		Commands          []Command          `json:"commands"`
-		IntegrationEvents []IntegrationEvent `json:"integration_events"`
+		DomainEvents      []formatter.DomainEvent      `json:"domain_events"`
+		Commands          []formatter.Command          `json:"commands"`
+		IntegrationEvents []formatter.IntegrationEvent `json:"integration_events"`
 		Summary           struct {
 			DomainEvents      int `json:"domain_events"`
 			Commands          int `json:"commands"`
@@ -334,6 +295,10 @@ func runDeriveL3() error {
 	fmt.Fprintf(os.Stderr, "  Generated: %d Events, %d Commands\n",
 		len(evResult.DomainEvents), len(evResult.Commands))
 
+	result.DomainEvents = evResult.DomainEvents
+	result.Commands = evResult.Commands
+	result.IntegrationEvents = evResult.IntegrationEvents
+
 	// Phase 6: Generate Dependency Graph
 	fmt.Fprintln(os.Stderr, "\nPhase L3-6: Generating Dependency Graph...")
 
@@ -367,12 +332,19 @@ func runDeriveL3() error {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
+	// The documents and their derivation state are written together
+	tx, err := beginDerivation(outputDir)
+	if err != nil {
+		return err
+	}
+	defer tx.Abort()
+
 	// Write output files
 	fmt.Fprintln(os.Stderr, "\nPhase L3-W: Writing output...")
 
 	// Write Test Cases (TDAI format)
 	tcPath := filepath.Join(outputDir, "test-cases.md")
-	if err := writeL3TestCases(tcPath, allTestCases, tcResult.Summary); err != nil {
+	if err := writeL3TestCases(tx, tcPath, allTestCases, tcResult.Summary); err != nil {
 		return fmt.Errorf("failed to write test cases: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", tcPath)
@@ -381,54 +353,80 @@ func runDeriveL3() error {
 
 	apiPath := filepath.Join(outputDir, "openapi.json")
 	apiContent, _ := json.MarshalIndent(result.APISpec, "", "  ")
-	if err := os.WriteFile(apiPath, apiContent, 0644); err != nil {
+	if err := tx.WriteFile(apiPath, apiContent); err != nil {
 		return fmt.Errorf("failed to write API spec: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", apiPath)
 
 	// Write Implementation Skeletons
 	implPath := filepath.Join(outputDir, "implementation-skeletons.md")
-	if err := writeSkeletons(implPath, result.ImplementationSkeletons); err != nil {
+	if err := writeSkeletons(tx, implPath, result.ImplementationSkeletons); err != nil {
 		return fmt.Errorf("failed to write skeletons: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", implPath)
 
 	// Write full JSON for further processing
-	jsonPath := filepath.Join(outputDir, "l3-output.json")
+	jsonPath := filepath.Join(outputDir, spec.L3OutputFile)
 	jsonContent, _ := json.MarshalIndent(result, "", "  ")
-	if err := os.WriteFile(jsonPath, jsonContent, 0644); err != nil {
+	if err := tx.WriteFile(jsonPath, jsonContent); err != nil {
 		return fmt.Errorf("failed to write JSON output: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", jsonPath)
 
 	// Write Feature Tickets
 	ftPath := filepath.Join(outputDir, "feature-tickets.md")
-	if err := writeFeatureTickets(ftPath, ftResult.FeatureTickets); err != nil {
+	if err := writeFeatureTickets(tx, ftPath, ftResult.FeatureTickets); err != nil {
 		return fmt.Errorf("failed to write feature tickets: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", ftPath)
 
 	// Write Service Boundaries
 	sbPath := filepath.Join(outputDir, "service-boundaries.md")
-	if err := writeServiceBoundaries(sbPath, sbResult.Services); err != nil {
+	if err := writeServiceBoundaries(tx, sbPath, sbResult.Services); err != nil {
 		return fmt.Errorf("failed to write service boundaries: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", sbPath)
 
 	// Write Event & Message Design
 	evPath := filepath.Join(outputDir, "event-message-design.md")
-	if err := writeEventDesign(evPath, evResult.DomainEvents, evResult.Commands, evResult.IntegrationEvents); err != nil {
+	if err := writeEventDesign(tx, evPath, evResult.DomainEvents, evResult.Commands, evResult.IntegrationEvents); err != nil {
 		return fmt.Errorf("failed to write event design: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", evPath)
 
+	// Write AsyncAPI document and event JSON Schemas
+	var sharedTypes []formatter.SharedType
+	if l2, err := spec.LoadL2Output(inputDir); err == nil {
+		sharedTypes = l2.SharedTypes
+	}
+	design := apispec.EventDesign{
+		DomainEvents:      evResult.DomainEvents,
+		Commands:          evResult.Commands,
+		IntegrationEvents: evResult.IntegrationEvents,
+	}
+	if err := writeEventSpecs(tx, outputDir, design, sharedTypes, apispec.Info{Title: "Events"}); err != nil {
+		return fmt.Errorf("failed to write event specifications: %w", err)
+	}
+
 	// Write Dependency Graph
 	dgPath := filepath.Join(outputDir, "dependency-graph.md")
-	if err := writeDependencyGraph(dgPath, dgResult.Components, dgResult.Dependencies); err != nil {
+	if err := writeDependencyGraph(tx, dgPath, dgResult.Components, dgResult.Dependencies); err != nil {
 		return fmt.Errorf("failed to write dependency graph: %w", err)
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", dgPath)
 
+	// Mark the written documents and record them in the derivation state
+	rec := derivation.NewRecorder(stateProjectDir(outputDir), "l3")
+	rec.Tx = tx
+	for _, path := range []string{tcPath, implPath, ftPath, sbPath, evPath} {
+		if err := rec.Record(path); err != nil {
+			return err
+		}
+	}
+	if err := commitRecorder(rec); err != nil {
+		return err
+	}
+
 	// Print summary
 	fmt.Fprintln(os.Stderr, "\n========================================")
 	fmt.Fprintln(os.Stderr, "   L3 DERIVATION COMPLETE")
@@ -442,7 +440,7 @@ func runDeriveL3() error {
 		tcResult.Summary.ByCategory.Negative,
 		tcResult.Summary.ByCategory.Boundary,
 		tcResult.Summary.ByCategory.Hallucination)
-	fmt.Fprintf(os.Stderr, "  API Endpoints:       %d\n", len(result.APISpec.Paths))
+	fmt.Fprintf(os.Stderr, "  API Endpoints:       %d\n", result.Summary.EndpointsCount)
 	fmt.Fprintf(os.Stderr, "  Impl Skeletons:      %d\n", len(result.ImplementationSkeletons))
 
 	funcCount := 0
@@ -470,12 +468,8 @@ func runDeriveL3() error {
 	return nil
 }
 
-func writeSkeletons(path string, skeletons []ImplementationSkeleton) error {
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+func writeSkeletons(tx *derivation.Transaction, path string, skeletons []ImplementationSkeleton) error {
+	f := &bytes.Buffer{}
 
 	timestamp := time.Now().Format(time.RFC3339)
 	fm := formatter.DefaultFrontmatter("Implementation Skeletons", timestamp, "L3")
@@ -515,15 +509,11 @@ func writeSkeletons(path string, skeletons []ImplementationSkeleton) error {
 		fmt.Fprintf(f, "---\n\n")
 	}
 
-	return nil
+	return tx.WriteFile(path, f.Bytes())
 }
 
-func writeFeatureTickets(path string, tickets []FeatureTicket) error {
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+func writeFeatureTickets(tx *derivation.Transaction, path string, tickets []formatter.FeatureTicket) error {
+	f := &bytes.Buffer{}
 
 	timestamp := time.Now().Format(time.RFC3339)
 	fm := formatter.DefaultFrontmatter("Feature Definition Tickets", timestamp, "L3")
@@ -580,15 +570,11 @@ func writeFeatureTickets(path string, tickets []FeatureTicket) error {
 		fmt.Fprintf(f, "---\n\n")
 	}
 
-	return nil
+	return tx.WriteFile(path, f.Bytes())
 }
 
-func writeServiceBoundaries(path string, services []ServiceBoundary) error {
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+func writeServiceBoundaries(tx *derivation.Transaction, path string, services []ServiceBoundary) error {
+	f := &bytes.Buffer{}
 
 	timestamp := time.Now().Format(time.RFC3339)
 	fm := formatter.DefaultFrontmatter("Service Boundaries", timestamp, "L3")
@@ -644,15 +630,11 @@ func writeServiceBoundaries(path string, services []ServiceBoundary) error {
 		fmt.Fprintf(f, "---\n\n")
 	}
 
-	return nil
+	return tx.WriteFile(path, f.Bytes())
 }
 
-func writeEventDesign(path string, events []DomainEvent, commands []Command, integrationEvents []IntegrationEvent) error {
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+func writeEventDesign(tx *derivation.Transaction, path string, events []formatter.DomainEvent, commands []formatter.Command, integrationEvents []formatter.IntegrationEvent) error {
+	f := &bytes.Buffer{}
 
 	timestamp := time.Now().Format(time.RFC3339)
 	fm := formatter.DefaultFrontmatter("Event & Message Design", timestamp, "L3")
@@ -726,15 +708,11 @@ func writeEventDesign(path string, events []DomainEvent, commands []Command, int
 		fmt.Fprintf(f, "---\n\n")
 	}
 
-	return nil
+	return tx.WriteFile(path, f.Bytes())
 }
 
-func writeDependencyGraph(path string, components []GraphComponent, dependencies []GraphDependency) error {
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+func writeDependencyGraph(tx *derivation.Transaction, path string, components []GraphComponent, dependencies []GraphDependency) error {
+	f := &bytes.Buffer{}
 
 	timestamp := time.Now().Format(time.RFC3339)
 	fm := formatter.DefaultFrontmatter("Dependency Graph", timestamp, "L3")
@@ -807,7 +785,7 @@ func writeDependencyGraph(path string, components []GraphComponent, dependencies
 	}
 	fmt.Fprintf(f, "\n")
 
-	return nil
+	return tx.WriteFile(path, f.Bytes())
 }
 
 func sanitizeID(id string) string {
@@ -836,7 +814,7 @@ func extractDomainCode(name string) string {
 }
 
 // writeL3TestCases writes test cases to markdown file using the formatter
-func writeL3TestCases(path string, testCases []generator.TestCase, summary generator.TDAISummary) error {
+func writeL3TestCases(tx *derivation.Transaction, path string, testCases []generator.TestCase, summary generator.TDAISummary) error {
 	timestamp := time.Now().Format(time.RFC3339)
 
 	// Convert generator types to formatter types
@@ -877,5 +855,38 @@ func writeL3TestCases(path string, testCases []generator.TestCase, summary gener
 	fmtSummary.Coverage.HasHallucinationTests = summary.Coverage.HasHallucinationTests
 
 	content := formatter.FormatTestCases(fmtCases, fmtSummary, timestamp)
-	return os.WriteFile(path, []byte(content), 0644)
+	return tx.WriteFile(path, []byte(content))
+}
+
+// deriveL3APISpec builds the OpenAPI document from the L2 interface
+// contracts in l2-output.json. Without structured contracts it falls back to
+// asking the LLM for a free-form document from tech-specs.md.
+func deriveL3APISpec(client *claude.Client, inputDir string, tsContent []byte) (interface{}, int, error) {
+	l2, err := spec.LoadL2Output(inputDir)
+	if err == nil && len(l2.InterfaceContracts) > 0 {
+		doc := apispec.BuildOpenAPI(l2.InterfaceContracts, l2.SharedTypes, apispec.Info{Title: "API"})
+		fmt.Fprintf(os.Stderr, "  Built from %d interface contracts in %s\n", len(l2.InterfaceContracts), spec.L2OutputFile)
+		return doc, doc.OperationCount(), nil
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "  Warning: %v\n", err)
+	}
+	fmt.Fprintln(os.Stderr, "  Warning: no structured interface contracts, falling back to LLM generation")
+
+	apiPrompt := prompts.DeriveL3API + "\n\n" + string(tsContent)
+
+	var apiResult APISpec
+	if err := client.CallJSON(apiPrompt, &apiResult); err != nil {
+		return nil, 0, fmt.Errorf("failed to generate API spec: %w", err)
+	}
+
+	count := 0
+	for _, item := range apiResult.Paths {
+		if methods, ok := item.(map[string]interface{}); ok {
+			count += len(methods)
+		} else {
+			count++
+		}
+	}
+	return apiResult, count, nil
 }
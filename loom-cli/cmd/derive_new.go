@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/ikadar/loom-cli/internal/claude"
 	"github.com/ikadar/loom-cli/internal/config"
+	"github.com/ikadar/loom-cli/internal/decisions"
+	"github.com/ikadar/loom-cli/internal/derivation"
 	"github.com/ikadar/loom-cli/internal/domain"
 	"github.com/ikadar/loom-cli/internal/formatter"
 	"github.com/ikadar/loom-cli/prompts"
@@ -211,10 +214,21 @@ func runDeriveNew() error {
 		}
 	}
 
-	if err := writeOutputFiles(cfg, result, domainModelDoc, boundedContextMap, newDecisions); err != nil {
+	// The documents and their derivation state are written together
+	tx, err := beginDerivation(cfg.OutputDir)
+	if err != nil {
+		return err
+	}
+	defer tx.Abort()
+
+	if err := writeOutputFiles(cfg, tx, result, domainModelDoc, boundedContextMap, newDecisions); err != nil {
 		return fmt.Errorf("failed to write output: %w", err)
 	}
 
+	if err := recordL1Outputs(cfg, tx, input.Decisions); err != nil {
+		return err
+	}
+
 	// Print summary
 	printDeriveSummary(cfg, input.DomainModel, input.Decisions, result, domainModelDoc, boundedContextMap)
 
@@ -288,7 +302,7 @@ func deriveDocuments(client *claude.Client, dm *domain.Domain, decisions []domai
 }
 
 // Phase 6: Write output files
-func writeOutputFiles(cfg *config.Config, result *domain.DerivationResult, domainModelDoc *DomainModelDoc, boundedContextMap *BoundedContextMap, newDecisions []domain.Decision) error {
+func writeOutputFiles(cfg *config.Config, tx *derivation.Transaction, result *domain.DerivationResult, domainModelDoc *DomainModelDoc, boundedContextMap *BoundedContextMap, newDecisions []domain.Decision) error {
 	// Create output directory
 	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
 		return err
@@ -297,7 +311,7 @@ func writeOutputFiles(cfg *config.Config, result *domain.DerivationResult, domai
 	// Write domain-model.md
 	dmPath := cfg.OutputDir + "/domain-model.md"
 	dmContent := formatDomainModel(domainModelDoc)
-	if err := os.WriteFile(dmPath, []byte(dmContent), 0644); err != nil {
+	if err := tx.WriteFile(dmPath, []byte(dmContent)); err != nil {
 		return err
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", dmPath)
@@ -305,7 +319,7 @@ func writeOutputFiles(cfg *config.Config, result *domain.DerivationResult, domai
 	// Write bounded-context-map.md
 	bcPath := cfg.OutputDir + "/bounded-context-map.md"
 	bcContent := formatBoundedContextMap(boundedContextMap)
-	if err := os.WriteFile(bcPath, []byte(bcContent), 0644); err != nil {
+	if err := tx.WriteFile(bcPath, []byte(bcContent)); err != nil {
 		return err
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", bcPath)
@@ -313,7 +327,7 @@ func writeOutputFiles(cfg *config.Config, result *domain.DerivationResult, domai
 	// Write acceptance-criteria.md
 	acPath := cfg.OutputDir + "/acceptance-criteria.md"
 	acContent := formatAC(result.AcceptanceCriteria)
-	if err := os.WriteFile(acPath, []byte(acContent), 0644); err != nil {
+	if err := tx.WriteFile(acPath, []byte(acContent)); err != nil {
 		return err
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", acPath)
@@ -321,20 +335,21 @@ func writeOutputFiles(cfg *config.Config, result *domain.DerivationResult, domai
 	// Write business-rules.md
 	brPath := cfg.OutputDir + "/business-rules.md"
 	brContent := formatBR(result.BusinessRules)
-	if err := os.WriteFile(brPath, []byte(brContent), 0644); err != nil {
+	if err := tx.WriteFile(brPath, []byte(brContent)); err != nil {
 		return err
 	}
 	fmt.Fprintf(os.Stderr, "  Written: %s\n", brPath)
 
-	// Append new decisions to decisions.md
+	// Record new decisions in the decision store and re-render decisions.md
 	if len(newDecisions) > 0 {
-		decContent := formatDecisions(newDecisions)
-		f, err := os.OpenFile(cfg.DecisionsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		ds, err := decisions.Load(cfg.DecisionsFile)
 		if err != nil {
 			return err
 		}
-		defer f.Close()
-		if _, err := f.WriteString(decContent); err != nil {
+		for _, d := range newDecisions {
+			ds.AddDecision(decisions.FromDomain(d))
+		}
+		if err := decisions.Save(cfg.DecisionsFile, ds); err != nil {
 			return err
 		}
 		fmt.Fprintf(os.Stderr, "  Updated: %s (+%d decisions)\n", cfg.DecisionsFile, len(newDecisions))
@@ -343,6 +358,90 @@ func writeOutputFiles(cfg *config.Config, result *domain.DerivationResult, domai
 	return nil
 }
 
+// recordL1Outputs marks the L1 documents and registers them in the
+// derivation state, derived from the L0 inputs and linked to the decisions
+// they reference
+func recordL1Outputs(cfg *config.Config, tx *derivation.Transaction, ds []domain.Decision) error {
+	rec := derivation.NewRecorder(stateProjectDir(cfg.OutputDir), "l1")
+	rec.Tx = tx
+
+	if cfg.InputFile != "" || cfg.InputDir != "" {
+		_, files, err := cfg.ReadInputFiles()
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			rec.AddSource(f, derivation.ArtifactUserStory)
+		}
+	}
+	if cfg.NFRFile != "" {
+		rec.AddSource(cfg.NFRFile, derivation.ArtifactNFR)
+	}
+	if cfg.VocabularyFile != "" {
+		rec.AddSource(cfg.VocabularyFile, derivation.ArtifactVocabulary)
+	}
+
+	for _, d := range ds {
+		rec.AddDecision(&derivation.Decision{
+			ID:        d.ID,
+			Question:  d.Question,
+			Answer:    d.Answer,
+			Source:    d.Source,
+			DecidedAt: d.DecidedAt,
+			Category:  d.Category,
+			Subject:   d.Subject,
+		})
+	}
+
+	for _, name := range []string{"domain-model.md", "bounded-context-map.md", "acceptance-criteria.md", "business-rules.md"} {
+		if err := rec.Record(filepath.Join(cfg.OutputDir, name)); err != nil {
+			return err
+		}
+	}
+	return commitRecorder(rec)
+}
+
+// stateProjectDir returns the project whose derivation state records the
+// files written to outputDir: the closest directory holding .loom, else the
+// parent of a layer directory as cascade lays them out, else outputDir
+func stateProjectDir(outputDir string) string {
+	if dir := findProjectDir(outputDir); dir != "" {
+		return dir
+	}
+	clean := filepath.Clean(outputDir)
+	switch filepath.Base(clean) {
+	case "l1", "l2", "l3":
+		return filepath.Dir(clean)
+	}
+	return clean
+}
+
+// beginDerivation starts the transaction that stages the documents written
+// to outputDir along with their derivation state
+func beginDerivation(outputDir string) (*derivation.Transaction, error) {
+	sm := derivation.NewStateManager(stateProjectDir(outputDir))
+	tx, err := sm.Begin()
+	if err != nil {
+		return nil, err
+	}
+	printRecovery(sm)
+	return tx, nil
+}
+
+// commitRecorder registers the recorded files in the derivation state
+func commitRecorder(rec *derivation.Recorder) error {
+	res, err := rec.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to record derivation state: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "  Recorded: %d artifacts, %d upstream links in %s\n",
+		len(res.Artifacts), res.Edges, derivation.NewStateManager(rec.ProjectDir).Store.Path())
+	if len(res.Removed) > 0 {
+		fmt.Fprintf(os.Stderr, "  Removed from state: %s\n", strings.Join(res.Removed, ", "))
+	}
+	return nil
+}
+
 // toAnchor converts an ID to a lowercase anchor (e.g., "AC-CUST-001" -> "ac-cust-001")
 func toAnchor(id string) string {
 	return strings.ToLower(id)
@@ -423,21 +522,6 @@ func formatBR(brs []domain.BusinessRule) string {
 	return sb.String()
 }
 
-func formatDecisions(decisions []domain.Decision) string {
-	var sb strings.Builder
-
-	sb.WriteString(fmt.Sprintf("\n## Decisions from %s\n\n", time.Now().Format("2006-01-02")))
-
-	for _, d := range decisions {
-		sb.WriteString(fmt.Sprintf("- **%s: %s**\n", d.ID, d.Subject))
-		sb.WriteString(fmt.Sprintf("  - Q: %s\n", d.Question))
-		sb.WriteString(fmt.Sprintf("  - A: %s\n", d.Answer))
-		sb.WriteString(fmt.Sprintf("  - Decided: %s by %s\n\n", d.DecidedAt.Format("2006-01-02 15:04"), d.Source))
-	}
-
-	return sb.String()
-}
-
 func formatDomainModel(doc *DomainModelDoc) string {
 	var sb strings.Builder
 	timestamp := time.Now().Format(time.RFC3339)
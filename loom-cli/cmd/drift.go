@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ikadar/loom-cli/internal/drift"
+	"github.com/ikadar/loom-cli/internal/formatter"
+	"github.com/ikadar/loom-cli/internal/spec"
+)
+
+// DriftConfig holds configuration for the drift command
+type DriftConfig struct {
+	CodeDir string // Implementation code base
+	SpecDir string // Directory containing l1/, l2/ and l3/
+	L1Dir   string // Overrides <spec-dir>/l1
+	L2Dir   string // Overrides <spec-dir>/l2
+	L3Dir   string // Overrides <spec-dir>/l3
+	JSON    bool   // Print the report as JSON
+}
+
+func runDrift() error {
+	driftFlags := flag.NewFlagSet("drift", flag.ExitOnError)
+	codeDir := driftFlags.String("code", "", "Implementation code base (Go)")
+	specDir := driftFlags.String("spec-dir", ".", "Directory containing l1/, l2/ and l3/")
+	l1Dir := driftFlags.String("l1-dir", "", "L1 directory (default: <spec-dir>/l1)")
+	l2Dir := driftFlags.String("l2-dir", "", "L2 directory (default: <spec-dir>/l2)")
+	l3Dir := driftFlags.String("l3-dir", "", "L3 directory (default: <spec-dir>/l3)")
+	jsonOutput := driftFlags.Bool("json", false, "Print the report as JSON")
+
+	if len(os.Args) > 2 {
+		driftFlags.Parse(os.Args[2:])
+	}
+
+	cfg := &DriftConfig{
+		CodeDir: *codeDir,
+		SpecDir: *specDir,
+		L1Dir:   *l1Dir,
+		L2Dir:   *l2Dir,
+		L3Dir:   *l3Dir,
+		JSON:    *jsonOutput,
+	}
+
+	return executeDrift(cfg)
+}
+
+func executeDrift(cfg *DriftConfig) error {
+	if cfg.CodeDir == "" {
+		return fmt.Errorf("--code is required")
+	}
+	if cfg.L1Dir == "" {
+		cfg.L1Dir = filepath.Join(cfg.SpecDir, "l1")
+	}
+	if cfg.L2Dir == "" {
+		cfg.L2Dir = filepath.Join(cfg.SpecDir, "l2")
+	}
+	if cfg.L3Dir == "" {
+		cfg.L3Dir = filepath.Join(cfg.SpecDir, "l3")
+	}
+
+	l2, err := spec.LoadL2Output(cfg.L2Dir)
+	if err != nil {
+		return err
+	}
+	s := drift.Spec{Aggregates: l2.Aggregates, Contracts: l2.InterfaceContracts}
+
+	// Test cases and ACs are optional: without them only identifiers and
+	// routes are compared
+	if content, err := os.ReadFile(filepath.Join(cfg.L3Dir, "test-cases.md")); err == nil {
+		s.TestCases = formatter.ParseTestCases(string(content))
+	}
+	if content, err := os.ReadFile(filepath.Join(cfg.L1Dir, "acceptance-criteria.md")); err == nil {
+		for _, ac := range spec.ParseAcceptanceCriteria(string(content)) {
+			s.ACIDs = append(s.ACIDs, ac.ID)
+		}
+	}
+
+	code, err := drift.ScanGoCode(cfg.CodeDir)
+	if err != nil {
+		return err
+	}
+	report := drift.Detect(s, code)
+
+	if cfg.JSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		printDriftReport(report, cfg.CodeDir)
+	}
+
+	if report.HasDrift() {
+		return fmt.Errorf("drift detected: %d missing, %d extra, %d renamed, %d untested ACs",
+			len(report.Missing), len(report.Extra), len(report.Renamed), len(report.UntestedACs))
+	}
+	return nil
+}
+
+func printDriftReport(r *drift.Report, codeDir string) {
+	fmt.Println("\n========================================")
+	fmt.Println("   CODE DRIFT REPORT")
+	fmt.Printf("   Code: %s\n", codeDir)
+	fmt.Println("========================================")
+	fmt.Printf("Checked %d spec elements\n", r.Checked)
+
+	if len(r.Missing) > 0 {
+		fmt.Printf("\nMissing in code (%d):\n", len(r.Missing))
+		for _, it := range r.Missing {
+			fmt.Printf("  ✗ %-7s %s", it.Kind, it.Name)
+			if it.SpecID != "" {
+				fmt.Printf("  [%s]", it.SpecID)
+			}
+			fmt.Println()
+		}
+	}
+
+	if len(r.Renamed) > 0 {
+		fmt.Printf("\nRenamed (%d):\n", len(r.Renamed))
+		for _, rn := range r.Renamed {
+			fmt.Printf("  ~ %-7s %s → %s  (%s)\n", rn.Kind, rn.SpecName, rn.CodeName, rn.Location)
+		}
+	}
+
+	if len(r.Extra) > 0 {
+		fmt.Printf("\nNot in spec (%d):\n", len(r.Extra))
+		for _, it := range r.Extra {
+			fmt.Printf("  + %-7s %s", it.Kind, it.Name)
+			if it.Location != nil {
+				fmt.Printf("  (%s)", it.Location)
+			}
+			fmt.Println()
+		}
+	}
+
+	if len(r.UntestedACs) > 0 {
+		fmt.Printf("\nAcceptance criteria without tests (%d):\n", len(r.UntestedACs))
+		for _, ac := range r.UntestedACs {
+			fmt.Printf("  ! %s\n", ac)
+		}
+	}
+
+	if !r.HasDrift() {
+		fmt.Println("\n✓ Code matches the spec")
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ikadar/loom-cli/internal/apispec"
+	"github.com/ikadar/loom-cli/internal/derivation"
+	"github.com/ikadar/loom-cli/internal/formatter"
+	"github.com/ikadar/loom-cli/internal/spec"
+)
+
+// Output locations of the event specifications
+const (
+	AsyncAPIFile    = "asyncapi.json"
+	EventSchemasDir = "event-schemas"
+)
+
+// GenAsyncAPIConfig holds configuration for the gen-asyncapi command
+type GenAsyncAPIConfig struct {
+	InputDir  string // L3 directory containing l3-output.json
+	L2Dir     string // L2 directory with shared types (optional)
+	OutputDir string // Directory for asyncapi.json and event-schemas/
+	Title     string // API title
+	Version   string // API version
+}
+
+func runGenAsyncAPI() error {
+	genFlags := flag.NewFlagSet("gen-asyncapi", flag.ExitOnError)
+	inputDir := genFlags.String("input-dir", ".", "L3 directory containing l3-output.json")
+	l2Dir := genFlags.String("l2-dir", "", "L2 directory containing l2-output.json (shared types)")
+	outputDir := genFlags.String("output-dir", "", "Output directory (default: input dir)")
+	title := genFlags.String("title", "Events", "API title")
+	version := genFlags.String("version", "1.0.0", "API version")
+
+	if len(os.Args) > 2 {
+		genFlags.Parse(os.Args[2:])
+	}
+
+	cfg := &GenAsyncAPIConfig{
+		InputDir:  *inputDir,
+		L2Dir:     *l2Dir,
+		OutputDir: *outputDir,
+		Title:     *title,
+		Version:   *version,
+	}
+
+	return executeGenAsyncAPI(cfg)
+}
+
+func executeGenAsyncAPI(cfg *GenAsyncAPIConfig) error {
+	if cfg.OutputDir == "" {
+		cfg.OutputDir = cfg.InputDir
+	}
+
+	l3, err := spec.LoadL3Output(cfg.InputDir)
+	if err != nil {
+		return err
+	}
+	design := apispec.EventDesign{
+		DomainEvents:      l3.DomainEvents,
+		Commands:          l3.Commands,
+		IntegrationEvents: l3.IntegrationEvents,
+	}
+	if len(design.DomainEvents)+len(design.Commands)+len(design.IntegrationEvents) == 0 {
+		return fmt.Errorf("no events or commands found in %s (re-run derive-l3 to record the event design)", filepath.Join(cfg.InputDir, spec.L3OutputFile))
+	}
+
+	var sharedTypes []formatter.SharedType
+	if cfg.L2Dir != "" {
+		l2, err := spec.LoadL2Output(cfg.L2Dir)
+		if err != nil {
+			return err
+		}
+		sharedTypes = l2.SharedTypes
+	}
+
+	return writeEventSpecs(nil, cfg.OutputDir, design, sharedTypes, apispec.Info{Title: cfg.Title, Version: cfg.Version})
+}
+
+// writeEventSpecs writes asyncapi.json and one JSON Schema file per event
+// version into outputDir, staged in tx if it is not nil
+func writeEventSpecs(tx *derivation.Transaction, outputDir string, design apispec.EventDesign, sharedTypes []formatter.SharedType, info apispec.Info) error {
+	doc := apispec.BuildAsyncAPI(design, sharedTypes, info)
+	docPath := filepath.Join(outputDir, AsyncAPIFile)
+	if err := writeJSONFile(tx, docPath, doc); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "  Written: %s (%d channels, %d messages)\n", docPath, len(doc.Channels), doc.MessageCount())
+
+	schemas := apispec.BuildEventSchemas(design, sharedTypes)
+	if len(schemas) == 0 {
+		return nil
+	}
+	schemaDir := filepath.Join(outputDir, EventSchemasDir)
+	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	for _, sf := range schemas {
+		if err := writeJSONFile(tx, filepath.Join(schemaDir, sf.Name), sf.Schema); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "  Written: %s/ (%d event schemas)\n", schemaDir, len(schemas))
+	return nil
+}
+
+func writeJSONFile(tx *derivation.Transaction, path string, v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filepath.Base(path), err)
+	}
+	if err := tx.WriteFile(path, append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ikadar/loom-cli/internal/codegen"
+	"github.com/ikadar/loom-cli/internal/spec"
+)
+
+// GenCodeConfig holds configuration for the gen-code command
+type GenCodeConfig struct {
+	InputDir  string // L2 directory containing l2-output.json
+	OutputDir string // Root directory of the generated packages
+	Lang      string // Target language (go)
+}
+
+func runGenCode() error {
+	genFlags := flag.NewFlagSet("gen-code", flag.ExitOnError)
+	inputDir := genFlags.String("input-dir", ".", "L2 directory containing l2-output.json")
+	outputDir := genFlags.String("output-dir", "", "Root directory of the generated packages")
+	lang := genFlags.String("lang", "go", "Target language (go)")
+
+	if len(os.Args) > 2 {
+		genFlags.Parse(os.Args[2:])
+	}
+
+	cfg := &GenCodeConfig{
+		InputDir:  *inputDir,
+		OutputDir: *outputDir,
+		Lang:      *lang,
+	}
+
+	return executeGenCode(cfg)
+}
+
+func executeGenCode(cfg *GenCodeConfig) error {
+	if cfg.OutputDir == "" {
+		return fmt.Errorf("--output-dir is required")
+	}
+	if cfg.Lang != "go" {
+		return fmt.Errorf("unsupported language: %s (supported: go)", cfg.Lang)
+	}
+
+	l2, err := spec.LoadL2Output(cfg.InputDir)
+	if err != nil {
+		return err
+	}
+	if len(l2.Aggregates) == 0 && len(l2.InterfaceContracts) == 0 {
+		return fmt.Errorf("no aggregates or interface contracts found in %s", filepath.Join(cfg.InputDir, spec.L2OutputFile))
+	}
+	fmt.Fprintf(os.Stderr, "Read %d aggregates, %d interface contracts\n", len(l2.Aggregates), len(l2.InterfaceContracts))
+
+	skeletons, err := codegen.GenerateGoSkeletons(l2.Aggregates, l2.InterfaceContracts, l2.SharedTypes, spec.L2OutputFile)
+	if err != nil {
+		return err
+	}
+
+	stubsAdded := 0
+	for _, sk := range skeletons {
+		dir := filepath.Join(cfg.OutputDir, filepath.FromSlash(sk.Dir))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		// Generated declarations are always rewritten
+		genPath := filepath.Join(cfg.OutputDir, filepath.FromSlash(sk.Generated.Path))
+		if err := os.WriteFile(genPath, sk.Generated.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", genPath, err)
+		}
+		fmt.Fprintf(os.Stderr, "  Written: %s\n", genPath)
+
+		if len(sk.Stubs.Stubs) == 0 {
+			continue
+		}
+
+		// Hand-written code only gets stubs for what it does not declare yet
+		declared, err := codegen.DeclaredIdentifiers(dir)
+		if err != nil {
+			return fmt.Errorf("failed to scan hand-written code in %s: %w", dir, err)
+		}
+		stubPath := filepath.Join(cfg.OutputDir, filepath.FromSlash(sk.Stubs.Path))
+		existing, err := os.ReadFile(stubPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", stubPath, err)
+		}
+		content, added := codegen.MergeStubs(existing, declared, sk.Stubs)
+		if len(added) == 0 {
+			continue
+		}
+		if err := os.WriteFile(stubPath, content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", stubPath, err)
+		}
+		if len(existing) == 0 {
+			fmt.Fprintf(os.Stderr, "  Created: %s (%d stubs)\n", stubPath, len(added))
+		} else {
+			fmt.Fprintf(os.Stderr, "  Appended %d stub(s) to %s: %v\n", len(added), stubPath, added)
+		}
+		stubsAdded += len(added)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nGenerated %d package(s) in %s", len(skeletons), cfg.OutputDir)
+	if stubsAdded > 0 {
+		fmt.Fprintf(os.Stderr, ", %d stub(s) to implement (marked TODO)", stubsAdded)
+	}
+	fmt.Fprintln(os.Stderr)
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ikadar/loom-cli/internal/codegen"
+	"github.com/ikadar/loom-cli/internal/formatter"
+	"github.com/ikadar/loom-cli/internal/spec"
+)
+
+// GenGherkinConfig holds configuration for the gen-gherkin command
+type GenGherkinConfig struct {
+	L1Dir     string // Directory with acceptance-criteria.md and bounded-context-map.md
+	L3Dir     string // Directory with test-cases.md (optional)
+	OutputDir string // Directory for .feature files
+}
+
+func runGenGherkin() error {
+	genFlags := flag.NewFlagSet("gen-gherkin", flag.ExitOnError)
+	l1Dir := genFlags.String("l1-dir", "", "L1 directory with acceptance-criteria.md")
+	l3Dir := genFlags.String("l3-dir", "", "L3 directory with test-cases.md (optional)")
+	outputDir := genFlags.String("output-dir", "", "Directory for .feature files")
+
+	if len(os.Args) > 2 {
+		genFlags.Parse(os.Args[2:])
+	}
+
+	cfg := &GenGherkinConfig{
+		L1Dir:     *l1Dir,
+		L3Dir:     *l3Dir,
+		OutputDir: *outputDir,
+	}
+
+	return executeGenGherkin(cfg)
+}
+
+func executeGenGherkin(cfg *GenGherkinConfig) error {
+	if cfg.L1Dir == "" {
+		return fmt.Errorf("--l1-dir is required")
+	}
+	if cfg.OutputDir == "" {
+		return fmt.Errorf("--output-dir is required")
+	}
+
+	acPath := filepath.Join(cfg.L1Dir, "acceptance-criteria.md")
+	acContent, err := os.ReadFile(acPath)
+	if err != nil {
+		return fmt.Errorf("failed to read acceptance criteria: %w", err)
+	}
+	acs := spec.ParseAcceptanceCriteria(string(acContent))
+	if len(acs) == 0 {
+		return fmt.Errorf("no acceptance criteria found in %s", acPath)
+	}
+
+	// Bounded contexts are optional: without them all features are unassigned
+	var contexts []spec.BoundedContext
+	bcPath := filepath.Join(cfg.L1Dir, "bounded-context-map.md")
+	if bcContent, err := os.ReadFile(bcPath); err == nil {
+		contexts = spec.ParseBoundedContexts(string(bcContent))
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read bounded context map: %w", err)
+	}
+
+	var testCases []formatter.TestCase
+	if cfg.L3Dir != "" {
+		tcPath := filepath.Join(cfg.L3Dir, "test-cases.md")
+		tcContent, err := os.ReadFile(tcPath)
+		if err != nil {
+			return fmt.Errorf("failed to read test cases: %w", err)
+		}
+		testCases = formatter.ParseTestCases(string(tcContent))
+	}
+
+	fmt.Fprintf(os.Stderr, "Read %d ACs, %d bounded contexts, %d test cases\n", len(acs), len(contexts), len(testCases))
+
+	files, orphans := codegen.GenerateGherkin(acs, testCases, contexts)
+
+	for _, f := range files {
+		path := filepath.Join(cfg.OutputDir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(path, f.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Fprintf(os.Stderr, "  Written: %s\n", path)
+	}
+
+	for _, tc := range orphans {
+		fmt.Fprintf(os.Stderr, "  Warning: %s references unknown AC, not exported\n", tc.ID)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nGenerated %d feature file(s)\n", len(files))
+	return nil
+}
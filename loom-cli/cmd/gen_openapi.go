@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ikadar/loom-cli/internal/apispec"
+	"github.com/ikadar/loom-cli/internal/spec"
+)
+
+// GenOpenAPIConfig holds configuration for the gen-openapi command
+type GenOpenAPIConfig struct {
+	InputDir string // L2 directory containing l2-output.json
+	Output   string // Output file path
+	Title    string // API title
+	Version  string // API version
+}
+
+func runGenOpenAPI() error {
+	genFlags := flag.NewFlagSet("gen-openapi", flag.ExitOnError)
+	inputDir := genFlags.String("input-dir", ".", "L2 directory containing l2-output.json")
+	output := genFlags.String("output", "openapi.json", "Output file path")
+	title := genFlags.String("title", "API", "API title")
+	version := genFlags.String("version", "1.0.0", "API version")
+
+	if len(os.Args) > 2 {
+		genFlags.Parse(os.Args[2:])
+	}
+
+	cfg := &GenOpenAPIConfig{
+		InputDir: *inputDir,
+		Output:   *output,
+		Title:    *title,
+		Version:  *version,
+	}
+
+	return executeGenOpenAPI(cfg)
+}
+
+func executeGenOpenAPI(cfg *GenOpenAPIConfig) error {
+	l2, err := spec.LoadL2Output(cfg.InputDir)
+	if err != nil {
+		return err
+	}
+	if len(l2.InterfaceContracts) == 0 {
+		return fmt.Errorf("no interface contracts found in %s", filepath.Join(cfg.InputDir, spec.L2OutputFile))
+	}
+
+	doc := apispec.BuildOpenAPI(l2.InterfaceContracts, l2.SharedTypes, apispec.Info{Title: cfg.Title, Version: cfg.Version})
+
+	content, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal OpenAPI document: %w", err)
+	}
+	if dir := filepath.Dir(cfg.Output); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(cfg.Output, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", cfg.Output, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Written: %s (%d operations, %d schemas)\n", cfg.Output, doc.OperationCount(), len(doc.Components.Schemas))
+	return nil
+}
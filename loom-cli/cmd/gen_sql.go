@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/codegen"
+	"github.com/ikadar/loom-cli/internal/derivation"
+	"github.com/ikadar/loom-cli/internal/spec"
+)
+
+// DataModelSnapshot prefixes the derivation state snapshots gen-sql diffs
+// against, one per dialect and output directory
+const DataModelSnapshot = "data_model"
+
+// GenSQLConfig holds configuration for the gen-sql command
+type GenSQLConfig struct {
+	InputDir   string // L2 directory containing l2-output.json
+	OutputDir  string // Directory for schema.sql and migrations/
+	ProjectDir string // Project root with the .loom derivation state
+	Dialect    string // postgres, mysql or sqlite
+	Name       string // Migration name
+}
+
+var (
+	migrationFilePattern = regexp.MustCompile(`^(\d+)_.*\.(up|down)\.sql$`)
+	migrationSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+func runGenSQL() error {
+	genFlags := flag.NewFlagSet("gen-sql", flag.ExitOnError)
+	inputDir := genFlags.String("input-dir", ".", "L2 directory containing l2-output.json")
+	outputDir := genFlags.String("output-dir", "", "Directory for schema.sql and migrations/")
+	projectDir := genFlags.String("project-dir", "", "Project root directory (default: nearest .loom above output dir)")
+	dialect := genFlags.String("dialect", codegen.DialectPostgres, "SQL dialect: postgres, mysql, sqlite")
+	name := genFlags.String("name", "", "Migration name (default: schema_update, or init for the first one)")
+
+	if len(os.Args) > 2 {
+		genFlags.Parse(os.Args[2:])
+	}
+
+	cfg := &GenSQLConfig{
+		InputDir:   *inputDir,
+		OutputDir:  *outputDir,
+		ProjectDir: *projectDir,
+		Dialect:    *dialect,
+		Name:       *name,
+	}
+
+	return executeGenSQL(cfg)
+}
+
+// sqlProjectDir returns the project whose state records the data model
+// written to outputDir: the nearest one above it, else the current directory
+func sqlProjectDir(outputDir string) string {
+	if dir := findProjectDir(outputDir); dir != "" {
+		return dir
+	}
+	return "."
+}
+
+func executeGenSQL(cfg *GenSQLConfig) error {
+	if cfg.OutputDir == "" {
+		return fmt.Errorf("--output-dir is required")
+	}
+	if !codegen.ValidDialect(cfg.Dialect) {
+		return fmt.Errorf("unsupported dialect: %s (supported: postgres, mysql, sqlite)", cfg.Dialect)
+	}
+	if cfg.ProjectDir == "" {
+		cfg.ProjectDir = sqlProjectDir(cfg.OutputDir)
+	}
+
+	l2, err := spec.LoadL2Output(cfg.InputDir)
+	if err != nil {
+		return err
+	}
+	if len(l2.Tables) == 0 {
+		return fmt.Errorf("no tables found in %s", filepath.Join(cfg.InputDir, spec.L2OutputFile))
+	}
+	model := codegen.DataModel{Tables: l2.Tables, Enums: l2.Enums}
+	fmt.Fprintf(os.Stderr, "Read %d tables, %d enums\n", len(model.Tables), len(model.Enums))
+
+	schema, err := codegen.GenerateSchemaSQL(model, cfg.Dialect)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	schemaPath := filepath.Join(cfg.OutputDir, "schema.sql")
+	if err := os.WriteFile(schemaPath, []byte(schema), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", schemaPath, err)
+	}
+	fmt.Fprintf(os.Stderr, "  Written: %s\n", schemaPath)
+
+	// Migrations diff against the data model recorded by the previous run
+	sm := derivation.NewStateManager(cfg.ProjectDir)
+	if err := sm.Lock(); err != nil {
+		return fmt.Errorf("failed to lock state: %w", err)
+	}
+	defer sm.Unlock()
+
+	state, err := sm.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	snapshot := dataModelSnapshot(cfg)
+	var previous codegen.DataModel
+	hasPrevious, err := state.GetSnapshot(snapshot, &previous)
+	if err != nil {
+		return err
+	}
+
+	migrationsDir := filepath.Join(cfg.OutputDir, "migrations")
+	version, err := nextMigrationVersion(migrationsDir)
+	if err != nil {
+		return err
+	}
+
+	name := cfg.Name
+	if !hasPrevious && version > 1 {
+		// Migrations exist but were not generated from a recorded model:
+		// record the current model as the baseline instead of guessing
+		fmt.Fprintln(os.Stderr, "  No previous data model in the derivation state; recording the current one as baseline")
+	} else {
+		if !hasPrevious && name == "" {
+			name = "init"
+		}
+		if name == "" {
+			name = "schema_update"
+		}
+		migration, err := codegen.GenerateMigration(previous, model, cfg.Dialect)
+		if err != nil {
+			return err
+		}
+		if migration.Empty() {
+			fmt.Fprintln(os.Stderr, "  Data model unchanged, no migration needed")
+		} else if err := writeMigration(migrationsDir, version, name, cfg.Dialect, migration); err != nil {
+			return err
+		}
+	}
+
+	if err := state.SetSnapshot(snapshot, model); err != nil {
+		return err
+	}
+	if err := sm.Save(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	return nil
+}
+
+// dataModelSnapshot names the snapshot of a dialect and output directory,
+// e.g. data_model/postgres/db. The directory is relative to the project.
+func dataModelSnapshot(cfg *GenSQLConfig) string {
+	outputDir, err := filepath.Abs(cfg.OutputDir)
+	if err != nil {
+		outputDir = cfg.OutputDir
+	}
+	if projectDir, err := filepath.Abs(cfg.ProjectDir); err == nil {
+		if rel, err := filepath.Rel(projectDir, outputDir); err == nil && !strings.HasPrefix(rel, "..") {
+			outputDir = rel
+		}
+	}
+	return DataModelSnapshot + "/" + cfg.Dialect + "/" + filepath.ToSlash(outputDir)
+}
+
+// writeMigration writes the up and down files of one migration
+func writeMigration(dir string, version int, name, dialect string, m codegen.Migration) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+
+	base := fmt.Sprintf("%06d_%s", version, migrationSlug(name))
+	files := []struct {
+		suffix, body string
+	}{
+		{"up", m.Up},
+		{"down", m.Down},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, base+"."+f.suffix+".sql")
+		content := fmt.Sprintf("-- Migration %s (%s), generated by loom-cli gen-sql (%s)\n\n%s\n", base, f.suffix, dialect, f.body)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Fprintf(os.Stderr, "  Written: %s\n", path)
+	}
+	return nil
+}
+
+// nextMigrationVersion returns one more than the highest existing version
+func nextMigrationVersion(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 1, nil
+		}
+		return 0, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	highest := 0
+	for _, e := range entries {
+		if m := migrationFilePattern.FindStringSubmatch(e.Name()); m != nil {
+			if v, err := strconv.Atoi(m[1]); err == nil && v > highest {
+				highest = v
+			}
+		}
+	}
+	return highest + 1, nil
+}
+
+func migrationSlug(name string) string {
+	slug := strings.Trim(migrationSlugPattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return "schema_update"
+	}
+	return slug
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+)
+
+func TestDataModelSnapshot_PerDialectAndOutput(t *testing.T) {
+	projectDir := t.TempDir()
+
+	postgres := dataModelSnapshot(&GenSQLConfig{ProjectDir: projectDir, OutputDir: filepath.Join(projectDir, "db"), Dialect: "postgres"})
+	if postgres != "data_model/postgres/db" {
+		t.Errorf("Expected the output dir relative to the project, got %s", postgres)
+	}
+
+	seen := map[string]bool{postgres: true}
+	for _, cfg := range []*GenSQLConfig{
+		{ProjectDir: projectDir, OutputDir: filepath.Join(projectDir, "db"), Dialect: "mysql"},
+		{ProjectDir: projectDir, OutputDir: filepath.Join(projectDir, "db2"), Dialect: "postgres"},
+	} {
+		name := dataModelSnapshot(cfg)
+		if seen[name] {
+			t.Errorf("Expected a separate snapshot for %s in %s, got %s", cfg.Dialect, cfg.OutputDir, name)
+		}
+		seen[name] = true
+	}
+}
+
+func TestSQLProjectDir_NearestProjectAboveOutput(t *testing.T) {
+	projectDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(projectDir, derivation.LoomDirName), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sqlProjectDir(filepath.Join(projectDir, "db", "sql")); got != projectDir {
+		t.Errorf("Expected %s, got %s", projectDir, got)
+	}
+	if got := sqlProjectDir(t.TempDir()); got != "." {
+		t.Errorf("Expected the current directory without a project, got %s", got)
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ikadar/loom-cli/internal/codegen"
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// GenTestsConfig holds configuration for the gen-tests command
+type GenTestsConfig struct {
+	InputDir  string // L3 directory containing test-cases.md
+	OutputDir string // Directory for generated test files
+	Lang      string // Target language (go)
+	Package   string // Package name of generated files
+	GroupBy   string // ac or aggregate
+	Force     bool   // Overwrite existing test files
+}
+
+func runGenTests() error {
+	genFlags := flag.NewFlagSet("gen-tests", flag.ExitOnError)
+	inputDir := genFlags.String("input-dir", ".", "L3 directory containing test-cases.md")
+	outputDir := genFlags.String("output-dir", "", "Directory for generated test files")
+	lang := genFlags.String("lang", "go", "Target language (go)")
+	pkg := genFlags.String("package", "", "Package name (default: output directory name)")
+	groupBy := genFlags.String("group", codegen.GroupByAC, "One file per: ac, aggregate")
+	force := genFlags.Bool("force", false, "Overwrite existing test files")
+
+	if len(os.Args) > 2 {
+		genFlags.Parse(os.Args[2:])
+	}
+
+	cfg := &GenTestsConfig{
+		InputDir:  *inputDir,
+		OutputDir: *outputDir,
+		Lang:      *lang,
+		Package:   *pkg,
+		GroupBy:   *groupBy,
+		Force:     *force,
+	}
+
+	return executeGenTests(cfg)
+}
+
+func executeGenTests(cfg *GenTestsConfig) error {
+	if cfg.OutputDir == "" {
+		return fmt.Errorf("--output-dir is required")
+	}
+	if cfg.Lang != "go" {
+		return fmt.Errorf("unsupported language: %s (supported: go)", cfg.Lang)
+	}
+
+	tcPath := filepath.Join(cfg.InputDir, "test-cases.md")
+	content, err := os.ReadFile(tcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read test cases: %w", err)
+	}
+
+	testCases := formatter.ParseTestCases(string(content))
+	if len(testCases) == 0 {
+		return fmt.Errorf("no test cases found in %s", tcPath)
+	}
+	fmt.Fprintf(os.Stderr, "Read %d test cases from %s\n", len(testCases), tcPath)
+
+	pkg := cfg.Package
+	if pkg == "" {
+		absOut, err := filepath.Abs(cfg.OutputDir)
+		if err != nil {
+			absOut = cfg.OutputDir
+		}
+		pkg = codegen.GoPackageName(filepath.Base(absOut))
+	}
+
+	files, err := codegen.GenerateGoTests(testCases, codegen.GoTestOptions{
+		Package: pkg,
+		GroupBy: cfg.GroupBy,
+		Source:  "test-cases.md",
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(cfg.OutputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	written, skipped := 0, 0
+	for _, f := range files {
+		path := filepath.Join(cfg.OutputDir, f.Path)
+		if _, err := os.Stat(path); err == nil && !cfg.Force {
+			fmt.Fprintf(os.Stderr, "  Skipped (exists): %s\n", path)
+			skipped++
+			continue
+		}
+		if err := os.WriteFile(path, f.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Fprintf(os.Stderr, "  Written: %s\n", path)
+		written++
+	}
+
+	fmt.Fprintf(os.Stderr, "\nGenerated %d test file(s) in package %s", written, pkg)
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, ", skipped %d existing (use --force to overwrite)", skipped)
+	}
+	fmt.Fprintln(os.Stderr)
+
+	return nil
+}
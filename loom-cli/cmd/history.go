@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+)
+
+// HistoryConfig holds configuration for the history, diff and rollback commands
+type HistoryConfig struct {
+	ProjectDir string
+	Runs       []string // run references (positional args)
+	Format     string   // output format: text, json
+	DryRun     bool     // rollback: show what would be restored
+}
+
+func runHistory() error {
+	historyFlags := flag.NewFlagSet("history", flag.ExitOnError)
+	projectDir := historyFlags.String("project-dir", ".", "Project root directory")
+	format := historyFlags.String("format", "text", "Output format (text, json)")
+
+	if len(os.Args) > 2 {
+		historyFlags.Parse(os.Args[2:])
+	}
+
+	return executeHistory(&HistoryConfig{
+		ProjectDir: *projectDir,
+		Format:     *format,
+	})
+}
+
+func executeHistory(cfg *HistoryConfig) error {
+	history := derivation.NewHistory(derivation.NewStateManager(cfg.ProjectDir))
+	runs, err := history.List()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Format == "json" {
+		if runs == nil {
+			runs = []*derivation.Run{}
+		}
+		outputJSON(runs)
+		return nil
+	}
+
+	if len(runs) == 0 {
+		fmt.Println("No derivation runs recorded.")
+		return nil
+	}
+
+	fmt.Println("\n=== Derivation History ===")
+	fmt.Println()
+	for _, run := range runs {
+		fmt.Printf("%s  %s  %-14s  +%d ~%d -%d\n",
+			run.ID, run.CreatedAt.Local().Format("2006-01-02 15:04:05"), run.Command,
+			len(run.Added), len(run.Changed), len(run.Removed))
+		if len(run.Decisions) > 0 {
+			fmt.Printf("      decisions: %s\n", strings.Join(run.Decisions, ", "))
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+func runDiff() error {
+	diffFlags := flag.NewFlagSet("diff", flag.ExitOnError)
+	projectDir := diffFlags.String("project-dir", ".", "Project root directory")
+	format := diffFlags.String("format", "text", "Output format (text, json)")
+
+	if len(os.Args) > 2 {
+		diffFlags.Parse(os.Args[2:])
+	}
+
+	return executeDiff(&HistoryConfig{
+		ProjectDir: *projectDir,
+		Runs:       diffFlags.Args(),
+		Format:     *format,
+	})
+}
+
+func executeDiff(cfg *HistoryConfig) error {
+	if len(cfg.Runs) != 2 {
+		return fmt.Errorf("usage: loom-cli diff <run1> <run2>")
+	}
+
+	history := derivation.NewHistory(derivation.NewStateManager(cfg.ProjectDir))
+	from, err := history.Get(cfg.Runs[0])
+	if err != nil {
+		return err
+	}
+	to, err := history.Get(cfg.Runs[1])
+	if err != nil {
+		return err
+	}
+
+	diff := derivation.DiffRuns(from, to)
+	if cfg.Format == "json" {
+		outputJSON(diff)
+		return nil
+	}
+
+	fmt.Printf("\n=== Diff %s → %s ===\n\n", diff.From, diff.To)
+	if len(diff.Added)+len(diff.Changed)+len(diff.Removed) == 0 {
+		fmt.Println("No artifact changes.")
+	}
+	for _, id := range diff.Added {
+		fmt.Printf("  + %s\n", id)
+	}
+	for _, id := range diff.Changed {
+		fmt.Printf("  ~ %s\n", id)
+	}
+	for _, id := range diff.Removed {
+		fmt.Printf("  - %s\n", id)
+	}
+	if len(diff.Files) > 0 {
+		fmt.Println("\nDocuments:")
+		for _, path := range diff.Files {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+func runRollback() error {
+	rollbackFlags := flag.NewFlagSet("rollback", flag.ExitOnError)
+	projectDir := rollbackFlags.String("project-dir", ".", "Project root directory")
+	dryRun := rollbackFlags.Bool("dry-run", false, "Show what would be restored")
+
+	if len(os.Args) > 2 {
+		rollbackFlags.Parse(os.Args[2:])
+	}
+
+	return executeRollback(&HistoryConfig{
+		ProjectDir: *projectDir,
+		Runs:       rollbackFlags.Args(),
+		DryRun:     *dryRun,
+	})
+}
+
+func executeRollback(cfg *HistoryConfig) error {
+	if len(cfg.Runs) != 1 {
+		return fmt.Errorf("usage: loom-cli rollback <run>")
+	}
+
+	history := derivation.NewHistory(derivation.NewStateManager(cfg.ProjectDir))
+
+	if cfg.DryRun {
+		target, err := history.Get(cfg.Runs[0])
+		if err != nil {
+			return err
+		}
+		latest, err := history.Get(derivation.LatestRun)
+		if err != nil {
+			return err
+		}
+		var restored []string
+		for _, path := range derivation.DiffRuns(latest, target).Files {
+			if _, ok := target.Files[path]; ok {
+				restored = append(restored, path)
+			}
+		}
+		fmt.Printf("[DRY-RUN] Rolling back to run %s would restore %d document(s):\n", target.ID, len(restored))
+		for _, path := range restored {
+			fmt.Printf("  %s\n", path)
+		}
+		return nil
+	}
+
+	result, err := history.Rollback(cfg.Runs[0])
+	if err != nil {
+		return fmt.Errorf("rollback failed: %w", err)
+	}
+
+	fmt.Printf("Rolled back to run %s (%s).\n", result.Run.ID, result.Run.Command)
+	for _, path := range result.Restored {
+		fmt.Printf("  Restored: %s\n", path)
+	}
+	fmt.Printf("Recorded as run %s.\n", result.Recorded.ID)
+	return nil
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+	"github.com/ikadar/loom-cli/internal/formatter"
+	"github.com/ikadar/loom-cli/internal/results"
+)
+
+// IngestResultsConfig holds configuration for the ingest-results command
+type IngestResultsConfig struct {
+	Input      string // Report file, "-" for stdin
+	Format     string // auto, gotest or junit
+	L3Dir      string // Directory containing test-cases.md
+	ProjectDir string
+	Verbose    bool
+}
+
+func runIngestResults() error {
+	ingestFlags := flag.NewFlagSet("ingest-results", flag.ExitOnError)
+	input := ingestFlags.String("input", "", "Test report: go test -json output or JUnit XML (- for stdin)")
+	format := ingestFlags.String("format", results.FormatAuto, "Report format (auto, gotest, junit)")
+	l3Dir := ingestFlags.String("l3-dir", ".", "L3 directory containing test-cases.md")
+	projectDir := ingestFlags.String("project-dir", ".", "Project root directory")
+	verbose := ingestFlags.Bool("verbose", false, "List tests that reference no test case")
+
+	if len(os.Args) > 2 {
+		ingestFlags.Parse(os.Args[2:])
+	}
+
+	cfg := &IngestResultsConfig{
+		Input:      *input,
+		Format:     *format,
+		L3Dir:      *l3Dir,
+		ProjectDir: *projectDir,
+		Verbose:    *verbose,
+	}
+
+	return executeIngestResults(cfg)
+}
+
+func executeIngestResults(cfg *IngestResultsConfig) error {
+	if cfg.Input == "" {
+		return fmt.Errorf("--input is required")
+	}
+
+	var data []byte
+	var err error
+	if cfg.Input == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(cfg.Input)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read test report: %w", err)
+	}
+
+	outcomes, err := results.Parse(data, cfg.Format)
+	if err != nil {
+		return err
+	}
+
+	tcPath := filepath.Join(cfg.L3Dir, "test-cases.md")
+	content, err := os.ReadFile(tcPath)
+	if err != nil {
+		return fmt.Errorf("failed to read test cases: %w", err)
+	}
+	testCases := formatter.ParseTestCases(string(content))
+	if len(testCases) == 0 {
+		return fmt.Errorf("no test cases found in %s", tcPath)
+	}
+
+	ingestion := results.Map(testCases, outcomes, time.Now().UTC())
+
+	sm := derivation.NewStateManager(cfg.ProjectDir)
+	if err := sm.Lock(); err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer sm.Unlock()
+
+	state, err := sm.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	state.SetTestResults(ingestion.Results)
+	if err := sm.Save(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	counts := ingestion.Counts()
+	fmt.Fprintf(os.Stderr, "Ingested %d tests for %d test cases: %d passed, %d failed, %d skipped, %d missing\n",
+		len(outcomes), len(testCases), counts[derivation.TestPass], counts[derivation.TestFail],
+		counts[derivation.TestSkip], counts[derivation.TestMissing])
+
+	verified := 0
+	coverage := results.Coverage(ingestion.Results)
+	for _, c := range coverage {
+		if c.Verified() {
+			verified++
+		}
+		for _, tc := range c.Failing {
+			fmt.Fprintf(os.Stderr, "  ✗ %s (%s)\n", tc, c.AC)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "ACs verified: %d/%d\n", verified, len(coverage))
+
+	if len(ingestion.Unmatched) > 0 {
+		fmt.Fprintf(os.Stderr, "%d tests reference no test case\n", len(ingestion.Unmatched))
+		if cfg.Verbose {
+			for _, name := range ingestion.Unmatched {
+				fmt.Fprintf(os.Stderr, "  ? %s\n", name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -16,6 +16,7 @@ type InitConfig struct {
 	ProjectDir string
 	Force      bool
 	ScanDocs   bool
+	Backend    string // state storage backend: json or kv
 }
 
 func runInit() error {
@@ -24,6 +25,7 @@ func runInit() error {
 	projectDir := initFlags.String("project-dir", ".", "Project root directory")
 	force := initFlags.Bool("force", false, "Overwrite existing state file")
 	scanDocs := initFlags.Bool("scan", false, "Scan existing documents and build initial state")
+	backend := initFlags.String("backend", derivation.BackendJSON, "State storage backend (json, kv)")
 
 	// Parse arguments
 	if len(os.Args) > 2 {
@@ -34,6 +36,7 @@ func runInit() error {
 		ProjectDir: *projectDir,
 		Force:      *force,
 		ScanDocs:   *scanDocs,
+		Backend:    *backend,
 	}
 
 	return executeInit(cfg)
@@ -55,12 +58,26 @@ func executeInit(cfg *InitConfig) error {
 	sm := derivation.NewStateManager(absPath)
 
 	// Check if state already exists
-	if _, err := os.Stat(sm.StatePath); err == nil {
+	if sm.Store.Exists() {
 		if !cfg.Force {
-			return fmt.Errorf("state file already exists at %s (use --force to overwrite)", sm.StatePath)
+			return fmt.Errorf("state file already exists at %s (use --force to overwrite)", sm.Store.Path())
 		}
 		fmt.Printf("Overwriting existing state file...\n")
+		if err := sm.Store.Remove(); err != nil {
+			return err
+		}
+	}
+
+	// Select the storage backend
+	backend := cfg.Backend
+	if backend == "" {
+		backend = derivation.BackendJSON
+	}
+	store, err := derivation.NewStateStore(backend, sm.LoomDir)
+	if err != nil {
+		return err
 	}
+	sm.Store = store
 
 	// Create .loom directory
 	if err := os.MkdirAll(sm.LoomDir, 0755); err != nil {
@@ -83,7 +100,7 @@ func executeInit(cfg *InitConfig) error {
 	}
 
 	fmt.Printf("Initialized loom project at %s\n", absPath)
-	fmt.Printf("State file: %s\n", sm.StatePath)
+	fmt.Printf("State file: %s (%s)\n", sm.Store.Path(), sm.Store.Backend())
 
 	if len(state.Artifacts) > 0 {
 		fmt.Printf("Discovered %d artifacts\n", len(state.Artifacts))
@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ikadar/loom-cli/internal/claude"
+	"github.com/ikadar/loom-cli/internal/decisions"
 	"github.com/ikadar/loom-cli/internal/domain"
 	"github.com/ikadar/loom-cli/internal/interview"
 )
@@ -17,6 +21,9 @@ const (
 	ExitCodeQuestion  = 100 // There's a question to answer
 )
 
+// DecisionCorpusEnv lists decision corpus directories (like PATH)
+const DecisionCorpusEnv = "LOOM_DECISION_CORPUS"
+
 func runInterview() error {
 	// Parse arguments
 	args := os.Args[2:]
@@ -26,6 +33,12 @@ func runInterview() error {
 	var answersJSON string // For batch answers (grouped mode)
 	var initFile string
 	var grouped bool
+	var corpusDirs []string
+	var adaptive bool
+	var budget int
+	var criticalOnly bool
+	maxDepth := interview.DefaultMaxFollowUpDepth
+	maxFollowUps := interview.DefaultMaxFollowUps
 
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
@@ -51,12 +64,60 @@ func runInterview() error {
 			}
 		case "--grouped", "-g":
 			grouped = true
+		case "--corpus":
+			if i+1 < len(args) {
+				i++
+				corpusDirs = append(corpusDirs, args[i])
+			}
+		case "--budget":
+			if i+1 < len(args) {
+				i++
+				n, err := strconv.Atoi(args[i])
+				if err != nil || n < 1 {
+					return fmt.Errorf("--budget requires a positive number")
+				}
+				budget = n
+			}
+		case "--critical-only":
+			criticalOnly = true
+		case "--adaptive":
+			adaptive = true
+		case "--max-depth":
+			if i+1 < len(args) {
+				i++
+				n, err := strconv.Atoi(args[i])
+				if err != nil || n < 1 {
+					return fmt.Errorf("--max-depth requires a positive number")
+				}
+				maxDepth = n
+			}
+		case "--max-followups":
+			if i+1 < len(args) {
+				i++
+				n, err := strconv.Atoi(args[i])
+				if err != nil || n < 0 {
+					return fmt.Errorf("--max-followups requires a non-negative number")
+				}
+				maxFollowUps = n
+			}
 		}
 	}
 
+	// Decision corpus from the environment (path list)
+	if env := os.Getenv(DecisionCorpusEnv); env != "" {
+		corpusDirs = append(corpusDirs, filepath.SplitList(env)...)
+	}
+
 	// Mode 1: Initialize from analysis file
 	if initFile != "" {
-		return initInterview(initFile, stateFile, grouped)
+		var settings *domain.AdaptiveSettings
+		if adaptive {
+			settings = &domain.AdaptiveSettings{
+				MaxDepth:     maxDepth,
+				MaxFollowUps: maxFollowUps,
+			}
+		}
+		return initInterview(initFile, stateFile, grouped, corpusDirs, settings, budget, criticalOnly)
 	}
 
 	// Mode 2: Continue interview with answer
@@ -68,7 +129,7 @@ func runInterview() error {
 }
 
 // initInterview creates a new interview state from analysis output
-func initInterview(analysisFile, stateFile string, grouped bool) error {
+func initInterview(analysisFile, stateFile string, grouped bool, corpusDirs []string, adaptive *domain.AdaptiveSettings, budget int, criticalOnly bool) error {
 	// Read analysis file
 	content, err := os.ReadFile(analysisFile)
 	if err != nil {
@@ -89,6 +150,19 @@ func initInterview(analysisFile, stateFile string, grouped bool) error {
 	// Add dependency information to questions
 	questions := addDependencies(analysis.Ambiguities)
 
+	// Ask the most severe, highest-impact questions first
+	questions = interview.PrioritizeQuestions(questions, analysis.DomainModel)
+
+	// Look up similar decisions from other projects
+	if len(corpusDirs) > 0 {
+		corpus, err := decisions.BuildCorpus(corpusDirs)
+		if err != nil {
+			return fmt.Errorf("failed to index decision corpus: %w", err)
+		}
+		questions = interview.AttachPriorDecisions(questions, corpus, decisions.DefaultMinSimilarity)
+		fmt.Fprintf(os.Stderr, "Indexed %d prior decisions from corpus\n", len(corpus.Entries))
+	}
+
 	// Create initial state
 	state := domain.InterviewState{
 		SessionID:    fmt.Sprintf("interview-%d", time.Now().Unix()),
@@ -99,6 +173,9 @@ func initInterview(analysisFile, stateFile string, grouped bool) error {
 		Skipped:      []string{},
 		InputContent: analysis.InputContent,
 		Complete:     false,
+		Adaptive:     adaptive,
+		Budget:       budget,
+		CriticalOnly: criticalOnly,
 	}
 
 	// Save state
@@ -127,6 +204,7 @@ func continueInterview(stateFile, answerJSON, answersJSON string) error {
 
 	// Determine if we're in grouped mode based on answersJSON
 	grouped := answersJSON != ""
+	answeredFrom := len(state.Decisions)
 
 	// Process batch answers if provided (grouped mode)
 	if answersJSON != "" {
@@ -151,6 +229,7 @@ func continueInterview(stateFile, answerJSON, answersJSON string) error {
 						Source:    answer.Source,
 						Category:  q.Category,
 						Subject:   q.Subject,
+						ParentID:  q.ParentID,
 					}
 					state.Decisions = append(state.Decisions, decision)
 					state.CurrentIndex++ // Advance for each answer
@@ -181,6 +260,7 @@ func continueInterview(stateFile, answerJSON, answersJSON string) error {
 					Source:    answer.Source,
 					Category:  q.Category,
 					Subject:   q.Subject,
+					ParentID:  q.ParentID,
 				}
 				state.Decisions = append(state.Decisions, decision)
 				break
@@ -191,6 +271,19 @@ func continueInterview(stateFile, answerJSON, answersJSON string) error {
 		state.CurrentIndex++
 	}
 
+	// Adaptive mode: ask for follow-ups raised by the new answers
+	if state.Adaptive != nil && len(state.Decisions) > answeredFrom {
+		generator := interview.NewFollowUpGenerator(claude.NewClient())
+		added, err := generator.ProposeFollowUps(state, state.Decisions[answeredFrom:])
+		if err != nil {
+			// Follow-ups are best effort; the interview continues without them
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else if len(added) > 0 {
+			interview.PrioritizeRemaining(state)
+			fmt.Fprintf(os.Stderr, "Added %d follow-up question(s)\n", len(added))
+		}
+	}
+
 	// Save updated state
 	if err := saveState(state, stateFile); err != nil {
 		return err
@@ -220,8 +313,16 @@ func outputNextQuestion(state *domain.InterviewState, stateFile string) error {
 			continue
 		}
 
+		// Outside --critical-only / --budget: record the default instead
+		if interview.ShouldDefault(state, q) {
+			state.Decisions = append(state.Decisions, interview.DefaultDecision(q))
+			state.CurrentIndex++
+			saveState(state, stateFile)
+			continue
+		}
+
 		// Found a question to ask
-		answeredCount := len(state.Decisions) - countExisting(state.Decisions)
+		answeredCount := interview.AskedCount(state.Decisions)
 		remaining := totalQuestions - state.CurrentIndex - len(state.Skipped)
 
 		output := domain.InterviewOutput{
@@ -241,13 +342,7 @@ func outputNextQuestion(state *domain.InterviewState, stateFile string) error {
 	state.Complete = true
 	saveState(state, stateFile)
 
-	output := domain.InterviewOutput{
-		Status:         "complete",
-		RemainingCount: 0,
-		SkippedCount:   len(state.Skipped),
-		Message:        fmt.Sprintf("Interview complete. %d decisions recorded, %d questions skipped.",
-			len(state.Decisions)-countExisting(state.Decisions), len(state.Skipped)),
-	}
+	output := completeOutput(state)
 
 	outputJSON(output)
 	os.Exit(ExitCodeComplete)
@@ -262,18 +357,15 @@ func outputNextGroup(state *domain.InterviewState, stateFile string) error {
 	remaining := interview.FilterAnsweredQuestions(state.Questions, state.Decisions)
 	remaining = interview.FilterSkippedQuestions(remaining, state.Decisions, state.Skipped)
 
+	// Outside --critical-only / --budget: record defaults instead of asking
+	remaining = interview.ApplyDefaults(state, remaining)
+
 	if len(remaining) == 0 {
 		// No more questions - interview complete
 		state.Complete = true
 		saveState(state, stateFile)
 
-		output := domain.InterviewOutput{
-			Status:         "complete",
-			RemainingCount: 0,
-			SkippedCount:   len(state.Skipped),
-			Message: fmt.Sprintf("Interview complete. %d decisions recorded, %d questions skipped.",
-				len(state.Decisions)-countExisting(state.Decisions), len(state.Skipped)),
-		}
+		output := completeOutput(state)
 
 		outputJSON(output)
 		os.Exit(ExitCodeComplete)
@@ -290,7 +382,8 @@ func outputNextGroup(state *domain.InterviewState, stateFile string) error {
 
 	// Output the first group
 	group := groups[0]
-	answeredCount := len(state.Decisions) - countExisting(state.Decisions)
+	answeredCount := interview.AskedCount(state.Decisions)
+	saveState(state, stateFile)
 
 	output := domain.InterviewOutput{
 		Status:         "group",
@@ -306,6 +399,21 @@ func outputNextGroup(state *domain.InterviewState, stateFile string) error {
 	return nil
 }
 
+// completeOutput builds the final output, listing the defaulted questions so
+// reviewers can see which assumptions the derivation relies on
+func completeOutput(state *domain.InterviewState) domain.InterviewOutput {
+	defaulted := interview.DefaultedDecisions(state.Decisions)
+
+	return domain.InterviewOutput{
+		Status:         "complete",
+		RemainingCount: 0,
+		SkippedCount:   len(state.Skipped),
+		Message: fmt.Sprintf("Interview complete. %d decisions recorded, %d questions skipped, %d defaulted.",
+			len(state.Decisions)-countExisting(state.Decisions), len(state.Skipped), len(defaulted)),
+		Defaulted: defaulted,
+	}
+}
+
 // shouldSkip checks if a question should be skipped based on previous answers
 func shouldSkip(q *domain.Ambiguity, decisions []domain.Decision) bool {
 	if len(q.DependsOn) == 0 {
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+)
+
+// LockConfig holds configuration for the lock command
+type LockConfig struct {
+	Action     string // status or break
+	ProjectDir string
+	Format     string // output format: text, json
+	Force      bool   // break: also remove locks whose owner is running
+}
+
+func runLock() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: loom-cli lock <status|break> [options]")
+	}
+	action := os.Args[2]
+	if action != "status" && action != "break" {
+		return fmt.Errorf("unknown lock action: %s (expected status or break)", action)
+	}
+
+	lockFlags := flag.NewFlagSet("lock "+action, flag.ExitOnError)
+	projectDir := lockFlags.String("project-dir", ".", "Project root directory")
+	format := lockFlags.String("format", "text", "Output format (text, json)")
+	force := lockFlags.Bool("force", false, "Break locks even if their owner is still running")
+
+	lockFlags.Parse(os.Args[3:])
+
+	return executeLock(&LockConfig{
+		Action:     action,
+		ProjectDir: *projectDir,
+		Format:     *format,
+		Force:      *force,
+	})
+}
+
+func executeLock(cfg *LockConfig) error {
+	sm := derivation.NewStateManager(cfg.ProjectDir)
+
+	if cfg.Action == "break" {
+		broken, err := sm.BreakLock(cfg.Force)
+		if err != nil {
+			return err
+		}
+		if len(broken) == 0 {
+			fmt.Println("No stale locks to break.")
+		}
+		for _, l := range broken {
+			fmt.Printf("Broke %s lock (%s)\n", l.Mode, l)
+		}
+		if !cfg.Force {
+			if status, err := sm.LockStatus(); err == nil && (status.Exclusive != nil || len(status.Shared) > 0) {
+				fmt.Println("Locks held by running processes were kept; use --force to break them.")
+			}
+		}
+		return nil
+	}
+
+	status, err := sm.LockStatus()
+	if err != nil {
+		return err
+	}
+	if cfg.Format == "json" {
+		outputJSON(status)
+		return nil
+	}
+
+	if status.Exclusive == nil && len(status.Shared) == 0 {
+		fmt.Println("State is not locked.")
+		return nil
+	}
+	for _, l := range append([]*derivation.LockInfo{status.Exclusive}, status.Shared...) {
+		if l == nil {
+			continue
+		}
+		state := "active"
+		if l.Stale {
+			state = "stale"
+		}
+		fmt.Printf("%-9s  %-6s  %s, last heartbeat %s ago\n",
+			l.Mode, state, l, time.Since(l.HeartbeatAt).Round(time.Second))
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/ikadar/loom-cli/internal/decisions"
 	"github.com/ikadar/loom-cli/internal/derivation"
 )
 
@@ -15,7 +16,9 @@ type MigrateConfig struct {
 	DryRun     bool
 	BackupDir  string
 	Verbose    bool
-	Force      bool // Force migration even if already has markers
+	Force      bool   // Force migration even if already has markers
+	Decisions  string // Migrate this decisions.md to the decision store instead
+	Mapping    string // JSON file describing a custom ID scheme
 }
 
 func runMigrate() error {
@@ -25,6 +28,8 @@ func runMigrate() error {
 	backupDir := migrateFlags.String("backup-dir", "", "Directory for backups (default: .loom/backups)")
 	verbose := migrateFlags.Bool("verbose", false, "Show detailed output")
 	force := migrateFlags.Bool("force", false, "Force migration even if markers exist")
+	decisionsFile := migrateFlags.String("decisions", "", "Migrate a decisions.md file to the decision store")
+	mapping := migrateFlags.String("mapping", "", "JSON file mapping custom ID patterns to types and layers")
 
 	if len(os.Args) > 2 {
 		migrateFlags.Parse(os.Args[2:])
@@ -36,6 +41,12 @@ func runMigrate() error {
 		BackupDir:  *backupDir,
 		Verbose:    *verbose,
 		Force:      *force,
+		Decisions:  *decisionsFile,
+		Mapping:    *mapping,
+	}
+
+	if cfg.Decisions != "" {
+		return executeMigrateDecisions(cfg)
 	}
 
 	return executeMigrate(cfg)
@@ -59,6 +70,14 @@ func executeMigrate(cfg *MigrateConfig) error {
 		migrator.BackupDir = cfg.BackupDir
 	}
 
+	if cfg.Mapping != "" {
+		mapping, err := derivation.LoadMigrationMapping(cfg.Mapping)
+		if err != nil {
+			return err
+		}
+		migrator.UseMapping(mapping)
+	}
+
 	// Run migration
 	result, err := migrator.MigrateProject(cfg.ProjectDir)
 	if err != nil {
@@ -103,6 +122,35 @@ func executeMigrate(cfg *MigrateConfig) error {
 	return nil
 }
 
+func executeMigrateDecisions(cfg *MigrateConfig) error {
+	fmt.Println("=== Decisions Migration ===")
+	fmt.Println()
+
+	if cfg.DryRun {
+		fmt.Println("DRY RUN MODE - no changes will be made")
+		fmt.Println()
+	}
+
+	ds, err := decisions.Migrate(cfg.Decisions, cfg.DryRun)
+	if err != nil {
+		return fmt.Errorf("decisions migration failed: %w", err)
+	}
+
+	fmt.Printf("Decisions found: %d\n", len(ds.Decisions))
+	if cfg.Verbose {
+		for _, d := range ds.Decisions {
+			fmt.Printf("  %s: %s\n", d.AmbiguityID, d.Question)
+		}
+	}
+
+	if !cfg.DryRun {
+		fmt.Printf("\nStore written: %s\n", decisions.StorePath(cfg.Decisions))
+		fmt.Printf("Re-rendered:   %s (original kept as %s.bak)\n", cfg.Decisions, cfg.Decisions)
+	}
+
+	return nil
+}
+
 func printMigrationReport(result *derivation.MigrationResult, verbose bool) {
 	stats := result.Statistics
 
@@ -150,6 +198,22 @@ func printMigrationReport(result *derivation.MigrationResult, verbose bool) {
 		}
 	}
 
+	// Inferred dependencies
+	if verbose && len(result.Dependencies) > 0 {
+		fmt.Println("\nDependencies:")
+		for _, dep := range result.Dependencies {
+			fmt.Printf("  %s → %s (%s, %s)\n", dep.Upstream, dep.Downstream, dep.Source, dep.Confidence)
+		}
+	}
+
+	// Low-confidence sections and links
+	if len(result.Review) > 0 {
+		fmt.Println("\nNeeds Review:")
+		for _, item := range result.Review {
+			fmt.Printf("  ? %s (%s): %s\n", item.ID, item.File, item.Reason)
+		}
+	}
+
 	// Warnings
 	if len(result.Warnings) > 0 {
 		fmt.Println("\nWarnings:")
@@ -19,6 +19,7 @@ type RederiveConfig struct {
 	Verbose        bool     // Detailed output
 	PreserveManual bool     // Keep manual sections
 	Interactive    bool     // Confirm each derivation
+	Merge          string   // How hand edits are merged (derivation.MergeStrategy)
 }
 
 func runRederive() error {
@@ -30,6 +31,7 @@ func runRederive() error {
 	verbose := rederiveFlags.Bool("verbose", false, "Show detailed output")
 	preserveManual := rederiveFlags.Bool("preserve-manual", true, "Keep manual sections during re-derivation")
 	interactive := rederiveFlags.Bool("interactive", false, "Confirm each derivation")
+	merge := rederiveFlags.String("merge", string(derivation.MergePreserveManual), "How hand edits are merged: preserve_manual, overwrite_all, interactive, report_only")
 
 	if len(os.Args) > 2 {
 		rederiveFlags.Parse(os.Args[2:])
@@ -47,6 +49,7 @@ func runRederive() error {
 		Verbose:        *verbose,
 		PreserveManual: *preserveManual,
 		Interactive:    *interactive,
+		Merge:          *merge,
 	}
 
 	return executeRederive(cfg)
@@ -57,6 +60,15 @@ func executeRederive(cfg *RederiveConfig) error {
 	if !cfg.All && len(cfg.ArtifactIDs) == 0 && cfg.Layer == "" {
 		return fmt.Errorf("specify artifact IDs, --layer, or --all")
 	}
+	strategy := derivation.MergeStrategy(cfg.Merge)
+	switch strategy {
+	case "":
+		strategy = derivation.MergePreserveManual
+	case derivation.MergePreserveManual, derivation.MergeOverwriteAll,
+		derivation.MergeInteractive, derivation.MergeReportOnly:
+	default:
+		return fmt.Errorf("unknown merge strategy: %s", cfg.Merge)
+	}
 
 	// Load state
 	sm := derivation.NewStateManager(cfg.ProjectDir)
@@ -70,6 +82,10 @@ func executeRederive(cfg *RederiveConfig) error {
 	executor.DryRun = cfg.DryRun
 	executor.Verbose = cfg.Verbose
 	executor.PreserveManual = cfg.PreserveManual
+	executor.MergeStrategy = strategy
+	if strategy == derivation.MergeInteractive {
+		executor.Resolver = resolveConflict
+	}
 
 	// Set up progress callback
 	executor.ProgressCallback = func(event derivation.ProgressEvent) {
@@ -152,6 +168,19 @@ func executeRederive(cfg *RederiveConfig) error {
 		}
 	}
 
+	// Stage all writes so that documents and state are replaced together
+	var tx *derivation.Transaction
+	if !cfg.DryRun && strategy != derivation.MergeReportOnly {
+		tx, err = sm.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Abort()
+		printRecovery(sm)
+		executor.Tx = tx
+		executor.Hasher.Tx = tx
+	}
+
 	// Execute derivation
 	result, err := executor.Execute(artifactIDs)
 	if err != nil {
@@ -162,11 +191,24 @@ func executeRederive(cfg *RederiveConfig) error {
 	printResults(result)
 
 	// Save updated state
-	if !cfg.DryRun && len(result.Derived) > 0 {
-		if err := sm.Save(state); err != nil {
+	written := 0
+	for _, d := range result.Derived {
+		if d.Written {
+			written++
+		}
+	}
+	if written > 0 {
+		if err := tx.SaveState(state); err != nil {
 			return fmt.Errorf("failed to save state: %w", err)
 		}
-		fmt.Println("State saved.")
+		run, err := derivation.NewHistory(sm).Record("rederive", state, tx)
+		if err != nil {
+			return fmt.Errorf("failed to record history: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit derivation: %w", err)
+		}
+		fmt.Printf("State saved (run %s).\n", run.ID)
 	}
 
 	// Return error if any derivations failed
@@ -276,7 +318,17 @@ func printResults(result *derivation.ExecutionResult) {
 	if len(result.Derived) > 0 {
 		fmt.Println("\nDerived:")
 		for _, d := range result.Derived {
-			fmt.Printf("  ✓ %s (%s) → %s\n", d.ArtifactID, d.Layer, d.OutputFile)
+			note := ""
+			switch {
+			case d.Conflicts > 0:
+				note = fmt.Sprintf(" [hand edits merged, %d conflict(s) marked]", d.Conflicts)
+			case d.Edited:
+				note = " [hand edits merged]"
+			}
+			if !d.Written {
+				note += " [not written]"
+			}
+			fmt.Printf("  ✓ %s (%s) → %s%s\n", d.ArtifactID, d.Layer, d.OutputFile, note)
 		}
 	}
 
@@ -299,6 +351,45 @@ func printResults(result *derivation.ExecutionResult) {
 	fmt.Println()
 }
 
+// resolveConflict asks which side of a merge conflict to keep
+func resolveConflict(c *derivation.Conflict) (string, bool, error) {
+	fmt.Printf("\nConflict in %s\n", c.ArtifactID)
+	fmt.Println("--- current (edited by hand) ---")
+	fmt.Println(c.Current)
+	fmt.Println("--- generated ---")
+	fmt.Println(c.Generated)
+	fmt.Println(strings.Repeat("─", 50))
+	fmt.Print("Keep [c]urrent / [g]enerated / [b]oth / [m]ark conflict? [m] ")
+
+	var input string
+	fmt.Scanln(&input)
+
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "c", "current":
+		return c.Current, true, nil
+	case "g", "generated":
+		return c.Generated, true, nil
+	case "b", "both":
+		return c.Current + "\n" + c.Generated, true, nil
+	default:
+		return "", false, nil
+	}
+}
+
+// printRecovery reports a derivation that was interrupted by a crash and
+// finished when the state lock was taken
+func printRecovery(sm *derivation.StateManager) {
+	r := sm.Recovered
+	if r == nil {
+		return
+	}
+	if r.RolledForward {
+		fmt.Fprintf(os.Stderr, "Recovered interrupted derivation %s: completed %d file(s)\n", r.ID, len(r.Files))
+	} else {
+		fmt.Fprintf(os.Stderr, "Recovered interrupted derivation %s: discarded its staged files\n", r.ID)
+	}
+}
+
 func confirmExecution() bool {
 	fmt.Print("Proceed with derivation? [y/N] ")
 	var response string
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+)
+
+// RefactorConfig holds configuration for the refactor command
+type RefactorConfig struct {
+	Action     string // rename-id, rename-domain
+	ProjectDir string
+	Old        string
+	New        string
+	DryRun     bool   // show the diff without writing
+	Format     string // output format: text, json
+}
+
+func runRefactor() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: loom-cli refactor <rename-id|rename-domain> <old> <new> [options]")
+	}
+	action := os.Args[2]
+	if action != "rename-id" && action != "rename-domain" {
+		return fmt.Errorf("unknown refactor action: %s (expected rename-id or rename-domain)", action)
+	}
+
+	refactorFlags := flag.NewFlagSet("refactor "+action, flag.ExitOnError)
+	projectDir := refactorFlags.String("project-dir", ".", "Project root directory")
+	dryRun := refactorFlags.Bool("dry-run", false, "Show the changes without writing")
+	format := refactorFlags.String("format", "text", "Output format (text, json)")
+
+	args := parseArgs(refactorFlags, os.Args[3:])
+	if len(args) != 2 {
+		return fmt.Errorf("usage: loom-cli refactor %s <old> <new> [options]", action)
+	}
+
+	return executeRefactor(&RefactorConfig{
+		Action:     action,
+		ProjectDir: *projectDir,
+		Old:        args[0],
+		New:        args[1],
+		DryRun:     *dryRun,
+		Format:     *format,
+	})
+}
+
+func executeRefactor(cfg *RefactorConfig) error {
+	var rename *derivation.Rename
+	var err error
+	if cfg.Action == "rename-domain" {
+		rename, err = derivation.NewDomainRename(cfg.Old, cfg.New)
+	} else {
+		rename, err = derivation.NewIDRename(cfg.Old, cfg.New)
+	}
+	if err != nil {
+		return err
+	}
+
+	sm := derivation.NewStateManager(cfg.ProjectDir)
+	if !sm.Store.Exists() {
+		return fmt.Errorf("no derivation state found in %s (run 'loom-cli init' first)", sm.LoomDir)
+	}
+	result, err := sm.Refactor(rename, cfg.DryRun)
+	if err != nil {
+		return fmt.Errorf("refactor failed: %w", err)
+	}
+
+	if cfg.Format == "json" {
+		outputJSON(result)
+		return nil
+	}
+
+	if len(result.Files) == 0 && len(result.IDs) == 0 {
+		fmt.Printf("Nothing references %s.\n", cfg.Old)
+		return nil
+	}
+
+	prefix := ""
+	if cfg.DryRun {
+		prefix = "[DRY-RUN] "
+	}
+	for _, doc := range result.Files {
+		fmt.Printf("--- a/%s\n+++ b/%s\n", doc.Path, doc.Path)
+		for _, line := range doc.Lines {
+			fmt.Printf("@@ %d @@\n-%s\n+%s\n", line.Line, line.Old, line.New)
+		}
+	}
+
+	ids := make([]string, 0, len(result.IDs))
+	for id := range result.IDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	fmt.Printf("\n%sRenamed %d ID(s) in state and %d document(s):\n", prefix, len(ids), len(result.Files))
+	for _, id := range ids {
+		fmt.Printf("  %s → %s\n", id, result.IDs[id])
+	}
+	if result.Run != nil {
+		fmt.Printf("Recorded as run %s.\n", result.Run.ID)
+	}
+	return nil
+}
+
+// parseArgs parses flags given before, between or after positional
+// arguments and returns the positional ones
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestParseArgs_FlagsAfterPositional(t *testing.T) {
+	fs := flag.NewFlagSet("refactor rename-id", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "")
+	format := fs.String("format", "text", "")
+
+	args := parseArgs(fs, []string{"AC-ORD-001", "AC-ORD-009", "--dry-run", "--format", "json"})
+
+	if !reflect.DeepEqual(args, []string{"AC-ORD-001", "AC-ORD-009"}) {
+		t.Errorf("Expected both IDs as positional arguments, got %v", args)
+	}
+	if !*dryRun || *format != "json" {
+		t.Errorf("Expected the trailing flags parsed, got dry-run=%v format=%s", *dryRun, *format)
+	}
+}
+
+func TestParseArgs_FlagsInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("artifact merge", flag.ContinueOnError)
+	into := fs.String("into", "", "")
+
+	args := parseArgs(fs, []string{"--into", "BR-ORD-001", "BR-ORD-002", "BR-ORD-003"})
+
+	if !reflect.DeepEqual(args, []string{"BR-ORD-002", "BR-ORD-003"}) || *into != "BR-ORD-001" {
+		t.Errorf("Unexpected parse: args=%v into=%s", args, *into)
+	}
+}
@@ -22,8 +22,6 @@ func Execute() error {
 		return runInit()
 	case "analyze":
 		return runAnalyze()
-	case "analyze-v2":
-		return runAnalyzeV2()
 	case "interview":
 		return runInterview()
 	case "derive":
@@ -38,10 +36,42 @@ func Execute() error {
 		return runSyncLinks()
 	case "cascade":
 		return runCascade()
+	case "gen-tests":
+		return runGenTests()
+	case "gen-gherkin":
+		return runGenGherkin()
+	case "gen-code":
+		return runGenCode()
+	case "gen-sql":
+		return runGenSQL()
+	case "gen-openapi":
+		return runGenOpenAPI()
+	case "gen-asyncapi":
+		return runGenAsyncAPI()
+	case "tickets":
+		return runTickets()
+	case "drift":
+		return runDrift()
+	case "ingest-results":
+		return runIngestResults()
 	case "status":
 		return runStatus()
 	case "rederive":
 		return runRederive()
+	case "history":
+		return runHistory()
+	case "diff":
+		return runDiff()
+	case "rollback":
+		return runRollback()
+	case "lock":
+		return runLock()
+	case "state":
+		return runState()
+	case "refactor":
+		return runRefactor()
+	case "artifact":
+		return runArtifact()
 	case "migrate":
 		return runMigrate()
 	case "version":
@@ -68,9 +98,29 @@ Usage:
   loom-cli derive-l3 [options]   # L2 → L3 (Operational Design)
   loom-cli status [options]      # Show derivation status (stale artifacts)
   loom-cli rederive [options]    # Re-derive stale artifacts
+  loom-cli history [options]     # List derivation runs
+  loom-cli diff <run1> <run2>    # Compare two runs artifact by artifact
+  loom-cli rollback <run>        # Restore documents and state of a run
+  loom-cli lock <status|break>   # Show or break locks on the derivation state
+  loom-cli state migrate --to <backend> # Move the derivation state to another backend
+  loom-cli state merge <base> <ours> <theirs> # Merge derivation states (git merge driver)
+  loom-cli refactor rename-id <old> <new>     # Rename an ID in all documents and state
+  loom-cli refactor rename-domain <old> <new> # Rename a domain code, e.g. ORD → ORDER
+  loom-cli artifact deprecate <ID> [--replaced-by IDs] # Retire an artifact
+  loom-cli artifact split <ID> --into <ID>,<ID>        # Split an artifact into new ones
+  loom-cli artifact merge <ID>... --into <ID>          # Merge artifacts into another
+  loom-cli artifact delete <ID> [--cascade]            # Delete an artifact
   loom-cli migrate [options]     # Migrate existing project to LOOM format
   loom-cli validate [options]    # Validate generated documents
   loom-cli sync-links [options]  # Fix missing bidirectional links
+  loom-cli gen-tests [options]   # L3 test cases → executable test skeletons
+  loom-cli gen-gherkin [options] # L1 ACs + L3 test cases → .feature files
+  loom-cli gen-code [options]    # L2 aggregates + contracts → Go packages
+  loom-cli gen-sql [options]     # L2 data model → SQL DDL + migrations
+  loom-cli gen-openapi [options] # L2 interface contracts → OpenAPI 3.1
+  loom-cli gen-asyncapi [options] # L3 event design → AsyncAPI 3.0 + JSON Schemas
+  loom-cli tickets <export|sync> [options] # Feature tickets → GitHub/Jira/Linear
+  loom-cli drift --code <dir> [options]    # Compare implementation code with the spec
   loom-cli version
   loom-cli help
 
@@ -84,9 +134,25 @@ Commands:
   derive-l3  Derive L3 Operational Design (Test Cases, API Spec, Skeletons, Events)
   status     Show derivation status and stale artifacts
   rederive   Re-derive stale artifacts (update from upstream changes)
+  history    List derivation runs recorded under .loom/history
+  diff       Show artifacts added, changed and removed between two runs
+  rollback   Restore the documents and state recorded by a run
+  lock       Show who holds the state lock, or break stale locks
+  state      Manage derivation state storage (migrate between json and kv, merge)
+  refactor   Rename IDs or domain codes across documents, anchors and state
+  artifact   Deprecate, split, merge or delete artifacts with their dependencies
   migrate    Migrate existing project to LOOM-marked format
   validate   Validate documents (structure, traceability, completeness, TDAI)
   sync-links Add missing bidirectional references between documents
+  gen-tests  Generate table-driven test files from L3 test-cases.md
+  gen-gherkin Export ACs and test cases as Gherkin features (Cucumber/godog)
+  gen-code   Generate Go code skeletons from aggregates and interface contracts
+  gen-sql    Generate SQL DDL and up/down migrations from the L2 data model
+  gen-openapi Generate an OpenAPI 3.1 document from interface contracts
+  gen-asyncapi Generate AsyncAPI and per-event JSON Schemas from the event design
+  tickets    Export feature tickets for issue trackers or sync them via the API
+  drift      Report code that diverged from the spec (missing, extra, renamed)
+  ingest-results Record test results (go test -json, JUnit) as AC verification
   version    Show version information
   help       Show this help message
 
@@ -99,6 +165,7 @@ Init Options:
   --project-dir <path>  Project root directory (default: current directory)
   --force               Overwrite existing state file
   --scan                Scan existing documents and build initial state
+  --backend <json|kv>   State storage backend (default: json; kv for large projects)
 
 Cascade Options (Full Derivation):
   --input-file <path>     L0 input file (user story)
@@ -107,30 +174,59 @@ Cascade Options (Full Derivation):
   --skip-interview        Skip interview, use AI defaults
   --decisions <path>      Use existing decisions.md
   --interactive, -i       Interactive approval mode
-  --resume                Resume from previous state
+  --resume                Resume from previous state (re-runs the phases
+                          whose input changed since they last ran)
   --from <level>          Re-derive from level (l1, l2, l3)
+  --full                  Re-analyze all of L0 instead of changed sections only
 
 Analyze Options:
   --input-file <path>     Path to single L0 input file
   --input-dir <path>      Path to directory with L0 files
   --decisions <path>      Path to existing decisions.md
+  --full                  Ignore the analysis cache and analyze all input
+
+  Analyze caches its result in .loom/analysis-cache.json next to the input.
+  Later runs hash each user story (story heading or LOOM section), drop
+  what changed or removed stories contributed, and only discover and
+  analyze added or changed stories.
 
 Interview Options:
   --init <path>           Initialize interview from analysis JSON
   --state <path>          Path to interview state file
   --answer <json>         JSON with answer: {"question_id":"...", "answer":"...", "source":"user"}
+  --corpus <dir>          Directory of other projects' decisions.md files (repeatable;
+                          also read from $LOOM_DECISION_CORPUS). Similar past decisions
+                          are attached to each question as prior_decisions.
+  --budget <n>            With --init: ask at most n questions (most severe and highest
+                          impact first); the rest are answered with their defaults
+  --critical-only         With --init: only ask critical questions; default the rest
+  --adaptive              With --init: after each answer (or group), ask the model for
+                          follow-up questions and append them (parent_id links them)
+  --max-depth <n>         Adaptive: maximum follow-up depth (default: 2)
+  --max-followups <n>     Adaptive: maximum follow-ups per interview (default: 15)
 
   Exit codes:
     0   = Interview complete, no more questions
     1   = Error
     100 = Question available (output contains question JSON)
 
+  Questions are ordered by severity, then by how many entities/operations the
+  answer affects. On completion, "defaulted" lists every question answered with
+  a default (source: default).
+
 Derive Options (L0 → L1):
   --output-dir <path>     Directory for generated L1 documents (required)
-  --decisions <path>      Path to decisions.md (to append new decisions)
+  --decisions <path>      Path to decisions.md (new decisions are recorded here)
   --analysis-file <path>  Path to analysis JSON or interview state
   --vocabulary <path>     Optional domain vocabulary file (enhances domain model)
   --nfr <path>            Optional non-functional requirements file (adds to BRs/ACs)
+  --input-file <path>     L0 input the analysis was made from (recorded as upstream)
+  --input-dir <path>      L0 input directory the analysis was made from
+
+  derive, derive-l2 and derive-l3 wrap each artifact section in LOOM markers
+  and record artifacts, upstream hashes, decisions and dependency edges in
+  .loom/ of the project: the closest directory holding .loom, else the parent
+  of an l1/l2/l3 output directory, else the output directory.
 
 Derive-L2 Options (L1 → L2):
   --input-dir <path>      Directory containing L1 docs (acceptance-criteria.md, business-rules.md)
@@ -156,24 +252,152 @@ Rederive Options:
   --verbose               Show detailed output
   --preserve-manual       Keep manual sections during re-derivation (default: true)
   --interactive           Confirm each derivation
+  --merge <strategy>      How hand edits inside generated sections are merged:
+                            preserve_manual  three-way merge, conflicts marked (default)
+                            overwrite_all    replace with the new generation
+                            interactive      ask which side to keep per conflict
+                            report_only      report merges without writing
   <artifact-ids>          Specific artifact IDs to derive (positional args)
 
+History / Diff / Rollback Options:
+  --project-dir <path>    Project root directory (default: current directory)
+  --format <text|json>    Output format (history, diff; default: text)
+  --dry-run               Show what a rollback would restore (rollback)
+  <run>                   Run ID, number without leading zeros, or "latest"
+
+Lock Options:
+  --project-dir <path>    Project root directory (default: current directory)
+  --format <text|json>    Output format (status; default: text)
+  --force                 Break locks even if their owner is still running (break)
+
+State Options:
+  --project-dir <path>    Project root directory (default: current directory)
+  --to <json|kv>          Target storage backend (migrate)
+
+  To merge .loom/derivation-state.json across git branches, register the driver:
+    echo '.loom/derivation-state.json merge=loom' >> .gitattributes
+    git config merge.loom.driver "loom-cli state merge %O %A %B"
+
+Refactor Options:
+  --project-dir <path>    Project root directory (default: current directory)
+  --dry-run               Show the changed lines without writing
+  --format <text|json>    Output format (default: text)
+
+Artifact Options:
+  --project-dir <path>    Project root directory (default: current directory)
+  --replaced-by <ids>     Comma-separated replacements (deprecate)
+  --reason <text>         Reason added to the deprecation note (deprecate)
+  --into <ids>            New IDs (split) or the target ID (merge)
+  --cascade               Also delete artifacts left without upstream (delete)
+  --format <text|json>    Output format (default: text)
+
 Migrate Options:
   --project-dir <path>    Project root directory (default: current directory)
   --dry-run               Preview without making changes
   --backup-dir <path>     Directory for backups (default: .loom/backups)
   --verbose               Show detailed output
   --force                 Force migration even if markers exist
+  --decisions <path>      Migrate decisions.md to the decision store (.loom/decisions.json)
+  --mapping <path>        JSON file mapping custom ID patterns to types and layers
 
 Validate Options:
   --input-dir <path>      Directory containing documents to validate (required)
   --level <L1|L2|L3|ALL>  Validation level (default: ALL)
+  --project-dir <path>    Project with ingested test results (default: nearest .loom above input dir)
   --json                  Output results as JSON
 
 Sync-Links Options:
   --input-dir <path>      Directory containing documents to sync (required)
   --dry-run               Show what would be changed without modifying files
 
+Gen-Tests Options:
+  --input-dir <path>      L3 directory containing test-cases.md (default: .)
+  --output-dir <path>     Directory for generated test files (required)
+  --lang <lang>           Target language (default: go)
+  --package <name>        Package name (default: output directory name)
+  --group <ac|aggregate>  One test file per AC or per aggregate (default: ac)
+  --force                 Overwrite existing test files (default: skip them)
+
+Gen-Gherkin Options:
+  --l1-dir <path>         L1 directory with acceptance-criteria.md (required)
+                          and bounded-context-map.md (optional)
+  --l3-dir <path>         L3 directory with test-cases.md (optional)
+  --output-dir <path>     Directory for .feature files (required)
+
+  Writes <context>/<user-story>.feature: one Scenario per AC, Scenario
+  Outlines with Examples for negative/boundary test data, TC ID and
+  category tags (@negative, @hallucination).
+
+Gen-Code Options:
+  --input-dir <path>      L2 directory containing l2-output.json (default: .)
+  --output-dir <path>     Root directory of the generated packages (required)
+  --lang <lang>           Target language (default: go)
+
+  Writes one package per aggregate and per interface contract. <pkg>_gen.go
+  is rewritten on every run; <pkg>.go is hand-written and only receives
+  stubs for missing invariant checks, behaviors and value objects.
+
+Gen-SQL Options:
+  --input-dir <path>      L2 directory containing l2-output.json (default: .)
+  --output-dir <path>     Directory for schema.sql and migrations/ (required)
+  --dialect <name>        postgres, mysql or sqlite (default: postgres)
+  --project-dir <path>    Project root directory (default: nearest .loom above output dir,
+                          else current directory)
+  --name <name>           Migration name (default: init, then schema_update)
+
+  Writes schema.sql on every run. The data model is recorded in the
+  derivation state per dialect and output directory; the next run for
+  them diffs against it and writes migrations/NNNNNN_<name>.up.sql and
+  .down.sql.
+
+Gen-OpenAPI Options:
+  --input-dir <path>      L2 directory containing l2-output.json (default: .)
+  --output <file>         Output file (default: openapi.json)
+  --title <title>         API title (default: API)
+  --version <version>     API version (default: 1.0.0)
+
+Gen-AsyncAPI Options:
+  --input-dir <path>      L3 directory containing l3-output.json (default: .)
+  --l2-dir <path>         L2 directory whose shared types are referenced by payloads
+  --output-dir <path>     Directory for asyncapi.json and event-schemas/ (default: input dir)
+  --title <title>         API title (default: Events)
+  --version <version>     API version (default: 1.0.0)
+
+Tickets Options:
+  export                  Write an import file (CSV or JSON payloads)
+  sync                    Create/update issues via the tracker API; exported
+                          issues are tracked in .loom so re-syncs update them
+  --input-dir <path>      L3 directory with l3-output.json or feature-tickets.md (default: .)
+  --tracker <name>        github, jira or linear (default: github)
+  --format <fmt>          Export format: csv or json (default: json)
+  --output <file>         Export file (default: stdout)
+  --url <url>             Tracker API base URL (required for jira)
+  --repo <owner/repo>     GitHub repository
+  --project <key>         Jira project key
+  --team <id>             Linear team ID
+  --user <name>           Jira user; the token is then used for basic auth
+  --token-env <var>       Variable holding the API token (default: <TRACKER>_TOKEN)
+  --project-dir <path>    Project root directory (default: current directory)
+  --dry-run               Show what sync would create or update
+
+Drift Options:
+  --code <dir>            Implementation code base to check (required, Go)
+  --spec-dir <path>       Directory containing l1/, l2/ and l3/ (default: .)
+  --l1-dir, --l2-dir, --l3-dir <path>
+                          Override the individual spec directories
+  --json                  Print the report as JSON
+
+Ingest-Results Options:
+  --input <file>          go test -json output or JUnit XML (- for stdin, required)
+  --format <fmt>          auto, gotest or junit (default: auto)
+  --l3-dir <path>         L3 directory containing test-cases.md (default: .)
+  --project-dir <path>    Project root directory (default: current directory)
+  --verbose               List tests that reference no test case
+
+  Tests reference test cases by TC ID in their name, e.g. the subtests
+  gen-tests writes (TestAC_ORD_001/TC-AC-ORD-001-P01). Test cases no test
+  ran are recorded as missing; status and validate report the results.
+
 Validation Rules:
   V001  Every document has IDs
   V002  IDs follow expected patterns (AC-XXX-NNN, BR-XXX-NNN, etc.)
@@ -185,6 +409,7 @@ Validation Rules:
   V008  Negative test ratio >= 20% (TDAI)
   V009  Every AC has hallucination prevention test (TDAI)
   V010  No duplicate IDs
+  V011  No AC has failing tests (after ingest-results)
 
 Cascade Example (Recommended):
   loom-cli cascade --input-file story.md --output-dir ./specs --skip-interview
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+)
+
+// StateConfig holds configuration for the state command
+type StateConfig struct {
+	Action     string // migrate, merge
+	ProjectDir string
+	Backend    string   // migrate: target storage backend
+	Files      []string // merge: base, ours and theirs state files
+}
+
+func runState() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: loom-cli state <migrate|merge> [options]")
+	}
+	action := os.Args[2]
+	if action != "migrate" && action != "merge" {
+		return fmt.Errorf("unknown state action: %s (expected migrate or merge)", action)
+	}
+
+	stateFlags := flag.NewFlagSet("state "+action, flag.ExitOnError)
+	projectDir := stateFlags.String("project-dir", ".", "Project root directory")
+	backend := stateFlags.String("to", "", "Target storage backend (json, kv)")
+
+	stateFlags.Parse(os.Args[3:])
+
+	return executeState(&StateConfig{
+		Action:     action,
+		ProjectDir: *projectDir,
+		Backend:    *backend,
+		Files:      stateFlags.Args(),
+	})
+}
+
+func executeState(cfg *StateConfig) error {
+	if cfg.Action == "merge" {
+		return executeStateMerge(cfg)
+	}
+	if cfg.Backend == "" {
+		return fmt.Errorf("--to is required (%s)", strings.Join(derivation.Backends, ", "))
+	}
+
+	sm := derivation.NewStateManager(cfg.ProjectDir)
+	if !sm.Store.Exists() {
+		return fmt.Errorf("no derivation state found in %s (run 'loom-cli init' first)", sm.LoomDir)
+	}
+	from := sm.Store.Backend()
+	if err := sm.MigrateStore(cfg.Backend); err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrated derivation state from %s to %s: %s\n", from, sm.Store.Backend(), sm.Store.Path())
+	return nil
+}
+
+// executeStateMerge runs as a git merge driver: it merges theirs into ours
+// and fails, leaving the file marked as conflicted, if records remain that
+// could not be resolved
+func executeStateMerge(cfg *StateConfig) error {
+	if len(cfg.Files) != 3 {
+		return fmt.Errorf("usage: loom-cli state merge <base> <ours> <theirs>")
+	}
+
+	sm := derivation.NewStateManager(cfg.ProjectDir)
+	res, err := sm.MergeFiles(cfg.Files[0], cfg.Files[1], cfg.Files[2])
+	if err != nil {
+		return err
+	}
+
+	// stdout belongs to git
+	for _, c := range res.Conflicts {
+		if c.Resolution != "" {
+			fmt.Fprintf(os.Stderr, "loom: %s %s: %s\n", c.Kind, c.ID, c.Resolution)
+		} else {
+			fmt.Fprintf(os.Stderr, "loom: %s %s: changed on both sides, kept ours\n", c.Kind, c.ID)
+		}
+	}
+	if unresolved := res.Unresolved(); len(unresolved) > 0 {
+		return fmt.Errorf("%d state conflict(s) need manual resolution in %s", len(unresolved), cfg.Files[1])
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/ikadar/loom-cli/internal/derivation"
+	"github.com/ikadar/loom-cli/internal/results"
 )
 
 // StatusConfig holds configuration for the status command
@@ -43,8 +44,13 @@ func runStatus() error {
 }
 
 func executeStatus(cfg *StatusConfig) error {
-	// Create state manager and load state
+	// Create state manager and load state; derivations wait until done
 	sm := derivation.NewStateManager(cfg.ProjectDir)
+	if err := sm.LockShared(); err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer sm.Unlock()
+
 	state, err := sm.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load state (run 'loom-cli init' first): %w", err)
@@ -62,6 +68,7 @@ func executeStatus(cfg *StatusConfig) error {
 
 	// Build status summary
 	summary := buildStatusSummary(state, staleArtifacts, cfg.Layer)
+	summary.Verification = results.Coverage(state.GetTestResults())
 
 	// Output based on format
 	if cfg.Format == "json" {
@@ -78,6 +85,7 @@ type StatusSummary struct {
 	ByLayer        map[string]map[derivation.ArtifactStatus]int
 	StaleArtifacts []*derivation.Artifact
 	Artifacts      []*derivation.Artifact // Filtered list
+	Verification   []results.ACCoverage   // Per-AC test results, from ingest-results
 }
 
 func buildStatusSummary(state *derivation.DerivationState, stale []*derivation.Artifact, layerFilter string) *StatusSummary {
@@ -160,6 +168,7 @@ func outputStatusText(summary *StatusSummary, cfg *StatusConfig, tracker *deriva
 		derivation.StatusAffected,
 		derivation.StatusModified,
 		derivation.StatusOrphaned,
+		derivation.StatusDeprecated,
 	}
 	for _, status := range statusOrder {
 		if count := summary.ByStatus[status]; count > 0 {
@@ -189,6 +198,28 @@ func outputStatusText(summary *StatusSummary, cfg *StatusConfig, tracker *deriva
 	}
 	fmt.Println()
 
+	// Verification coverage
+	if len(summary.Verification) > 0 {
+		verified := 0
+		for _, c := range summary.Verification {
+			if c.Verified() {
+				verified++
+			}
+		}
+		fmt.Printf("Verification: %d/%d ACs verified\n", verified, len(summary.Verification))
+		for _, c := range summary.Verification {
+			switch {
+			case c.Failed > 0:
+				fmt.Printf("  ✗ %s: %d/%d failing (%s)\n", c.AC, c.Failed, c.Total, strings.Join(c.Failing, ", "))
+			case !c.Verified():
+				fmt.Printf("  ? %s: %d/%d passing (%d missing, %d skipped)\n", c.AC, c.Passed, c.Total, c.Missing, c.Skipped)
+			case cfg.Verbose:
+				fmt.Printf("  ✓ %s: %d/%d passing\n", c.AC, c.Passed, c.Total)
+			}
+		}
+		fmt.Println()
+	}
+
 	// Stale artifacts detail
 	if len(summary.StaleArtifacts) > 0 {
 		fmt.Println("Stale Artifacts (need re-derivation):")
@@ -254,6 +285,8 @@ func statusIcon(status derivation.ArtifactStatus) string {
 		return "✎ MODIFIED"
 	case derivation.StatusOrphaned:
 		return "✗ ORPHANED"
+	case derivation.StatusDeprecated:
+		return "⊘ DEPRECATED"
 	default:
 		return "? UNKNOWN"
 	}
@@ -301,11 +334,27 @@ func outputStatusJSON(summary *StatusSummary, showPlan bool, tracker *derivation
 	// Simple JSON output (avoid importing encoding/json for now)
 	fmt.Printf("{\n")
 	fmt.Printf("  \"total_artifacts\": %d,\n", output.TotalArtifacts)
-	fmt.Printf("  \"stale_count\": %d,\n", len(output.StaleArtifacts))
+	fields := []string{fmt.Sprintf("  \"stale_count\": %d", len(output.StaleArtifacts))}
 	if len(output.StaleArtifacts) > 0 {
-		fmt.Printf("  \"stale_artifacts\": [%s]\n", formatJSONArray(output.StaleArtifacts))
+		fields = append(fields, fmt.Sprintf("  \"stale_artifacts\": [%s]", formatJSONArray(output.StaleArtifacts)))
+	}
+	if len(summary.Verification) > 0 {
+		var verified, failing, unverified []string
+		for _, c := range summary.Verification {
+			switch {
+			case c.Verified():
+				verified = append(verified, c.AC)
+			case c.Failed > 0:
+				failing = append(failing, c.AC)
+			default:
+				unverified = append(unverified, c.AC)
+			}
+		}
+		fields = append(fields,
+			fmt.Sprintf("  \"verification\": {\"acs\": %d, \"verified\": [%s], \"failing\": [%s], \"unverified\": [%s]}",
+				len(summary.Verification), formatJSONArray(verified), formatJSONArray(failing), formatJSONArray(unverified)))
 	}
-	fmt.Printf("}\n")
+	fmt.Printf("%s\n}\n", strings.Join(fields, ",\n"))
 
 	return nil
 }
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+	"github.com/ikadar/loom-cli/internal/formatter"
+	"github.com/ikadar/loom-cli/internal/spec"
+	"github.com/ikadar/loom-cli/internal/tickets"
+)
+
+// TicketsConfig holds configuration for the tickets command
+type TicketsConfig struct {
+	Action     string // export or sync
+	InputDir   string // L3 directory with the feature tickets
+	ProjectDir string // Project root (.loom state for sync)
+	Tracker    string // github, jira or linear
+	Format     string // Export format (csv or json)
+	Output     string // Export file; stdout when empty
+	URL        string // Tracker API base URL
+	Repo       string // GitHub owner/repo
+	Project    string // Jira project key
+	Team       string // Linear team ID
+	User       string // Jira user for basic auth
+	TokenEnv   string // Environment variable holding the API token
+	DryRun     bool   // Show what sync would do
+}
+
+func runTickets() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: loom-cli tickets <export|sync> [options]")
+	}
+	action := os.Args[2]
+	if action != "export" && action != "sync" {
+		return fmt.Errorf("unknown tickets action: %s (expected export or sync)", action)
+	}
+
+	ticketsFlags := flag.NewFlagSet("tickets "+action, flag.ExitOnError)
+	inputDir := ticketsFlags.String("input-dir", ".", "L3 directory containing l3-output.json or feature-tickets.md")
+	projectDir := ticketsFlags.String("project-dir", ".", "Project root directory")
+	tracker := ticketsFlags.String("tracker", tickets.TrackerGitHub, "Tracker: github, jira or linear")
+	format := ticketsFlags.String("format", tickets.FormatJSON, "Export format: csv or json")
+	output := ticketsFlags.String("output", "", "Export file (default: stdout)")
+	url := ticketsFlags.String("url", "", "Tracker API base URL")
+	repo := ticketsFlags.String("repo", "", "GitHub repository (owner/repo)")
+	project := ticketsFlags.String("project", "", "Jira project key")
+	team := ticketsFlags.String("team", "", "Linear team ID")
+	user := ticketsFlags.String("user", "", "Jira user (basic auth with the token)")
+	tokenEnv := ticketsFlags.String("token-env", "", "Environment variable holding the API token (default: <TRACKER>_TOKEN)")
+	dryRun := ticketsFlags.Bool("dry-run", false, "Show what sync would create or update")
+
+	ticketsFlags.Parse(os.Args[3:])
+
+	cfg := &TicketsConfig{
+		Action:     action,
+		InputDir:   *inputDir,
+		ProjectDir: *projectDir,
+		Tracker:    *tracker,
+		Format:     *format,
+		Output:     *output,
+		URL:        *url,
+		Repo:       *repo,
+		Project:    *project,
+		Team:       *team,
+		User:       *user,
+		TokenEnv:   *tokenEnv,
+		DryRun:     *dryRun,
+	}
+
+	return executeTickets(cfg)
+}
+
+func executeTickets(cfg *TicketsConfig) error {
+	if !tickets.ValidTracker(cfg.Tracker) {
+		return fmt.Errorf("unsupported tracker: %s (supported: %s)", cfg.Tracker, strings.Join(tickets.Trackers, ", "))
+	}
+
+	fts, err := spec.LoadFeatureTickets(cfg.InputDir)
+	if err != nil {
+		return err
+	}
+	if len(fts) == 0 {
+		return fmt.Errorf("no feature tickets found in %s", cfg.InputDir)
+	}
+
+	if cfg.Action == "export" {
+		return exportTickets(cfg, fts)
+	}
+	return syncTickets(cfg, fts)
+}
+
+func exportTickets(cfg *TicketsConfig, fts []formatter.FeatureTicket) error {
+	content, err := tickets.Export(fts, tickets.ExportOptions{
+		Tracker: cfg.Tracker,
+		Format:  cfg.Format,
+		Project: cfg.Project,
+		TeamID:  cfg.Team,
+	})
+	if err != nil {
+		return err
+	}
+
+	if cfg.Output == "" {
+		_, err := os.Stdout.Write(content)
+		return err
+	}
+	if dir := filepath.Dir(cfg.Output); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(cfg.Output, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", cfg.Output, err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d tickets for %s (%s): %s\n", len(fts), cfg.Tracker, cfg.Format, cfg.Output)
+	return nil
+}
+
+func syncTickets(cfg *TicketsConfig, fts []formatter.FeatureTicket) error {
+	tokenEnv := cfg.TokenEnv
+	if tokenEnv == "" {
+		tokenEnv = strings.ToUpper(cfg.Tracker) + "_TOKEN"
+	}
+	token := os.Getenv(tokenEnv)
+	if token == "" && !cfg.DryRun {
+		return fmt.Errorf("no API token: set %s", tokenEnv)
+	}
+
+	tracker, err := tickets.NewTracker(tickets.TrackerConfig{
+		Tracker: cfg.Tracker,
+		BaseURL: cfg.URL,
+		Token:   token,
+		User:    cfg.User,
+		Repo:    cfg.Repo,
+		Project: cfg.Project,
+		TeamID:  cfg.Team,
+	})
+	if err != nil {
+		return err
+	}
+
+	sm := derivation.NewStateManager(cfg.ProjectDir)
+	if err := sm.Lock(); err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer sm.Unlock()
+
+	state, err := sm.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	result, syncErr := tickets.Sync(context.Background(), tracker, fts, state, cfg.DryRun)
+
+	// Record what was created even when the sync failed part-way
+	if !cfg.DryRun && (len(result.Created) > 0 || len(result.Updated) > 0) {
+		if err := sm.Save(state); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+	}
+
+	verb := ""
+	if cfg.DryRun {
+		verb = "would be "
+	}
+	for _, id := range result.Created {
+		ref := ""
+		if tt := state.GetTrackerTicket(cfg.Tracker, id); tt != nil {
+			ref = " → " + tt.Key
+		}
+		fmt.Fprintf(os.Stderr, "  Created: %s%s\n", id, ref)
+	}
+	for _, id := range result.Updated {
+		fmt.Fprintf(os.Stderr, "  Updated: %s → %s\n", id, state.GetTrackerTicket(cfg.Tracker, id).Key)
+	}
+	fmt.Fprintf(os.Stderr, "\n%s: %d %screated, %d %supdated, %d unchanged\n",
+		cfg.Tracker, len(result.Created), verb, len(result.Updated), verb, len(result.Unchanged))
+
+	return syncErr
+}
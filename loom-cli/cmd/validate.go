@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+	"github.com/ikadar/loom-cli/internal/results"
 )
 
 // ValidationResult holds the complete validation output
@@ -60,6 +63,7 @@ const (
 	RuleV008 = "V008" // Negative test ratio >= 20%
 	RuleV009 = "V009" // Every AC has hallucination prevention test
 	RuleV010 = "V010" // No duplicate IDs
+	RuleV011 = "V011" // No AC has failing tests (from ingest-results)
 )
 
 // ID patterns for validation
@@ -92,6 +96,7 @@ func runValidate() error {
 	args := os.Args[2:]
 
 	var inputDir string
+	var projectDir string
 	var level string
 	var jsonOutput bool
 
@@ -102,6 +107,11 @@ func runValidate() error {
 				i++
 				inputDir = args[i]
 			}
+		case "--project-dir":
+			if i+1 < len(args) {
+				i++
+				projectDir = args[i]
+			}
 		case "--level":
 			if i+1 < len(args) {
 				i++
@@ -119,11 +129,37 @@ func runValidate() error {
 		level = "ALL"
 	}
 
+	if projectDir == "" {
+		projectDir = findProjectDir(inputDir)
+	}
+
+	// Deprecated artifacts are not missing, even when their sections are gone
+	var deprecated map[string][]string
+	var deprecatedErr error
+	if projectDir != "" {
+		deprecated, deprecatedErr = loadDeprecatedIDs(projectDir)
+	}
+
 	// Run validation
-	result, err := validate(inputDir, level)
+	result, err := validate(inputDir, level, deprecated)
 	if err != nil {
 		return err
 	}
+	if deprecatedErr != nil {
+		result.Warnings = append(result.Warnings, ValidationWarning{
+			Rule:    RuleV003,
+			Message: fmt.Sprintf("Could not load deprecated artifacts: %v", deprecatedErr),
+		})
+		result.Summary = calculateSummary(result)
+	}
+
+	// Phase 6: Test results ingested into the derivation state, if any
+	if projectDir != "" {
+		if check, ok := validateTestResults(projectDir, result); ok {
+			result.Checks = append(result.Checks, check)
+			result.Summary = calculateSummary(result)
+		}
+	}
 
 	// Output results
 	if jsonOutput {
@@ -136,7 +172,7 @@ func runValidate() error {
 	return outputText(result)
 }
 
-func validate(inputDir string, level string) (*ValidationResult, error) {
+func validate(inputDir string, level string, deprecated map[string][]string) (*ValidationResult, error) {
 	result := &ValidationResult{
 		Level:    level,
 		Errors:   []ValidationError{},
@@ -229,12 +265,12 @@ func validate(inputDir string, level string) (*ValidationResult, error) {
 
 	// Phase 3: Traceability Validation
 	fmt.Fprintln(os.Stderr, "\nPhase 3: Traceability Validation...")
-	traceCheck := validateTraceability(allIDs, allRefs, result)
+	traceCheck := validateTraceability(allIDs, allRefs, deprecated, result)
 	result.Checks = append(result.Checks, traceCheck...)
 
 	// Phase 4: Completeness Validation
 	fmt.Fprintln(os.Stderr, "\nPhase 4: Completeness Validation...")
-	completeCheck := validateCompleteness(inputDir, acIDs, tcByAC, allIDs, result)
+	completeCheck := validateCompleteness(inputDir, acIDs, tcByAC, allIDs, deprecated, result)
 	result.Checks = append(result.Checks, completeCheck...)
 
 	// Phase 5: TDAI Validation
@@ -450,7 +486,7 @@ func validateStructural(files []string, allIDs map[string]string, result *Valida
 	return checks
 }
 
-func validateTraceability(allIDs map[string]string, allRefs map[string][]string, result *ValidationResult) []ValidationCheck {
+func validateTraceability(allIDs map[string]string, allRefs map[string][]string, deprecated map[string][]string, result *ValidationResult) []ValidationCheck {
 	var checks []ValidationCheck
 
 	// V003: Check that all references point to existing IDs
@@ -458,7 +494,18 @@ func validateTraceability(allIDs map[string]string, allRefs map[string][]string,
 	invalidRefs := 0
 	for fromID, refs := range allRefs {
 		for _, ref := range refs {
-			if _, exists := allIDs[ref]; exists {
+			if replacedBy, ok := deprecated[ref]; ok {
+				// Deprecated IDs resolve, but references should move on
+				validRefs++
+				message := fmt.Sprintf("Reference '%s' from '%s' is deprecated", ref, fromID)
+				if len(replacedBy) > 0 {
+					message += fmt.Sprintf(" (replaced by %s)", strings.Join(replacedBy, ", "))
+				}
+				result.Warnings = append(result.Warnings, ValidationWarning{
+					Rule:    RuleV003,
+					Message: message,
+				})
+			} else if _, exists := allIDs[ref]; exists {
 				validRefs++
 			} else {
 				invalidRefs++
@@ -498,13 +545,16 @@ func validateTraceability(allIDs map[string]string, allRefs map[string][]string,
 	return checks
 }
 
-func validateCompleteness(inputDir string, acIDs []string, tcByAC map[string][]string, allIDs map[string]string, result *ValidationResult) []ValidationCheck {
+func validateCompleteness(inputDir string, acIDs []string, tcByAC map[string][]string, allIDs map[string]string, deprecated map[string][]string, result *ValidationResult) []ValidationCheck {
 	var checks []ValidationCheck
 
-	// V005: Every AC has at least 1 test case
+	// V005: Every AC has at least 1 test case; deprecated ACs need none
 	acsWithTests := 0
 	acsWithoutTests := 0
 	for _, acID := range acIDs {
+		if _, ok := deprecated[acID]; ok {
+			continue
+		}
 		if tcs, ok := tcByAC[acID]; ok && len(tcs) > 0 {
 			acsWithTests++
 		} else {
@@ -1150,6 +1200,99 @@ func validateTDAI(inputDir string, acIDs []string, tcByAC map[string][]string, t
 	return checks
 }
 
+// findProjectDir returns the closest directory at or above dir holding a
+// .loom directory, or "" if there is none
+func findProjectDir(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(abs, derivation.LoomDirName)); err == nil && info.IsDir() {
+			return abs
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return ""
+		}
+		abs = parent
+	}
+}
+
+// loadDeprecatedIDs returns the deprecated artifacts of the project state
+// and their replacements
+func loadDeprecatedIDs(projectDir string) (map[string][]string, error) {
+	sm := derivation.NewStateManager(projectDir)
+	if !sm.Store.Exists() {
+		return nil, nil
+	}
+	if err := sm.LockShared(); err != nil {
+		return nil, err
+	}
+	defer sm.Unlock()
+
+	state, err := sm.Load()
+	if err != nil {
+		return nil, err
+	}
+	return state.DeprecatedIDs(), nil
+}
+
+// validateTestResults checks the last ingested test run for failing tests.
+// It reports false when no results were ingested.
+func validateTestResults(projectDir string, result *ValidationResult) (ValidationCheck, bool) {
+	sm := derivation.NewStateManager(projectDir)
+	if err := sm.LockShared(); err != nil {
+		result.Warnings = append(result.Warnings, ValidationWarning{
+			Rule:    RuleV011,
+			Message: fmt.Sprintf("Could not load test results: %v", err),
+		})
+		return ValidationCheck{}, false
+	}
+	defer sm.Unlock()
+
+	state, err := sm.Load()
+	if err != nil {
+		result.Warnings = append(result.Warnings, ValidationWarning{
+			Rule:    RuleV011,
+			Message: fmt.Sprintf("Could not load test results: %v", err),
+		})
+		return ValidationCheck{}, false
+	}
+	coverage := results.Coverage(state.GetTestResults())
+	if len(coverage) == 0 {
+		return ValidationCheck{}, false
+	}
+
+	failing := 0
+	for _, c := range coverage {
+		if c.Failed == 0 {
+			continue
+		}
+		failing++
+		result.Errors = append(result.Errors, ValidationError{
+			Rule:    RuleV011,
+			Message: fmt.Sprintf("AC '%s' has failing tests: %s", c.AC, strings.Join(c.Failing, ", ")),
+			RefID:   c.AC,
+		})
+	}
+
+	if failing > 0 {
+		return ValidationCheck{
+			Rule:    RuleV011,
+			Status:  "fail",
+			Message: fmt.Sprintf("%d of %d ACs have failing tests", failing, len(coverage)),
+			Count:   failing,
+		}, true
+	}
+	return ValidationCheck{
+		Rule:    RuleV011,
+		Status:  "pass",
+		Message: fmt.Sprintf("No failing tests for %d ACs", len(coverage)),
+		Count:   len(coverage),
+	}, true
+}
+
 func calculateSummary(result *ValidationResult) ValidationSummary {
 	summary := ValidationSummary{
 		TotalChecks: len(result.Checks),
@@ -1208,6 +1351,14 @@ func outputText(result *ValidationResult) error {
 		}
 	}
 
+	// Test results
+	for _, check := range result.Checks {
+		if check.Rule == RuleV011 {
+			fmt.Println("\nTest Results:")
+			printCheck(check)
+		}
+	}
+
 	// Errors
 	if len(result.Errors) > 0 {
 		fmt.Println("\n----------------------------------------")
@@ -3,6 +3,7 @@ package cmd
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -491,7 +492,7 @@ func TestValidateTraceability_ValidRefs(t *testing.T) {
 		"TS-ORD-001": {"BR-ORD-001", "AC-ORD-001"},
 	}
 
-	checks := validateTraceability(allIDs, allRefs, result)
+	checks := validateTraceability(allIDs, allRefs, nil, result)
 
 	// Find V003 check
 	var v003Check *ValidationCheck
@@ -529,7 +530,7 @@ func TestValidateTraceability_InvalidRefs(t *testing.T) {
 		"TS-ORD-001": {"BR-ORD-999", "AC-NONEXISTENT"},
 	}
 
-	checks := validateTraceability(allIDs, allRefs, result)
+	checks := validateTraceability(allIDs, allRefs, nil, result)
 
 	// Find V003 check
 	var v003Check *ValidationCheck
@@ -553,6 +554,39 @@ func TestValidateTraceability_InvalidRefs(t *testing.T) {
 	}
 }
 
+func TestValidateTraceability_DeprecatedRefs(t *testing.T) {
+	result := &ValidationResult{
+		Errors:   []ValidationError{},
+		Warnings: []ValidationWarning{},
+	}
+
+	allIDs := map[string]string{
+		"TS-ORD-001": "tech-specs.md",
+	}
+
+	allRefs := map[string][]string{
+		"TS-ORD-001": {"BR-ORD-002"},
+	}
+
+	deprecated := map[string][]string{
+		"BR-ORD-002": {"BR-ORD-001"},
+	}
+
+	checks := validateTraceability(allIDs, allRefs, deprecated, result)
+
+	if checks[0].Rule != RuleV003 || checks[0].Status != "pass" {
+		t.Errorf("Expected V003 to pass, got %+v", checks[0])
+	}
+
+	if len(result.Errors) != 0 {
+		t.Errorf("Expected no errors, got %d", len(result.Errors))
+	}
+
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0].Message, "replaced by BR-ORD-001") {
+		t.Errorf("Expected a deprecation warning, got %+v", result.Warnings)
+	}
+}
+
 func TestCalculateSummary(t *testing.T) {
 	result := &ValidationResult{
 		Checks: []ValidationCheck{
@@ -659,7 +693,7 @@ func TestValidate_Integration(t *testing.T) {
 		t.Skip("Fixture not found, skipping integration test")
 	}
 
-	result, err := validate(fixturePath, "L2")
+	result, err := validate(fixturePath, "L2", nil)
 	if err != nil {
 		t.Fatalf("Validation error: %v", err)
 	}
@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+// CacheFormatVersion is the current version of the analysis cache
+const CacheFormatVersion = 1
+
+// CacheDirName is the directory, inside the input directory, holding the cache
+const CacheDirName = ".loom"
+
+// CacheFileName is the name of the analysis cache for an input directory
+const CacheFileName = "analysis-cache.json"
+
+// Cache records the result of the previous analysis run, so the next run
+// only has to analyze the sections that changed
+type Cache struct {
+	// FormatVersion is the cache format version
+	FormatVersion int `json:"format_version"`
+
+	// UpdatedAt is when the cache was last written
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Sections maps section IDs to their content hash
+	Sections map[string]string `json:"sections"`
+
+	// DomainModel is the merged domain model of all sections
+	DomainModel *domain.Domain `json:"domain_model"`
+
+	// Contributions are the domain models discovered per section;
+	// DomainModel is their merge
+	Contributions []Contribution `json:"contributions,omitempty"`
+
+	// Ambiguities are all ambiguities found, before filtering resolved ones
+	Ambiguities []domain.Ambiguity `json:"ambiguities"`
+}
+
+// Contribution is the domain model discovered from a group of sections.
+// When one of the sections changes or goes away, the whole contribution
+// is withdrawn from the merged model.
+type Contribution struct {
+	// Sections are the IDs of the sections the model was discovered from
+	Sections []string `json:"sections"`
+
+	// DomainModel is what discovery found in these sections
+	DomainModel *domain.Domain `json:"domain_model"`
+}
+
+// CachePath returns the cache path for an analyze input (file or directory)
+func CachePath(inputFile, inputDir string) string {
+	if inputDir != "" {
+		return filepath.Join(inputDir, CacheDirName, CacheFileName)
+	}
+	base := strings.TrimSuffix(filepath.Base(inputFile), filepath.Ext(inputFile))
+	return filepath.Join(filepath.Dir(inputFile), CacheDirName, base+"."+CacheFileName)
+}
+
+// LoadCache reads an analysis cache. It returns nil without error if the
+// cache does not exist.
+func LoadCache(path string) (*Cache, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read analysis cache: %w", err)
+	}
+
+	var cache Cache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("failed to parse analysis cache: %w", err)
+	}
+
+	if cache.FormatVersion > CacheFormatVersion {
+		return nil, fmt.Errorf("analysis cache format version %d is newer than supported version %d", cache.FormatVersion, CacheFormatVersion)
+	}
+	if cache.DomainModel == nil {
+		return nil, nil
+	}
+
+	return &cache, nil
+}
+
+// Save writes the cache atomically
+func (c *Cache) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	c.FormatVersion = CacheFormatVersion
+	c.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal analysis cache: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write analysis cache: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename analysis cache: %w", err)
+	}
+
+	return nil
+}
+
+// Analysis modes reported in Summary
+const (
+	ModeFull        = "full"
+	ModeIncremental = "incremental"
+	ModeUnchanged   = "unchanged"
+)
+
+// Summary describes what an analysis run re-analyzed
+type Summary struct {
+	// Mode is full, incremental or unchanged
+	Mode string `json:"mode"`
+
+	// Reason explains a full run (no cache, --full)
+	Reason string `json:"reason,omitempty"`
+
+	Changes
+
+	// ReanalyzedEntities and ReanalyzedOperations are the subjects whose
+	// ambiguities were (re-)computed in an incremental run
+	ReanalyzedEntities   []string `json:"reanalyzed_entities,omitempty"`
+	ReanalyzedOperations []string `json:"reanalyzed_operations,omitempty"`
+}
@@ -0,0 +1,311 @@
+package analysis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+// MergeDomain merges a domain model discovered from changed sections into
+// the existing model. Entities and operations are matched by name (case
+// insensitive); mentioned attributes, states, inputs and rules are united,
+// and non-empty operation fields of the delta win. Neither input is modified.
+func MergeDomain(base, delta *domain.Domain) *domain.Domain {
+	merged := &domain.Domain{}
+	if base != nil {
+		merged.Entities = append(merged.Entities, base.Entities...)
+		merged.Operations = append(merged.Operations, base.Operations...)
+		merged.Relationships = append(merged.Relationships, base.Relationships...)
+		merged.BusinessRules = append(merged.BusinessRules, base.BusinessRules...)
+		merged.UIMentions = append(merged.UIMentions, base.UIMentions...)
+	}
+	if delta == nil {
+		return merged
+	}
+
+	entityIdx := make(map[string]int, len(merged.Entities))
+	for i, e := range merged.Entities {
+		entityIdx[nameKey(e.Name)] = i
+	}
+	for _, e := range delta.Entities {
+		i, ok := entityIdx[nameKey(e.Name)]
+		if !ok {
+			entityIdx[nameKey(e.Name)] = len(merged.Entities)
+			merged.Entities = append(merged.Entities, e)
+			continue
+		}
+		existing := merged.Entities[i]
+		existing.MentionedAttributes = union(existing.MentionedAttributes, e.MentionedAttributes)
+		existing.MentionedOperations = union(existing.MentionedOperations, e.MentionedOperations)
+		existing.MentionedStates = union(existing.MentionedStates, e.MentionedStates)
+		merged.Entities[i] = existing
+	}
+
+	opIdx := make(map[string]int, len(merged.Operations))
+	for i, op := range merged.Operations {
+		opIdx[nameKey(op.Name)] = i
+	}
+	for _, op := range delta.Operations {
+		i, ok := opIdx[nameKey(op.Name)]
+		if !ok {
+			opIdx[nameKey(op.Name)] = len(merged.Operations)
+			merged.Operations = append(merged.Operations, op)
+			continue
+		}
+		existing := merged.Operations[i]
+		if op.Actor != "" {
+			existing.Actor = op.Actor
+		}
+		if op.Trigger != "" {
+			existing.Trigger = op.Trigger
+		}
+		if op.Target != "" {
+			existing.Target = op.Target
+		}
+		existing.MentionedInputs = union(existing.MentionedInputs, op.MentionedInputs)
+		existing.MentionedRules = union(existing.MentionedRules, op.MentionedRules)
+		merged.Operations[i] = existing
+	}
+
+	relSeen := make(map[string]bool, len(merged.Relationships))
+	for _, r := range merged.Relationships {
+		relSeen[relationshipKey(r)] = true
+	}
+	for _, r := range delta.Relationships {
+		if !relSeen[relationshipKey(r)] {
+			relSeen[relationshipKey(r)] = true
+			merged.Relationships = append(merged.Relationships, r)
+		}
+	}
+
+	merged.BusinessRules = union(merged.BusinessRules, delta.BusinessRules)
+	merged.UIMentions = union(merged.UIMentions, delta.UIMentions)
+
+	return merged
+}
+
+// Withdraw drops the contributions of changed and removed sections. It
+// returns the contributions kept and the unchanged sections that shared a
+// dropped contribution, which have to be discovered again.
+func Withdraw(contributions []Contribution, changes Changes) ([]Contribution, []string) {
+	gone := make(map[string]bool)
+	for _, id := range changes.Changed {
+		gone[id] = true
+	}
+	for _, id := range changes.Removed {
+		gone[id] = true
+	}
+
+	var kept []Contribution
+	var rediscover []string
+	for _, c := range contributions {
+		withdrawn := false
+		for _, id := range c.Sections {
+			if gone[id] {
+				withdrawn = true
+				break
+			}
+		}
+		if !withdrawn {
+			kept = append(kept, c)
+			continue
+		}
+		for _, id := range c.Sections {
+			if !gone[id] {
+				rediscover = append(rediscover, id)
+			}
+		}
+	}
+	return kept, rediscover
+}
+
+// BuildDomain merges the contributions, in order, into one domain model
+func BuildDomain(contributions []Contribution) *domain.Domain {
+	merged := MergeDomain(nil, nil)
+	for _, c := range contributions {
+		merged = MergeDomain(merged, c.DomainModel)
+	}
+	return merged
+}
+
+// ChangedSubjects returns the entities and operations of after that are new
+// or differ from before. These are the ones that need (re-)analysis.
+func ChangedSubjects(before, after *domain.Domain) ([]domain.Entity, []domain.Operation) {
+	oldEntities := make(map[string]domain.Entity)
+	oldOps := make(map[string]domain.Operation)
+	if before != nil {
+		for _, e := range before.Entities {
+			oldEntities[nameKey(e.Name)] = e
+		}
+		for _, op := range before.Operations {
+			oldOps[nameKey(op.Name)] = op
+		}
+	}
+
+	var entities []domain.Entity
+	for _, e := range after.Entities {
+		if old, ok := oldEntities[nameKey(e.Name)]; !ok || !sameEntity(old, e) {
+			entities = append(entities, e)
+		}
+	}
+
+	var ops []domain.Operation
+	for _, op := range after.Operations {
+		if old, ok := oldOps[nameKey(op.Name)]; !ok || !sameOperation(old, op) {
+			ops = append(ops, op)
+		}
+	}
+
+	return entities, ops
+}
+
+// RetainAmbiguities keeps the cached ambiguities that are still valid: their
+// subject still exists in the domain model and is not being re-analyzed.
+// Ambiguities without a known subject are kept.
+func RetainAmbiguities(cached []domain.Ambiguity, dm *domain.Domain, entities []domain.Entity, ops []domain.Operation) []domain.Ambiguity {
+	present := make(map[string]bool)
+	for _, e := range dm.Entities {
+		present[nameKey(e.Name)] = true
+	}
+	for _, op := range dm.Operations {
+		present[nameKey(op.Name)] = true
+	}
+
+	reanalyzed := make(map[string]bool)
+	for _, e := range entities {
+		reanalyzed[nameKey(e.Name)] = true
+	}
+	for _, op := range ops {
+		reanalyzed[nameKey(op.Name)] = true
+	}
+
+	var kept []domain.Ambiguity
+	for _, a := range cached {
+		key := nameKey(a.Subject)
+		if key != "" && (!present[key] || reanalyzed[key]) {
+			continue
+		}
+		kept = append(kept, a)
+	}
+	return kept
+}
+
+// RenumberAmbiguities gives fresh ambiguities IDs that do not collide with
+// the IDs already in use (kept ambiguities, recorded decisions). The model
+// numbers each analysis call from 001, so delta runs would otherwise reuse
+// IDs. A new ID continues the numbering of its prefix, e.g. AMB-ENT-014.
+func RenumberAmbiguities(fresh []domain.Ambiguity, usedIDs []string) []domain.Ambiguity {
+	used := make(map[string]bool, len(usedIDs))
+	next := make(map[string]int)
+
+	reserve := func(id string) {
+		used[id] = true
+		if prefix, n, ok := splitID(id); ok && n >= next[prefix] {
+			next[prefix] = n + 1
+		}
+	}
+	for _, id := range usedIDs {
+		reserve(id)
+	}
+
+	result := make([]domain.Ambiguity, len(fresh))
+	renamed := make(map[string]string)
+	for i, a := range fresh {
+		if used[a.ID] {
+			prefix, _, ok := splitID(a.ID)
+			if !ok {
+				prefix = a.ID
+			}
+			if next[prefix] == 0 {
+				next[prefix] = 1
+			}
+			newID := fmt.Sprintf("%s-%03d", prefix, next[prefix])
+			renamed[a.ID] = newID
+			a.ID = newID
+		}
+		reserve(a.ID)
+		result[i] = a
+	}
+
+	// Keep skip conditions pointing at the renumbered questions
+	for i := range result {
+		if len(result[i].DependsOn) == 0 {
+			continue
+		}
+		deps := make([]domain.SkipCondition, len(result[i].DependsOn))
+		copy(deps, result[i].DependsOn)
+		for j := range deps {
+			if id, ok := renamed[deps[j].QuestionID]; ok {
+				deps[j].QuestionID = id
+			}
+		}
+		result[i].DependsOn = deps
+	}
+
+	return result
+}
+
+func sameEntity(a, b domain.Entity) bool {
+	return a.Name == b.Name &&
+		sameStrings(a.MentionedAttributes, b.MentionedAttributes) &&
+		sameStrings(a.MentionedOperations, b.MentionedOperations) &&
+		sameStrings(a.MentionedStates, b.MentionedStates)
+}
+
+func sameOperation(a, b domain.Operation) bool {
+	return a.Name == b.Name && a.Actor == b.Actor && a.Trigger == b.Trigger && a.Target == b.Target &&
+		sameStrings(a.MentionedInputs, b.MentionedInputs) &&
+		sameStrings(a.MentionedRules, b.MentionedRules)
+}
+
+// sameStrings compares string slices, treating nil and empty as equal
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// splitID splits "AMB-ENT-007" into "AMB-ENT" and 7
+func splitID(id string) (string, int, bool) {
+	i := strings.LastIndex(id, "-")
+	if i <= 0 {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(id[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return id[:i], n, true
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func relationshipKey(r domain.Relationship) string {
+	return nameKey(r.From) + "|" + nameKey(r.To) + "|" + nameKey(r.Type)
+}
+
+// union appends the values of add missing from base (case insensitive)
+func union(base, add []string) []string {
+	seen := make(map[string]bool, len(base))
+	for _, v := range base {
+		seen[nameKey(v)] = true
+	}
+	result := append([]string(nil), base...)
+	for _, v := range add {
+		if !seen[nameKey(v)] {
+			seen[nameKey(v)] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
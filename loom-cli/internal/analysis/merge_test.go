@@ -0,0 +1,169 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+func baseDomain() *domain.Domain {
+	return &domain.Domain{
+		Entities: []domain.Entity{
+			{Name: "Order", MentionedAttributes: []string{"total"}},
+			{Name: "Customer", MentionedAttributes: []string{}},
+		},
+		Operations: []domain.Operation{
+			{Name: "PlaceOrder", Actor: "Customer", Target: "Order"},
+		},
+		Relationships: []domain.Relationship{
+			{From: "Customer", To: "Order", Type: "has_many"},
+		},
+		BusinessRules: []string{"Orders need at least one item"},
+	}
+}
+
+func TestMergeDomain(t *testing.T) {
+	base := baseDomain()
+	delta := &domain.Domain{
+		Entities: []domain.Entity{
+			{Name: "order", MentionedAttributes: []string{"Total", "status"}},
+			{Name: "Shipment"},
+		},
+		Operations: []domain.Operation{
+			{Name: "PlaceOrder", Trigger: "checkout"},
+			{Name: "ShipOrder", Target: "Order"},
+		},
+		Relationships: []domain.Relationship{
+			{From: "Customer", To: "Order", Type: "has_many"},
+			{From: "Order", To: "Shipment", Type: "has_one"},
+		},
+		BusinessRules: []string{"orders need at least one item", "Shipped orders cannot be cancelled"},
+	}
+
+	merged := MergeDomain(base, delta)
+
+	if len(merged.Entities) != 3 {
+		t.Fatalf("Expected 3 entities, got %d", len(merged.Entities))
+	}
+	order := merged.Entities[0]
+	if len(order.MentionedAttributes) != 2 || order.MentionedAttributes[1] != "status" {
+		t.Errorf("Expected attributes united case-insensitively, got %v", order.MentionedAttributes)
+	}
+	if len(merged.Operations) != 2 {
+		t.Fatalf("Expected 2 operations, got %d", len(merged.Operations))
+	}
+	place := merged.Operations[0]
+	if place.Actor != "Customer" || place.Trigger != "checkout" {
+		t.Errorf("Expected existing actor kept and trigger added, got %+v", place)
+	}
+	if len(merged.Relationships) != 2 {
+		t.Errorf("Expected 2 relationships, got %d", len(merged.Relationships))
+	}
+	if len(merged.BusinessRules) != 2 {
+		t.Errorf("Expected 2 business rules, got %v", merged.BusinessRules)
+	}
+
+	// Inputs are not modified
+	if len(base.Entities) != 2 || len(base.Entities[0].MentionedAttributes) != 1 {
+		t.Error("MergeDomain modified the base model")
+	}
+}
+
+func TestChangedSubjects(t *testing.T) {
+	base := baseDomain()
+	merged := MergeDomain(base, &domain.Domain{
+		Entities:   []domain.Entity{{Name: "Order", MentionedStates: []string{"shipped"}}, {Name: "Customer"}},
+		Operations: []domain.Operation{{Name: "ShipOrder"}},
+	})
+
+	entities, ops := ChangedSubjects(base, merged)
+
+	if len(entities) != 1 || entities[0].Name != "Order" {
+		t.Errorf("Expected only Order to be changed, got %v", entities)
+	}
+	if len(ops) != 1 || ops[0].Name != "ShipOrder" {
+		t.Errorf("Expected only ShipOrder to be new, got %v", ops)
+	}
+}
+
+func TestRetainAmbiguities(t *testing.T) {
+	dm := baseDomain()
+	cached := []domain.Ambiguity{
+		{ID: "AMB-ENT-001", Subject: "Order"},
+		{ID: "AMB-ENT-002", Subject: "Customer"},
+		{ID: "AMB-ENT-003", Subject: "Coupon"}, // no longer in the model
+		{ID: "AMB-OP-001", Subject: "PlaceOrder"},
+		{ID: "AMB-UI-001"},
+	}
+
+	kept := RetainAmbiguities(cached, dm, []domain.Entity{{Name: "Order"}}, nil)
+
+	want := []string{"AMB-ENT-002", "AMB-OP-001", "AMB-UI-001"}
+	if len(kept) != len(want) {
+		t.Fatalf("Expected %d kept ambiguities, got %v", len(want), kept)
+	}
+	for i, id := range want {
+		if kept[i].ID != id {
+			t.Errorf("Position %d: expected %s, got %s", i, id, kept[i].ID)
+		}
+	}
+}
+
+func TestRenumberAmbiguities(t *testing.T) {
+	fresh := []domain.Ambiguity{
+		{ID: "AMB-ENT-001"},
+		{ID: "AMB-ENT-002", DependsOn: []domain.SkipCondition{{QuestionID: "AMB-ENT-001"}}},
+		{ID: "AMB-OP-001"},
+	}
+
+	result := RenumberAmbiguities(fresh, []string{"AMB-ENT-001", "AMB-ENT-007"})
+
+	if result[0].ID != "AMB-ENT-008" || result[1].ID != "AMB-ENT-002" {
+		t.Errorf("Unexpected IDs %s, %s", result[0].ID, result[1].ID)
+	}
+	if result[1].DependsOn[0].QuestionID != "AMB-ENT-008" {
+		t.Errorf("Expected dependency to follow the renumbered question, got %s", result[1].DependsOn[0].QuestionID)
+	}
+	if result[2].ID != "AMB-OP-001" {
+		t.Errorf("Expected unused ID to be kept, got %s", result[2].ID)
+	}
+	if fresh[1].DependsOn[0].QuestionID != "AMB-ENT-001" {
+		t.Error("RenumberAmbiguities modified its input")
+	}
+}
+
+func TestWithdraw(t *testing.T) {
+	contributions := []Contribution{
+		{Sections: []string{"s#us-001", "s#us-002"}, DomainModel: baseDomain()},
+		{Sections: []string{"s#us-003"}, DomainModel: &domain.Domain{
+			Entities: []domain.Entity{{Name: "Order", MentionedAttributes: []string{"discount"}}, {Name: "Coupon"}},
+		}},
+		{Sections: []string{"s#us-004"}, DomainModel: &domain.Domain{
+			Entities: []domain.Entity{{Name: "Shipment"}},
+		}},
+	}
+
+	kept, rediscover := Withdraw(contributions, Changes{Changed: []string{"s#us-003"}, Removed: []string{"s#us-002"}})
+	if len(kept) != 1 || kept[0].Sections[0] != "s#us-004" {
+		t.Errorf("Expected only the untouched contribution kept, got %+v", kept)
+	}
+	if len(rediscover) != 1 || rediscover[0] != "s#us-001" {
+		t.Errorf("Expected the unchanged section sharing a contribution rediscovered, got %v", rediscover)
+	}
+
+	// The changed story no longer mentions coupons or discounts
+	kept = append(kept, Contribution{Sections: []string{"s#us-003"}, DomainModel: &domain.Domain{
+		Entities: []domain.Entity{{Name: "Order", MentionedAttributes: []string{"total"}}},
+	}})
+	merged := BuildDomain(kept)
+	names := make(map[string]domain.Entity)
+	for _, e := range merged.Entities {
+		names[e.Name] = e
+	}
+	if _, ok := names["Coupon"]; ok || len(names["Order"].MentionedAttributes) != 1 {
+		t.Errorf("Expected the old contribution withdrawn, got %+v", merged.Entities)
+	}
+	if _, ok := names["Shipment"]; !ok {
+		t.Error("Expected the kept contribution merged")
+	}
+}
@@ -0,0 +1,260 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+)
+
+// PreambleID is the section ID suffix for content before the first story heading
+const PreambleID = "_preamble"
+
+// UnmarkedID is the section ID suffix for content outside LOOM markers
+const UnmarkedID = "_unmarked"
+
+// Section is an independently hashed part of the L0 input, usually a
+// single user story
+type Section struct {
+	// ID identifies the section across runs ("<file>#<story>")
+	ID string `json:"id"`
+
+	// Source is the base name of the input file
+	Source string `json:"source"`
+
+	// Hash is the content hash of the section
+	Hash string `json:"hash"`
+
+	// Content is the section text
+	Content string `json:"-"`
+}
+
+var (
+	headingPattern    = regexp.MustCompile(`^(#{2,6})\s+(.+?)\s*#*\s*$`)
+	storyIDPattern    = regexp.MustCompile(`^[A-Z][A-Z0-9]*(?:-[A-Z0-9]+)*-\d+\b`)
+	loomMarkerPattern = regexp.MustCompile(`<!--\s*LOOM:(BEGIN|END|MANUAL)\b`)
+	slugPattern       = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// SplitFiles reads the L0 input files and splits them into sections
+func SplitFiles(paths []string) ([]Section, error) {
+	var sections []Section
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		sections = append(sections, SplitContent(filepath.Base(path), string(content))...)
+	}
+	return sections, nil
+}
+
+// SplitContent splits one L0 file into sections. LOOM-marked files are split
+// along their markers (via Hasher.HashSections); plain markdown is split at
+// headings, one section per story (see splitHeadings).
+func SplitContent(source, content string) []Section {
+	h := derivation.NewHasher()
+	if loomMarkerPattern.MatchString(content) {
+		return splitMarked(h, source, content)
+	}
+	return splitHeadings(h, source, content)
+}
+
+// splitMarked uses the LOOM section markers. Text outside of any marker is
+// kept as one extra section so edits there are detected too.
+func splitMarked(h *derivation.Hasher, source, content string) []Section {
+	lines := strings.Split(content, "\n")
+	covered := make([]bool, len(lines))
+
+	var sections []Section
+	ids := make(map[string]int)
+
+	for _, sh := range h.HashSections(content) {
+		start, end := sh.StartLine, sh.EndLine
+		if end < start {
+			end = start
+		}
+		for i := start - 1; i < end && i < len(lines); i++ {
+			covered[i] = true
+		}
+
+		name := sh.SectionID
+		if name == "" {
+			name = fmt.Sprintf("%s-L%d", sh.SectionType, sh.StartLine)
+		}
+		sections = append(sections, Section{
+			ID:      uniqueID(ids, source+"#"+name),
+			Source:  source,
+			Hash:    sh.Hash,
+			Content: strings.Join(lines[start-1:min(end, len(lines))], "\n"),
+		})
+	}
+
+	var rest []string
+	for i, line := range lines {
+		if !covered[i] {
+			rest = append(rest, line)
+		}
+	}
+	if text := strings.TrimSpace(strings.Join(rest, "\n")); text != "" {
+		sections = append(sections, Section{
+			ID:      source + "#" + UnmarkedID,
+			Source:  source,
+			Hash:    h.HashContent(text),
+			Content: text,
+		})
+	}
+
+	return sections
+}
+
+// splitHeadings splits plain markdown at headings outside code fences: at
+// every heading naming a story ID (US-001), and at level-2 and level-3
+// headings unless they are subheadings of a story. Stories nested under a
+// "## User Stories" heading thus get a section each.
+func splitHeadings(h *derivation.Hasher, source, content string) []Section {
+	var sections []Section
+	ids := make(map[string]int)
+
+	name := PreambleID
+	var current []string
+	inFence := false
+	level, story := 0, false
+
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(current, "\n"))
+		if text != "" {
+			sections = append(sections, Section{
+				ID:      uniqueID(ids, source+"#"+name),
+				Source:  source,
+				Hash:    h.HashContent(text),
+				Content: text,
+			})
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if !inFence {
+			if m := headingPattern.FindStringSubmatch(line); m != nil {
+				headingLevel := len(m[1])
+				isStory := storyIDPattern.MatchString(m[2])
+				if isStory || headingLevel <= level || (headingLevel <= 3 && !story) {
+					flush()
+					name = slug(m[2])
+					level, story = headingLevel, isStory
+				}
+			}
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	return sections
+}
+
+// uniqueID disambiguates repeated section IDs within a file
+func uniqueID(seen map[string]int, id string) string {
+	seen[id]++
+	if seen[id] == 1 {
+		return id
+	}
+	return fmt.Sprintf("%s-%d", id, seen[id])
+}
+
+// slug turns a heading into a stable section name
+func slug(heading string) string {
+	s := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(heading), "-"), "-")
+	if s == "" {
+		return "section"
+	}
+	return s
+}
+
+// SectionHashes returns the section hashes keyed by section ID
+func SectionHashes(sections []Section) map[string]string {
+	hashes := make(map[string]string, len(sections))
+	for _, s := range sections {
+		hashes[s.ID] = s.Hash
+	}
+	return hashes
+}
+
+// JoinSections combines sections into analysis input, in the same layout
+// as config.ReadInputFiles
+func JoinSections(sections []Section) string {
+	parts := make([]string, 0, len(sections))
+	for _, s := range sections {
+		parts = append(parts, fmt.Sprintf("<!-- SOURCE: %s -->\n%s", s.Source, s.Content))
+	}
+	return strings.Join(parts, "\n\n---\n\n")
+}
+
+// Changes lists the sections that differ from a previous run
+type Changes struct {
+	Added   []string `json:"added,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Empty reports whether no section changed
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Changed) == 0 && len(c.Removed) == 0
+}
+
+// String summarizes the changes for progress output
+func (c Changes) String() string {
+	return fmt.Sprintf("%d added, %d changed, %d removed", len(c.Added), len(c.Changed), len(c.Removed))
+}
+
+// DiffSections compares current sections with previously recorded hashes.
+// Added and changed IDs keep the order of the current sections.
+func DiffSections(previous map[string]string, current []Section) Changes {
+	var changes Changes
+	seen := make(map[string]bool, len(current))
+
+	for _, s := range current {
+		seen[s.ID] = true
+		old, ok := previous[s.ID]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, s.ID)
+		case old != s.Hash:
+			changes.Changed = append(changes.Changed, s.ID)
+		}
+	}
+
+	for id := range previous {
+		if !seen[id] {
+			changes.Removed = append(changes.Removed, id)
+		}
+	}
+	sort.Strings(changes.Removed)
+
+	return changes
+}
+
+// Select returns the sections with the given IDs, in section order
+func Select(sections []Section, ids ...[]string) []Section {
+	want := make(map[string]bool)
+	for _, list := range ids {
+		for _, id := range list {
+			want[id] = true
+		}
+	}
+
+	var result []Section
+	for _, s := range sections {
+		if want[s.ID] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
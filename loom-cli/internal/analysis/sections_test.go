@@ -0,0 +1,208 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+const storiesV1 = `# Order Management
+
+Stories for the order service.
+
+## US-001: Place order
+
+As a customer I want to place an order.
+
+## US-002: Cancel order
+
+As a customer I want to cancel an order.
+
+` + "```" + `
+## not a heading inside a fence
+` + "```" + `
+`
+
+func TestSplitContent_Headings(t *testing.T) {
+	sections := SplitContent("stories.md", storiesV1)
+
+	if len(sections) != 3 {
+		t.Fatalf("Expected 3 sections, got %d", len(sections))
+	}
+
+	ids := []string{"stories.md#" + PreambleID, "stories.md#us-001-place-order", "stories.md#us-002-cancel-order"}
+	for i, id := range ids {
+		if sections[i].ID != id {
+			t.Errorf("Section %d: expected ID %s, got %s", i, id, sections[i].ID)
+		}
+		if !strings.HasPrefix(sections[i].Hash, "sha256:") {
+			t.Errorf("Section %d: expected sha256 hash, got %s", i, sections[i].Hash)
+		}
+	}
+	if !strings.Contains(sections[2].Content, "not a heading inside a fence") {
+		t.Error("Fenced heading should stay in its story section")
+	}
+}
+
+func TestSplitContent_NestedStories(t *testing.T) {
+	content := `# E-Commerce Order System
+
+## Overview
+
+An online shop.
+
+## User Stories
+
+### US-001: Browse Products
+
+As a customer I want to browse products.
+
+#### Acceptance
+
+Products are listed by category.
+
+### US-002: Add to Cart
+
+As a customer I want to add products to my cart.
+
+## Business Rules
+
+Orders need at least one item.
+`
+	sections := SplitContent("input-l0.md", content)
+
+	var ids []string
+	for _, s := range sections {
+		ids = append(ids, strings.TrimPrefix(s.ID, "input-l0.md#"))
+	}
+	want := []string{PreambleID, "overview", "user-stories", "us-001-browse-products", "us-002-add-to-cart", "business-rules"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Fatalf("Expected sections %v, got %v", want, ids)
+	}
+	if !strings.Contains(sections[3].Content, "listed by category") {
+		t.Error("Expected a story's subheadings to stay in its section")
+	}
+
+	added := strings.Replace(content, "## Business Rules", "### US-003: Checkout\n\nAs a customer I want to check out.\n\n## Business Rules", 1)
+	changes := DiffSections(SectionHashes(sections), SplitContent("input-l0.md", added))
+	if len(changes.Added) != 1 || len(changes.Changed) != 0 {
+		t.Errorf("Expected only the new story to need analysis, got %s", changes)
+	}
+}
+
+func TestSplitContent_LoomMarkers(t *testing.T) {
+	content := `# Stories
+
+<!-- LOOM:BEGIN generated id="US-001" -->
+## US-001
+Place order.
+<!-- LOOM:END generated -->
+
+Loose notes.
+`
+	sections := SplitContent("stories.md", content)
+
+	if len(sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(sections))
+	}
+	if sections[0].ID != "stories.md#US-001" {
+		t.Errorf("Expected marked section ID, got %s", sections[0].ID)
+	}
+	if sections[1].ID != "stories.md#"+UnmarkedID {
+		t.Errorf("Expected unmarked remainder section, got %s", sections[1].ID)
+	}
+	if !strings.Contains(sections[1].Content, "Loose notes.") {
+		t.Error("Expected text outside markers in the remainder section")
+	}
+}
+
+func TestDiffSections(t *testing.T) {
+	before := SplitContent("stories.md", storiesV1)
+	previous := SectionHashes(before)
+
+	after := strings.Replace(storiesV1, "cancel an order.", "cancel an order before shipping.", 1)
+	after += "\n## US-003: Track order\n\nAs a customer I want to track an order.\n"
+	current := SplitContent("stories.md", after)
+
+	changes := DiffSections(previous, current)
+	if len(changes.Added) != 1 || changes.Added[0] != "stories.md#us-003-track-order" {
+		t.Errorf("Expected US-003 added, got %v", changes.Added)
+	}
+	if len(changes.Changed) != 1 || changes.Changed[0] != "stories.md#us-002-cancel-order" {
+		t.Errorf("Expected US-002 changed, got %v", changes.Changed)
+	}
+	if len(changes.Removed) != 0 {
+		t.Errorf("Expected nothing removed, got %v", changes.Removed)
+	}
+
+	delta := Select(current, changes.Added, changes.Changed)
+	if len(delta) != 2 {
+		t.Fatalf("Expected 2 delta sections, got %d", len(delta))
+	}
+	joined := JoinSections(delta)
+	if !strings.Contains(joined, "<!-- SOURCE: stories.md -->") || strings.Contains(joined, "Place order") {
+		t.Errorf("Unexpected delta input:\n%s", joined)
+	}
+
+	removed := DiffSections(previous, current[:1])
+	if len(removed.Removed) != 2 {
+		t.Errorf("Expected 2 removed sections, got %v", removed.Removed)
+	}
+	if !DiffSections(previous, before).Empty() {
+		t.Error("Expected no changes for identical input")
+	}
+}
+
+func TestCache_SaveLoad(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := CachePath("", tmpDir)
+	if path != filepath.Join(tmpDir, ".loom", "analysis-cache.json") {
+		t.Errorf("Unexpected cache path %s", path)
+	}
+
+	if cache, err := LoadCache(path); err != nil || cache != nil {
+		t.Fatalf("Expected no cache, got %v, %v", cache, err)
+	}
+
+	cache := &Cache{
+		Sections:    map[string]string{"stories.md#us-001": "sha256:abc"},
+		DomainModel: &domain.Domain{Entities: []domain.Entity{{Name: "Order"}}},
+		Ambiguities: []domain.Ambiguity{{ID: "AMB-ENT-001", Subject: "Order"}},
+		Contributions: []Contribution{
+			{Sections: []string{"stories.md#us-001"}, DomainModel: &domain.Domain{Entities: []domain.Entity{{Name: "Order"}}}},
+		},
+	}
+	if err := cache.Save(path); err != nil {
+		t.Fatalf("Failed to save cache: %v", err)
+	}
+
+	loaded, err := LoadCache(path)
+	if err != nil {
+		t.Fatalf("Failed to load cache: %v", err)
+	}
+	if loaded.FormatVersion != CacheFormatVersion || len(loaded.DomainModel.Entities) != 1 || len(loaded.Ambiguities) != 1 {
+		t.Errorf("Unexpected cache contents %+v", loaded)
+	}
+
+	if len(loaded.Contributions) != 1 || loaded.Contributions[0].Sections[0] != "stories.md#us-001" {
+		t.Errorf("Expected the contributions loaded, got %+v", loaded.Contributions)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"format_version": 99}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCache(path); err == nil {
+		t.Error("Expected error for newer cache format version")
+	}
+}
+
+func TestCachePath_SingleFile(t *testing.T) {
+	got := CachePath(filepath.Join("specs", "stories.md"), "")
+	want := filepath.Join("specs", ".loom", "stories.analysis-cache.json")
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
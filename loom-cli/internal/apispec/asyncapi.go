@@ -0,0 +1,414 @@
+package apispec
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// AsyncAPIVersion is the AsyncAPI version of generated documents
+const AsyncAPIVersion = "3.0.0"
+
+// Message kinds recorded in x-loom-kind
+const (
+	KindDomainEvent      = "domain_event"
+	KindCommand          = "command"
+	KindIntegrationEvent = "integration_event"
+)
+
+// EventDesign is the L3 event and message design
+type EventDesign struct {
+	DomainEvents      []formatter.DomainEvent
+	Commands          []formatter.Command
+	IntegrationEvents []formatter.IntegrationEvent
+}
+
+// AsyncAPIDocument is an AsyncAPI 3.0 document
+type AsyncAPIDocument struct {
+	AsyncAPI           string                     `json:"asyncapi"`
+	Info               Info                       `json:"info"`
+	DefaultContentType string                     `json:"defaultContentType"`
+	Channels           map[string]*Channel        `json:"channels"`
+	Operations         map[string]*AsyncOperation `json:"operations"`
+	Components         *AsyncComponents           `json:"components"`
+}
+
+// Channel carries the messages of one aggregate, source context or the
+// command bus
+type Channel struct {
+	Address     string                `json:"address"`
+	Description string                `json:"description,omitempty"`
+	Messages    map[string]*Reference `json:"messages"`
+}
+
+// Reference is a $ref to another part of the document
+type Reference struct {
+	Ref string `json:"$ref"`
+}
+
+// AsyncOperation sends or receives messages on a channel
+type AsyncOperation struct {
+	Action   string      `json:"action"` // send or receive
+	Channel  Reference   `json:"channel"`
+	Summary  string      `json:"summary,omitempty"`
+	Messages []Reference `json:"messages"`
+}
+
+// Message is a message definition with its payload schema
+type Message struct {
+	Name        string  `json:"name"`
+	Title       string  `json:"title,omitempty"`
+	Summary     string  `json:"summary,omitempty"`
+	Description string  `json:"description,omitempty"`
+	ContentType string  `json:"contentType"`
+	Payload     *Schema `json:"payload"`
+
+	LoomID         string   `json:"x-loom-id,omitempty"`
+	LoomKind       string   `json:"x-loom-kind"`
+	LoomVersion    string   `json:"x-loom-version,omitempty"`
+	LoomConsumers  []string `json:"x-loom-consumers,omitempty"`
+	LoomInvariants []string `json:"x-loom-invariants,omitempty"`
+}
+
+// AsyncComponents holds the message definitions and shared schemas
+type AsyncComponents struct {
+	Messages map[string]*Message `json:"messages"`
+	Schemas  map[string]*Schema  `json:"schemas,omitempty"`
+}
+
+// SchemaFile is a standalone JSON Schema file for one event version
+type SchemaFile struct {
+	Name   string // file name, e.g. OrderPlaced.v1.schema.json
+	Schema *Schema
+}
+
+var (
+	payloadEntryPattern = regexp.MustCompile(`^\s*([\w.]+)\s*(?::\s*(.+?)|\((.+)\))?\s*$`)
+	versionCleanPattern = regexp.MustCompile(`[^0-9A-Za-z.\-]+`)
+)
+
+// BuildAsyncAPI builds an AsyncAPI 3.0 document from the event design.
+// Domain events get one channel per aggregate, integration events one per
+// source context and commands share a command channel. Payload schemas are
+// built from the event fields; shared types become component schemas.
+func BuildAsyncAPI(design EventDesign, sharedTypes []formatter.SharedType, info Info) *AsyncAPIDocument {
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+	if info.Title == "" {
+		info.Title = "Events"
+	}
+
+	doc := &AsyncAPIDocument{
+		AsyncAPI:           AsyncAPIVersion,
+		Info:               info,
+		DefaultContentType: "application/json",
+		Channels:           make(map[string]*Channel),
+		Operations:         make(map[string]*AsyncOperation),
+		Components:         &AsyncComponents{Messages: make(map[string]*Message)},
+	}
+
+	mapper := NewSchemaMapper(componentSchemaRef, sharedTypeNames(sharedTypes))
+	if len(sharedTypes) > 0 {
+		doc.Components.Schemas = make(map[string]*Schema, len(sharedTypes))
+		for _, st := range sharedTypes {
+			doc.Components.Schemas[st.Name] = sharedTypeSchema(mapper, st)
+		}
+	}
+
+	for _, e := range design.DomainEvents {
+		aggregate := e.Aggregate
+		if aggregate == "" {
+			aggregate = "Domain"
+		}
+		channel := doc.channel(kebab(aggregate), kebab(aggregate)+".events", fmt.Sprintf("Domain events of the %s aggregate", aggregate))
+		msg := &Message{
+			Name:           e.Name,
+			Title:          e.Name,
+			Summary:        e.Purpose,
+			Description:    triggerDescription(e.Trigger),
+			ContentType:    "application/json",
+			Payload:        fieldsSchema(mapper, e.Payload),
+			LoomID:         e.ID,
+			LoomKind:       KindDomainEvent,
+			LoomVersion:    normalizeVersion(e.Version),
+			LoomConsumers:  e.Consumers,
+			LoomInvariants: e.InvariantsReflected,
+		}
+		doc.addMessage(channel, msg, "send", "publish"+exportedIdent(e.Name))
+	}
+
+	for _, c := range design.Commands {
+		channel := doc.channel("commands", "commands", "Commands handled by the domain")
+		msg := &Message{
+			Name:        c.Name,
+			Title:       c.Name,
+			Summary:     c.Intent,
+			Description: commandDescription(c),
+			ContentType: "application/json",
+			Payload:     fieldsSchema(mapper, c.RequiredData),
+			LoomID:      c.ID,
+			LoomKind:    KindCommand,
+		}
+		doc.addMessage(channel, msg, "receive", "handle"+exportedIdent(c.Name))
+	}
+
+	for _, ie := range design.IntegrationEvents {
+		source := ie.Source
+		if source == "" {
+			source = "Integration"
+		}
+		channel := doc.channel("integration-"+kebab(source), kebab(source)+".integration", fmt.Sprintf("Integration events published by %s", source))
+		msg := &Message{
+			Name:          ie.Name,
+			Title:         ie.Name,
+			Summary:       ie.Purpose,
+			ContentType:   "application/json",
+			Payload:       fieldsSchema(mapper, parsePayloadEntries(ie.Payload)),
+			LoomID:        ie.ID,
+			LoomKind:      KindIntegrationEvent,
+			LoomVersion:   normalizeVersion(""),
+			LoomConsumers: ie.Consumers,
+		}
+		doc.addMessage(channel, msg, "send", "publish"+exportedIdent(ie.Name))
+	}
+
+	return doc
+}
+
+// BuildEventSchemas builds one standalone JSON Schema per event version.
+// Referenced shared types are embedded under $defs so each file validates
+// payloads on its own.
+func BuildEventSchemas(design EventDesign, sharedTypes []formatter.SharedType) []SchemaFile {
+	const defsRef = "#/$defs/"
+	mapper := NewSchemaMapper(defsRef, sharedTypeNames(sharedTypes))
+	defs := make(map[string]*Schema, len(sharedTypes))
+	for _, st := range sharedTypes {
+		defs[st.Name] = sharedTypeSchema(mapper, st)
+	}
+
+	var files []SchemaFile
+	seen := make(map[string]bool)
+	add := func(id, name, description, version string, fields []formatter.EventField) {
+		fileName := fmt.Sprintf("%s.v%s.schema.json", exportedIdent(name), version)
+		if seen[fileName] {
+			return
+		}
+		seen[fileName] = true
+
+		s := fieldsSchema(mapper, fields)
+		s.SchemaURI = JSONSchemaDialect
+		s.ID = fmt.Sprintf("urn:loom:event:%s:v%s", exportedIdent(name), version)
+		s.Title = fmt.Sprintf("%s v%s", name, version)
+		s.Description = description
+		s.LoomID = id
+
+		used := make(map[string]bool)
+		collectRefs(s, defsRef, defs, used)
+		if len(used) > 0 {
+			s.Defs = make(map[string]*Schema, len(used))
+			for n := range used {
+				s.Defs[n] = defs[n]
+			}
+		}
+		files = append(files, SchemaFile{Name: fileName, Schema: s})
+	}
+
+	for _, e := range design.DomainEvents {
+		add(e.ID, e.Name, e.Purpose, normalizeVersion(e.Version), e.Payload)
+	}
+	for _, ie := range design.IntegrationEvents {
+		add(ie.ID, ie.Name, ie.Purpose, normalizeVersion(""), parsePayloadEntries(ie.Payload))
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files
+}
+
+// MessageCount returns the number of messages in the document
+func (d *AsyncAPIDocument) MessageCount() int {
+	return len(d.Components.Messages)
+}
+
+// channel returns the channel with the given key, creating it if needed
+func (d *AsyncAPIDocument) channel(key, address, description string) string {
+	if _, ok := d.Channels[key]; !ok {
+		d.Channels[key] = &Channel{Address: address, Description: description, Messages: make(map[string]*Reference)}
+	}
+	return key
+}
+
+// addMessage registers a message component, links it from the channel and
+// adds the operation sending or receiving it
+func (d *AsyncAPIDocument) addMessage(channelKey string, msg *Message, action, opID string) {
+	key := uniqueKey(exportedIdent(msg.Name), func(k string) bool { _, ok := d.Components.Messages[k]; return ok })
+	d.Components.Messages[key] = msg
+
+	ch := d.Channels[channelKey]
+	ch.Messages[key] = &Reference{Ref: "#/components/messages/" + key}
+
+	opID = uniqueKey(opID, func(k string) bool { _, ok := d.Operations[k]; return ok })
+	d.Operations[opID] = &AsyncOperation{
+		Action:   action,
+		Channel:  Reference{Ref: "#/channels/" + channelKey},
+		Summary:  msg.Summary,
+		Messages: []Reference{{Ref: "#/channels/" + channelKey + "/messages/" + key}},
+	}
+}
+
+// fieldsSchema builds an object schema from payload fields. Fields are
+// required unless their type is nullable.
+func fieldsSchema(mapper *SchemaMapper, fields []formatter.EventField) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, f := range fields {
+		name := strings.TrimSpace(f.Field)
+		if name == "" {
+			continue
+		}
+		s.Properties[name] = mapper.Schema(f.Type)
+		if !nullableSpecType(f.Type) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// parsePayloadEntries parses integration event payload entries written as
+// "field", "field: Type" or "field (Type)"
+func parsePayloadEntries(entries []string) []formatter.EventField {
+	var fields []formatter.EventField
+	for _, entry := range entries {
+		m := payloadEntryPattern.FindStringSubmatch(entry)
+		if m == nil {
+			continue
+		}
+		typ := m[2]
+		if typ == "" {
+			typ = m[3]
+		}
+		fields = append(fields, formatter.EventField{Field: m[1], Type: strings.TrimSpace(typ)})
+	}
+	return fields
+}
+
+// collectRefs records the named types a schema references, transitively
+func collectRefs(s *Schema, prefix string, defs map[string]*Schema, used map[string]bool) {
+	if s == nil {
+		return
+	}
+	if name := strings.TrimPrefix(s.Ref, prefix); s.Ref != "" && name != s.Ref && !used[name] {
+		if def, ok := defs[name]; ok {
+			used[name] = true
+			collectRefs(def, prefix, defs, used)
+		}
+	}
+	collectRefs(s.Items, prefix, defs, used)
+	collectRefs(s.AdditionalProperties, prefix, defs, used)
+	for _, p := range s.Properties {
+		collectRefs(p, prefix, defs, used)
+	}
+	for _, sub := range s.OneOf {
+		collectRefs(sub, prefix, defs, used)
+	}
+	for _, sub := range s.AllOf {
+		collectRefs(sub, prefix, defs, used)
+	}
+}
+
+// nullableSpecType reports whether a spec type admits null
+func nullableSpecType(specType string) bool {
+	t := strings.ToLower(strings.TrimSpace(specType))
+	return strings.HasSuffix(t, "?") || strings.HasPrefix(t, "optional<") ||
+		strings.HasPrefix(t, "maybe<") || strings.HasPrefix(t, "nullable<") ||
+		strings.Contains(t, "| null") || strings.HasPrefix(t, "null |")
+}
+
+// normalizeVersion turns "v1", "1.0" or "" into a file-name safe version
+func normalizeVersion(version string) string {
+	v := strings.TrimSpace(version)
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "v"), "V")
+	v = versionCleanPattern.ReplaceAllString(v, "-")
+	if v == "" {
+		return "1"
+	}
+	return v
+}
+
+func triggerDescription(trigger string) string {
+	if trigger == "" {
+		return ""
+	}
+	return "Trigger: " + trigger
+}
+
+func commandDescription(c formatter.Command) string {
+	var parts []string
+	if c.ExpectedOutcome != "" {
+		parts = append(parts, "Expected outcome: "+c.ExpectedOutcome)
+	}
+	if len(c.FailureConditions) > 0 {
+		parts = append(parts, "Fails when: "+strings.Join(c.FailureConditions, "; "))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func sharedTypeNames(sharedTypes []formatter.SharedType) []string {
+	names := make([]string, 0, len(sharedTypes))
+	for _, st := range sharedTypes {
+		names = append(names, st.Name)
+	}
+	return names
+}
+
+// exportedIdent returns a PascalCase identifier
+func exportedIdent(name string) string {
+	var sb strings.Builder
+	for _, w := range nonWordPattern.Split(name, -1) {
+		if w != "" {
+			sb.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		}
+	}
+	if sb.Len() == 0 {
+		return "Message"
+	}
+	return sb.String()
+}
+
+// kebab returns a lower-case, dash-separated channel name
+func kebab(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			if i > 0 && sb.Len() > 0 && !strings.HasSuffix(sb.String(), "-") {
+				prev := name[i-1]
+				if prev >= 'a' && prev <= 'z' || prev >= '0' && prev <= '9' {
+					sb.WriteByte('-')
+				}
+			}
+			sb.WriteRune(r + ('a' - 'A'))
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		default:
+			if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "-") {
+				sb.WriteByte('-')
+			}
+		}
+	}
+	return strings.Trim(sb.String(), "-")
+}
+
+// uniqueKey appends a counter to key until taken reports it free
+func uniqueKey(key string, taken func(string) bool) string {
+	if !taken(key) {
+		return key
+	}
+	for n := 2; ; n++ {
+		if k := fmt.Sprintf("%s%d", key, n); !taken(k) {
+			return k
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package apispec
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+func testEventDesign() EventDesign {
+	return EventDesign{
+		DomainEvents: []formatter.DomainEvent{
+			{
+				ID:        "EVT-ORD-001",
+				Name:      "OrderPlaced",
+				Purpose:   "An order was placed",
+				Trigger:   "placeOrder succeeds",
+				Aggregate: "Order",
+				Payload: []formatter.EventField{
+					{Field: "orderId", Type: "UUID"},
+					{Field: "total", Type: "Money"},
+					{Field: "couponCode", Type: "string?"},
+				},
+				Consumers:           []string{"Billing"},
+				InvariantsReflected: []string{"INV-ORD-001"},
+				Version:             "v2",
+			},
+			{ID: "EVT-ORD-002", Name: "OrderCancelled", Aggregate: "Order", Payload: []formatter.EventField{{Field: "orderId", Type: "UUID"}}},
+		},
+		Commands: []formatter.Command{
+			{ID: "CMD-ORD-001", Name: "PlaceOrder", Intent: "Place an order", RequiredData: []formatter.EventField{{Field: "customerId", Type: "UUID"}}},
+		},
+		IntegrationEvents: []formatter.IntegrationEvent{
+			{ID: "IE-ORD-001", Name: "OrderPlaced", Source: "Ordering", Consumers: []string{"Shipping"}, Payload: []string{"orderId: UUID", "placedAt (DateTime)", "note"}},
+		},
+	}
+}
+
+func testMoney() []formatter.SharedType {
+	return []formatter.SharedType{{Name: "Money", Fields: []formatter.TypeField{
+		{Name: "amount", Type: "decimal", Constraints: "required"},
+		{Name: "currency", Type: "string", Constraints: "required"},
+	}}}
+}
+
+func TestBuildAsyncAPI_ChannelsAndMessages(t *testing.T) {
+	doc := BuildAsyncAPI(testEventDesign(), testMoney(), Info{})
+
+	if doc.AsyncAPI != "3.0.0" || doc.Info.Title != "Events" {
+		t.Errorf("unexpected header: %s %+v", doc.AsyncAPI, doc.Info)
+	}
+
+	order := doc.Channels["order"]
+	if order == nil || order.Address != "order.events" || len(order.Messages) != 2 {
+		t.Fatalf("unexpected order channel: %+v", order)
+	}
+	if _, ok := doc.Channels["commands"]; !ok {
+		t.Errorf("missing commands channel")
+	}
+	if _, ok := doc.Channels["integration-ordering"]; !ok {
+		t.Errorf("missing integration channel: %v", doc.Channels)
+	}
+
+	placed := doc.Components.Messages["OrderPlaced"]
+	if placed == nil || placed.LoomKind != KindDomainEvent || placed.LoomVersion != "2" || placed.LoomID != "EVT-ORD-001" {
+		t.Fatalf("unexpected OrderPlaced message: %+v", placed)
+	}
+	if !reflect.DeepEqual(placed.Payload.Required, []string{"orderId", "total"}) {
+		t.Errorf("unexpected required payload fields: %v", placed.Payload.Required)
+	}
+	if placed.Payload.Properties["total"].Ref != "#/components/schemas/Money" {
+		t.Errorf("expected Money ref, got %+v", placed.Payload.Properties["total"])
+	}
+
+	// The integration event with the same name gets its own message
+	ie := doc.Components.Messages["OrderPlaced2"]
+	if ie == nil || ie.LoomKind != KindIntegrationEvent {
+		t.Fatalf("expected integration message OrderPlaced2, got %+v", ie)
+	}
+	if got := ie.Payload.Properties["placedAt"]; got.Format != "date-time" {
+		t.Errorf("expected date-time placedAt, got %+v", got)
+	}
+
+	op := doc.Operations["publishOrderPlaced"]
+	if op == nil || op.Action != "send" || op.Channel.Ref != "#/channels/order" || op.Messages[0].Ref != "#/channels/order/messages/OrderPlaced" {
+		t.Errorf("unexpected publish operation: %+v", op)
+	}
+	if op := doc.Operations["handlePlaceOrder"]; op == nil || op.Action != "receive" {
+		t.Errorf("unexpected command operation: %+v", op)
+	}
+	if _, ok := doc.Operations["publishOrderPlaced2"]; !ok {
+		t.Errorf("expected deduplicated operation ID: %v", doc.Operations)
+	}
+}
+
+func TestBuildEventSchemas(t *testing.T) {
+	files := BuildEventSchemas(testEventDesign(), testMoney())
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	want := []string{"OrderCancelled.v1.schema.json", "OrderPlaced.v1.schema.json", "OrderPlaced.v2.schema.json"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("schema files = %v, want %v", names, want)
+	}
+
+	placed := files[2].Schema
+	if placed.SchemaURI != JSONSchemaDialect || placed.LoomID != "EVT-ORD-001" {
+		t.Errorf("unexpected schema header: %+v", placed)
+	}
+	if placed.Properties["total"].Ref != "#/$defs/Money" || placed.Defs["Money"] == nil {
+		t.Errorf("expected embedded Money definition, got %+v", placed.Defs)
+	}
+	if files[0].Schema.Defs != nil {
+		t.Errorf("OrderCancelled must not embed unused definitions")
+	}
+
+	data, err := json.Marshal(placed)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if _, ok := raw["$defs"]; !ok {
+		t.Errorf("expected $defs key in %s", data)
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	tests := map[string]string{"": "1", "v1": "1", "1.0": "1.0", "V2 beta": "2-beta"}
+	for in, want := range tests {
+		if got := normalizeVersion(in); got != want {
+			t.Errorf("normalizeVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
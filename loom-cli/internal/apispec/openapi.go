@@ -0,0 +1,514 @@
+package apispec
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// OpenAPIVersion is the OpenAPI version of generated documents
+const OpenAPIVersion = "3.1.0"
+
+// ErrorSchemaName is the component schema of contract error bodies
+const ErrorSchemaName = "Error"
+
+// componentSchemaRef is the $ref prefix of component schemas
+const componentSchemaRef = "#/components/schemas/"
+
+// Document is an OpenAPI 3.1 document
+type Document struct {
+	OpenAPI           string              `json:"openapi"`
+	Info              Info                `json:"info"`
+	JSONSchemaDialect string              `json:"jsonSchemaDialect,omitempty"`
+	Tags              []Tag               `json:"tags,omitempty"`
+	Paths             map[string]PathItem `json:"paths"`
+	Components        *Components         `json:"components,omitempty"`
+}
+
+// Info is the document metadata
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag groups the operations of one interface contract
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// LoomContract is the interface contract ID
+	LoomContract string `json:"x-loom-contract,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations
+type PathItem map[string]*Operation
+
+// Operation is one API operation, linked back to the spec via x-loom fields
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+
+	// Security is empty for public operations and nil when unknown
+	Security *[]map[string][]string `json:"security,omitempty"`
+
+	LoomID                 string   `json:"x-loom-id,omitempty"`
+	LoomContract           string   `json:"x-loom-contract,omitempty"`
+	LoomAcceptanceCriteria []string `json:"x-loom-acceptance-criteria,omitempty"`
+	LoomBusinessRules      []string `json:"x-loom-business-rules,omitempty"`
+	LoomPreconditions      []string `json:"x-loom-preconditions,omitempty"`
+	LoomPostconditions     []string `json:"x-loom-postconditions,omitempty"`
+
+	// LoomSecurity keeps the contract's authentication text when it
+	// does not settle whether this operation needs it
+	LoomSecurity string `json:"x-loom-security,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is a JSON request body
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is one response of an operation
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+
+	// LoomErrorCodes lists the contract error codes returned with this status
+	LoomErrorCodes []string `json:"x-loom-error-codes,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is an OpenAPI security scheme
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+var (
+	pathParamPattern = regexp.MustCompile(`\{([^}]+)\}|:(\w+)`)
+	nonWordPattern   = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+	// publicAuthPattern is authentication text declaring no authentication
+	publicAuthPattern = regexp.MustCompile(`(?i)^\W*(?:none|public|anonymous|not required)\b`)
+
+	// authSchemePattern names an authentication mechanism
+	authSchemePattern = regexp.MustCompile(`(?i)jwt|bearer|token|api[ -]?key|basic|oauth|session`)
+
+	// qualifiedAuthPattern exempts some operations from authentication,
+	// e.g. "Bearer JWT (except registration)"
+	qualifiedAuthPattern = regexp.MustCompile(`(?i)\b(?:except|unless|public|anonymous|optional(?:ly)?)\b`)
+
+	// alternativeAuthPattern separates alternative mechanisms, e.g.
+	// "Bearer JWT or session token"
+	alternativeAuthPattern = regexp.MustCompile(`(?i)\bor\b`)
+
+	// publicOperationPattern marks an operation as open to anyone
+	publicOperationPattern = regexp.MustCompile(`(?i)\b(?:public|anonymous|unauthenticated|no\s+auth(?:entication)?\s+required|without\s+(?:authentication|login))\b`)
+)
+
+// BuildOpenAPI builds an OpenAPI 3.1 document from interface contracts.
+// Shared types become component schemas, each contract becomes a tag, error
+// codes become error responses grouped by HTTP status and security comes
+// from the contract's security requirements. Operations carry x-loom
+// extension fields with their contract, AC and BR IDs.
+func BuildOpenAPI(contracts []formatter.InterfaceContract, sharedTypes []formatter.SharedType, info Info) *Document {
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+	if info.Title == "" {
+		info.Title = "API"
+	}
+
+	doc := &Document{
+		OpenAPI:           OpenAPIVersion,
+		Info:              info,
+		JSONSchemaDialect: JSONSchemaDialect,
+		Paths:             make(map[string]PathItem),
+		Components: &Components{
+			Schemas: map[string]*Schema{
+				ErrorSchemaName: {
+					Type:     "object",
+					Required: []string{"code", "message"},
+					Properties: map[string]*Schema{
+						"code":    {Type: "string"},
+						"message": {Type: "string"},
+					},
+				},
+			},
+		},
+	}
+
+	mapper := NewSchemaMapper(componentSchemaRef, sharedTypeNames(sharedTypes))
+	for _, st := range sharedTypes {
+		doc.Components.Schemas[st.Name] = sharedTypeSchema(mapper, st)
+	}
+
+	usedIDs := make(map[string]int)
+	for _, ic := range contracts {
+		tag := ic.ServiceName
+		if tag == "" {
+			tag = ic.ID
+		}
+		doc.Tags = append(doc.Tags, Tag{Name: tag, Description: ic.Purpose, LoomContract: ic.ID})
+
+		for _, op := range ic.Operations {
+			path, params := openAPIPath(ic.BaseURL, op.Path)
+			method := strings.ToLower(strings.TrimSpace(op.Method))
+			if method == "" {
+				method = "post"
+			}
+
+			operation := buildOperation(mapper, op, method, params)
+			operation.Tags = []string{tag}
+			operation.LoomContract = ic.ID
+			applySecurity(doc.Components, operation, op, ic.SecurityRequirements)
+			if authz := strings.TrimSpace(ic.SecurityRequirements.Authorization); authz != "" && !isPublic(operation) {
+				operation.Description = strings.TrimSpace(operation.Description + "\n\nAuthorization: " + authz)
+			}
+
+			// operationIds must be unique across the document
+			usedIDs[operation.OperationID]++
+			if n := usedIDs[operation.OperationID]; n > 1 {
+				operation.OperationID = fmt.Sprintf("%s%d", operation.OperationID, n)
+			}
+
+			item, ok := doc.Paths[path]
+			if !ok {
+				item = make(PathItem)
+				doc.Paths[path] = item
+			}
+			if _, exists := item[method]; exists {
+				// Two contracts define the same route; keep the first
+				continue
+			}
+			item[method] = operation
+		}
+	}
+
+	return doc
+}
+
+// OperationCount returns the number of operations in the document
+func (d *Document) OperationCount() int {
+	n := 0
+	for _, item := range d.Paths {
+		n += len(item)
+	}
+	return n
+}
+
+// buildOperation maps a contract operation to an OpenAPI operation. Path
+// placeholders become path parameters; the remaining input fields become
+// query parameters for GET/DELETE/HEAD and a JSON request body otherwise.
+func buildOperation(mapper *SchemaMapper, op formatter.ContractOperation, method string, pathParams []string) *Operation {
+	operation := &Operation{
+		OperationID:            operationID(op),
+		Summary:                op.Description,
+		Responses:              make(map[string]*Response),
+		LoomID:                 op.ID,
+		LoomAcceptanceCriteria: op.RelatedACs,
+		LoomBusinessRules:      op.RelatedBRs,
+		LoomPreconditions:      op.Preconditions,
+		LoomPostconditions:     op.Postconditions,
+	}
+	if op.Name != "" && op.Description != "" {
+		operation.Summary = op.Name
+		operation.Description = op.Description
+	}
+
+	inPath := make(map[string]bool, len(pathParams))
+	for _, p := range pathParams {
+		inPath[p] = true
+		schema := &Schema{Type: "string"}
+		if f, ok := op.InputSchema[p]; ok {
+			schema = mapper.Schema(f.Type)
+		}
+		operation.Parameters = append(operation.Parameters, &Parameter{Name: p, In: "path", Required: true, Schema: schema})
+	}
+
+	fields := sortedFieldNames(op.InputSchema)
+	switch method {
+	case "get", "delete", "head":
+		for _, name := range fields {
+			if inPath[name] {
+				continue
+			}
+			f := op.InputSchema[name]
+			operation.Parameters = append(operation.Parameters, &Parameter{Name: name, In: "query", Required: f.Required, Schema: mapper.Schema(f.Type)})
+		}
+	default:
+		body := objectSchema(mapper, op.InputSchema, inPath)
+		if len(body.Properties) > 0 {
+			operation.RequestBody = &RequestBody{
+				Required: len(body.Required) > 0,
+				Content:  map[string]*MediaType{"application/json": {Schema: body}},
+			}
+		}
+	}
+
+	// Success response
+	success := "200"
+	if method == "post" {
+		success = "201"
+	}
+	if output := objectSchema(mapper, op.OutputSchema, nil); len(output.Properties) > 0 {
+		operation.Responses[success] = &Response{
+			Description: "Success",
+			Content:     map[string]*MediaType{"application/json": {Schema: output}},
+		}
+	} else {
+		operation.Responses["204"] = &Response{Description: "Success (no content)"}
+	}
+
+	// Error responses, one per HTTP status listing its error codes
+	byStatus := make(map[string][]formatter.ContractError)
+	var statuses []string
+	for _, e := range op.Errors {
+		status := "default"
+		if e.HTTPStatus >= 100 && e.HTTPStatus < 600 {
+			status = strconv.Itoa(e.HTTPStatus)
+		}
+		if _, ok := byStatus[status]; !ok {
+			statuses = append(statuses, status)
+		}
+		byStatus[status] = append(byStatus[status], e)
+	}
+	for _, status := range statuses {
+		errs := byStatus[status]
+		codes := make([]string, 0, len(errs))
+		messages := make([]string, 0, len(errs))
+		for _, e := range errs {
+			codes = append(codes, e.Code)
+			msg := e.Code
+			if e.Message != "" {
+				msg += ": " + e.Message
+			}
+			messages = append(messages, msg)
+		}
+		operation.Responses[status] = &Response{
+			Description: strings.Join(messages, "; "),
+			Content: map[string]*MediaType{"application/json": {Schema: &Schema{AllOf: []*Schema{
+				{Ref: componentSchemaRef + ErrorSchemaName},
+				{Properties: map[string]*Schema{"code": {Enum: codes}}},
+			}}}},
+			LoomErrorCodes: codes,
+		}
+	}
+
+	return operation
+}
+
+// objectSchema builds an object schema from a contract schema
+func objectSchema(mapper *SchemaMapper, fields map[string]formatter.SchemaField, skip map[string]bool) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, name := range sortedFieldNames(fields) {
+		if skip[name] {
+			continue
+		}
+		s.Properties[name] = mapper.Schema(fields[name].Type)
+		if fields[name].Required {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// sharedTypeSchema builds the component schema of a shared type. Fields
+// whose constraints mention "required" or "not null" are required.
+func sharedTypeSchema(mapper *SchemaMapper, st formatter.SharedType) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, f := range st.Fields {
+		prop := mapper.Schema(f.Type)
+		if c := strings.TrimSpace(f.Constraints); c != "" {
+			if prop.Ref != "" {
+				// Siblings of $ref are allowed in 3.1 but keep it readable
+				prop = &Schema{AllOf: []*Schema{prop}}
+			}
+			prop.Description = c
+			lower := strings.ToLower(c)
+			if strings.Contains(lower, "required") || strings.Contains(lower, "not null") {
+				s.Required = append(s.Required, f.Name)
+			}
+		}
+		s.Properties[f.Name] = prop
+	}
+	return s
+}
+
+// applySecurity sets the security of an operation. Operations marked
+// public, or of a contract without authentication, get an empty
+// requirement. When the contract's authentication exempts some operations,
+// the operation is left unsecured with an x-loom-security note rather than
+// guessing which ones. Alternative mechanisms become alternative
+// requirements.
+func applySecurity(c *Components, operation *Operation, op formatter.ContractOperation, req formatter.SecurityRequirements) {
+	auth := strings.TrimSpace(req.Authentication)
+	public := []map[string][]string{}
+
+	text := strings.Join(append([]string{op.Name, op.Description}, op.Preconditions...), " ")
+	if publicOperationPattern.MatchString(text) {
+		operation.Security = &public
+		return
+	}
+	if auth == "" {
+		return
+	}
+	if publicAuthPattern.MatchString(auth) && !authSchemePattern.MatchString(auth) {
+		operation.Security = &public
+		return
+	}
+
+	authz := strings.ToLower(req.Authorization)
+	if qualifiedAuthPattern.MatchString(auth) ||
+		strings.Contains(authz, "public") || strings.Contains(authz, "anonymous") || strings.Contains(authz, "guest") {
+		operation.LoomSecurity = auth
+		return
+	}
+
+	// Each alternative mechanism is a requirement object of its own, any
+	// one of which satisfies the operation
+	var requirements []map[string][]string
+	seen := make(map[string]bool)
+	for _, alternative := range alternativeAuthPattern.Split(auth, -1) {
+		if !authSchemePattern.MatchString(alternative) && len(requirements) > 0 {
+			continue
+		}
+		name := securityScheme(c, alternative)
+		if !seen[name] {
+			seen[name] = true
+			requirements = append(requirements, map[string][]string{name: {}})
+		}
+	}
+	operation.Security = &requirements
+}
+
+// securityScheme registers the security scheme named by authentication
+// text and returns its name
+func securityScheme(c *Components, auth string) string {
+	auth = strings.ToLower(auth)
+
+	var name string
+	var scheme *SecurityScheme
+	switch {
+	case strings.Contains(auth, "api key") || strings.Contains(auth, "apikey") || strings.Contains(auth, "api-key"):
+		name, scheme = "apiKeyAuth", &SecurityScheme{Type: "apiKey", In: "header", Name: "X-API-Key", Description: "API key"}
+	case strings.Contains(auth, "basic"):
+		name, scheme = "basicAuth", &SecurityScheme{Type: "http", Scheme: "basic", Description: "HTTP basic authentication"}
+	case strings.Contains(auth, "jwt"):
+		name, scheme = "bearerAuth", &SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "JWT bearer token"}
+	case strings.Contains(auth, "session"):
+		name, scheme = "sessionAuth", &SecurityScheme{Type: "apiKey", In: "cookie", Name: "session", Description: "Session token"}
+	default:
+		// Bearer tokens, OAuth2 access tokens and anything unrecognized
+		name, scheme = "bearerAuth", &SecurityScheme{Type: "http", Scheme: "bearer", Description: "Bearer token"}
+	}
+
+	if c.SecuritySchemes == nil {
+		c.SecuritySchemes = make(map[string]*SecurityScheme)
+	}
+	if _, ok := c.SecuritySchemes[name]; !ok {
+		c.SecuritySchemes[name] = scheme
+	}
+	return name
+}
+
+// isPublic reports whether an operation explicitly needs no authentication
+func isPublic(operation *Operation) bool {
+	return operation.Security != nil && len(*operation.Security) == 0
+}
+
+// openAPIPath joins base URL and path, converts :param to {param} and
+// returns the path parameters in order
+func openAPIPath(baseURL, path string) (string, []string) {
+	full := strings.TrimRight(strings.TrimSpace(baseURL), "/") + "/" + strings.TrimLeft(strings.TrimSpace(path), "/")
+	if i := strings.Index(full, "://"); i >= 0 {
+		// Absolute base URLs: keep the path only
+		rest := full[i+3:]
+		if j := strings.Index(rest, "/"); j >= 0 {
+			full = rest[j:]
+		} else {
+			full = "/"
+		}
+	}
+	if len(full) > 1 {
+		full = strings.TrimRight(full, "/")
+	}
+
+	var params []string
+	full = pathParamPattern.ReplaceAllStringFunc(full, func(m string) string {
+		sub := pathParamPattern.FindStringSubmatch(m)
+		name := sub[1]
+		if name == "" {
+			name = sub[2]
+		}
+		params = append(params, name)
+		return "{" + name + "}"
+	})
+	return full, params
+}
+
+// operationID returns a camelCase operation ID
+func operationID(op formatter.ContractOperation) string {
+	name := op.Name
+	if name == "" {
+		name = op.ID
+	}
+	words := nonWordPattern.Split(name, -1)
+	var sb strings.Builder
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+		if sb.Len() == 0 {
+			sb.WriteString(strings.ToLower(w[:1]) + w[1:])
+		} else {
+			sb.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		}
+	}
+	if sb.Len() == 0 {
+		return "operation"
+	}
+	return sb.String()
+}
+
+func sortedFieldNames(fields map[string]formatter.SchemaField) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
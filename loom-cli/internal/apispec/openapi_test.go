@@ -0,0 +1,246 @@
+package apispec
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+func testContracts() ([]formatter.InterfaceContract, []formatter.SharedType) {
+	contracts := []formatter.InterfaceContract{
+		{
+			ID:          "IC-ORD-001",
+			ServiceName: "OrderService",
+			Purpose:     "Manage orders",
+			BaseURL:     "/api/v1",
+			Operations: []formatter.ContractOperation{
+				{
+					ID:     "OP-ORD-001",
+					Name:   "placeOrder",
+					Method: "POST",
+					Path:   "/orders",
+					InputSchema: map[string]formatter.SchemaField{
+						"customerId": {Type: "UUID", Required: true},
+						"lines":      {Type: "List<OrderLine>", Required: true},
+						"note":       {Type: "string?"},
+					},
+					OutputSchema: map[string]formatter.SchemaField{
+						"orderId": {Type: "UUID", Required: true},
+					},
+					Errors: []formatter.ContractError{
+						{Code: "EMPTY_ORDER", HTTPStatus: 400, Message: "Order has no lines"},
+						{Code: "INVALID_QUANTITY", HTTPStatus: 400},
+						{Code: "CUSTOMER_NOT_FOUND", HTTPStatus: 404},
+					},
+					RelatedACs: []string{"AC-ORD-001"},
+					RelatedBRs: []string{"BR-ORD-001"},
+				},
+				{
+					ID:     "OP-ORD-002",
+					Name:   "getOrder",
+					Method: "GET",
+					Path:   "/orders/:orderId",
+					InputSchema: map[string]formatter.SchemaField{
+						"orderId": {Type: "UUID", Required: true},
+						"expand":  {Type: "boolean"},
+					},
+				},
+			},
+			SecurityRequirements: formatter.SecurityRequirements{
+				Authentication: "JWT bearer token",
+				Authorization:  "Customer owns the order",
+			},
+		},
+	}
+	shared := []formatter.SharedType{
+		{Name: "OrderLine", Fields: []formatter.TypeField{
+			{Name: "sku", Type: "string", Constraints: "required"},
+			{Name: "quantity", Type: "integer"},
+		}},
+	}
+	return contracts, shared
+}
+
+func TestBuildOpenAPI_Operations(t *testing.T) {
+	contracts, shared := testContracts()
+	doc := BuildOpenAPI(contracts, shared, Info{})
+
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != "API" || doc.Info.Version != "1.0.0" {
+		t.Errorf("unexpected header: %s %+v", doc.OpenAPI, doc.Info)
+	}
+	if doc.OperationCount() != 2 {
+		t.Fatalf("expected 2 operations, got %d", doc.OperationCount())
+	}
+
+	post := doc.Paths["/api/v1/orders"]["post"]
+	if post == nil {
+		t.Fatalf("missing POST /api/v1/orders: %v", doc.Paths)
+	}
+	if post.OperationID != "placeOrder" || post.LoomID != "OP-ORD-001" || post.LoomContract != "IC-ORD-001" {
+		t.Errorf("unexpected operation identity: %+v", post)
+	}
+	if !reflect.DeepEqual(post.LoomAcceptanceCriteria, []string{"AC-ORD-001"}) || !reflect.DeepEqual(post.LoomBusinessRules, []string{"BR-ORD-001"}) {
+		t.Errorf("missing traceability links: %v %v", post.LoomAcceptanceCriteria, post.LoomBusinessRules)
+	}
+
+	body := post.RequestBody.Content["application/json"].Schema
+	if !reflect.DeepEqual(body.Required, []string{"customerId", "lines"}) {
+		t.Errorf("unexpected required fields: %v", body.Required)
+	}
+	if got := body.Properties["lines"]; got.Type != "array" || got.Items.Ref != "#/components/schemas/OrderLine" {
+		t.Errorf("expected array of OrderLine refs, got %+v", got)
+	}
+	if got := body.Properties["note"]; !reflect.DeepEqual(got.Type, []string{"string", "null"}) {
+		t.Errorf("expected nullable string, got %+v", got.Type)
+	}
+
+	if _, ok := post.Responses["201"]; !ok {
+		t.Errorf("expected 201 response, got %v", post.Responses)
+	}
+	bad := post.Responses["400"]
+	if bad == nil || !reflect.DeepEqual(bad.LoomErrorCodes, []string{"EMPTY_ORDER", "INVALID_QUANTITY"}) {
+		t.Fatalf("expected 400 with two error codes, got %+v", bad)
+	}
+	codeEnum := bad.Content["application/json"].Schema.AllOf[1].Properties["code"].Enum
+	if !reflect.DeepEqual(codeEnum, []string{"EMPTY_ORDER", "INVALID_QUANTITY"}) {
+		t.Errorf("unexpected code enum: %v", codeEnum)
+	}
+	if _, ok := post.Responses["404"]; !ok {
+		t.Errorf("expected 404 response")
+	}
+
+	get := doc.Paths["/api/v1/orders/{orderId}"]["get"]
+	if get == nil {
+		t.Fatalf("missing GET /api/v1/orders/{orderId}: %v", doc.Paths)
+	}
+	if len(get.Parameters) != 2 || get.Parameters[0].In != "path" || get.Parameters[0].Schema.Format != "uuid" || get.Parameters[1].In != "query" {
+		t.Errorf("unexpected parameters: %+v %+v", get.Parameters[0], get.Parameters[1])
+	}
+	if get.RequestBody != nil {
+		t.Errorf("GET must not have a request body")
+	}
+	if _, ok := get.Responses["204"]; !ok {
+		t.Errorf("expected 204 when there is no output, got %v", get.Responses)
+	}
+}
+
+func TestBuildOpenAPI_ComponentsAndSecurity(t *testing.T) {
+	contracts, shared := testContracts()
+	doc := BuildOpenAPI(contracts, shared, Info{Title: "Orders", Version: "2.0.0"})
+
+	line := doc.Components.Schemas["OrderLine"]
+	if line == nil || !reflect.DeepEqual(line.Required, []string{"sku"}) {
+		t.Fatalf("unexpected OrderLine schema: %+v", line)
+	}
+	if _, ok := doc.Components.Schemas[ErrorSchemaName]; !ok {
+		t.Errorf("missing Error schema")
+	}
+
+	scheme := doc.Components.SecuritySchemes["bearerAuth"]
+	if scheme == nil || scheme.Scheme != "bearer" || scheme.BearerFormat != "JWT" || scheme.Description != "JWT bearer token" {
+		t.Fatalf("unexpected security scheme: %+v", scheme)
+	}
+	post := doc.Paths["/api/v1/orders"]["post"]
+	if post.Security == nil || len(*post.Security) != 1 {
+		t.Fatalf("operation does not require bearerAuth: %v", post.Security)
+	}
+	if _, ok := (*post.Security)[0]["bearerAuth"]; !ok {
+		t.Errorf("operation does not require bearerAuth: %v", *post.Security)
+	}
+
+	contracts[0].SecurityRequirements.Authentication = "None (public endpoint)"
+	public := BuildOpenAPI(contracts, shared, Info{})
+	if public.Components.SecuritySchemes != nil || !isPublic(public.Paths["/api/v1/orders"]["post"]) {
+		t.Errorf("public contract must have an empty security requirement")
+	}
+	data, _ := json.Marshal(public.Paths["/api/v1/orders"]["post"])
+	if !strings.Contains(string(data), `"security":[]`) {
+		t.Errorf("expected security: [] in %s", data)
+	}
+}
+
+func TestBuildOpenAPI_MixedSecurity(t *testing.T) {
+	contracts, shared := testContracts()
+	contracts[0].SecurityRequirements.Authentication = "Bearer JWT (except registration and verification)"
+	contracts[0].Operations[1].Description = "Public order lookup"
+
+	doc := BuildOpenAPI(contracts, shared, Info{})
+
+	post := doc.Paths["/api/v1/orders"]["post"]
+	if post.Security != nil || post.LoomSecurity != "Bearer JWT (except registration and verification)" {
+		t.Errorf("mixed authentication must leave the operation unsecured with a note, got %v %q", post.Security, post.LoomSecurity)
+	}
+	if get := doc.Paths["/api/v1/orders/{orderId}"]["get"]; !isPublic(get) {
+		t.Errorf("operation marked public must have an empty security requirement, got %v", get.Security)
+	}
+	if doc.Components.SecuritySchemes != nil {
+		t.Errorf("no scheme is required, got %v", doc.Components.SecuritySchemes)
+	}
+}
+
+func TestBuildOpenAPI_AlternativeSecurity(t *testing.T) {
+	contracts, shared := testContracts()
+	contracts[0].SecurityRequirements.Authentication = "Bearer JWT or session token for guest carts"
+
+	doc := BuildOpenAPI(contracts, shared, Info{})
+
+	post := doc.Paths["/api/v1/orders"]["post"]
+	if post.Security == nil || len(*post.Security) != 2 {
+		t.Fatalf("expected two alternative requirements, got %v", post.Security)
+	}
+	if _, ok := (*post.Security)[0]["bearerAuth"]; !ok {
+		t.Errorf("first alternative must be bearerAuth, got %v", *post.Security)
+	}
+	if _, ok := (*post.Security)[1]["sessionAuth"]; !ok {
+		t.Errorf("second alternative must be sessionAuth, got %v", *post.Security)
+	}
+
+	contracts[0].SecurityRequirements.Authentication = "Bearer JWT for write operations"
+	doc = BuildOpenAPI(contracts, shared, Info{})
+	if post := doc.Paths["/api/v1/orders"]["post"]; post.Security == nil || len(*post.Security) != 1 {
+		t.Errorf("authentication without an exemption must be required, got %v %q", post.Security, post.LoomSecurity)
+	}
+}
+
+func TestBuildOpenAPI_Deterministic(t *testing.T) {
+	contracts, shared := testContracts()
+	first, _ := json.Marshal(BuildOpenAPI(contracts, shared, Info{}))
+	for i := 0; i < 5; i++ {
+		next, _ := json.Marshal(BuildOpenAPI(contracts, shared, Info{}))
+		if string(next) != string(first) {
+			t.Fatalf("output differs between runs")
+		}
+	}
+}
+
+func TestSchemaMapper_Schema(t *testing.T) {
+	m := NewSchemaMapper("#/defs/", []string{"Money"})
+
+	tests := []struct {
+		in   string
+		want Schema
+	}{
+		{"string", Schema{Type: "string"}},
+		{"DateTime", Schema{Type: "string", Format: "date-time"}},
+		{"money", Schema{Ref: "#/defs/Money"}},
+		{"enum(a, b)", Schema{Type: "string", Enum: []string{"a", "b"}}},
+		{"OrderId", Schema{Type: "string", Description: "OrderId"}},
+		{"Widget", Schema{LoomType: "Widget"}},
+		{"Map<string, int>", Schema{Type: "object", AdditionalProperties: &Schema{Type: "integer", Format: "int32"}}},
+		{"string | undefined", Schema{Type: []string{"string", "null"}}},
+	}
+	for _, tt := range tests {
+		got := m.Schema(tt.in)
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("Schema(%q) = %+v, want %+v", tt.in, *got, tt.want)
+		}
+	}
+
+	ref := m.Schema("Money?")
+	if len(ref.OneOf) != 2 || ref.OneOf[0].Ref != "#/defs/Money" || ref.OneOf[1].Type != "null" {
+		t.Errorf("unexpected nullable ref: %+v", ref)
+	}
+}
@@ -0,0 +1,147 @@
+// Package apispec builds API description documents (OpenAPI, AsyncAPI,
+// JSON Schema) deterministically from the structured L2/L3 specification.
+package apispec
+
+import (
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/spec"
+)
+
+// JSONSchemaDialect is the JSON Schema version used by OpenAPI 3.1 and the
+// standalone schema files
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema (draft 2020-12) object
+type Schema struct {
+	SchemaURI   string             `json:"$schema,omitempty"`
+	ID          string             `json:"$id,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        interface{}        `json:"type,omitempty"` // string, or []string when nullable
+	Format      string             `json:"format,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	OneOf       []*Schema          `json:"oneOf,omitempty"`
+	AllOf       []*Schema          `json:"allOf,omitempty"`
+
+	// AdditionalProperties is the value schema of map types
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+
+	// Defs holds the named types of standalone schema files
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+
+	// LoomID is the spec ID a top-level schema was generated from
+	LoomID string `json:"x-loom-id,omitempty"`
+
+	// LoomType is the spec type a schema could not be mapped from
+	LoomType string `json:"x-loom-type,omitempty"`
+}
+
+// SchemaMapper maps spec types (as written in L2/L3 documents) to schemas.
+// Named types map to $ref under RefPrefix.
+type SchemaMapper struct {
+	// RefPrefix is prepended to named types, e.g. #/components/schemas/
+	RefPrefix string
+
+	named map[string]string // lower-case name -> schema name
+}
+
+// NewSchemaMapper creates a mapper resolving the given named types
+func NewSchemaMapper(refPrefix string, names []string) *SchemaMapper {
+	m := &SchemaMapper{RefPrefix: refPrefix, named: make(map[string]string, len(names))}
+	for _, n := range names {
+		m.named[strings.ToLower(n)] = n
+	}
+	return m
+}
+
+// Schema maps a spec type to a schema. Unknown types become an empty
+// (any) schema carrying the original type in x-loom-type.
+func (m *SchemaMapper) Schema(specType string) *Schema {
+	return m.schema(spec.ParseType(specType))
+}
+
+// schema maps a parsed spec type to a schema
+func (m *SchemaMapper) schema(t *spec.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	switch t.Kind {
+	case spec.TypeNullable:
+		return nullable(m.schema(t.Elem))
+	case spec.TypeUnion:
+		return &Schema{OneOf: []*Schema{m.schema(t.Alternatives[0]), m.schema(t.Alternatives[1])}}
+	case spec.TypeEnum:
+		return &Schema{Type: "string", Enum: t.Values}
+	case spec.TypeList:
+		return &Schema{Type: "array", Items: m.schema(t.Elem)}
+	case spec.TypeMap:
+		return &Schema{Type: "object", AdditionalProperties: m.schema(t.Elem)}
+	case spec.TypeGeneric:
+		return &Schema{LoomType: t.Raw}
+	}
+
+	base := t.Name
+	if name, ok := m.named[strings.ToLower(base)]; ok {
+		return &Schema{Ref: m.RefPrefix + name}
+	}
+
+	switch strings.ToLower(base) {
+	case "string", "text", "varchar", "char":
+		return &Schema{Type: "string"}
+	case "uuid", "guid":
+		return &Schema{Type: "string", Format: "uuid"}
+	case "email":
+		return &Schema{Type: "string", Format: "email"}
+	case "url", "uri":
+		return &Schema{Type: "string", Format: "uri"}
+	case "date":
+		return &Schema{Type: "string", Format: "date"}
+	case "datetime", "timestamp", "timestamptz", "instant":
+		return &Schema{Type: "string", Format: "date-time"}
+	case "time":
+		return &Schema{Type: "string", Format: "time"}
+	case "duration":
+		return &Schema{Type: "string", Format: "duration"}
+	case "int", "integer", "int32", "smallint":
+		return &Schema{Type: "integer", Format: "int32"}
+	case "long", "int64", "bigint":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "decimal", "number", "numeric", "money", "double", "float", "real":
+		return &Schema{Type: "number"}
+	case "bool", "boolean":
+		return &Schema{Type: "boolean"}
+	case "object", "json", "map":
+		return &Schema{Type: "object"}
+	case "bytes", "binary":
+		return &Schema{Type: "string", Format: "byte"}
+	}
+
+	// Identity types such as OrderId
+	if strings.HasSuffix(base, "Id") || strings.HasSuffix(base, "ID") {
+		return &Schema{Type: "string", Description: base}
+	}
+
+	return &Schema{LoomType: t.Raw}
+}
+
+// nullable allows null in addition to the schema
+func nullable(s *Schema) *Schema {
+	switch t := s.Type.(type) {
+	case string:
+		if len(s.Enum) == 0 {
+			s.Type = []string{t, "null"}
+			return s
+		}
+	case nil:
+		if s.LoomType != "" {
+			return s // any already includes null
+		}
+	}
+	return &Schema{OneOf: []*Schema{s, {Type: "null"}}}
+}
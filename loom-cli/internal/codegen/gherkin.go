@@ -0,0 +1,293 @@
+package codegen
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/domain"
+	"github.com/ikadar/loom-cli/internal/formatter"
+	"github.com/ikadar/loom-cli/internal/spec"
+)
+
+// UnassignedContext is the directory for features whose ACs match no
+// bounded context
+const UnassignedContext = "unassigned"
+
+// UnassignedStory is the feature for ACs without a source user story
+const UnassignedStory = "Unassigned"
+
+var (
+	fileSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+	tagPattern      = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+)
+
+// gherkinFeature collects the ACs of one user story within one context
+type gherkinFeature struct {
+	Context string
+	Story   string
+	ACs     []domain.AcceptanceCriteria
+}
+
+// GenerateGherkin renders acceptance criteria and their test cases as
+// Gherkin .feature files: one directory per bounded context, one Feature
+// per user story, one Scenario per AC. Negative and boundary test cases with
+// test data become Scenario Outlines with one tagged Examples table per test
+// case. It also returns the test cases whose AC is not in acs.
+func GenerateGherkin(acs []domain.AcceptanceCriteria, cases []formatter.TestCase, contexts []spec.BoundedContext) ([]GeneratedFile, []formatter.TestCase) {
+	casesByAC := make(map[string][]formatter.TestCase)
+	known := make(map[string]bool, len(acs))
+	for _, ac := range acs {
+		known[ac.ID] = true
+	}
+	var orphans []formatter.TestCase
+	for _, tc := range cases {
+		acID := ACForTestCase(tc)
+		if !known[acID] {
+			orphans = append(orphans, tc)
+			continue
+		}
+		casesByAC[acID] = append(casesByAC[acID], tc)
+	}
+
+	var features []*gherkinFeature
+	index := make(map[string]*gherkinFeature)
+	for _, ac := range acs {
+		ctx := UnassignedContext
+		if bc := spec.ContextForID(ac.ID, contexts); bc != nil {
+			ctx = fileSlug(strings.TrimPrefix(bc.ID, "BC-"))
+		}
+		story := UnassignedStory
+		if len(ac.SourceRefs) > 0 {
+			story = ac.SourceRefs[0]
+		}
+
+		key := ctx + "/" + story
+		f, ok := index[key]
+		if !ok {
+			f = &gherkinFeature{Context: ctx, Story: story}
+			index[key] = f
+			features = append(features, f)
+		}
+		f.ACs = append(f.ACs, ac)
+	}
+
+	var files []GeneratedFile
+	seenPaths := make(map[string]int)
+	for _, f := range features {
+		p := path.Join(f.Context, fileSlug(f.Story)+".feature")
+		seenPaths[p]++
+		if n := seenPaths[p]; n > 1 {
+			p = path.Join(f.Context, fmt.Sprintf("%s-%d.feature", fileSlug(f.Story), n))
+		}
+		files = append(files, GeneratedFile{
+			Path:    p,
+			Content: []byte(renderFeature(f, casesByAC)),
+		})
+	}
+
+	return files, orphans
+}
+
+// renderFeature renders one .feature file
+func renderFeature(f *gherkinFeature, casesByAC map[string][]formatter.TestCase) string {
+	var sb strings.Builder
+
+	sb.WriteString("# Generated by loom-cli gen-gherkin from acceptance-criteria.md and test-cases.md\n")
+	if tagPattern.MatchString(f.Story) {
+		sb.WriteString("@" + f.Story + "\n")
+	}
+	sb.WriteString("Feature: " + gherkinText(f.Story) + "\n")
+	sb.WriteString(fmt.Sprintf("  Acceptance criteria derived from %s.\n", gherkinText(f.Story)))
+
+	for _, ac := range f.ACs {
+		cases := casesByAC[ac.ID]
+
+		// The AC itself is the happy path; positive test cases tag it
+		tags := []string{ac.ID}
+		for _, tc := range cases {
+			if tc.Category == "positive" {
+				tags = append(tags, tc.ID)
+			}
+		}
+		if len(tags) > 1 {
+			tags = append(tags, "positive")
+		}
+		sb.WriteString("\n")
+		writeTags(&sb, "  ", tags)
+		sb.WriteString(fmt.Sprintf("  Scenario: %s – %s\n", ac.ID, gherkinText(ac.Title)))
+		for _, ec := range ac.ErrorCases {
+			sb.WriteString("    # Error case: " + gherkinText(ec) + "\n")
+		}
+		writeStep(&sb, "Given", ac.Given)
+		writeStep(&sb, "When", ac.When)
+		writeStep(&sb, "Then", ac.Then)
+
+		// Data-driven negative and boundary cases: one outline per category
+		for _, category := range []string{"negative", "boundary"} {
+			var outline []formatter.TestCase
+			for _, tc := range cases {
+				if tc.Category == category && len(tc.TestData) > 0 {
+					outline = append(outline, tc)
+				}
+			}
+			if len(outline) > 0 {
+				renderOutline(&sb, ac, category, outline)
+			}
+		}
+
+		// Everything else becomes a plain scenario per test case
+		for _, tc := range cases {
+			if tc.Category == "positive" || ((tc.Category == "negative" || tc.Category == "boundary") && len(tc.TestData) > 0) {
+				continue
+			}
+			renderTestCaseScenario(&sb, ac, tc)
+		}
+	}
+
+	return sb.String()
+}
+
+// renderOutline renders a Scenario Outline whose Examples come from the
+// test data of the given test cases, one tagged Examples table per case
+func renderOutline(sb *strings.Builder, ac domain.AcceptanceCriteria, category string, cases []formatter.TestCase) {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, tc := range cases {
+		for _, td := range tc.TestData {
+			if !seen[td.Field] {
+				seen[td.Field] = true
+				fields = append(fields, td.Field)
+			}
+		}
+	}
+
+	placeholders := make([]string, len(fields))
+	for i, field := range fields {
+		placeholders[i] = fmt.Sprintf("%s \"<%s>\"", field, field)
+	}
+
+	sb.WriteString("\n")
+	writeTags(sb, "  ", []string{ac.ID, category})
+	sb.WriteString(fmt.Sprintf("  Scenario Outline: %s – %s (%s cases)\n", ac.ID, gherkinText(ac.Title), category))
+	writeStep(sb, "Given", ac.Given)
+	when := strings.TrimSpace(ac.When)
+	if when == "" {
+		when = "the action is performed"
+	}
+	writeStep(sb, "When", when+" with "+strings.Join(placeholders, ", "))
+	writeStep(sb, "Then", "<expected>")
+
+	header := append(append([]string{}, fields...), "expected")
+	for _, tc := range cases {
+		values := make(map[string]string, len(tc.TestData))
+		for _, td := range tc.TestData {
+			values[td.Field] = fmt.Sprint(td.Value)
+		}
+		row := make([]string, 0, len(header))
+		for _, field := range fields {
+			row = append(row, values[field])
+		}
+		row = append(row, strings.Join(tc.ExpectedResults, "; "))
+
+		sb.WriteString("\n")
+		writeTags(sb, "    ", []string{tc.ID})
+		sb.WriteString("    Examples: " + gherkinText(tc.Name) + "\n")
+		writeTable(sb, "      ", header, [][]string{row})
+	}
+}
+
+// renderTestCaseScenario renders a test case as a plain scenario
+func renderTestCaseScenario(sb *strings.Builder, ac domain.AcceptanceCriteria, tc formatter.TestCase) {
+	sb.WriteString("\n")
+	tags := []string{ac.ID, tc.ID}
+	if tc.Category != "" {
+		tags = append(tags, tc.Category)
+	}
+	writeTags(sb, "  ", tags)
+	sb.WriteString(fmt.Sprintf("  Scenario: %s – %s\n", tc.ID, gherkinText(tc.Name)))
+
+	given := tc.Preconditions
+	if len(given) == 0 && ac.Given != "" {
+		given = []string{ac.Given}
+	}
+	when := tc.Steps
+	if len(when) == 0 && ac.When != "" {
+		when = []string{ac.When}
+	}
+	writeSteps(sb, "Given", given)
+	writeSteps(sb, "When", when)
+	writeSteps(sb, "Then", tc.ExpectedResults)
+	if tc.ShouldNot != "" {
+		writeStep(sb, "But", "it should not: "+tc.ShouldNot)
+	}
+}
+
+// writeSteps writes the first step with keyword and the rest with And
+func writeSteps(sb *strings.Builder, keyword string, steps []string) {
+	for i, s := range steps {
+		if i == 0 {
+			writeStep(sb, keyword, s)
+		} else {
+			writeStep(sb, "And", s)
+		}
+	}
+}
+
+func writeStep(sb *strings.Builder, keyword, text string) {
+	if text = gherkinText(text); text != "" {
+		sb.WriteString(fmt.Sprintf("    %s %s\n", keyword, text))
+	}
+}
+
+func writeTags(sb *strings.Builder, indent string, tags []string) {
+	parts := make([]string, 0, len(tags))
+	for _, t := range tags {
+		parts = append(parts, "@"+strings.Join(strings.Fields(t), "_"))
+	}
+	sb.WriteString(indent + strings.Join(parts, " ") + "\n")
+}
+
+// writeTable writes an aligned Gherkin table
+func writeTable(sb *strings.Builder, indent string, header []string, rows [][]string) {
+	all := append([][]string{header}, rows...)
+	widths := make([]int, len(header))
+	for _, row := range all {
+		for i, cell := range row {
+			row[i] = tableCell(cell)
+			if n := len([]rune(row[i])); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	for _, row := range all {
+		sb.WriteString(indent + "|")
+		for i, cell := range row {
+			sb.WriteString(" " + cell + strings.Repeat(" ", widths[i]-len([]rune(cell))) + " |")
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// tableCell escapes a value for a Gherkin table cell
+func tableCell(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return strings.TrimSpace(s)
+}
+
+// gherkinText keeps spec text on a single line
+func gherkinText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// fileSlug turns a name into a file or directory name
+func fileSlug(s string) string {
+	slug := strings.Trim(fileSlugPattern.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if slug == "" {
+		return "feature"
+	}
+	return slug
+}
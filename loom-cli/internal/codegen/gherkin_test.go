@@ -0,0 +1,70 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/domain"
+	"github.com/ikadar/loom-cli/internal/formatter"
+	"github.com/ikadar/loom-cli/internal/spec"
+)
+
+func TestGenerateGherkin(t *testing.T) {
+	acs := []domain.AcceptanceCriteria{
+		{ID: "AC-ORD-001", Title: "Place order", Given: "a cart with items", When: "the customer submits the order", Then: "the order is created", SourceRefs: []string{"US-001"}},
+		{ID: "AC-ORD-002", Title: "Cancel order", Given: "a placed order", When: "the customer cancels", Then: "the order is cancelled", SourceRefs: []string{"US-001"}},
+		{ID: "AC-PAY-001", Title: "Pay", Given: "an order", When: "paying", Then: "paid"},
+	}
+	contexts := []spec.BoundedContext{{ID: "BC-Ordering", Name: "Ordering", CoreEntities: []string{"Order"}}}
+	cases := []formatter.TestCase{
+		{ID: "TC-AC-ORD-001-P01", Category: "positive", ACRef: "AC-ORD-001"},
+		{ID: "TC-AC-ORD-001-N01", Name: "Zero quantity", Category: "negative", ACRef: "AC-ORD-001",
+			TestData:        []formatter.TestData{{Field: "quantity", Value: int64(0)}},
+			ExpectedResults: []string{"Error QTY_INVALID"}},
+		{ID: "TC-AC-ORD-001-N02", Name: "Bad | note", Category: "negative", ACRef: "AC-ORD-001",
+			TestData:        []formatter.TestData{{Field: "quantity", Value: int64(1)}, {Field: "note", Value: "a|b"}},
+			ExpectedResults: []string{"Error NOTE_INVALID"}},
+		{ID: "TC-AC-ORD-001-H01", Name: "No discount", Category: "hallucination", ACRef: "AC-ORD-001",
+			Steps: []string{"Submit order"}, ExpectedResults: []string{"Full price charged"}, ShouldNot: "apply a discount"},
+		{ID: "TC-AC-XYZ-001-P01", Category: "positive", ACRef: "AC-XYZ-001"},
+	}
+
+	files, orphans := GenerateGherkin(acs, cases, contexts)
+
+	if len(orphans) != 1 || orphans[0].ID != "TC-AC-XYZ-001-P01" {
+		t.Errorf("Expected one orphan test case, got %v", orphans)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 feature files, got %d", len(files))
+	}
+	if files[0].Path != "ordering/us-001.feature" {
+		t.Errorf("Expected ordering/us-001.feature, got %s", files[0].Path)
+	}
+	if files[1].Path != "unassigned/unassigned.feature" {
+		t.Errorf("Expected unassigned/unassigned.feature, got %s", files[1].Path)
+	}
+
+	feature := string(files[0].Content)
+	checks := []string{
+		"@US-001\nFeature: US-001\n",
+		"  @AC-ORD-001 @TC-AC-ORD-001-P01 @positive\n  Scenario: AC-ORD-001 – Place order\n",
+		"    Given a cart with items\n    When the customer submits the order\n    Then the order is created\n",
+		"  @AC-ORD-001 @negative\n  Scenario Outline: AC-ORD-001 – Place order (negative cases)\n",
+		`    When the customer submits the order with quantity "<quantity>", note "<note>"`,
+		"    Then <expected>\n",
+		"    @TC-AC-ORD-001-N01\n    Examples: Zero quantity\n",
+		"      | quantity | note | expected          |\n      | 0        |      | Error QTY_INVALID |\n",
+		`| 1        | a\|b | Error NOTE_INVALID |`,
+		"  @AC-ORD-001 @TC-AC-ORD-001-H01 @hallucination\n  Scenario: TC-AC-ORD-001-H01 – No discount\n",
+		"    Given a cart with items\n    When Submit order\n    Then Full price charged\n    But it should not: apply a discount\n",
+		"  Scenario: AC-ORD-002 – Cancel order\n",
+	}
+	for _, c := range checks {
+		if !strings.Contains(feature, c) {
+			t.Errorf("Expected feature to contain %q\n%s", c, feature)
+		}
+	}
+	if strings.Count(feature, "Feature:") != 1 {
+		t.Error("Expected exactly one Feature per file")
+	}
+}
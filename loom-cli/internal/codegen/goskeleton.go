@@ -0,0 +1,811 @@
+package codegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// GeneratedGoSuffix marks Go files that are rewritten on every run
+const GeneratedGoSuffix = "_gen.go"
+
+// GoSkeleton is one generated Go package: a file that is regenerated on
+// every run and a hand-written file that is only ever appended to
+type GoSkeleton struct {
+	// Dir is the package directory, relative to the output directory
+	Dir string
+
+	// Package is the Go package name
+	Package string
+
+	// Generated holds the declarations derived from the spec (DO NOT EDIT)
+	Generated GeneratedFile
+
+	// Stubs lists what the hand-written code must provide
+	Stubs StubFile
+}
+
+// StubFile is a hand-written file seeded by a generator. Existing content
+// is never changed; stubs for missing declarations are appended.
+type StubFile struct {
+	// Path is relative to the output directory
+	Path string
+
+	// Header is written when the file is created (package clause)
+	Header string
+
+	// Stubs are the declarations the generated code depends on
+	Stubs []Stub
+}
+
+// Stub is a declaration expected in hand-written code
+type Stub struct {
+	// Name is the declared identifier; methods are Type.Method
+	Name string
+
+	// Code is the Go source of the stub
+	Code string
+}
+
+// GenerateGoSkeletons renders aggregate designs and interface contracts as
+// Go packages. Each aggregate gets structs for its root and entities, event
+// structs, a repository interface and a constructor that runs one check per
+// invariant; the checks, behaviors and value objects are hand-written stubs.
+// Each interface contract gets a service interface, input/output structs and
+// typed errors for its error codes.
+func GenerateGoSkeletons(aggregates []formatter.AggregateDesign, contracts []formatter.InterfaceContract, sharedTypes []formatter.SharedType, source string) ([]GoSkeleton, error) {
+	var skeletons []GoSkeleton
+	seenDirs := make(map[string]bool)
+
+	for _, agg := range aggregates {
+		sk, err := aggregateSkeleton(agg, source)
+		if err != nil {
+			return nil, err
+		}
+		if seenDirs[sk.Dir] {
+			return nil, fmt.Errorf("aggregate %s: package %s generated twice", agg.Name, sk.Dir)
+		}
+		seenDirs[sk.Dir] = true
+		skeletons = append(skeletons, sk)
+	}
+
+	for _, ic := range contracts {
+		sk, err := serviceSkeleton(ic, sharedTypes, source)
+		if err != nil {
+			return nil, err
+		}
+		if seenDirs[sk.Dir] {
+			return nil, fmt.Errorf("contract %s: package %s generated twice", ic.ServiceName, sk.Dir)
+		}
+		seenDirs[sk.Dir] = true
+		skeletons = append(skeletons, sk)
+	}
+
+	return skeletons, nil
+}
+
+// goField is a struct field or function parameter
+type goField struct {
+	Name    string // Go name
+	Type    string // Go type
+	Spec    string // name in the spec
+	Comment string
+}
+
+// aggregateSkeleton renders one aggregate package
+func aggregateSkeleton(agg formatter.AggregateDesign, source string) (GoSkeleton, error) {
+	rootName := agg.Root.Entity
+	if rootName == "" {
+		rootName = agg.Name
+	}
+	pkg := GoPackageName(agg.Name)
+	root := exportedName(rootName)
+
+	m := newTypeMapper()
+	m.declare(rootName, root)
+	for _, e := range agg.Entities {
+		m.declare(e.Name, exportedName(e.Name))
+	}
+	valueObjects := make([]goField, 0, len(agg.ValueObjects))
+	for _, vo := range agg.ValueObjects {
+		name, desc := splitNameDesc(vo)
+		goName := exportedName(name)
+		if goName == root || m.known[strings.ToLower(name)] != "" {
+			continue
+		}
+		m.declare(name, goName)
+		valueObjects = append(valueObjects, goField{Name: goName, Spec: name, Comment: desc})
+	}
+	for _, ev := range agg.Events {
+		m.declare(ev.Name, exportedName(ev.Name))
+	}
+
+	rootFields := entityFields(m, agg.Root.Identity, agg.Root.Attributes)
+	recv := receiverName(root, rootFields)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("// Package %s implements the %s aggregate.\n", pkg, commentText(agg.Name)))
+	if agg.Purpose != "" {
+		sb.WriteString("//\n// " + commentText(agg.Purpose) + "\n")
+	}
+	sb.WriteString("package " + pkg + "\n\n")
+
+	var body strings.Builder
+
+	// Root and entities
+	body.WriteString(fmt.Sprintf("// %s is the root of the %s aggregate", root, commentText(agg.Name)))
+	if agg.ID != "" {
+		body.WriteString(" (" + agg.ID + ")")
+	}
+	body.WriteString("\n")
+	writeStruct(&body, root, rootFields, false)
+
+	for _, e := range agg.Entities {
+		name := exportedName(e.Name)
+		if name == root {
+			continue
+		}
+		body.WriteString(fmt.Sprintf("\n// %s is an entity of the %s aggregate", name, commentText(agg.Name)))
+		if e.Purpose != "" {
+			body.WriteString(": " + commentText(e.Purpose))
+		}
+		body.WriteString("\n")
+		writeStruct(&body, name, entityFields(m, e.Identity, e.Attributes), false)
+	}
+
+	// Constructor: assigns fields, then runs the hand-written invariant checks
+	checks := invariantChecks(agg.Invariants)
+	body.WriteString(fmt.Sprintf("\n// New%s creates and validates the %s root", root, root))
+	if len(checks) > 0 {
+		body.WriteString(":\n")
+		for _, c := range checks {
+			body.WriteString("//   - " + commentText(c.Spec) + "\n")
+		}
+	} else {
+		body.WriteString("\n")
+	}
+	local := recv
+	params := make([]string, 0, len(rootFields))
+	assigns := make([]string, 0, len(rootFields))
+	for _, f := range rootFields {
+		p := unexportedName(f.Spec)
+		if p == local {
+			local = "agg"
+		}
+		params = append(params, p+" "+f.Type)
+		assigns = append(assigns, fmt.Sprintf("%s: %s,", f.Name, p))
+	}
+	body.WriteString(fmt.Sprintf("func New%s(%s) (*%s, error) {\n", root, strings.Join(params, ", "), root))
+	body.WriteString(fmt.Sprintf("%s := &%s{\n%s\n}\n", local, root, strings.Join(assigns, "\n")))
+	for _, c := range checks {
+		body.WriteString(fmt.Sprintf("if err := %s.%s(); err != nil {\nreturn nil, err\n}\n", local, c.Name))
+	}
+	body.WriteString("return " + local + ", nil\n}\n")
+
+	// Events
+	attrTypes := make(map[string]string, len(rootFields))
+	for _, f := range rootFields {
+		attrTypes[strings.ToLower(f.Spec)] = f.Type
+	}
+	for _, ev := range agg.Events {
+		name := exportedName(ev.Name)
+		var fields []goField
+		seen := make(map[string]bool)
+		for _, p := range ev.Payload {
+			pname, ptype := splitParam(p)
+			goName := exportedName(pname)
+			if seen[goName] {
+				continue
+			}
+			seen[goName] = true
+			t := attrTypes[strings.ToLower(pname)]
+			if ptype != "" {
+				t = m.goType(ptype)
+			}
+			if t == "" {
+				t = "any"
+			}
+			fields = append(fields, goField{Name: goName, Type: t, Spec: pname})
+		}
+		body.WriteString(fmt.Sprintf("\n// %s is a domain event emitted by the %s aggregate\n", name, commentText(agg.Name)))
+		writeStruct(&body, name, fields, true)
+	}
+
+	// Repository
+	repoName := root + "Repository"
+	if agg.Repository.Name != "" {
+		repoName = exportedName(agg.Repository.Name)
+	}
+	body.WriteString(fmt.Sprintf("\n// %s persists %s aggregates", repoName, root))
+	if agg.Repository.Concurrency != "" {
+		body.WriteString(".\n// Concurrency: " + commentText(agg.Repository.Concurrency))
+	}
+	body.WriteString("\n")
+	body.WriteString("type " + repoName + " interface {\n")
+	seenMethods := make(map[string]bool)
+	for _, rm := range agg.Repository.Methods {
+		name := exportedName(rm.Name)
+		if seenMethods[name] {
+			continue
+		}
+		seenMethods[name] = true
+		args := []string{"ctx context.Context"}
+		for _, p := range splitParams(rm.Params) {
+			pname, ptype := splitParam(p)
+			if ptype == "" {
+				// A bare type ("Order") or a bare name ("orderId")
+				if t := m.goType(pname); t != "any" {
+					ptype = pname
+				}
+			}
+			t := m.goType(ptype)
+			if t == root {
+				t = "*" + root
+			}
+			args = append(args, unexportedName(pname)+" "+t)
+		}
+		ret := "error"
+		if t := m.goType(rm.Returns); t != "" && !strings.EqualFold(strings.TrimSpace(rm.Returns), "void") {
+			if t == root {
+				t = "*" + root
+			}
+			ret = "(" + t + ", error)"
+		}
+		body.WriteString(fmt.Sprintf("%s(%s) %s\n", name, strings.Join(dedupeParams(args), ", "), ret))
+	}
+	body.WriteString("}\n")
+
+	writeImports(&sb, m.imports, len(agg.Repository.Methods) > 0, false)
+	sb.WriteString(body.String())
+
+	generated, err := formatGo(pkg, sb.String(), source)
+	if err != nil {
+		return GoSkeleton{}, err
+	}
+
+	// Hand-written part
+	var stubs []Stub
+	for _, vo := range valueObjects {
+		var code strings.Builder
+		code.WriteString(fmt.Sprintf("// %s is a value object of the %s aggregate", vo.Name, commentText(agg.Name)))
+		if vo.Comment != "" {
+			code.WriteString(": " + commentText(vo.Comment))
+		}
+		code.WriteString(fmt.Sprintf("\ntype %s struct {\n\t// TODO: fields\n}\n", vo.Name))
+		stubs = append(stubs, Stub{Name: vo.Name, Code: code.String()})
+	}
+	for _, c := range checks {
+		code := fmt.Sprintf("// %s enforces %s\nfunc (%s *%s) %s() error {\n\t// TODO: return an error when the invariant does not hold\n\treturn nil\n}\n",
+			c.Name, commentText(c.Spec), recv, root, c.Name)
+		stubs = append(stubs, Stub{Name: root + "." + c.Name, Code: code})
+	}
+	seenBehaviors := make(map[string]bool)
+	fieldNames := make(map[string]bool, len(rootFields))
+	for _, f := range rootFields {
+		fieldNames[f.Name] = true
+	}
+	for _, b := range agg.Behaviors {
+		name := exportedName(b.Name)
+		if fieldNames[name] || seenBehaviors[name] {
+			continue
+		}
+		seenBehaviors[name] = true
+		stubs = append(stubs, Stub{Name: root + "." + name, Code: behaviorStub(recv, root, name, b)})
+	}
+
+	return GoSkeleton{
+		Dir:       pkg,
+		Package:   pkg,
+		Generated: GeneratedFile{Path: path.Join(pkg, pkg+GeneratedGoSuffix), Content: generated},
+		Stubs: StubFile{
+			Path:   path.Join(pkg, pkg+".go"),
+			Header: "package " + pkg + "\n\n// Hand-written part of the " + commentText(agg.Name) + " aggregate. gen-code never changes\n// existing code here; it only appends stubs for missing declarations.\n",
+			Stubs:  stubs,
+		},
+	}, nil
+}
+
+// behaviorStub renders a behavior method with its conditions as TODOs
+func behaviorStub(recv, root, name string, b formatter.AggBehavior) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("// %s handles %s", name, commentText(b.Name)))
+	if b.Command != "" {
+		sb.WriteString(".\n// Command: " + commentText(b.Command))
+	}
+	if b.Emits != "" {
+		sb.WriteString("\n// Emits: " + commentText(b.Emits))
+	}
+	sb.WriteString(fmt.Sprintf("\nfunc (%s *%s) %s() error {\n", recv, root, name))
+	for _, p := range b.Preconditions {
+		sb.WriteString("\t// TODO: precondition: " + commentText(p) + "\n")
+	}
+	for _, p := range b.Postconditions {
+		sb.WriteString("\t// TODO: postcondition: " + commentText(p) + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("\tpanic(%q)\n}\n", "TODO: implement "+root+"."+name))
+	return sb.String()
+}
+
+// serviceSkeleton renders one interface contract as a service package
+func serviceSkeleton(ic formatter.InterfaceContract, sharedTypes []formatter.SharedType, source string) (GoSkeleton, error) {
+	pkg := GoPackageName(ic.ServiceName)
+	service := exportedName(ic.ServiceName)
+	if !strings.HasSuffix(service, "Service") {
+		service += "Service"
+	}
+
+	m := newTypeMapper()
+	shared := make(map[string]formatter.SharedType)
+	for _, st := range sharedTypes {
+		m.declare(st.Name, exportedName(st.Name))
+		shared[exportedName(st.Name)] = st
+	}
+
+	var body strings.Builder
+
+	// Service interface and its messages
+	body.WriteString(fmt.Sprintf("// %s is the %s contract", service, commentText(ic.ServiceName)))
+	if ic.ID != "" {
+		body.WriteString(" (" + ic.ID + ")")
+	}
+	body.WriteString("\n")
+	if ic.Purpose != "" {
+		body.WriteString("//\n// " + commentText(ic.Purpose) + "\n")
+	}
+	body.WriteString("type " + service + " interface {\n")
+	var messages strings.Builder
+	seenOps := make(map[string]bool)
+	for _, op := range ic.Operations {
+		name := exportedName(op.Name)
+		if seenOps[name] {
+			continue
+		}
+		seenOps[name] = true
+
+		body.WriteString("// " + name)
+		if op.Method != "" || op.Path != "" {
+			body.WriteString(" handles " + strings.TrimSpace(op.Method+" "+ic.BaseURL+op.Path))
+		}
+		if op.ID != "" {
+			body.WriteString(" (" + op.ID + ")")
+		}
+		body.WriteString("\n")
+		if op.Description != "" {
+			body.WriteString("// " + commentText(op.Description) + "\n")
+		}
+		if len(op.Errors) > 0 {
+			codes := make([]string, len(op.Errors))
+			for i, e := range op.Errors {
+				codes[i] = "Err" + exportedName(e.Code)
+			}
+			body.WriteString("// Errors: " + strings.Join(codes, ", ") + "\n")
+		}
+		if len(op.RelatedACs) > 0 {
+			body.WriteString("// Acceptance criteria: " + strings.Join(op.RelatedACs, ", ") + "\n")
+		}
+		body.WriteString(fmt.Sprintf("%s(ctx context.Context, in *%sInput) (*%sOutput, error)\n", name, name, name))
+
+		messages.WriteString(fmt.Sprintf("\n// %sInput is the request of %s\n", name, name))
+		writeStruct(&messages, name+"Input", schemaFields(m, op.InputSchema), true)
+		messages.WriteString(fmt.Sprintf("\n// %sOutput is the response of %s\n", name, name))
+		writeStruct(&messages, name+"Output", schemaFields(m, op.OutputSchema), true)
+	}
+	body.WriteString("}\n")
+	body.WriteString(messages.String())
+
+	// Shared types referenced by the messages, including nested references
+	emitted := make(map[string]bool)
+	for {
+		var next string
+		for _, st := range sharedTypes {
+			goName := exportedName(st.Name)
+			if !emitted[goName] && m.used[goName] {
+				next = goName
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+		emitted[next] = true
+		st := shared[next]
+		var fields []goField
+		for _, f := range st.Fields {
+			fields = append(fields, goField{Name: exportedName(f.Name), Type: nonEmptyType(m.goType(f.Type)), Spec: f.Name, Comment: f.Constraints})
+		}
+		body.WriteString(fmt.Sprintf("\n// %s is a type shared between contracts\n", next))
+		writeStruct(&body, next, fields, true)
+	}
+
+	// Typed errors, one per error code
+	var codes []formatter.ContractError
+	seenCodes := make(map[string]bool)
+	for _, op := range ic.Operations {
+		for _, e := range op.Errors {
+			if e.Code == "" || seenCodes[e.Code] {
+				continue
+			}
+			seenCodes[e.Code] = true
+			codes = append(codes, e)
+		}
+	}
+	body.WriteString(contractErrorType)
+	if len(codes) > 0 {
+		body.WriteString("\n// Errors defined by the contract\nvar (\n")
+		for _, e := range codes {
+			name := "Err" + exportedName(e.Code)
+			body.WriteString(fmt.Sprintf("%s = &ContractError{Code: %q, HTTPStatus: %d, Message: %q}\n", name, e.Code, e.HTTPStatus, commentText(e.Message)))
+		}
+		body.WriteString(")\n")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("// Package %s defines the %s interface contract.\n", pkg, commentText(ic.ServiceName)))
+	sb.WriteString("package " + pkg + "\n\n")
+	writeImports(&sb, m.imports, true, true)
+	sb.WriteString(body.String())
+
+	generated, err := formatGo(pkg, sb.String(), source)
+	if err != nil {
+		return GoSkeleton{}, err
+	}
+
+	return GoSkeleton{
+		Dir:       pkg,
+		Package:   pkg,
+		Generated: GeneratedFile{Path: path.Join(pkg, pkg+GeneratedGoSuffix), Content: generated},
+	}, nil
+}
+
+const contractErrorType = `
+// ContractError is an error defined by the interface contract
+type ContractError struct {
+	Code       string
+	HTTPStatus int
+	Message    string
+}
+
+func (e *ContractError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Is matches contract errors by code, so errors.Is works on copies
+func (e *ContractError) Is(target error) bool {
+	t, ok := target.(*ContractError)
+	return ok && t.Code == e.Code
+}
+`
+
+// invariantCheck is the hand-written method enforcing one invariant
+type invariantCheck struct {
+	Name string // method name
+	Spec string // "INV-ORD-001: rule"
+}
+
+func invariantChecks(invariants []formatter.AggInvariant) []invariantCheck {
+	var checks []invariantCheck
+	seen := make(map[string]bool)
+	for i, inv := range invariants {
+		id := inv.ID
+		if id == "" {
+			id = fmt.Sprintf("Invariant%d", i+1)
+		}
+		name := "check" + exportedName(id)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		spec := id
+		if inv.Rule != "" {
+			spec += ": " + inv.Rule
+		}
+		checks = append(checks, invariantCheck{Name: name, Spec: spec})
+	}
+	return checks
+}
+
+// entityFields builds the ID field and attribute fields of an entity
+func entityFields(m *typeMapper, identity string, attrs []formatter.AggAttribute) []goField {
+	idName, idType := splitParam(identity)
+	if idType == "" && idName != "" && unicode.IsUpper([]rune(idName)[0]) {
+		// Identity given as a type ("OrderId") rather than a name ("orderId")
+		idName, idType = "id", idName
+	}
+	if idName == "" {
+		idName = "id"
+	}
+	t := m.goType(idType)
+	if idType == "" || t == "" || t == "any" {
+		t = "string"
+	}
+	fields := []goField{{Name: "ID", Type: t, Spec: idName}}
+
+	seen := map[string]bool{"ID": true}
+	skip := strings.ToLower(idName)
+	for _, a := range attrs {
+		name := exportedName(a.Name)
+		if seen[name] || strings.ToLower(a.Name) == skip {
+			continue
+		}
+		seen[name] = true
+		f := goField{Name: name, Type: nonEmptyType(m.goType(a.Type)), Spec: a.Name}
+		if !a.Mutable {
+			f.Comment = "immutable"
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// schemaFields builds struct fields from a contract schema, sorted by name
+func schemaFields(m *typeMapper, schema map[string]formatter.SchemaField) []goField {
+	keys := make([]string, 0, len(schema))
+	for k := range schema {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var fields []goField
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		name := exportedName(k)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		f := goField{Name: name, Type: nonEmptyType(m.goType(schema[k].Type)), Spec: k}
+		if schema[k].Required {
+			f.Comment = "required"
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// writeStruct writes a struct type, optionally with json tags
+func writeStruct(sb *strings.Builder, name string, fields []goField, jsonTags bool) {
+	sb.WriteString("type " + name + " struct {\n")
+	for _, f := range fields {
+		sb.WriteString(f.Name + " " + f.Type)
+		if jsonTags {
+			tag := f.Spec
+			if f.Comment != "required" {
+				tag += ",omitempty"
+			}
+			sb.WriteString(fmt.Sprintf(" `json:%q`", tag))
+		}
+		if f.Comment != "" {
+			sb.WriteString(" // " + commentText(f.Comment))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("}\n")
+}
+
+func writeImports(sb *strings.Builder, imports map[string]bool, needContext, needErrors bool) {
+	var list []string
+	if needContext {
+		list = append(list, "context")
+	}
+	for imp := range imports {
+		list = append(list, imp)
+	}
+	sort.Strings(list)
+	if len(list) == 0 {
+		return
+	}
+	sb.WriteString("import (\n")
+	for _, imp := range list {
+		sb.WriteString(fmt.Sprintf("%q\n", imp))
+	}
+	sb.WriteString(")\n\n")
+}
+
+// formatGo adds the generated-code header and gofmts the source
+func formatGo(pkg, src, source string) ([]byte, error) {
+	if source == "" {
+		source = "the L2 spec"
+	}
+	header := fmt.Sprintf("// Code generated by loom-cli gen-code from %s. DO NOT EDIT.\n\n", source)
+	out, err := format.Source([]byte(header + src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated package %s: %w", pkg, err)
+	}
+	return out, nil
+}
+
+// receiverName picks a short receiver name that no field parameter shadows
+func receiverName(typeName string, fields []goField) string {
+	recv := strings.ToLower(typeName[:1])
+	for _, f := range fields {
+		if unexportedName(f.Spec) == recv {
+			return "self"
+		}
+	}
+	if goKeywords[recv] {
+		return "self"
+	}
+	return recv
+}
+
+// splitParams splits a parameter list at top-level commas
+func splitParams(s string) []string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "("), ")")
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '<', '(', '[', '{':
+			depth++
+		case '>', ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, s[start:])
+
+	var out []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" && !strings.EqualFold(p, "none") && !strings.EqualFold(p, "void") {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// splitParam splits "name: Type" into name and type; without a colon the
+// whole string is returned as the name
+func splitParam(s string) (string, string) {
+	if i := strings.Index(s, ":"); i >= 0 {
+		return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+	}
+	return strings.TrimSpace(s), ""
+}
+
+// splitNameDesc splits "Money (amount, currency)" or "Money - desc" into
+// the type name and its description
+func splitNameDesc(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	for _, sep := range []string{" (", " - ", " – ", ": "} {
+		if i := strings.Index(s, sep); i > 0 {
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i:])
+		}
+	}
+	return s, ""
+}
+
+// dedupeParams renames repeated parameter names
+func dedupeParams(params []string) []string {
+	seen := make(map[string]int)
+	out := make([]string, len(params))
+	for i, p := range params {
+		name, rest, _ := strings.Cut(p, " ")
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s%d", name, n)
+		}
+		out[i] = name + " " + rest
+	}
+	return out
+}
+
+func nonEmptyType(t string) string {
+	if t == "" {
+		return "any"
+	}
+	return t
+}
+
+// MergeStubs appends the stubs whose names are not yet declared to the
+// existing content of a hand-written file, creating it from the header when
+// empty. Existing content is never modified. It returns the new content and
+// the names of the stubs added.
+func MergeStubs(existing []byte, declared map[string]bool, sf StubFile) ([]byte, []string) {
+	var missing []Stub
+	for _, s := range sf.Stubs {
+		if !declared[s.Name] {
+			missing = append(missing, s)
+		}
+	}
+	if len(missing) == 0 && len(existing) > 0 {
+		return existing, nil
+	}
+
+	var sb strings.Builder
+	if len(existing) == 0 {
+		sb.WriteString(sf.Header)
+	} else {
+		sb.Write(existing)
+		if !strings.HasSuffix(string(existing), "\n") {
+			sb.WriteString("\n")
+		}
+	}
+
+	added := make([]string, 0, len(missing))
+	for _, s := range missing {
+		sb.WriteString("\n" + s.Code)
+		added = append(added, s.Name)
+	}
+
+	return []byte(sb.String()), added
+}
+
+// DeclaredIdentifiers lists the top-level declarations of the hand-written
+// Go files in dir (generated and test files are ignored). Methods are
+// reported as Type.Method. A missing directory declares nothing.
+func DeclaredIdentifiers(dir string) (map[string]bool, error) {
+	declared := make(map[string]bool)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return declared, nil
+		}
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	fset := token.NewFileSet()
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, GeneratedGoSuffix) || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv != nil && len(d.Recv.List) > 0 {
+					declared[ReceiverType(d.Recv.List[0].Type)+"."+d.Name.Name] = true
+				} else {
+					declared[d.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						declared[s.Name.Name] = true
+					case *ast.ValueSpec:
+						for _, n := range s.Names {
+							declared[n.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return declared, nil
+}
+
+// ReceiverType returns the type name of a method receiver
+func ReceiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return ReceiverType(t.X)
+	case *ast.IndexExpr:
+		return ReceiverType(t.X)
+	case *ast.IndexListExpr:
+		return ReceiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
@@ -0,0 +1,249 @@
+package codegen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+func testAggregate() formatter.AggregateDesign {
+	return formatter.AggregateDesign{
+		ID:   "AGG-ORD-001",
+		Name: "Order",
+		Invariants: []formatter.AggInvariant{
+			{ID: "INV-ORD-001", Rule: "Order must have at least one line"},
+		},
+		Root: formatter.AggRoot{
+			Entity:   "Order",
+			Identity: "orderId: OrderId",
+			Attributes: []formatter.AggAttribute{
+				{Name: "orderId", Type: "OrderId"},
+				{Name: "lines", Type: "List<OrderLine>", Mutable: true},
+				{Name: "total", Type: "Money"},
+				{Name: "placedAt", Type: "DateTime"},
+			},
+		},
+		Entities:     []formatter.AggEntity{{Name: "OrderLine", Identity: "lineId", Attributes: []formatter.AggAttribute{{Name: "quantity", Type: "integer"}}}},
+		ValueObjects: []string{"Money (amount, currency)"},
+		Behaviors:    []formatter.AggBehavior{{Name: "cancel", Preconditions: []string{"status is PLACED"}}},
+		Repository: formatter.AggRepository{Methods: []formatter.RepoMethod{
+			{Name: "findById", Params: "orderId: OrderId", Returns: "Order | null"},
+			{Name: "save", Params: "Order", Returns: "void"},
+		}},
+	}
+}
+
+func TestGenerateGoSkeletons_Aggregate(t *testing.T) {
+	skeletons, err := GenerateGoSkeletons([]formatter.AggregateDesign{testAggregate()}, nil, nil, "l2-output.json")
+	if err != nil {
+		t.Fatalf("GenerateGoSkeletons failed: %v", err)
+	}
+	if len(skeletons) != 1 {
+		t.Fatalf("Expected 1 package, got %d", len(skeletons))
+	}
+
+	sk := skeletons[0]
+	if sk.Generated.Path != "order/order_gen.go" || sk.Stubs.Path != "order/order.go" {
+		t.Errorf("Unexpected paths %s, %s", sk.Generated.Path, sk.Stubs.Path)
+	}
+
+	gen := string(sk.Generated.Content)
+	checks := []string{
+		"// Code generated by loom-cli gen-code from l2-output.json. DO NOT EDIT.",
+		"package order",
+		"\tID       string\n\tLines    []OrderLine\n\tTotal    Money     // immutable\n\tPlacedAt time.Time // immutable\n",
+		"func NewOrder(orderID string, lines []OrderLine, total Money, placedAt time.Time) (*Order, error) {",
+		"\tif err := o.checkInvOrd001(); err != nil {",
+		"type OrderRepository interface {",
+		"\tFindByID(ctx context.Context, orderID string) (*Order, error)",
+		"\tSave(ctx context.Context, order *Order) error",
+	}
+	for _, c := range checks {
+		if !strings.Contains(gen, c) {
+			t.Errorf("Expected generated code to contain %q\n%s", c, gen)
+		}
+	}
+
+	var names []string
+	for _, s := range sk.Stubs.Stubs {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "Money,Order.checkInvOrd001,Order.Cancel" {
+		t.Errorf("Unexpected stubs %v", names)
+	}
+}
+
+func TestGenerateGoSkeletons_Contract(t *testing.T) {
+	ic := formatter.InterfaceContract{
+		ID:          "IC-ORD-001",
+		ServiceName: "OrderService",
+		Operations: []formatter.ContractOperation{{
+			Name:         "placeOrder",
+			Method:       "POST",
+			Path:         "/orders",
+			InputSchema:  map[string]formatter.SchemaField{"items": {Type: "OrderItem[]", Required: true}},
+			OutputSchema: map[string]formatter.SchemaField{"orderId": {Type: "string"}},
+			Errors: []formatter.ContractError{
+				{Code: "EMPTY_CART", HTTPStatus: 400, Message: "Cart is empty"},
+			},
+		}},
+	}
+	shared := []formatter.SharedType{
+		{Name: "OrderItem", Fields: []formatter.TypeField{{Name: "price", Type: "Money"}}},
+		{Name: "Money", Fields: []formatter.TypeField{{Name: "amount", Type: "decimal"}}},
+		{Name: "Unused"},
+	}
+
+	skeletons, err := GenerateGoSkeletons(nil, []formatter.InterfaceContract{ic}, shared, "")
+	if err != nil {
+		t.Fatalf("GenerateGoSkeletons failed: %v", err)
+	}
+
+	sk := skeletons[0]
+	if len(sk.Stubs.Stubs) != 0 {
+		t.Errorf("Expected no stubs for a contract, got %d", len(sk.Stubs.Stubs))
+	}
+
+	gen := string(sk.Generated.Content)
+	checks := []string{
+		"package orderservice",
+		"\tPlaceOrder(ctx context.Context, in *PlaceOrderInput) (*PlaceOrderOutput, error)",
+		"\tItems []OrderItem `json:\"items\"` // required",
+		"\tOrderID string `json:\"orderId,omitempty\"`",
+		"type OrderItem struct {",
+		"type Money struct {",
+		`ErrEmptyCart = &ContractError{Code: "EMPTY_CART", HTTPStatus: 400, Message: "Cart is empty"}`,
+	}
+	for _, c := range checks {
+		if !strings.Contains(gen, c) {
+			t.Errorf("Expected generated code to contain %q\n%s", c, gen)
+		}
+	}
+	if strings.Contains(gen, "Unused") {
+		t.Error("Expected unreferenced shared types to be left out")
+	}
+}
+
+func TestMergeStubs(t *testing.T) {
+	sf := StubFile{
+		Path:   "order/order.go",
+		Header: "package order\n",
+		Stubs: []Stub{
+			{Name: "Money", Code: "type Money struct{}\n"},
+			{Name: "Order.Cancel", Code: "func (o *Order) Cancel() error { return nil }\n"},
+		},
+	}
+
+	created, added := MergeStubs(nil, map[string]bool{}, sf)
+	if len(added) != 2 || !strings.HasPrefix(string(created), "package order\n") {
+		t.Errorf("Expected new file with 2 stubs, got %v\n%s", added, created)
+	}
+
+	existing := []byte("package order\n\ntype Money struct{ Amount int64 }\n")
+	merged, added := MergeStubs(existing, map[string]bool{"Money": true}, sf)
+	if len(added) != 1 || added[0] != "Order.Cancel" {
+		t.Errorf("Expected only Order.Cancel to be added, got %v", added)
+	}
+	if !strings.HasPrefix(string(merged), string(existing)) {
+		t.Errorf("Expected existing content to be kept verbatim\n%s", merged)
+	}
+
+	unchanged, added := MergeStubs(existing, map[string]bool{"Money": true, "Order.Cancel": true}, sf)
+	if len(added) != 0 || string(unchanged) != string(existing) {
+		t.Error("Expected no changes when every stub is declared")
+	}
+}
+
+func TestDeclaredIdentifiers(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"order.go":      "package order\n\ntype Money struct{}\n\nvar ErrX = 1\n\nfunc (o *Order) Cancel() error { return nil }\n",
+		"order_gen.go":  "package order\n\ntype Order struct{}\n",
+		"order_test.go": "package order\n\nfunc helper() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	declared, err := DeclaredIdentifiers(dir)
+	if err != nil {
+		t.Fatalf("DeclaredIdentifiers failed: %v", err)
+	}
+	for _, name := range []string{"Money", "ErrX", "Order.Cancel"} {
+		if !declared[name] {
+			t.Errorf("Expected %s to be declared", name)
+		}
+	}
+	if declared["Order"] || declared["helper"] {
+		t.Errorf("Expected generated and test files to be ignored, got %v", declared)
+	}
+
+	missing, err := DeclaredIdentifiers(filepath.Join(dir, "missing"))
+	if err != nil || len(missing) != 0 {
+		t.Errorf("Expected nothing declared in a missing directory, got %v, %v", missing, err)
+	}
+}
+
+func TestGenerateGoSkeletons_StubsParse(t *testing.T) {
+	skeletons, err := GenerateGoSkeletons([]formatter.AggregateDesign{testAggregate()}, nil, nil, "")
+	if err != nil {
+		t.Fatalf("GenerateGoSkeletons failed: %v", err)
+	}
+	stubs, _ := MergeStubs(nil, nil, skeletons[0].Stubs)
+
+	fset := token.NewFileSet()
+	if _, err := parser.ParseFile(fset, "order.go", stubs, 0); err != nil {
+		t.Errorf("Expected stub file to parse: %v\n%s", err, stubs)
+	}
+}
+
+func TestTypeMapper(t *testing.T) {
+	m := newTypeMapper()
+	m.declare("Money", "Money")
+
+	tests := map[string]string{
+		"string":           "string",
+		"UUID":             "string",
+		"OrderId":          "string",
+		"integer":          "int",
+		"decimal(10,2)":    "float64",
+		"boolean":          "bool",
+		"DateTime":         "time.Time",
+		"Money":            "Money",
+		"Money?":           "*Money",
+		"Money | null":     "*Money",
+		"List<Money>":      "[]Money",
+		"string[]":         "[]string",
+		"Map<string, int>": "map[string]int",
+		"Whatever":         "any",
+		"void":             "",
+	}
+	for spec, want := range tests {
+		if got := m.goType(spec); got != want {
+			t.Errorf("goType(%q) = %q, want %q", spec, got, want)
+		}
+	}
+	if !m.imports["time"] {
+		t.Error("Expected time import to be recorded")
+	}
+
+	names := map[string]string{"orderId": "OrderID", "created_at": "CreatedAt", "sku": "SKU", "2fa": "X2fa"}
+	for in, want := range names {
+		if got := exportedName(in); got != want {
+			t.Errorf("exportedName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := unexportedName("OrderId"); got != "orderID" {
+		t.Errorf("unexportedName(OrderId) = %q", got)
+	}
+	if got := unexportedName("type"); got != "type_" {
+		t.Errorf("unexportedName(type) = %q", got)
+	}
+}
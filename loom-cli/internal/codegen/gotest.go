@@ -0,0 +1,292 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// Test grouping modes for GenerateGoTests
+const (
+	GroupByAC        = "ac"        // one file per acceptance criterion
+	GroupByAggregate = "aggregate" // one file per aggregate (AC-<AGG>-NNN)
+)
+
+// UnmappedGroup collects test cases without an AC reference
+const UnmappedGroup = "UNMAPPED"
+
+// GeneratedFile is a file produced by a code generator
+type GeneratedFile struct {
+	// Path is relative to the output directory
+	Path string
+
+	// Content is the file content
+	Content []byte
+}
+
+// GoTestOptions configures Go test generation
+type GoTestOptions struct {
+	// Package is the Go package name of the generated files
+	Package string
+
+	// GroupBy is GroupByAC (default) or GroupByAggregate
+	GroupBy string
+
+	// Source names the spec the tests come from (for the file header)
+	Source string
+}
+
+var (
+	acFromTCPattern  = regexp.MustCompile(`^TC-(AC-[A-Za-z]+-\d+)`)
+	nonIdentPattern  = regexp.MustCompile(`[^A-Za-z0-9]+`)
+	packageStripExpr = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// testGroup is the set of test cases rendered into one file
+type testGroup struct {
+	Key   string
+	Cases []formatter.TestCase
+}
+
+// GenerateGoTests renders test cases as table-driven Go tests: one file per
+// AC (or aggregate), one t.Run subtest per test case named by its TC ID.
+// Test data becomes literals, steps become TODO-marked comments and every
+// case carries a traceability comment back to its AC and BRs.
+func GenerateGoTests(cases []formatter.TestCase, opts GoTestOptions) ([]GeneratedFile, error) {
+	if opts.Package == "" {
+		opts.Package = "acceptance"
+	}
+	if opts.GroupBy == "" {
+		opts.GroupBy = GroupByAC
+	}
+	if opts.GroupBy != GroupByAC && opts.GroupBy != GroupByAggregate {
+		return nil, fmt.Errorf("unknown test grouping %q (use %s or %s)", opts.GroupBy, GroupByAC, GroupByAggregate)
+	}
+
+	var files []GeneratedFile
+	for _, g := range groupTestCases(cases, opts.GroupBy) {
+		src := renderGoTestFile(g, opts)
+		formatted, err := format.Source([]byte(src))
+		if err != nil {
+			return nil, fmt.Errorf("failed to format generated tests for %s: %w", g.Key, err)
+		}
+		files = append(files, GeneratedFile{
+			Path:    strings.ToLower(identifier(g.Key)) + "_test.go",
+			Content: formatted,
+		})
+	}
+
+	return files, nil
+}
+
+// GoPackageName turns a directory name into a valid Go package name
+func GoPackageName(name string) string {
+	pkg := packageStripExpr.ReplaceAllString(strings.ToLower(name), "")
+	if pkg == "" {
+		return "acceptance"
+	}
+	if pkg[0] >= '0' && pkg[0] <= '9' {
+		pkg = "p" + pkg
+	}
+	return pkg
+}
+
+// groupTestCases groups test cases by AC or aggregate, in first-seen order
+func groupTestCases(cases []formatter.TestCase, groupBy string) []testGroup {
+	var groups []testGroup
+	index := make(map[string]int)
+
+	for _, tc := range cases {
+		key := ACForTestCase(tc)
+		if groupBy == GroupByAggregate && key != UnmappedGroup {
+			parts := strings.Split(key, "-")
+			key = parts[1]
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, testGroup{Key: key})
+		}
+		groups[i].Cases = append(groups[i].Cases, tc)
+	}
+
+	return groups
+}
+
+// ACForTestCase returns the AC a test case belongs to, from its AC reference
+// or its ID (TC-AC-ORD-001-P01 -> AC-ORD-001)
+func ACForTestCase(tc formatter.TestCase) string {
+	if strings.Count(tc.ACRef, "-") >= 2 {
+		return tc.ACRef
+	}
+	if m := acFromTCPattern.FindStringSubmatch(tc.ID); m != nil {
+		return m[1]
+	}
+	return UnmappedGroup
+}
+
+// renderGoTestFile renders one test file (unformatted)
+func renderGoTestFile(g testGroup, opts GoTestOptions) string {
+	var sb strings.Builder
+
+	source := opts.Source
+	if source == "" {
+		source = "test-cases.md"
+	}
+
+	sb.WriteString(fmt.Sprintf("// Generated by loom-cli gen-tests from %s.\n", source))
+	sb.WriteString("// Fill in the TODO steps; regenerating does not overwrite this file\n")
+	sb.WriteString("// unless --force is given.\n\n")
+	sb.WriteString(fmt.Sprintf("package %s\n\n", opts.Package))
+	sb.WriteString("import \"testing\"\n\n")
+
+	// go test ignores Test functions continuing with a lowercase letter
+	name := identifier(g.Key)
+	funcName := "Test" + strings.ToUpper(name[:1]) + name[1:]
+	sb.WriteString(fmt.Sprintf("// %s covers %s.\n", funcName, g.Key))
+	sb.WriteString(fmt.Sprintf("func %s(t *testing.T) {\n", funcName))
+	sb.WriteString("tests := []struct {\n")
+	sb.WriteString("id string\n")
+	sb.WriteString("name string\n")
+	sb.WriteString("category string\n")
+	sb.WriteString("data map[string]any\n")
+	sb.WriteString("shouldNot string\n")
+	sb.WriteString("run func(t *testing.T, data map[string]any)\n")
+	sb.WriteString("}{\n")
+
+	for _, tc := range g.Cases {
+		renderGoTestCase(&sb, tc)
+	}
+
+	sb.WriteString("}\n\n")
+	sb.WriteString("for _, tt := range tests {\n")
+	sb.WriteString("t.Run(tt.id, func(t *testing.T) {\n")
+	sb.WriteString("t.Logf(\"%s [%s]\", tt.name, tt.category)\n")
+	sb.WriteString("tt.run(t, tt.data)\n")
+	sb.WriteString("})\n")
+	sb.WriteString("}\n")
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// renderGoTestCase renders one table entry
+func renderGoTestCase(sb *strings.Builder, tc formatter.TestCase) {
+	refs := []string{ACForTestCase(tc)}
+	refs = append(refs, tc.BRRefs...)
+	sb.WriteString(fmt.Sprintf("// %s traces to %s\n", tc.ID, strings.Join(refs, ", ")))
+	sb.WriteString("{\n")
+	sb.WriteString(fmt.Sprintf("id: %s,\n", strconv.Quote(tc.ID)))
+	sb.WriteString(fmt.Sprintf("name: %s,\n", strconv.Quote(tc.Name)))
+	sb.WriteString(fmt.Sprintf("category: %s,\n", strconv.Quote(tc.Category)))
+
+	if len(tc.TestData) > 0 {
+		sb.WriteString("data: map[string]any{\n")
+		seen := make(map[string]int)
+		for _, td := range tc.TestData {
+			key := td.Field
+			seen[key]++
+			if seen[key] > 1 {
+				key = fmt.Sprintf("%s (%d)", key, seen[key])
+			}
+			sb.WriteString(fmt.Sprintf("%s: %s,", strconv.Quote(key), goLiteral(td.Value)))
+			if td.Notes != "" {
+				sb.WriteString(" // " + commentText(td.Notes))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("},\n")
+	}
+	if tc.ShouldNot != "" {
+		sb.WriteString(fmt.Sprintf("shouldNot: %s,\n", strconv.Quote(tc.ShouldNot)))
+	}
+
+	sb.WriteString("run: func(t *testing.T, data map[string]any) {\n")
+	if len(tc.Preconditions) > 0 {
+		sb.WriteString("// Preconditions:\n")
+		for _, p := range tc.Preconditions {
+			sb.WriteString("//   - " + commentText(p) + "\n")
+		}
+		sb.WriteString("// TODO: set up preconditions\n\n")
+	}
+	for i, step := range tc.Steps {
+		sb.WriteString(fmt.Sprintf("// Step %d: %s\n", i+1, commentText(step)))
+		sb.WriteString("// TODO: implement step\n\n")
+	}
+	if len(tc.ExpectedResults) > 0 {
+		sb.WriteString("// Expected:\n")
+		for _, r := range tc.ExpectedResults {
+			sb.WriteString("//   - " + commentText(r) + "\n")
+		}
+		sb.WriteString("// TODO: assert expected results\n\n")
+	}
+	if tc.ShouldNot != "" {
+		sb.WriteString("// Must NOT: " + commentText(tc.ShouldNot) + "\n")
+		sb.WriteString("// TODO: assert this does not happen\n\n")
+	}
+	sb.WriteString(fmt.Sprintf("t.Skip(%s)\n", strconv.Quote("TODO: implement "+tc.ID)))
+	sb.WriteString("},\n")
+	sb.WriteString("},\n")
+}
+
+// goLiteral renders a test data value as a Go literal
+func goLiteral(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(val)
+	case string:
+		return strconv.Quote(val)
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < 1e15 {
+			return strconv.FormatInt(int64(val), 10)
+		}
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case []interface{}:
+		items := make([]string, len(val))
+		for i, item := range val {
+			items[i] = goLiteral(item)
+		}
+		return "[]any{" + strings.Join(items, ", ") + "}"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			items[i] = strconv.Quote(k) + ": " + goLiteral(val[k])
+		}
+		return "map[string]any{" + strings.Join(items, ", ") + "}"
+	default:
+		return strconv.Quote(fmt.Sprint(val))
+	}
+}
+
+// identifier turns an ID such as AC-ORD-001 into AC_ORD_001
+func identifier(id string) string {
+	s := strings.Trim(nonIdentPattern.ReplaceAllString(id, "_"), "_")
+	if s == "" {
+		return "Cases"
+	}
+	return s
+}
+
+// commentText keeps spec text on a single comment line
+func commentText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,140 @@
+package codegen
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+func sampleCases() []formatter.TestCase {
+	return []formatter.TestCase{
+		{
+			ID:              "TC-AC-ORD-001-P01",
+			Name:            "Place order",
+			Category:        "positive",
+			ACRef:           "AC-ORD-001",
+			BRRefs:          []string{"BR-ORD-001"},
+			Preconditions:   []string{"Customer is logged in"},
+			TestData:        []formatter.TestData{{Field: "quantity", Value: float64(5), Notes: "Minimum\nvalid"}, {Field: "quantity", Value: "x"}},
+			Steps:           []string{"Submit order"},
+			ExpectedResults: []string{"Order is created"},
+		},
+		{
+			ID:        "TC-AC-ORD-001-H01",
+			Name:      "No discount",
+			Category:  "hallucination",
+			ACRef:     "AC-ORD-001",
+			Steps:     []string{"Submit order"},
+			ShouldNot: "Apply a \"free\" discount",
+		},
+		{
+			ID:       "TC-AC-ORD-002-N01",
+			Name:     "Reject empty order",
+			Category: "negative",
+			Steps:    []string{"Submit empty order"},
+		},
+		{
+			ID:       "TC-AC-PAY-001-P01",
+			Name:     "Pay order",
+			Category: "positive",
+			ACRef:    "AC-PAY-001",
+		},
+	}
+}
+
+func TestGenerateGoTests_PerAC(t *testing.T) {
+	files, err := GenerateGoTests(sampleCases(), GoTestOptions{Package: "orders"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 files, got %d", len(files))
+	}
+	paths := []string{"ac_ord_001_test.go", "ac_ord_002_test.go", "ac_pay_001_test.go"}
+	for i, p := range paths {
+		if files[i].Path != p {
+			t.Errorf("File %d: expected %s, got %s", i, p, files[i].Path)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), p, files[i].Content, parser.ParseComments); err != nil {
+			t.Errorf("Generated %s does not parse: %v", p, err)
+		}
+	}
+
+	src := string(files[0].Content)
+	checks := []string{
+		"package orders",
+		"func TestAC_ORD_001(t *testing.T)",
+		"t.Run(tt.id, func(t *testing.T)",
+		`id:       "TC-AC-ORD-001-P01"`,
+		`"quantity":     5, // Minimum valid`,
+		`"quantity (2)": "x",`,
+		"// TC-AC-ORD-001-P01 traces to AC-ORD-001, BR-ORD-001",
+		"// Step 1: Submit order",
+		"// TODO: implement step",
+		`shouldNot: "Apply a \"free\" discount"`,
+		`t.Skip("TODO: implement TC-AC-ORD-001-H01")`,
+	}
+	for _, c := range checks {
+		if !strings.Contains(src, c) {
+			t.Errorf("Expected generated code to contain %q\n%s", c, src)
+		}
+	}
+
+	// AC taken from the TC ID when the reference is missing
+	if !strings.Contains(string(files[1].Content), "func TestAC_ORD_002(t *testing.T)") {
+		t.Error("Expected AC derived from test case ID")
+	}
+}
+
+func TestGenerateGoTests_PerAggregate(t *testing.T) {
+	files, err := GenerateGoTests(sampleCases(), GoTestOptions{Package: "orders", GroupBy: GroupByAggregate})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(files) != 2 || files[0].Path != "ord_test.go" || files[1].Path != "pay_test.go" {
+		t.Fatalf("Expected ord_test.go and pay_test.go, got %v", files)
+	}
+	if strings.Count(string(files[0].Content), "t.Skip(") != 3 {
+		t.Error("Expected all three ORD test cases in one file")
+	}
+}
+
+func TestGenerateGoTests_LowercaseAggregate(t *testing.T) {
+	cases := []formatter.TestCase{{ID: "TC-AC-ord-001-P01", Name: "Place order", Category: "positive", ACRef: "AC-ord-001"}}
+	files, err := GenerateGoTests(cases, GoTestOptions{Package: "orders", GroupBy: GroupByAggregate})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(files) != 1 || files[0].Path != "ord_test.go" {
+		t.Fatalf("Expected ord_test.go, got %v", files)
+	}
+	if !strings.Contains(string(files[0].Content), "func TestOrd(t *testing.T)") {
+		t.Errorf("Expected an exported test name, got\n%s", files[0].Content)
+	}
+}
+
+func TestGenerateGoTests_UnknownGrouping(t *testing.T) {
+	if _, err := GenerateGoTests(sampleCases(), GoTestOptions{GroupBy: "service"}); err == nil {
+		t.Error("Expected error for unknown grouping")
+	}
+}
+
+func TestGoPackageName(t *testing.T) {
+	cases := map[string]string{
+		"acceptance-tests": "acceptancetests",
+		"Orders":           "orders",
+		"2025":             "p2025",
+		"--":               "acceptance",
+	}
+	for in, want := range cases {
+		if got := GoPackageName(in); got != want {
+			t.Errorf("GoPackageName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
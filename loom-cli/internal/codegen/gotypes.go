@@ -0,0 +1,167 @@
+package codegen
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ikadar/loom-cli/internal/spec"
+)
+
+// goInitialisms are written in upper case in Go identifiers
+var goInitialisms = map[string]bool{
+	"ID": true, "URL": true, "URI": true, "API": true, "HTTP": true, "JSON": true,
+	"UUID": true, "SQL": true, "IP": true, "SKU": true, "VAT": true, "EU": true,
+}
+
+var (
+	wordSplitPattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+	camelBoundary    = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// typeMapper maps spec types (as written by the model in L2 documents) to
+// Go types. Names of types generated in the same package map to themselves.
+type typeMapper struct {
+	known   map[string]string // normalized spec name -> Go type name
+	used    map[string]bool   // Go type names of declared types referenced
+	imports map[string]bool
+}
+
+func newTypeMapper() *typeMapper {
+	return &typeMapper{known: make(map[string]string), used: make(map[string]bool), imports: make(map[string]bool)}
+}
+
+// declare registers a type generated in the package
+func (m *typeMapper) declare(specName, goName string) {
+	m.known[strings.ToLower(specName)] = goName
+}
+
+// goType maps a spec type to a Go type. Unknown types become any.
+func (m *typeMapper) goType(specType string) string {
+	return m.mapType(spec.ParseType(specType))
+}
+
+// mapType maps a parsed spec type to a Go type
+func (m *typeMapper) mapType(t *spec.Type) string {
+	if t == nil {
+		return "any"
+	}
+
+	switch t.Kind {
+	case spec.TypeNullable:
+		return "*" + m.mapType(t.Elem)
+	case spec.TypeList:
+		return "[]" + m.mapType(t.Elem)
+	case spec.TypeMap:
+		return "map[" + m.mapType(t.Key) + "]" + m.mapType(t.Elem)
+	case spec.TypeEnum:
+		return "string"
+	case spec.TypeUnion, spec.TypeGeneric:
+		return "any"
+	}
+
+	base := t.Name
+	if goName, ok := m.known[strings.ToLower(base)]; ok {
+		m.used[goName] = true
+		return goName
+	}
+
+	switch strings.ToLower(base) {
+	case "string", "text", "varchar", "char", "email", "url", "uri", "enum", "phone", "json":
+		return "string"
+	case "uuid", "id", "identifier":
+		return "string"
+	case "int", "integer", "int32", "smallint":
+		return "int"
+	case "long", "int64", "bigint", "serial", "bigserial":
+		return "int64"
+	case "decimal", "number", "numeric", "float", "double", "real", "money", "float64":
+		return "float64"
+	case "bool", "boolean":
+		return "bool"
+	case "date", "datetime", "timestamp", "timestamptz", "instant", "time":
+		m.imports["time"] = true
+		return "time.Time"
+	case "duration":
+		m.imports["time"] = true
+		return "time.Duration"
+	case "bytes", "binary", "blob", "bytea":
+		return "[]byte"
+	case "void", "none", "unit":
+		return ""
+	}
+
+	// Identity types such as OrderId
+	if strings.HasSuffix(base, "Id") || strings.HasSuffix(base, "ID") {
+		return "string"
+	}
+
+	return "any"
+}
+
+// exportedName turns a spec name (camelCase, snake_case, words) into an
+// exported Go identifier, honouring common initialisms
+func exportedName(name string) string {
+	name = camelBoundary.ReplaceAllString(name, "${1} ${2}")
+	words := wordSplitPattern.Split(name, -1)
+
+	var sb strings.Builder
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+		upper := strings.ToUpper(w)
+		if goInitialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		r := []rune(strings.ToLower(w))
+		r[0] = unicode.ToUpper(r[0])
+		sb.WriteString(string(r))
+	}
+
+	id := sb.String()
+	if id == "" {
+		return "X"
+	}
+	if unicode.IsDigit([]rune(id)[0]) {
+		id = "X" + id
+	}
+	return id
+}
+
+// unexportedName turns a spec name into an unexported Go identifier
+func unexportedName(name string) string {
+	exp := exportedName(name)
+	// Lower the leading initialism or first letter: IDValue -> idValue
+	r := []rune(exp)
+	i := 0
+	for i < len(r) && unicode.IsUpper(r[i]) {
+		i++
+	}
+	switch {
+	case i == 0:
+	case i == 1 || i == len(r):
+		for j := 0; j < i; j++ {
+			r[j] = unicode.ToLower(r[j])
+		}
+	default:
+		for j := 0; j < i-1; j++ {
+			r[j] = unicode.ToLower(r[j])
+		}
+	}
+	id := string(r)
+	if goKeywords[id] {
+		id += "_"
+	}
+	return id
+}
+
+// goKeywords cannot be used as identifiers
+var goKeywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true,
+	"defer": true, "else": true, "fallthrough": true, "for": true, "func": true, "go": true,
+	"goto": true, "if": true, "import": true, "interface": true, "map": true, "package": true,
+	"range": true, "return": true, "select": true, "struct": true, "switch": true, "type": true,
+	"var": true,
+}
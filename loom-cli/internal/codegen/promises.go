@@ -0,0 +1,121 @@
+package codegen
+
+import (
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// Kinds of identifiers promised by the spec
+const (
+	PromiseType   = "type"
+	PromiseMethod = "method"
+	PromiseError  = "error"
+)
+
+// Promise is a Go identifier gen-code derives from a spec element. Code
+// implementing the spec is expected to declare it, whether it was
+// generated or written by hand.
+type Promise struct {
+	Kind   string // type, method or error
+	Name   string // identifier; methods as Type.Method
+	SpecID string // aggregate, contract or error code the promise comes from
+}
+
+// Owner returns the type a method promise belongs to, or "" for types and
+// errors
+func (p Promise) Owner() string {
+	if p.Kind != PromiseMethod {
+		return ""
+	}
+	if i := strings.Index(p.Name, "."); i >= 0 {
+		return p.Name[:i]
+	}
+	return ""
+}
+
+// GoPromises lists the identifiers gen-code generates, or expects to be
+// hand-written, for the aggregates and interface contracts
+func GoPromises(aggregates []formatter.AggregateDesign, contracts []formatter.InterfaceContract) []Promise {
+	var promises []Promise
+	seen := make(map[string]bool)
+	add := func(kind, name, specID string) {
+		key := kind + ":" + name
+		if name == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		promises = append(promises, Promise{Kind: kind, Name: name, SpecID: specID})
+	}
+
+	for _, agg := range aggregates {
+		specID := agg.ID
+		if specID == "" {
+			specID = agg.Name
+		}
+		rootName := agg.Root.Entity
+		if rootName == "" {
+			rootName = agg.Name
+		}
+		root := exportedName(rootName)
+		add(PromiseType, root, specID)
+
+		for _, e := range agg.Entities {
+			add(PromiseType, exportedName(e.Name), specID)
+		}
+		for _, vo := range agg.ValueObjects {
+			name, _ := splitNameDesc(vo)
+			add(PromiseType, exportedName(name), specID)
+		}
+		for _, ev := range agg.Events {
+			add(PromiseType, exportedName(ev.Name), specID)
+		}
+
+		repoName := root + "Repository"
+		if agg.Repository.Name != "" {
+			repoName = exportedName(agg.Repository.Name)
+		}
+		if len(agg.Repository.Methods) > 0 {
+			add(PromiseType, repoName, specID)
+		}
+		for _, rm := range agg.Repository.Methods {
+			add(PromiseMethod, repoName+"."+exportedName(rm.Name), specID)
+		}
+
+		for _, c := range invariantChecks(agg.Invariants) {
+			add(PromiseMethod, root+"."+c.Name, specID)
+		}
+
+		fieldNames := make(map[string]bool)
+		for _, f := range entityFields(newTypeMapper(), agg.Root.Identity, agg.Root.Attributes) {
+			fieldNames[f.Name] = true
+		}
+		for _, b := range agg.Behaviors {
+			if name := exportedName(b.Name); !fieldNames[name] {
+				add(PromiseMethod, root+"."+name, specID)
+			}
+		}
+	}
+
+	for _, ic := range contracts {
+		specID := ic.ID
+		if specID == "" {
+			specID = ic.ServiceName
+		}
+		service := exportedName(ic.ServiceName)
+		if !strings.HasSuffix(service, "Service") {
+			service += "Service"
+		}
+		add(PromiseType, service, specID)
+		for _, op := range ic.Operations {
+			add(PromiseMethod, service+"."+exportedName(op.Name), specID)
+			for _, e := range op.Errors {
+				if e.Code != "" {
+					add(PromiseError, "Err"+exportedName(e.Code), e.Code)
+				}
+			}
+		}
+	}
+
+	return promises
+}
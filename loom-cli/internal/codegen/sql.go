@@ -0,0 +1,502 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// SQL dialects supported by GenerateSchemaSQL and GenerateMigration
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite"
+)
+
+// DataModel is the L2 data model the SQL generators work from
+type DataModel struct {
+	Tables []formatter.DataTable `json:"tables"`
+	Enums  []formatter.DataEnum  `json:"enums"`
+}
+
+// sqlSchema is a data model normalized for one dialect
+type sqlSchema struct {
+	Dialect string
+	Enums   []formatter.DataEnum // CREATE TYPE (postgres only)
+	Tables  []*sqlTable          // in dependency order
+}
+
+type sqlTable struct {
+	Name        string
+	Comment     string
+	Columns     []sqlColumn
+	Constraints []sqlConstraint
+	Indexes     []sqlIndex
+	References  []string // tables referenced by foreign keys
+}
+
+type sqlColumn struct {
+	Name    string
+	Type    string
+	NotNull bool
+	Default string
+	Comment string // unrecognized constraint text, kept for the reader
+}
+
+// Constraint kinds
+const (
+	constraintPK     = "pk"
+	constraintFK     = "fk"
+	constraintUnique = "unique"
+	constraintCheck  = "check"
+)
+
+type sqlConstraint struct {
+	Name string
+	Kind string
+	Def  string // e.g. PRIMARY KEY (id)
+}
+
+type sqlIndex struct {
+	Name    string
+	Columns []string
+}
+
+var (
+	referencesPattern  = regexp.MustCompile(`(?i)\bREFERENCES\s+([\w."]+)\s*\(([^)]*)\)((?:\s+ON\s+(?:DELETE|UPDATE)\s+(?:CASCADE|RESTRICT|SET\s+NULL|SET\s+DEFAULT|NO\s+ACTION))*)`)
+	onDeletePattern    = regexp.MustCompile(`(?i)ON\s+DELETE\s+(CASCADE|RESTRICT|SET\s+NULL|SET\s+DEFAULT|NO\s+ACTION)`)
+	inlineCheckPattern = regexp.MustCompile(`(?i)\bCHECK\s*\((.*)\)`)
+	inlineDefault      = regexp.MustCompile(`(?i)\bDEFAULT\s+('(?:[^']|'')*'|[\w.]+\(\)|[\w.+-]+)`)
+	primaryKeyPattern  = regexp.MustCompile(`(?i)\bPRIMARY\s+KEY\b|\bPK\b`)
+	notNullPattern     = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	uniquePattern      = regexp.MustCompile(`(?i)\bUNIQUE\b`)
+	nullPattern        = regexp.MustCompile(`(?i)\bNULL\b`)
+	sqlTypePattern     = regexp.MustCompile(`^([A-Za-z][A-Za-z ]*?)\s*(\(.*\))?$`)
+	plainIdentifier    = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	numberLiteral      = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+	functionCall       = regexp.MustCompile(`^[\w.]+\(.*\)$`)
+)
+
+// sqlReserved lists words that must be quoted as identifiers
+var sqlReserved = map[string]bool{
+	"order": true, "user": true, "group": true, "select": true, "table": true, "from": true,
+	"where": true, "key": true, "index": true, "limit": true, "check": true, "default": true,
+	"primary": true, "references": true, "column": true, "to": true, "desc": true, "asc": true,
+}
+
+// ValidDialect reports whether dialect is supported
+func ValidDialect(dialect string) bool {
+	return dialect == DialectPostgres || dialect == DialectMySQL || dialect == DialectSQLite
+}
+
+// GenerateSchemaSQL renders the data model as DDL: enum types (postgres),
+// tables in foreign key order with their constraints, then indexes
+func GenerateSchemaSQL(model DataModel, dialect string) (string, error) {
+	schema, err := buildSchema(model, dialect)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("-- Generated by loom-cli gen-sql (%s) from the L2 data model\n", dialect))
+	for _, e := range schema.Enums {
+		sb.WriteString("\n" + createEnumSQL(e) + "\n")
+	}
+	for _, t := range schema.Tables {
+		sb.WriteString("\n")
+		if t.Comment != "" {
+			sb.WriteString("-- " + t.Comment + "\n")
+		}
+		sb.WriteString(createTableSQL(t, t.Name, dialect) + "\n")
+		for _, idx := range t.Indexes {
+			sb.WriteString(createIndexSQL(t.Name, idx, dialect) + "\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// buildSchema normalizes the data model for a dialect
+func buildSchema(model DataModel, dialect string) (*sqlSchema, error) {
+	if !ValidDialect(dialect) {
+		return nil, fmt.Errorf("unsupported SQL dialect: %s (supported: postgres, mysql, sqlite)", dialect)
+	}
+
+	enums := make(map[string]formatter.DataEnum, len(model.Enums))
+	for _, e := range model.Enums {
+		enums[strings.ToLower(e.Name)] = e
+	}
+
+	schema := &sqlSchema{Dialect: dialect}
+	if dialect == DialectPostgres {
+		schema.Enums = model.Enums
+	}
+
+	seen := make(map[string]bool)
+	for _, tbl := range model.Tables {
+		name := strings.TrimSpace(tbl.Name)
+		if name == "" {
+			return nil, fmt.Errorf("table %s has no name", tbl.ID)
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("table %s is defined twice", name)
+		}
+		seen[strings.ToLower(name)] = true
+		schema.Tables = append(schema.Tables, buildTable(tbl, enums, dialect))
+	}
+
+	schema.Tables = sortTablesByReference(schema.Tables)
+	return schema, nil
+}
+
+// buildTable turns a DataTable into columns, named constraints and indexes.
+// Inline REFERENCES, UNIQUE and CHECK column constraints become named table
+// constraints so that migrations can add and drop them individually.
+func buildTable(tbl formatter.DataTable, enums map[string]formatter.DataEnum, dialect string) *sqlTable {
+	t := &sqlTable{Name: tbl.Name}
+	var comment []string
+	for _, s := range []string{tbl.ID, tbl.Aggregate, tbl.Purpose} {
+		if s = commentText(s); s != "" {
+			comment = append(comment, s)
+		}
+	}
+	t.Comment = strings.Join(comment, " – ")
+
+	pkColumns := tbl.PrimaryKey.Columns
+	var inlinePK []string
+	var fks []formatter.DataForeignKey
+	fkSeen := make(map[string]bool)
+	for _, fk := range tbl.ForeignKeys {
+		fkSeen[strings.ToLower(strings.Join(fk.Columns, ","))] = true
+	}
+
+	for _, f := range tbl.Fields {
+		col := sqlColumn{Name: f.Name}
+		rest := f.Constraints
+
+		if m := referencesPattern.FindStringSubmatch(rest); m != nil {
+			if !fkSeen[strings.ToLower(f.Name)] {
+				fk := formatter.DataForeignKey{Columns: []string{f.Name}, References: m[1] + "(" + m[2] + ")"}
+				if od := onDeletePattern.FindStringSubmatch(m[3]); od != nil {
+					fk.OnDelete = od[1]
+				}
+				fks = append(fks, fk)
+			}
+			rest = strings.Replace(rest, m[0], " ", 1)
+		}
+		if m := inlineCheckPattern.FindStringSubmatch(rest); m != nil {
+			t.Constraints = append(t.Constraints, sqlConstraint{
+				Name: "chk_" + tbl.Name + "_" + f.Name, Kind: constraintCheck, Def: "CHECK (" + strings.TrimSpace(m[1]) + ")",
+			})
+			rest = strings.Replace(rest, m[0], " ", 1)
+		}
+		// "NOT NULL|UNIQUE" and "NOT NULL, UNIQUE" list constraints
+		rest = strings.NewReplacer("|", " ", ",", " ").Replace(rest)
+		if m := inlineDefault.FindStringSubmatch(rest); m != nil {
+			col.Default = m[1]
+			rest = strings.Replace(rest, m[0], " ", 1)
+		}
+		if primaryKeyPattern.MatchString(rest) {
+			inlinePK = append(inlinePK, f.Name)
+			rest = primaryKeyPattern.ReplaceAllString(rest, " ")
+		}
+		if notNullPattern.MatchString(rest) {
+			col.NotNull = true
+			rest = notNullPattern.ReplaceAllString(rest, " ")
+		}
+		if uniquePattern.MatchString(rest) {
+			t.Constraints = append(t.Constraints, sqlConstraint{
+				Name: "uq_" + tbl.Name + "_" + f.Name, Kind: constraintUnique, Def: "UNIQUE (" + quoteIdent(f.Name, dialect) + ")",
+			})
+			rest = uniquePattern.ReplaceAllString(rest, " ")
+		}
+		rest = nullPattern.ReplaceAllString(rest, " ")
+		col.Comment = commentText(rest)
+
+		if d := strings.TrimSpace(f.Default); d != "" {
+			col.Default = d
+		}
+		col.Default = sqlDefault(col.Default, dialect)
+
+		typ, enumValues := sqlType(f.Type, enums, dialect)
+		col.Type = typ
+		if enumValues != nil {
+			t.Constraints = append(t.Constraints, sqlConstraint{
+				Name: "chk_" + tbl.Name + "_" + f.Name + "_enum", Kind: constraintCheck,
+				Def: "CHECK (" + quoteIdent(f.Name, dialect) + " IN (" + sqlStringList(enumValues) + "))",
+			})
+		}
+		t.Columns = append(t.Columns, col)
+	}
+
+	if len(pkColumns) == 0 {
+		pkColumns = inlinePK
+	}
+	if len(pkColumns) > 0 {
+		for i := range t.Columns {
+			for _, pk := range pkColumns {
+				if strings.EqualFold(t.Columns[i].Name, pk) {
+					t.Columns[i].NotNull = true
+				}
+			}
+		}
+		t.Constraints = append([]sqlConstraint{{
+			Name: tbl.Name + "_pkey", Kind: constraintPK, Def: "PRIMARY KEY (" + quoteIdents(pkColumns, dialect) + ")",
+		}}, t.Constraints...)
+	}
+
+	for _, fk := range append(append([]formatter.DataForeignKey{}, tbl.ForeignKeys...), fks...) {
+		target, targetCols := fk.References, ""
+		if i := strings.Index(target, "("); i > 0 {
+			targetCols = strings.Trim(strings.TrimSpace(target[i:]), "()")
+			target = strings.TrimSpace(target[:i])
+		}
+		target = strings.Trim(target, `"`)
+		def := "FOREIGN KEY (" + quoteIdents(fk.Columns, dialect) + ") REFERENCES " + quoteIdent(target, dialect)
+		if targetCols != "" {
+			def += " (" + quoteIdents(strings.Split(targetCols, ","), dialect) + ")"
+		}
+		if od := strings.ToUpper(strings.Join(strings.Fields(fk.OnDelete), " ")); od != "" {
+			def += " ON DELETE " + od
+		}
+		t.Constraints = append(t.Constraints, sqlConstraint{
+			Name: "fk_" + tbl.Name + "_" + strings.Join(fk.Columns, "_"), Kind: constraintFK, Def: def,
+		})
+		if !strings.EqualFold(target, tbl.Name) {
+			t.References = append(t.References, target)
+		}
+	}
+
+	for i, c := range tbl.CheckConstraints {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("chk_%s_%d", tbl.Name, i+1)
+		}
+		t.Constraints = append(t.Constraints, sqlConstraint{
+			Name: name, Kind: constraintCheck, Def: "CHECK (" + strings.TrimSpace(c.Expression) + ")",
+		})
+	}
+
+	for _, idx := range tbl.Indexes {
+		name := idx.Name
+		if name == "" {
+			name = "idx_" + tbl.Name + "_" + strings.Join(idx.Columns, "_")
+		}
+		t.Indexes = append(t.Indexes, sqlIndex{Name: name, Columns: idx.Columns})
+	}
+
+	return t
+}
+
+// sqlType maps a spec column type to the dialect. Columns typed with an
+// enum return its values when the dialect enforces them with a CHECK.
+func sqlType(spec string, enums map[string]formatter.DataEnum, dialect string) (string, []string) {
+	spec = strings.TrimSpace(spec)
+	if e, ok := enums[strings.ToLower(spec)]; ok {
+		switch dialect {
+		case DialectPostgres:
+			return quoteIdent(e.Name, dialect), nil
+		case DialectMySQL:
+			return "ENUM(" + sqlStringList(e.Values) + ")", nil
+		default:
+			return "TEXT", e.Values
+		}
+	}
+
+	base, params := strings.ToUpper(spec), ""
+	if m := sqlTypePattern.FindStringSubmatch(spec); m != nil {
+		base, params = strings.ToUpper(strings.Join(strings.Fields(m[1]), " ")), m[2]
+	}
+	if base == "" {
+		base = "TEXT"
+	}
+
+	switch dialect {
+	case DialectPostgres:
+		switch base {
+		case "DATETIME":
+			return "TIMESTAMP", nil
+		case "BLOB":
+			return "BYTEA", nil
+		case "INT":
+			return "INTEGER", nil
+		case "DOUBLE":
+			return "DOUBLE PRECISION", nil
+		}
+	case DialectMySQL:
+		switch base {
+		case "UUID":
+			return "CHAR(36)", nil
+		case "TIMESTAMPTZ":
+			return "TIMESTAMP", nil
+		case "JSONB":
+			return "JSON", nil
+		case "BYTEA":
+			return "BLOB", nil
+		case "BIGSERIAL":
+			return "BIGINT AUTO_INCREMENT", nil
+		case "DOUBLE PRECISION":
+			return "DOUBLE", nil
+		}
+	case DialectSQLite:
+		// SQLite only knows storage classes; map to their affinity names
+		switch {
+		case strings.Contains(base, "INT"), base == "SERIAL", base == "BIGSERIAL", strings.HasPrefix(base, "BOOL"):
+			return "INTEGER", nil
+		case strings.Contains(base, "CHAR"), strings.Contains(base, "TEXT"), strings.Contains(base, "CLOB"),
+			base == "UUID", strings.HasPrefix(base, "JSON"), strings.HasPrefix(base, "DATE"), strings.HasPrefix(base, "TIME"):
+			return "TEXT", nil
+		case base == "BLOB", base == "BYTEA":
+			return "BLOB", nil
+		case strings.Contains(base, "REAL"), strings.Contains(base, "FLOA"), strings.Contains(base, "DOUB"):
+			return "REAL", nil
+		default:
+			return "NUMERIC", nil
+		}
+	}
+	return base + params, nil
+}
+
+// sqlDefault normalizes a column default for the dialect, quoting bare words
+func sqlDefault(d, dialect string) string {
+	d = strings.TrimSpace(d)
+	if d == "" {
+		return ""
+	}
+	upper := strings.ToUpper(d)
+	switch upper {
+	case "NOW()", "CURRENT_TIMESTAMP()", "CURRENT_TIMESTAMP":
+		if dialect == DialectPostgres && upper == "NOW()" {
+			return "NOW()"
+		}
+		return "CURRENT_TIMESTAMP"
+	case "GEN_RANDOM_UUID()", "UUID_GENERATE_V4()", "UUID()":
+		switch dialect {
+		case DialectMySQL:
+			return "(UUID())"
+		case DialectSQLite:
+			return "(lower(hex(randomblob(16))))"
+		}
+		return "gen_random_uuid()"
+	case "TRUE", "FALSE", "NULL", "CURRENT_DATE", "CURRENT_TIME":
+		return upper
+	}
+	if numberLiteral.MatchString(d) || strings.HasPrefix(d, "'") || strings.HasPrefix(d, "(") || functionCall.MatchString(d) {
+		return d
+	}
+	return "'" + strings.ReplaceAll(d, "'", "''") + "'"
+}
+
+// sortTablesByReference orders tables so that referenced tables come first,
+// keeping the spec order otherwise. Cycles keep their spec order.
+func sortTablesByReference(tables []*sqlTable) []*sqlTable {
+	index := make(map[string]*sqlTable, len(tables))
+	for _, t := range tables {
+		index[strings.ToLower(t.Name)] = t
+	}
+
+	var sorted []*sqlTable
+	state := make(map[*sqlTable]int) // 1 visiting, 2 done
+	var visit func(t *sqlTable)
+	visit = func(t *sqlTable) {
+		if state[t] != 0 {
+			return
+		}
+		state[t] = 1
+		for _, ref := range t.References {
+			if dep, ok := index[strings.ToLower(ref)]; ok {
+				visit(dep)
+			}
+		}
+		state[t] = 2
+		sorted = append(sorted, t)
+	}
+	for _, t := range tables {
+		visit(t)
+	}
+	return sorted
+}
+
+func createEnumSQL(e formatter.DataEnum) string {
+	return fmt.Sprintf("CREATE TYPE %s AS ENUM (%s);", quoteIdent(e.Name, DialectPostgres), sqlStringList(e.Values))
+}
+
+// createTableSQL renders CREATE TABLE, optionally under another name
+func createTableSQL(t *sqlTable, name, dialect string) string {
+	var lines, comments []string
+	for _, c := range t.Columns {
+		lines = append(lines, columnSQL(c, dialect))
+		comments = append(comments, c.Comment)
+	}
+	for _, c := range t.Constraints {
+		lines = append(lines, "CONSTRAINT "+quoteIdent(c.Name, dialect)+" "+c.Def)
+		comments = append(comments, "")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE " + quoteIdent(name, dialect) + " (\n")
+	for i, line := range lines {
+		sb.WriteString("    " + line)
+		if i < len(lines)-1 {
+			sb.WriteString(",")
+		}
+		if comments[i] != "" {
+			sb.WriteString(" -- " + comments[i])
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(");")
+	return sb.String()
+}
+
+// columnSQL renders a column definition without its comment
+func columnSQL(c sqlColumn, dialect string) string {
+	s := quoteIdent(c.Name, dialect) + " " + c.Type
+	if c.NotNull {
+		s += " NOT NULL"
+	}
+	if c.Default != "" {
+		s += " DEFAULT " + c.Default
+	}
+	return s
+}
+
+func createIndexSQL(table string, idx sqlIndex, dialect string) string {
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s);", quoteIdent(idx.Name, dialect), quoteIdent(table, dialect), quoteIdents(idx.Columns, dialect))
+}
+
+func dropIndexSQL(table string, idx sqlIndex, dialect string) string {
+	if dialect == DialectMySQL {
+		return fmt.Sprintf("DROP INDEX %s ON %s;", quoteIdent(idx.Name, dialect), quoteIdent(table, dialect))
+	}
+	return fmt.Sprintf("DROP INDEX %s;", quoteIdent(idx.Name, dialect))
+}
+
+// quoteIdent quotes an identifier when it is not a plain lower-case name
+func quoteIdent(name, dialect string) string {
+	name = strings.TrimSpace(name)
+	if plainIdentifier.MatchString(name) && !sqlReserved[name] {
+		return name
+	}
+	if dialect == DialectMySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteIdents(names []string, dialect string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = quoteIdent(n, dialect)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func sqlStringList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,184 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+func testDataModel() DataModel {
+	return DataModel{
+		Tables: []formatter.DataTable{
+			{
+				ID:   "TBL-ORD-002",
+				Name: "order_lines",
+				Fields: []formatter.DataField{
+					{Name: "id", Type: "UUID"},
+					{Name: "order_id", Type: "UUID", Constraints: "NOT NULL REFERENCES orders(id) ON DELETE CASCADE"},
+					{Name: "quantity", Type: "INTEGER", Constraints: "NOT NULL CHECK (quantity > 0)"},
+				},
+				PrimaryKey: formatter.DataPrimaryKey{Columns: []string{"id"}},
+				Indexes:    []formatter.DataIndex{{Name: "idx_lines_order", Columns: []string{"order_id"}}},
+			},
+			{
+				ID:   "TBL-ORD-001",
+				Name: "orders",
+				Fields: []formatter.DataField{
+					{Name: "id", Type: "UUID", Constraints: "PRIMARY KEY"},
+					{Name: "email", Type: "VARCHAR(255)", Constraints: "NOT NULL|UNIQUE"},
+					{Name: "status", Type: "order_status", Constraints: "NOT NULL", Default: "pending"},
+					{Name: "created_at", Type: "TIMESTAMP", Default: "NOW()"},
+				},
+				CheckConstraints: []formatter.DataConstraint{{Name: "chk_status", Expression: "status <> 'x'"}},
+			},
+		},
+		Enums: []formatter.DataEnum{{Name: "order_status", Values: []string{"pending", "paid"}}},
+	}
+}
+
+func TestGenerateSchemaSQL_Postgres(t *testing.T) {
+	sql, err := GenerateSchemaSQL(testDataModel(), DialectPostgres)
+	if err != nil {
+		t.Fatalf("GenerateSchemaSQL failed: %v", err)
+	}
+
+	checks := []string{
+		"CREATE TYPE order_status AS ENUM ('pending', 'paid');",
+		"    status order_status NOT NULL DEFAULT 'pending',\n",
+		"    created_at TIMESTAMP DEFAULT NOW(),\n",
+		"    CONSTRAINT orders_pkey PRIMARY KEY (id),\n",
+		"    CONSTRAINT uq_orders_email UNIQUE (email),\n",
+		"    CONSTRAINT chk_order_lines_quantity CHECK (quantity > 0),\n",
+		"    CONSTRAINT fk_order_lines_order_id FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE\n",
+		"CREATE INDEX idx_lines_order ON order_lines (order_id);",
+	}
+	for _, c := range checks {
+		if !strings.Contains(sql, c) {
+			t.Errorf("Expected schema to contain %q\n%s", c, sql)
+		}
+	}
+	if strings.Index(sql, "CREATE TABLE orders") > strings.Index(sql, "CREATE TABLE order_lines") {
+		t.Error("Expected referenced table orders to be created first")
+	}
+}
+
+func TestGenerateSchemaSQL_Dialects(t *testing.T) {
+	mysql, err := GenerateSchemaSQL(testDataModel(), DialectMySQL)
+	if err != nil {
+		t.Fatalf("GenerateSchemaSQL failed: %v", err)
+	}
+	for _, c := range []string{"id CHAR(36) NOT NULL", "status ENUM('pending', 'paid') NOT NULL", "DEFAULT CURRENT_TIMESTAMP"} {
+		if !strings.Contains(mysql, c) {
+			t.Errorf("Expected mysql schema to contain %q\n%s", c, mysql)
+		}
+	}
+	if strings.Contains(mysql, "CREATE TYPE") {
+		t.Error("Expected no CREATE TYPE for mysql")
+	}
+
+	sqlite, err := GenerateSchemaSQL(testDataModel(), DialectSQLite)
+	if err != nil {
+		t.Fatalf("GenerateSchemaSQL failed: %v", err)
+	}
+	for _, c := range []string{"id TEXT NOT NULL", "quantity INTEGER NOT NULL", "CONSTRAINT chk_orders_status_enum CHECK (status IN ('pending', 'paid'))"} {
+		if !strings.Contains(sqlite, c) {
+			t.Errorf("Expected sqlite schema to contain %q\n%s", c, sqlite)
+		}
+	}
+
+	if _, err := GenerateSchemaSQL(testDataModel(), "oracle"); err == nil {
+		t.Error("Expected error for unsupported dialect")
+	}
+}
+
+func TestGenerateMigration_Postgres(t *testing.T) {
+	from := testDataModel()
+	to := testDataModel()
+	to.Tables[1].Fields = append(to.Tables[1].Fields, formatter.DataField{Name: "note", Type: "TEXT"})
+	to.Tables[1].Fields[1].Type = "VARCHAR(320)"
+	to.Tables[1].Fields = append(to.Tables[1].Fields[:3:3], to.Tables[1].Fields[4:]...) // drop created_at
+	to.Tables = to.Tables[1:]                                                           // drop order_lines
+	to.Enums[0].Values = append(to.Enums[0].Values, "shipped")
+
+	m, err := GenerateMigration(from, to, DialectPostgres)
+	if err != nil {
+		t.Fatalf("GenerateMigration failed: %v", err)
+	}
+
+	up := []string{
+		"ALTER TYPE order_status ADD VALUE 'shipped';",
+		"DROP TABLE order_lines;",
+		"ALTER TABLE orders ADD COLUMN note TEXT;",
+		"ALTER TABLE orders ALTER COLUMN email TYPE VARCHAR(320) USING email::VARCHAR(320);",
+		"ALTER TABLE orders DROP COLUMN created_at;",
+	}
+	last := -1
+	for _, c := range up {
+		i := strings.Index(m.Up, c)
+		if i < 0 {
+			t.Errorf("Expected up migration to contain %q\n%s", c, m.Up)
+			continue
+		}
+		if i < last {
+			t.Errorf("Expected %q later in the up migration\n%s", c, m.Up)
+		}
+		last = i
+	}
+
+	down := []string{
+		"PostgreSQL cannot drop enum values",
+		"CREATE TABLE order_lines (",
+		"ALTER TABLE orders ADD COLUMN created_at TIMESTAMP DEFAULT NOW();",
+		"ALTER TABLE orders DROP COLUMN note;",
+	}
+	for _, c := range down {
+		if !strings.Contains(m.Down, c) {
+			t.Errorf("Expected down migration to contain %q\n%s", c, m.Down)
+		}
+	}
+}
+
+func TestGenerateMigration_SQLiteRebuild(t *testing.T) {
+	from := testDataModel()
+	to := testDataModel()
+	to.Tables[1].CheckConstraints[0].Expression = "status <> 'y'"
+
+	m, err := GenerateMigration(from, to, DialectSQLite)
+	if err != nil {
+		t.Fatalf("GenerateMigration failed: %v", err)
+	}
+	checks := []string{
+		"CREATE TABLE orders__new (",
+		"CONSTRAINT chk_status CHECK (status <> 'y')",
+		"INSERT INTO orders__new (id, email, status, created_at) SELECT id, email, status, created_at FROM orders;",
+		"DROP TABLE orders;",
+		"ALTER TABLE orders__new RENAME TO orders;",
+	}
+	for _, c := range checks {
+		if !strings.Contains(m.Up, c) {
+			t.Errorf("Expected up migration to contain %q\n%s", c, m.Up)
+		}
+	}
+	if !strings.Contains(m.Down, "CHECK (status <> 'x')") {
+		t.Errorf("Expected down migration to restore the old check\n%s", m.Down)
+	}
+}
+
+func TestGenerateMigration_Unchanged(t *testing.T) {
+	m, err := GenerateMigration(testDataModel(), testDataModel(), DialectMySQL)
+	if err != nil {
+		t.Fatalf("GenerateMigration failed: %v", err)
+	}
+	if !m.Empty() {
+		t.Errorf("Expected empty migration, got\n%s", m.Up)
+	}
+
+	initial, err := GenerateMigration(DataModel{}, testDataModel(), DialectMySQL)
+	if err != nil {
+		t.Fatalf("GenerateMigration failed: %v", err)
+	}
+	if !strings.Contains(initial.Up, "CREATE TABLE orders") || !strings.Contains(initial.Down, "DROP TABLE orders;") {
+		t.Errorf("Expected initial migration to create and drop all tables\n%s\n%s", initial.Up, initial.Down)
+	}
+}
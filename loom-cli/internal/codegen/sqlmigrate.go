@@ -0,0 +1,393 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// Migration is the SQL that moves a database between two data models
+type Migration struct {
+	Up   string
+	Down string
+}
+
+// Empty reports whether the data models produce the same schema
+func (m Migration) Empty() bool {
+	return m.Up == "" && m.Down == ""
+}
+
+// GenerateMigration diffs two data models and renders the up migration
+// (from → to) and the down migration (to → from). Added and dropped tables,
+// columns, constraints and indexes become ALTER statements; SQLite tables
+// that cannot be altered in place are rebuilt with their data copied over.
+func GenerateMigration(from, to DataModel, dialect string) (Migration, error) {
+	oldSchema, err := buildSchema(from, dialect)
+	if err != nil {
+		return Migration{}, err
+	}
+	newSchema, err := buildSchema(to, dialect)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	return Migration{
+		Up:   strings.Join(migrationStatements(oldSchema, newSchema), "\n"),
+		Down: strings.Join(migrationStatements(newSchema, oldSchema), "\n"),
+	}, nil
+}
+
+// tableChange is the diff of a table present in both schemas
+type tableChange struct {
+	Old, New *sqlTable
+
+	AddedColumns   []sqlColumn
+	DroppedColumns []sqlColumn
+	ChangedColumns [][2]sqlColumn // old, new
+
+	DroppedConstraints []sqlConstraint // removed or changed
+	AddedConstraints   []sqlConstraint // added or changed
+	DroppedIndexes     []sqlIndex
+	AddedIndexes       []sqlIndex
+}
+
+func (c *tableChange) empty() bool {
+	return len(c.AddedColumns) == 0 && len(c.DroppedColumns) == 0 && len(c.ChangedColumns) == 0 &&
+		len(c.DroppedConstraints) == 0 && len(c.AddedConstraints) == 0 &&
+		len(c.DroppedIndexes) == 0 && len(c.AddedIndexes) == 0
+}
+
+// needsRebuild reports whether SQLite has to recreate the table: it can only
+// add nullable (or defaulted) columns and indexes in place
+func (c *tableChange) needsRebuild() bool {
+	if len(c.DroppedColumns) > 0 || len(c.ChangedColumns) > 0 || len(c.DroppedConstraints) > 0 || len(c.AddedConstraints) > 0 {
+		return true
+	}
+	for _, col := range c.AddedColumns {
+		if col.NotNull && col.Default == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// migrationStatements renders the statements turning schema a into b
+func migrationStatements(a, b *sqlSchema) []string {
+	dialect := b.Dialect
+	var stmts []string
+	add := func(format string, args ...interface{}) {
+		stmts = append(stmts, fmt.Sprintf(format, args...))
+	}
+
+	oldTables := tableIndex(a.Tables)
+	newTables := tableIndex(b.Tables)
+
+	var changes []*tableChange
+	var added []*sqlTable
+	for _, t := range b.Tables {
+		old, ok := oldTables[strings.ToLower(t.Name)]
+		if !ok {
+			added = append(added, t)
+			continue
+		}
+		if c := diffTable(old, t); !c.empty() {
+			changes = append(changes, c)
+		}
+	}
+
+	// Enum types (postgres): new types and values first, drops last
+	oldEnums := make(map[string]formatter.DataEnum)
+	for _, e := range a.Enums {
+		oldEnums[strings.ToLower(e.Name)] = e
+	}
+	newEnums := make(map[string]bool)
+	for _, e := range b.Enums {
+		newEnums[strings.ToLower(e.Name)] = true
+		old, ok := oldEnums[strings.ToLower(e.Name)]
+		if !ok {
+			add("%s", createEnumSQL(e))
+			continue
+		}
+		oldValues := make(map[string]bool)
+		for _, v := range old.Values {
+			oldValues[v] = true
+		}
+		newValues := make(map[string]bool)
+		for _, v := range e.Values {
+			newValues[v] = true
+			if !oldValues[v] {
+				add("ALTER TYPE %s ADD VALUE %s;", quoteIdent(e.Name, dialect), sqlStringList([]string{v}))
+			}
+		}
+		for _, v := range old.Values {
+			if !newValues[v] {
+				add("-- WARNING: enum %s no longer has value %s; PostgreSQL cannot drop enum values, migrate rows and recreate the type manually", e.Name, sqlStringList([]string{v}))
+			}
+		}
+	}
+
+	rebuild := func(c *tableChange) bool {
+		return dialect == DialectSQLite && c.needsRebuild()
+	}
+
+	// Drop changed constraints and indexes before touching columns;
+	// foreign keys first so that nothing depends on what is dropped next
+	for _, kind := range []string{constraintFK, ""} {
+		for _, c := range changes {
+			if rebuild(c) {
+				continue
+			}
+			for _, con := range c.DroppedConstraints {
+				if (kind == constraintFK) == (con.Kind == constraintFK) {
+					add("%s", dropConstraintSQL(c.New.Name, con, dialect))
+				}
+			}
+		}
+	}
+	for _, c := range changes {
+		if rebuild(c) {
+			continue
+		}
+		for _, idx := range c.DroppedIndexes {
+			add("%s", dropIndexSQL(c.New.Name, idx, dialect))
+		}
+	}
+
+	// Dropped tables, dependents first; foreign keys into them are gone by now
+	for i := len(a.Tables) - 1; i >= 0; i-- {
+		t := a.Tables[i]
+		if _, ok := newTables[strings.ToLower(t.Name)]; ok {
+			continue
+		}
+		add("-- WARNING: drops table %s and its data", t.Name)
+		add("DROP TABLE %s;", quoteIdent(t.Name, dialect))
+	}
+
+	// New tables, in dependency order
+	for _, t := range added {
+		add("%s", createTableSQL(t, t.Name, dialect))
+		for _, idx := range t.Indexes {
+			add("%s", createIndexSQL(t.Name, idx, dialect))
+		}
+	}
+
+	// Column changes
+	for _, c := range changes {
+		table := quoteIdent(c.New.Name, dialect)
+		if rebuild(c) {
+			stmts = append(stmts, rebuildTableSQL(c, dialect)...)
+			continue
+		}
+		for _, col := range c.AddedColumns {
+			if col.NotNull && col.Default == "" {
+				add("-- WARNING: %s.%s is NOT NULL without a default; existing rows need a value", c.New.Name, col.Name)
+			}
+			add("ALTER TABLE %s ADD COLUMN %s;", table, columnSQL(col, dialect))
+		}
+		for _, pair := range c.ChangedColumns {
+			stmts = append(stmts, alterColumnSQL(c.New.Name, pair[0], pair[1], dialect)...)
+		}
+		for _, col := range c.DroppedColumns {
+			add("-- WARNING: drops column %s.%s and its data", c.New.Name, col.Name)
+			add("ALTER TABLE %s DROP COLUMN %s;", table, quoteIdent(col.Name, dialect))
+		}
+	}
+
+	// Add changed constraints and indexes; foreign keys last
+	for _, kind := range []string{"", constraintFK} {
+		for _, c := range changes {
+			if rebuild(c) {
+				continue
+			}
+			for _, con := range c.AddedConstraints {
+				if (kind == constraintFK) == (con.Kind == constraintFK) {
+					add("ALTER TABLE %s ADD CONSTRAINT %s %s;", quoteIdent(c.New.Name, dialect), quoteIdent(con.Name, dialect), con.Def)
+				}
+			}
+		}
+	}
+	for _, c := range changes {
+		if rebuild(c) {
+			continue
+		}
+		for _, idx := range c.AddedIndexes {
+			add("%s", createIndexSQL(c.New.Name, idx, dialect))
+		}
+	}
+
+	for _, e := range a.Enums {
+		if !newEnums[strings.ToLower(e.Name)] {
+			add("DROP TYPE %s;", quoteIdent(e.Name, dialect))
+		}
+	}
+
+	return stmts
+}
+
+func tableIndex(tables []*sqlTable) map[string]*sqlTable {
+	index := make(map[string]*sqlTable, len(tables))
+	for _, t := range tables {
+		index[strings.ToLower(t.Name)] = t
+	}
+	return index
+}
+
+// diffTable compares two versions of a table by column, constraint and
+// index name
+func diffTable(old, new *sqlTable) *tableChange {
+	c := &tableChange{Old: old, New: new}
+
+	oldCols := make(map[string]sqlColumn)
+	for _, col := range old.Columns {
+		oldCols[strings.ToLower(col.Name)] = col
+	}
+	newCols := make(map[string]bool)
+	for _, col := range new.Columns {
+		newCols[strings.ToLower(col.Name)] = true
+		prev, ok := oldCols[strings.ToLower(col.Name)]
+		switch {
+		case !ok:
+			c.AddedColumns = append(c.AddedColumns, col)
+		case prev.Type != col.Type || prev.NotNull != col.NotNull || prev.Default != col.Default:
+			c.ChangedColumns = append(c.ChangedColumns, [2]sqlColumn{prev, col})
+		}
+	}
+	for _, col := range old.Columns {
+		if !newCols[strings.ToLower(col.Name)] {
+			c.DroppedColumns = append(c.DroppedColumns, col)
+		}
+	}
+
+	oldCons := make(map[string]sqlConstraint)
+	for _, con := range old.Constraints {
+		oldCons[strings.ToLower(con.Name)] = con
+	}
+	newCons := make(map[string]sqlConstraint)
+	for _, con := range new.Constraints {
+		newCons[strings.ToLower(con.Name)] = con
+		if prev, ok := oldCons[strings.ToLower(con.Name)]; !ok || prev.Def != con.Def {
+			c.AddedConstraints = append(c.AddedConstraints, con)
+		}
+	}
+	for _, con := range old.Constraints {
+		if next, ok := newCons[strings.ToLower(con.Name)]; !ok || next.Def != con.Def {
+			c.DroppedConstraints = append(c.DroppedConstraints, con)
+		}
+	}
+
+	oldIdx := make(map[string]sqlIndex)
+	for _, idx := range old.Indexes {
+		oldIdx[strings.ToLower(idx.Name)] = idx
+	}
+	newIdx := make(map[string]sqlIndex)
+	for _, idx := range new.Indexes {
+		newIdx[strings.ToLower(idx.Name)] = idx
+		if prev, ok := oldIdx[strings.ToLower(idx.Name)]; !ok || !sameColumns(prev.Columns, idx.Columns) {
+			c.AddedIndexes = append(c.AddedIndexes, idx)
+		}
+	}
+	for _, idx := range old.Indexes {
+		if next, ok := newIdx[strings.ToLower(idx.Name)]; !ok || !sameColumns(next.Columns, idx.Columns) {
+			c.DroppedIndexes = append(c.DroppedIndexes, idx)
+		}
+	}
+
+	return c
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func dropConstraintSQL(table string, con sqlConstraint, dialect string) string {
+	t, name := quoteIdent(table, dialect), quoteIdent(con.Name, dialect)
+	if dialect == DialectMySQL {
+		switch con.Kind {
+		case constraintPK:
+			return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;", t)
+		case constraintFK:
+			return fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;", t, name)
+		case constraintUnique:
+			return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s;", t, name)
+		case constraintCheck:
+			return fmt.Sprintf("ALTER TABLE %s DROP CHECK %s;", t, name)
+		}
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", t, name)
+}
+
+// alterColumnSQL changes a column in place (postgres, mysql)
+func alterColumnSQL(table string, old, new sqlColumn, dialect string) []string {
+	t, col := quoteIdent(table, dialect), quoteIdent(new.Name, dialect)
+	if dialect == DialectMySQL {
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;", t, columnSQL(new, dialect))}
+	}
+
+	var stmts []string
+	if old.Type != new.Type {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;", t, col, new.Type, col, new.Type))
+	}
+	if old.NotNull != new.NotNull {
+		if new.NotNull {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", t, col))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", t, col))
+		}
+	}
+	if old.Default != new.Default {
+		if new.Default != "" {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", t, col, new.Default))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", t, col))
+		}
+	}
+	return stmts
+}
+
+// rebuildTableSQL recreates a SQLite table with the new definition and
+// copies the columns both versions share
+func rebuildTableSQL(c *tableChange, dialect string) []string {
+	name := c.New.Name
+	tmp := name + "__new"
+
+	oldCols := make(map[string]bool)
+	for _, col := range c.Old.Columns {
+		oldCols[strings.ToLower(col.Name)] = true
+	}
+	var common []string
+	for _, col := range c.New.Columns {
+		if oldCols[strings.ToLower(col.Name)] {
+			common = append(common, col.Name)
+		}
+	}
+
+	stmts := []string{
+		fmt.Sprintf("-- Rebuild %s: SQLite cannot alter columns or constraints in place.", name),
+		"-- Run with PRAGMA foreign_keys = OFF so that dropping the old table does not cascade.",
+	}
+	for _, col := range c.DroppedColumns {
+		stmts = append(stmts, fmt.Sprintf("-- WARNING: drops column %s.%s and its data", name, col.Name))
+	}
+	stmts = append(stmts, createTableSQL(c.New, tmp, dialect))
+	if len(common) > 0 {
+		cols := quoteIdents(common, dialect)
+		stmts = append(stmts, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;", quoteIdent(tmp, dialect), cols, cols, quoteIdent(name, dialect)))
+	}
+	stmts = append(stmts,
+		fmt.Sprintf("DROP TABLE %s;", quoteIdent(name, dialect)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", quoteIdent(tmp, dialect), quoteIdent(name, dialect)),
+	)
+	for _, idx := range c.New.Indexes {
+		stmts = append(stmts, createIndexSQL(name, idx, dialect))
+	}
+	return stmts
+}
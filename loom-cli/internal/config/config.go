@@ -18,6 +18,7 @@ type Config struct {
 	NFRFile        string // Optional non-functional requirements
 	Format         string // "text" or "json"
 	BatchMode      bool   // Non-interactive mode
+	Full           bool   // Ignore the analysis cache (analyze)
 	Verbose        bool
 }
 
@@ -52,6 +53,9 @@ func ParseArgsForAnalyze(args []string) (*Config, error) {
 			i++
 			cfg.DecisionsFile = args[i]
 
+		case "--full":
+			cfg.Full = true
+
 		case "--verbose", "-v":
 			cfg.Verbose = true
 		}
@@ -123,6 +127,20 @@ func ParseArgsForDerive(args []string) (*Config, error) {
 			i++
 			cfg.NFRFile = args[i]
 
+		case "--input-file":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--input-file requires a value")
+			}
+			i++
+			cfg.InputFile = args[i]
+
+		case "--input-dir":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--input-dir requires a value")
+			}
+			i++
+			cfg.InputDir = args[i]
+
 		case "--verbose", "-v":
 			cfg.Verbose = true
 		}
@@ -0,0 +1,222 @@
+package decisions
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// CorpusFileName is the file name indexed when scanning a corpus root
+const CorpusFileName = "decisions.md"
+
+// DefaultMinSimilarity is the lowest similarity reported by Corpus.Similar
+const DefaultMinSimilarity = 0.5
+
+// CorpusEntry is a decision taken in another project
+type CorpusEntry struct {
+	// Project is the name of the project the decision belongs to
+	Project string `json:"project"`
+
+	// Path is the decisions.md file the decision was read from
+	Path string `json:"path"`
+
+	// Decision is the recorded decision
+	Decision AmbiguityDecision `json:"decision"`
+
+	// terms are the weighted question terms, normalised to unit length
+	terms map[string]float64
+}
+
+// CorpusMatch is a corpus entry similar to a question
+type CorpusMatch struct {
+	Entry      *CorpusEntry
+	Similarity float64
+}
+
+// Corpus is a lexical index over decisions recorded in other projects
+type Corpus struct {
+	Entries []*CorpusEntry
+
+	// docFreq counts the entries each term occurs in
+	docFreq map[string]int
+}
+
+// BuildCorpus scans the given roots for decisions.md files and indexes
+// their questions. Each root may be a single project or a directory of
+// projects; the project name is the first directory below the root, or
+// the root's own name when it is a project itself.
+func BuildCorpus(roots []string) (*Corpus, error) {
+	c := &Corpus{
+		docFreq: make(map[string]int),
+	}
+
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+
+		err = filepath.Walk(absRoot, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil // Skip unreadable entries
+			}
+			if info.IsDir() {
+				name := info.Name()
+				if path != absRoot && (strings.HasPrefix(name, ".") || name == "node_modules") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Name() != CorpusFileName {
+				return nil
+			}
+
+			ds, err := Load(path)
+			if err != nil {
+				return nil // Skip files that cannot be parsed
+			}
+
+			project := corpusProject(absRoot, path)
+			for _, d := range ds.Decisions {
+				c.add(&CorpusEntry{
+					Project:  project,
+					Path:     path,
+					Decision: d,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.weight()
+	return c, nil
+}
+
+// corpusProject derives the project name for a decisions.md under root. A
+// root holding a loom store is a single project named after the root.
+func corpusProject(root, path string) string {
+	if info, err := os.Stat(filepath.Join(root, StoreDirName)); err == nil && info.IsDir() {
+		return filepath.Base(root)
+	}
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return filepath.Base(root)
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")[0]
+}
+
+// add indexes an entry; weights are finalised by weight
+func (c *Corpus) add(e *CorpusEntry) {
+	e.terms = termFrequencies(e.Decision.Question)
+	for term := range e.terms {
+		c.docFreq[term]++
+	}
+	c.Entries = append(c.Entries, e)
+}
+
+// weight converts the raw term frequencies of every entry to unit TF-IDF vectors
+func (c *Corpus) weight() {
+	for _, e := range c.Entries {
+		for term, tf := range e.terms {
+			e.terms[term] = tf * c.idf(term)
+		}
+		normalize(e.terms)
+	}
+}
+
+// idf returns the smoothed inverse document frequency of a term
+func (c *Corpus) idf(term string) float64 {
+	return math.Log(1+float64(len(c.Entries))/float64(1+c.docFreq[term])) + 1
+}
+
+// Similar returns up to limit entries whose question is similar to the
+// given question, most similar first. Entries below minSimilarity are
+// dropped.
+func (c *Corpus) Similar(question string, limit int, minSimilarity float64) []CorpusMatch {
+	if c == nil || len(c.Entries) == 0 {
+		return nil
+	}
+
+	query := termFrequencies(question)
+	for term, tf := range query {
+		query[term] = tf * c.idf(term)
+	}
+	normalize(query)
+
+	var matches []CorpusMatch
+	for _, e := range c.Entries {
+		score := 0.0
+		for term, w := range query {
+			score += w * e.terms[term]
+		}
+		if score >= minSimilarity {
+			matches = append(matches, CorpusMatch{Entry: e, Similarity: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Similarity > matches[j].Similarity
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// termFrequencies tokenizes text into lowercase terms with their counts
+func termFrequencies(text string) map[string]float64 {
+	tf := make(map[string]float64)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if len(w) < 2 || stopWords[w] {
+			continue
+		}
+		tf[stem(w)]++
+	}
+	return tf
+}
+
+// stem strips a plural "s" so that "orders" and "order" match
+func stem(w string) string {
+	if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// normalize scales a term vector to unit length
+func normalize(v map[string]float64) {
+	var sum float64
+	for _, w := range v {
+		sum += w * w
+	}
+	if sum == 0 {
+		return
+	}
+	norm := math.Sqrt(sum)
+	for term := range v {
+		v[term] /= norm
+	}
+}
+
+// stopWords are ignored when comparing questions
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "can": true, "do": true, "does": true, "for": true,
+	"from": true, "how": true, "if": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "should": true, "that": true, "the": true,
+	"there": true, "this": true, "to": true, "what": true, "when": true,
+	"which": true, "who": true, "will": true, "with": true, "we": true,
+}
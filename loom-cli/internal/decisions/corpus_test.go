@@ -0,0 +1,153 @@
+package decisions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCorpusProject(t *testing.T, root, project string, ds *DecisionSet) {
+	t.Helper()
+	path := filepath.Join(root, project, "input", CorpusFileName)
+	if err := ds.WriteToFile(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildCorpus_IndexesProjects(t *testing.T) {
+	root := t.TempDir()
+
+	writeCorpusProject(t, root, "billing", &DecisionSet{Decisions: []AmbiguityDecision{
+		{AmbiguityID: "AMB-ENT-001", Question: "Can orders be partially cancelled?", Answer: "Yes, per line item", Source: "user"},
+		{AmbiguityID: "AMB-ENT-002", Question: "What currency do invoices use?", Answer: "EUR only", Source: "user"},
+	}})
+	writeCorpusProject(t, root, "shipping", &DecisionSet{Decisions: []AmbiguityDecision{
+		{AmbiguityID: "AMB-OP-001", Question: "How long are tracking events retained?", Answer: "90 days", Source: "default"},
+	}})
+
+	// Hidden directories are not scanned
+	hidden := &DecisionSet{Decisions: []AmbiguityDecision{{AmbiguityID: "AMB-X-001", Question: "Q?", Answer: "A"}}}
+	if err := hidden.WriteToFile(filepath.Join(root, ".cache", CorpusFileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := BuildCorpus([]string{root})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(c.Entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(c.Entries))
+	}
+
+	projects := make(map[string]int)
+	for _, e := range c.Entries {
+		projects[e.Project]++
+	}
+	if projects["billing"] != 2 || projects["shipping"] != 1 {
+		t.Errorf("Unexpected project counts %v", projects)
+	}
+}
+
+func TestCorpus_Similar(t *testing.T) {
+	root := t.TempDir()
+	writeCorpusProject(t, root, "billing", &DecisionSet{Decisions: []AmbiguityDecision{
+		{AmbiguityID: "AMB-ENT-001", Question: "Can orders be partially cancelled?", Answer: "Yes, per line item", Source: "user"},
+		{AmbiguityID: "AMB-ENT-002", Question: "What currency do invoices use?", Answer: "EUR only", Source: "user"},
+		{AmbiguityID: "AMB-ENT-003", Question: "Is the customer email unique?", Answer: "Yes", Source: "user"},
+	}})
+
+	c, err := BuildCorpus([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := c.Similar("Can an order be partially cancelled by the customer?", 3, DefaultMinSimilarity)
+	if len(matches) == 0 {
+		t.Fatal("Expected at least one match")
+	}
+	if matches[0].Entry.Decision.AmbiguityID != "AMB-ENT-001" {
+		t.Errorf("Expected best match AMB-ENT-001, got %s", matches[0].Entry.Decision.AmbiguityID)
+	}
+	if matches[0].Entry.Project != "billing" {
+		t.Errorf("Expected project 'billing', got '%s'", matches[0].Entry.Project)
+	}
+	for i := 1; i < len(matches); i++ {
+		if matches[i].Similarity > matches[i-1].Similarity {
+			t.Error("Expected matches sorted by similarity")
+		}
+	}
+
+	// Unrelated question has no match
+	if got := c.Similar("How many warehouses are supported?", 3, DefaultMinSimilarity); len(got) != 0 {
+		t.Errorf("Expected no matches, got %d", len(got))
+	}
+}
+
+func TestCorpus_SimilarIdenticalQuestion(t *testing.T) {
+	root := t.TempDir()
+	writeCorpusProject(t, root, "p1", &DecisionSet{Decisions: []AmbiguityDecision{
+		{AmbiguityID: "AMB-ENT-001", Question: "Must station names be unique?", Answer: "Yes", Source: "user"},
+	}})
+
+	c, err := BuildCorpus([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := c.Similar("Must station names be unique?", 1, 0)
+	if len(matches) != 1 {
+		t.Fatalf("Expected 1 match, got %d", len(matches))
+	}
+	if matches[0].Similarity < 0.99 {
+		t.Errorf("Expected similarity ~1 for identical question, got %f", matches[0].Similarity)
+	}
+}
+
+func TestBuildCorpus_MissingRoot(t *testing.T) {
+	c, err := BuildCorpus([]string{filepath.Join(t.TempDir(), "missing")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(c.Entries) != 0 {
+		t.Errorf("Expected empty corpus, got %d entries", len(c.Entries))
+	}
+}
+
+func TestCorpus_SingleProjectRoot(t *testing.T) {
+	root := t.TempDir()
+	ds := &DecisionSet{Decisions: []AmbiguityDecision{{AmbiguityID: "AMB-ENT-001", Question: "Q?", Answer: "A"}}}
+	if err := ds.WriteToFile(filepath.Join(root, CorpusFileName)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, CorpusFileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := BuildCorpus([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Entries) != 1 || c.Entries[0].Project != filepath.Base(root) {
+		t.Errorf("Expected one entry for project %s", filepath.Base(root))
+	}
+}
+
+func TestCorpus_SingleProjectRootWithSubdirectory(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "shop")
+	if err := os.MkdirAll(filepath.Join(root, StoreDirName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ds := &DecisionSet{Decisions: []AmbiguityDecision{{AmbiguityID: "AMB-ENT-001", Question: "Q?", Answer: "A"}}}
+	if err := ds.WriteToFile(filepath.Join(root, "input", CorpusFileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := BuildCorpus([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Entries) != 1 || c.Entries[0].Project != "shop" {
+		t.Errorf("Expected one entry for project shop, got %v", c.Entries)
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
@@ -19,6 +17,8 @@ type AmbiguityDecision struct {
 	Answer      string    `json:"answer"`
 	Source      string    `json:"source"` // "user", "default", "existing"
 	Category    string    `json:"category"`
+	Subject     string    `json:"subject,omitempty"`
+	ParentID    string    `json:"parent_id,omitempty"` // question whose answer raised this one
 	Severity    string    `json:"severity"`
 	DecidedAt   time.Time `json:"decided_at"`
 }
@@ -28,83 +28,20 @@ type DecisionSet struct {
 	Decisions []AmbiguityDecision `json:"decisions"`
 }
 
-// LoadFromFile loads existing decisions from a decisions.md file
+// LoadFromFile loads existing decisions from a decisions.md file.
+// Both the canonical format written by WriteToFile and the legacy
+// bullet-list format are accepted.
 func LoadFromFile(path string) (*DecisionSet, error) {
-	ds := &DecisionSet{
-		Decisions: []AmbiguityDecision{},
-	}
-
 	content, err := os.ReadFile(path)
 	if os.IsNotExist(err) {
 		// No existing file, return empty set
-		return ds, nil
+		return &DecisionSet{Decisions: []AmbiguityDecision{}}, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read decisions file: %w", err)
 	}
 
-	// Parse decisions from markdown
-	// Looking for pattern: ### AMB-XXX-NNN: ...
-	// **Question:** ...
-	// **Decision:** ...
-	// **Source:** ...
-
-	lines := strings.Split(string(content), "\n")
-	var currentID, currentQuestion, currentAnswer, currentSource string
-
-	ambIDRegex := regexp.MustCompile(`^###\s+(AMB-[A-Z]+-\d+)`)
-
-	for i := 0; i < len(lines); i++ {
-		line := lines[i]
-
-		// Check for ambiguity header
-		if matches := ambIDRegex.FindStringSubmatch(line); matches != nil {
-			// Save previous if exists
-			if currentID != "" && currentAnswer != "" {
-				ds.Decisions = append(ds.Decisions, AmbiguityDecision{
-					AmbiguityID: currentID,
-					Question:    currentQuestion,
-					Answer:      currentAnswer,
-					Source:      currentSource,
-				})
-			}
-			currentID = matches[1]
-			currentQuestion = ""
-			currentAnswer = ""
-			currentSource = "existing"
-			continue
-		}
-
-		// Check for Question line
-		if strings.HasPrefix(line, "**Question:**") {
-			currentQuestion = strings.TrimSpace(strings.TrimPrefix(line, "**Question:**"))
-			continue
-		}
-
-		// Check for Decision line
-		if strings.HasPrefix(line, "**Decision:**") {
-			currentAnswer = strings.TrimSpace(strings.TrimPrefix(line, "**Decision:**"))
-			continue
-		}
-
-		// Check for Source line
-		if strings.HasPrefix(line, "**Source:**") {
-			currentSource = strings.TrimSpace(strings.TrimPrefix(line, "**Source:**"))
-			continue
-		}
-	}
-
-	// Save last one
-	if currentID != "" && currentAnswer != "" {
-		ds.Decisions = append(ds.Decisions, AmbiguityDecision{
-			AmbiguityID: currentID,
-			Question:    currentQuestion,
-			Answer:      currentAnswer,
-			Source:      currentSource,
-		})
-	}
-
-	return ds, nil
+	return ParseMarkdown(string(content))
 }
 
 // HasDecision checks if a decision exists for the given ambiguity ID
@@ -141,7 +78,7 @@ func (ds *DecisionSet) AddDecision(d AmbiguityDecision) {
 
 // ResolveAmbiguities resolves ambiguities either interactively or with defaults
 func ResolveAmbiguities(
-	ambiguities []domain.Step1_5Ambiguity,
+	ambiguities []domain.Ambiguity,
 	existingDecisions *DecisionSet,
 	interactive bool,
 	criticalOnly bool,
@@ -176,16 +113,21 @@ func ResolveAmbiguities(
 			// Interactive mode
 			fmt.Fprintf(os.Stderr, "\n%s [%s] (%s)\n", amb.ID, amb.Category, amb.Severity)
 			fmt.Fprintf(os.Stderr, "Question: %s\n", amb.Question)
-			fmt.Fprintf(os.Stderr, "Why important: %s\n", amb.WhyImportant)
+			if amb.ChecklistItem != "" {
+				fmt.Fprintf(os.Stderr, "Checklist: %s\n", amb.ChecklistItem)
+			}
 
-			if len(amb.SuggestedOptions) > 0 {
+			if len(amb.Options) > 0 {
 				fmt.Fprintf(os.Stderr, "\nOptions:\n")
-				for i, opt := range amb.SuggestedOptions {
+				for i, opt := range amb.Options {
 					fmt.Fprintf(os.Stderr, "  [%d] %s\n", i+1, opt)
 				}
-				if amb.DefaultSuggestion != "" {
-					fmt.Fprintf(os.Stderr, "  [Enter] Use default: %s\n", amb.DefaultSuggestion)
-				}
+			}
+			if amb.SuggestedAnswer != "" {
+				fmt.Fprintf(os.Stderr, "  [Enter] Use default: %s\n", amb.SuggestedAnswer)
+			}
+			for _, prior := range amb.PriorDecisions {
+				fmt.Fprintf(os.Stderr, "  Note: %s\n", prior.Summary)
 			}
 
 			fmt.Fprintf(os.Stderr, "\nYour answer (or number, or Enter for default): ")
@@ -199,13 +141,13 @@ func ResolveAmbiguities(
 
 			if input == "" {
 				// Use default
-				answer = amb.DefaultSuggestion
+				answer = amb.SuggestedAnswer
 				source = "default"
 			} else if len(input) == 1 && input[0] >= '1' && input[0] <= '9' {
 				// Number selection
 				idx := int(input[0] - '1')
-				if idx < len(amb.SuggestedOptions) {
-					answer = amb.SuggestedOptions[idx]
+				if idx < len(amb.Options) {
+					answer = amb.Options[idx]
 					source = "user"
 				} else {
 					answer = input
@@ -217,7 +159,7 @@ func ResolveAmbiguities(
 			}
 		} else {
 			// Use default
-			answer = amb.DefaultSuggestion
+			answer = amb.SuggestedAnswer
 			source = "default"
 			fmt.Fprintf(os.Stderr, "  [%s] %s -> %s (default)\n", amb.ID, truncate(amb.Question, 40), truncate(answer, 30))
 		}
@@ -227,7 +169,8 @@ func ResolveAmbiguities(
 			Question:    amb.Question,
 			Answer:      answer,
 			Source:      source,
-			Category:    string(amb.Category),
+			Category:    amb.Category,
+			Subject:     amb.Subject,
 			Severity:    string(amb.Severity),
 			DecidedAt:   time.Now(),
 		})
@@ -238,76 +181,7 @@ func ResolveAmbiguities(
 
 // WriteToFile writes decisions to a markdown file
 func (ds *DecisionSet) WriteToFile(path string) error {
-	// Ensure directory exists
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", dir, err)
-	}
-
-	var sb strings.Builder
-
-	// Header
-	sb.WriteString("---\n")
-	sb.WriteString("title: \"Ambiguity Decisions\"\n")
-	sb.WriteString(fmt.Sprintf("generated: %s\n", time.Now().Format(time.RFC3339)))
-	sb.WriteString("status: draft\n")
-	sb.WriteString("level: L0\n")
-	sb.WriteString("---\n\n")
-
-	sb.WriteString("# Ambiguity Decisions\n\n")
-	sb.WriteString("This document records decisions that resolve ambiguities identified during L0 analysis.\n")
-	sb.WriteString("These decisions inform the domain modeling process.\n\n")
-	sb.WriteString("---\n\n")
-
-	// Group by category
-	categories := make(map[string][]AmbiguityDecision)
-	categoryOrder := []string{}
-
-	for _, d := range ds.Decisions {
-		if _, exists := categories[d.Category]; !exists {
-			categoryOrder = append(categoryOrder, d.Category)
-		}
-		categories[d.Category] = append(categories[d.Category], d)
-	}
-
-	for _, cat := range categoryOrder {
-		decisions := categories[cat]
-		sb.WriteString(fmt.Sprintf("## %s\n\n", formatCategory(cat)))
-
-		for _, d := range decisions {
-			sb.WriteString(fmt.Sprintf("### %s\n\n", d.AmbiguityID))
-			sb.WriteString(fmt.Sprintf("**Question:** %s\n\n", d.Question))
-			sb.WriteString(fmt.Sprintf("**Decision:** %s\n\n", d.Answer))
-			sb.WriteString(fmt.Sprintf("**Source:** %s\n\n", d.Source))
-			sb.WriteString("---\n\n")
-		}
-	}
-
-	// Summary
-	sb.WriteString("## Summary\n\n")
-	sb.WriteString("| ID | Category | Severity | Source |\n")
-	sb.WriteString("|----|----------|----------|--------|\n")
-
-	userCount, defaultCount, existingCount := 0, 0, 0
-	for _, d := range ds.Decisions {
-		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", d.AmbiguityID, d.Category, d.Severity, d.Source))
-		switch d.Source {
-		case "user":
-			userCount++
-		case "default":
-			defaultCount++
-		case "existing":
-			existingCount++
-		}
-	}
-
-	sb.WriteString(fmt.Sprintf("\n**Statistics:**\n"))
-	sb.WriteString(fmt.Sprintf("- Total decisions: %d\n", len(ds.Decisions)))
-	sb.WriteString(fmt.Sprintf("- User: %d\n", userCount))
-	sb.WriteString(fmt.Sprintf("- Default: %d\n", defaultCount))
-	sb.WriteString(fmt.Sprintf("- Existing: %d\n", existingCount))
-
-	return os.WriteFile(path, []byte(sb.String()), 0644)
+	return writeMarkdown(path, ds.RenderMarkdown(time.Now()))
 }
 
 func truncate(s string, maxLen int) string {
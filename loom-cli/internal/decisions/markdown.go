@@ -0,0 +1,372 @@
+package decisions
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Markdown field labels used by the canonical decisions.md format
+const (
+	fieldQuestion = "**Question:**"
+	fieldDecision = "**Decision:**"
+	fieldSubject  = "**Subject:**"
+	fieldCategory = "**Category:**"
+	fieldSeverity = "**Severity:**"
+	fieldSource   = "**Source:**"
+	fieldDecided  = "**Decided:**"
+	fieldParent   = "**Follow-up of:**"
+)
+
+// continuationIndent prefixes the second and following lines of a
+// multi-line field value so that it can be parsed back verbatim.
+const continuationIndent = "  "
+
+var (
+	// ### AMB-DEF-001
+	headingIDRegex = regexp.MustCompile(`^###\s+(AMB-[A-Z0-9]+(?:-[A-Z0-9]+)*)\s*$`)
+	// - **AMB-ENT-001: Deletion behavior**
+	bulletIDRegex = regexp.MustCompile(`^- \*\*(AMB-[A-Z0-9]+(?:-[A-Z0-9]+)*):\s*(.*?)\*\*\s*$`)
+	// ## Entity Decisions
+	sectionRegex = regexp.MustCompile(`^##\s+(.+?)\s*$`)
+	// format_version: 1
+	formatVersionRegex = regexp.MustCompile(`^format_version:\s*(\d+)\s*$`)
+)
+
+// legacyDateLayouts are the timestamp layouts accepted on "Decided:" lines
+var legacyDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// RenderMarkdown renders the decision set as decisions.md content.
+// Every field of AmbiguityDecision is written, so ParseMarkdown can
+// restore the set without loss.
+func (ds *DecisionSet) RenderMarkdown(generated time.Time) string {
+	var sb strings.Builder
+
+	// Header
+	sb.WriteString("---\n")
+	sb.WriteString("title: \"Ambiguity Decisions\"\n")
+	sb.WriteString(fmt.Sprintf("generated: %s\n", generated.Format(time.RFC3339)))
+	sb.WriteString("status: draft\n")
+	sb.WriteString("level: L0\n")
+	sb.WriteString(fmt.Sprintf("format_version: %d\n", FormatVersion))
+	sb.WriteString("---\n\n")
+
+	sb.WriteString("# Ambiguity Decisions\n\n")
+	sb.WriteString("This document records decisions that resolve ambiguities identified during L0 analysis.\n")
+	sb.WriteString("These decisions inform the domain modeling process.\n\n")
+	sb.WriteString("---\n\n")
+
+	// Group by category
+	categories := make(map[string][]AmbiguityDecision)
+	categoryOrder := []string{}
+
+	for _, d := range ds.Decisions {
+		if _, exists := categories[d.Category]; !exists {
+			categoryOrder = append(categoryOrder, d.Category)
+		}
+		categories[d.Category] = append(categories[d.Category], d)
+	}
+
+	for _, cat := range categoryOrder {
+		decisions := categories[cat]
+		sb.WriteString(fmt.Sprintf("## %s\n\n", formatCategory(cat)))
+
+		for _, d := range decisions {
+			sb.WriteString(fmt.Sprintf("### %s\n\n", d.AmbiguityID))
+			writeField(&sb, fieldQuestion, d.Question)
+			writeField(&sb, fieldDecision, d.Answer)
+			if d.Subject != "" {
+				writeField(&sb, fieldSubject, d.Subject)
+			}
+			if d.Category != "" {
+				writeField(&sb, fieldCategory, d.Category)
+			}
+			if d.Severity != "" {
+				writeField(&sb, fieldSeverity, d.Severity)
+			}
+			writeField(&sb, fieldSource, d.Source)
+			if !d.DecidedAt.IsZero() {
+				writeField(&sb, fieldDecided, d.DecidedAt.Format(time.RFC3339Nano))
+			}
+			if d.ParentID != "" {
+				writeField(&sb, fieldParent, d.ParentID)
+			}
+			sb.WriteString("---\n\n")
+		}
+	}
+
+	// Summary
+	sb.WriteString("## Summary\n\n")
+	sb.WriteString("| ID | Category | Severity | Source |\n")
+	sb.WriteString("|----|----------|----------|--------|\n")
+
+	userCount, defaultCount, existingCount := 0, 0, 0
+	for _, d := range ds.Decisions {
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", d.AmbiguityID, d.Category, d.Severity, d.Source))
+		switch d.Source {
+		case "user":
+			userCount++
+		case "default":
+			defaultCount++
+		case "existing":
+			existingCount++
+		}
+	}
+
+	sb.WriteString("\n**Statistics:**\n")
+	sb.WriteString(fmt.Sprintf("- Total decisions: %d\n", len(ds.Decisions)))
+	sb.WriteString(fmt.Sprintf("- User: %d\n", userCount))
+	sb.WriteString(fmt.Sprintf("- Default: %d\n", defaultCount))
+	sb.WriteString(fmt.Sprintf("- Existing: %d\n", existingCount))
+
+	return sb.String()
+}
+
+// writeField writes a "**Label:** value" line followed by a blank line.
+// Additional lines of a multi-line value are indented.
+func writeField(sb *strings.Builder, label, value string) {
+	lines := strings.Split(value, "\n")
+	sb.WriteString(fmt.Sprintf("%s %s\n", label, lines[0]))
+	for _, line := range lines[1:] {
+		sb.WriteString(continuationIndent + line + "\n")
+	}
+	sb.WriteString("\n")
+}
+
+// ParseMarkdown parses decisions.md content into a decision set.
+//
+// Two layouts are recognised:
+//   - the canonical layout written by RenderMarkdown (### AMB-... headings
+//     with **Field:** lines)
+//   - the legacy bullet layout from docs/decisions-format.md
+//     (- **AMB-...: Title** entries with Q:/A:/Decided: sub-items, and the
+//     "Defaults Accepted" table)
+//
+// Entries without an answer are ignored. If the same ID appears more than
+// once, the last occurrence wins.
+func ParseMarkdown(content string) (*DecisionSet, error) {
+	ds := &DecisionSet{
+		Decisions: []AmbiguityDecision{},
+	}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	start := 0
+
+	// Frontmatter
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		for i := 1; i < len(lines); i++ {
+			line := strings.TrimSpace(lines[i])
+			if line == "---" {
+				start = i + 1
+				break
+			}
+			if m := formatVersionRegex.FindStringSubmatch(line); m != nil {
+				version, _ := strconv.Atoi(m[1])
+				if version > FormatVersion {
+					return nil, fmt.Errorf("decisions.md format version %d is newer than supported version %d", version, FormatVersion)
+				}
+			}
+		}
+	}
+
+	var current *AmbiguityDecision
+	var field *string // field receiving continuation lines
+	legacy := false
+	section := ""
+
+	flush := func() {
+		if current != nil && current.AmbiguityID != "" && current.Answer != "" {
+			ds.AddDecision(*current)
+		}
+		current = nil
+		field = nil
+	}
+
+	for _, raw := range lines[start:] {
+		trimmed := strings.TrimSpace(raw)
+
+		// Continuation of a multi-line canonical field
+		if field != nil && strings.HasPrefix(raw, continuationIndent) {
+			*field += "\n" + strings.TrimPrefix(raw, continuationIndent)
+			continue
+		}
+		field = nil
+
+		if m := headingIDRegex.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			current = &AmbiguityDecision{
+				AmbiguityID: m[1],
+				Source:      "existing",
+				Category:    parseCategoryHeading(section),
+			}
+			legacy = false
+			continue
+		}
+
+		if m := bulletIDRegex.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			current = &AmbiguityDecision{
+				AmbiguityID: m[1],
+				Subject:     strings.TrimSpace(m[2]),
+				Source:      "existing",
+				Category:    parseCategoryHeading(section),
+			}
+			legacy = true
+			continue
+		}
+
+		if m := sectionRegex.FindStringSubmatch(trimmed); m != nil && !strings.HasPrefix(trimmed, "###") {
+			flush()
+			section = m[1]
+			continue
+		}
+
+		if trimmed == "---" {
+			field = nil
+			continue
+		}
+
+		if section == "Defaults Accepted" && strings.HasPrefix(trimmed, "|") {
+			if d, ok := parseDefaultsRow(trimmed); ok {
+				ds.AddDecision(d)
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if legacy {
+			parseLegacyLine(current, trimmed)
+			continue
+		}
+
+		field = parseCanonicalLine(current, raw)
+	}
+	flush()
+
+	return ds, nil
+}
+
+// parseCanonicalLine applies a "**Field:** value" line to the decision and
+// returns the field that subsequent indented lines continue, if any.
+func parseCanonicalLine(d *AmbiguityDecision, line string) *string {
+	value := func(label string) string {
+		return strings.TrimPrefix(strings.TrimPrefix(line, label), " ")
+	}
+
+	switch {
+	case strings.HasPrefix(line, fieldQuestion):
+		d.Question = value(fieldQuestion)
+		return &d.Question
+	case strings.HasPrefix(line, fieldDecision):
+		d.Answer = value(fieldDecision)
+		return &d.Answer
+	case strings.HasPrefix(line, fieldSubject):
+		d.Subject = value(fieldSubject)
+		return &d.Subject
+	case strings.HasPrefix(line, fieldCategory):
+		d.Category = strings.TrimSpace(value(fieldCategory))
+	case strings.HasPrefix(line, fieldSeverity):
+		d.Severity = strings.TrimSpace(value(fieldSeverity))
+	case strings.HasPrefix(line, fieldSource):
+		d.Source = strings.TrimSpace(value(fieldSource))
+	case strings.HasPrefix(line, fieldDecided):
+		d.DecidedAt = parseDecidedAt(strings.TrimSpace(value(fieldDecided)))
+	case strings.HasPrefix(line, fieldParent):
+		d.ParentID = strings.TrimSpace(value(fieldParent))
+	}
+	return nil
+}
+
+// parseLegacyLine applies a "- Q:", "- A:" or "- Decided:" sub-item of the
+// bullet layout to the decision.
+func parseLegacyLine(d *AmbiguityDecision, trimmed string) {
+	switch {
+	case strings.HasPrefix(trimmed, "- Q:"):
+		d.Question = strings.TrimSpace(strings.TrimPrefix(trimmed, "- Q:"))
+	case strings.HasPrefix(trimmed, "- A:"):
+		d.Answer = strings.TrimSpace(strings.TrimPrefix(trimmed, "- A:"))
+	case strings.HasPrefix(trimmed, "- Decided:"):
+		// Decided: 2025-12-21 by user
+		rest := strings.TrimSpace(strings.TrimPrefix(trimmed, "- Decided:"))
+		date := rest
+		if idx := strings.LastIndex(rest, " by "); idx >= 0 {
+			date = strings.TrimSpace(rest[:idx])
+			d.Source = strings.TrimSpace(rest[idx+4:])
+		}
+		d.DecidedAt = parseDecidedAt(date)
+	}
+}
+
+// parseDefaultsRow parses a row of the legacy "Defaults Accepted" table:
+// | ID | Question | Default | Accepted |
+func parseDefaultsRow(row string) (AmbiguityDecision, bool) {
+	cells := strings.Split(strings.Trim(row, "|"), "|")
+	if len(cells) < 3 {
+		return AmbiguityDecision{}, false
+	}
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	if !strings.HasPrefix(cells[0], "AMB-") || cells[2] == "" {
+		return AmbiguityDecision{}, false
+	}
+
+	d := AmbiguityDecision{
+		AmbiguityID: cells[0],
+		Question:    cells[1],
+		Answer:      cells[2],
+		Source:      "default",
+	}
+	if len(cells) > 3 {
+		d.DecidedAt = parseDecidedAt(cells[3])
+	}
+	return d, true
+}
+
+// parseDecidedAt parses a decision timestamp, returning the zero time if
+// the value is not recognised.
+func parseDecidedAt(value string) time.Time {
+	for _, layout := range legacyDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseCategoryHeading maps a "## ..." section heading back to a category.
+// It reverses formatCategory and understands the legacy "Entity Decisions"
+// style headings.
+func parseCategoryHeading(heading string) string {
+	if heading == "" {
+		return ""
+	}
+	for _, cat := range knownCategories {
+		if formatCategory(cat) == heading {
+			return cat
+		}
+	}
+	if strings.HasSuffix(heading, " Decisions") {
+		return strings.ToLower(strings.TrimSuffix(heading, " Decisions"))
+	}
+	return ""
+}
+
+// knownCategories lists the categories with a dedicated heading in formatCategory
+var knownCategories = []string{
+	"missing_definition",
+	"unclear_relationship",
+	"synonym_resolution",
+	"boundary_ambiguity",
+	"business_rule_gap",
+	"state_lifecycle",
+}
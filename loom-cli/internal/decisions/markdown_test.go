@@ -0,0 +1,227 @@
+package decisions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderMarkdown_RoundTripIsLossless(t *testing.T) {
+	decidedAt := time.Date(2025, 12, 21, 14, 30, 5, 123000000, time.UTC)
+
+	original := &DecisionSet{
+		Decisions: []AmbiguityDecision{
+			{
+				AmbiguityID: "AMB-ENT-001",
+				Question:    "What happens to tasks when station deleted?",
+				Answer:      "Block deletion if tasks exist",
+				Source:      "user",
+				Category:    "entity",
+				Subject:     "Station",
+				Severity:    "critical",
+				DecidedAt:   decidedAt,
+			},
+			{
+				AmbiguityID: "AMB-OP-002",
+				Question:    "What is the time snap granularity?",
+				Answer:      "15 minutes\n\nRounded down when dragging.\n  Indented note",
+				Source:      "default",
+				Category:    "operation",
+				Severity:    "minor",
+				ParentID:    "AMB-OP-001",
+			},
+			{
+				AmbiguityID: "AMB-BRG-001",
+				Question:    "Can orders be cancelled?",
+				Answer:      "Yes, within 24h",
+				Source:      "existing",
+				Category:    "business_rule_gap",
+			},
+		},
+	}
+
+	content := original.RenderMarkdown(time.Now())
+
+	parsed, err := ParseMarkdown(content)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(parsed.Decisions) != len(original.Decisions) {
+		t.Fatalf("Expected %d decisions, got %d", len(original.Decisions), len(parsed.Decisions))
+	}
+
+	for _, orig := range original.Decisions {
+		got := parsed.GetDecision(orig.AmbiguityID)
+		if got == nil {
+			t.Errorf("Expected to find %s after round-trip", orig.AmbiguityID)
+			continue
+		}
+		if !got.DecidedAt.Equal(orig.DecidedAt) {
+			t.Errorf("DecidedAt mismatch for %s: got %v, want %v", orig.AmbiguityID, got.DecidedAt, orig.DecidedAt)
+		}
+		got.DecidedAt = orig.DecidedAt
+		if !reflect.DeepEqual(*got, orig) {
+			t.Errorf("Round-trip mismatch for %s:\ngot  %+v\nwant %+v", orig.AmbiguityID, *got, orig)
+		}
+	}
+
+	// Rendering the parsed set again must produce the same document
+	if again := parsed.RenderMarkdown(time.Time{}); again != original.RenderMarkdown(time.Time{}) {
+		t.Error("Expected re-rendered markdown to be identical")
+	}
+}
+
+func TestRenderMarkdown_FormatVersion(t *testing.T) {
+	ds := &DecisionSet{}
+	content := ds.RenderMarkdown(time.Now())
+
+	if !strings.Contains(content, "format_version: 1\n") {
+		t.Error("Expected format_version in frontmatter")
+	}
+}
+
+func TestParseMarkdown_NewerFormatVersion(t *testing.T) {
+	content := `---
+title: "Ambiguity Decisions"
+format_version: 99
+---
+
+### AMB-DEF-001
+
+**Question:** Q?
+
+**Decision:** A
+`
+
+	if _, err := ParseMarkdown(content); err == nil {
+		t.Error("Expected error for newer format version")
+	}
+}
+
+func TestParseMarkdown_LegacyBulletFormat(t *testing.T) {
+	content := `---
+# decisions.md - Loom Decision Log
+# Total decisions: 3
+---
+
+## Entity Decisions
+
+### Station
+
+- **AMB-ENT-001: Deletion behavior**
+  - Q: What happens to tasks when station deleted?
+  - A: Block deletion if tasks exist
+  - Decided: 2025-12-21 by user
+
+## Decisions from 2025-12-22
+
+- **AMB-OP-001: Schedule Task**
+  - Q: What happens when task overlaps existing?
+  - A: Block with error, show conflict details
+  - Decided: 2025-12-22 09:15 by default
+
+## Defaults Accepted
+
+| ID | Question | Default | Accepted |
+|----|----------|---------|----------|
+| AMB-ENT-050 | Max station name length | 100 chars | 2025-12-21 |
+`
+
+	ds, err := ParseMarkdown(content)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(ds.Decisions) != 3 {
+		t.Fatalf("Expected 3 decisions, got %d", len(ds.Decisions))
+	}
+
+	ent := ds.GetDecision("AMB-ENT-001")
+	if ent == nil {
+		t.Fatal("Expected AMB-ENT-001")
+	}
+	if ent.Subject != "Deletion behavior" {
+		t.Errorf("Expected subject 'Deletion behavior', got '%s'", ent.Subject)
+	}
+	if ent.Category != "entity" {
+		t.Errorf("Expected category 'entity', got '%s'", ent.Category)
+	}
+	if ent.Answer != "Block deletion if tasks exist" {
+		t.Errorf("Unexpected answer '%s'", ent.Answer)
+	}
+	if ent.Source != "user" {
+		t.Errorf("Expected source 'user', got '%s'", ent.Source)
+	}
+	if !ent.DecidedAt.Equal(time.Date(2025, 12, 21, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected decided date %v", ent.DecidedAt)
+	}
+
+	op := ds.GetDecision("AMB-OP-001")
+	if op == nil {
+		t.Fatal("Expected AMB-OP-001")
+	}
+	if op.Source != "default" {
+		t.Errorf("Expected source 'default', got '%s'", op.Source)
+	}
+	if op.DecidedAt.Hour() != 9 || op.DecidedAt.Minute() != 15 {
+		t.Errorf("Expected time 09:15, got %v", op.DecidedAt)
+	}
+
+	def := ds.GetDecision("AMB-ENT-050")
+	if def == nil {
+		t.Fatal("Expected AMB-ENT-050 from defaults table")
+	}
+	if def.Answer != "100 chars" || def.Source != "default" {
+		t.Errorf("Unexpected default decision %+v", *def)
+	}
+}
+
+func TestParseMarkdown_LastOccurrenceWins(t *testing.T) {
+	content := `### AMB-DEF-001
+
+**Question:** Max amount?
+
+**Decision:** 100
+
+### AMB-DEF-001
+
+**Question:** Max amount?
+
+**Decision:** 200
+`
+
+	ds, err := ParseMarkdown(content)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ds.Decisions) != 1 {
+		t.Fatalf("Expected 1 decision, got %d", len(ds.Decisions))
+	}
+	if ds.Decisions[0].Answer != "200" {
+		t.Errorf("Expected last answer '200', got '%s'", ds.Decisions[0].Answer)
+	}
+}
+
+func TestParseCategoryHeading(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Missing Definitions", "missing_definition"},
+		{"State & Lifecycle", "state_lifecycle"},
+		{"Entity Decisions", "entity"},
+		{"UI Decisions", "ui"},
+		{"Decisions from 2025-12-21", ""},
+		{"Summary", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		result := parseCategoryHeading(tt.input)
+		if result != tt.expected {
+			t.Errorf("parseCategoryHeading(%q) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package decisions
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+// FormatVersion is the current version of the decision store and of the
+// decisions.md layout rendered from it
+const FormatVersion = 1
+
+// StoreDirName is the directory, next to decisions.md, holding the store
+const StoreDirName = ".loom"
+
+// StoreFileName is the name of the canonical decision store file
+const StoreFileName = "decisions.json"
+
+// Store is the canonical, machine-readable form of a decision log.
+// decisions.md is rendered from it and parsed back into it.
+type Store struct {
+	// FormatVersion is the store format version
+	FormatVersion int `json:"format_version"`
+
+	// UpdatedAt is when the store was last written
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// MarkdownHash is the hash of the decisions.md rendered at UpdatedAt.
+	// A different hash on disk means decisions.md was edited by hand.
+	MarkdownHash string `json:"markdown_hash,omitempty"`
+
+	// Decisions are the recorded decisions, in log order
+	Decisions []AmbiguityDecision `json:"decisions"`
+}
+
+// StorePath returns the store path belonging to a decisions.md file
+func StorePath(markdownPath string) string {
+	return filepath.Join(filepath.Dir(markdownPath), StoreDirName, StoreFileName)
+}
+
+// LoadStore reads a decision store. It returns nil without error if the
+// store does not exist.
+func LoadStore(path string) (*Store, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read decision store: %w", err)
+	}
+
+	var store Store
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, fmt.Errorf("failed to parse decision store: %w", err)
+	}
+
+	if store.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("decision store format version %d is newer than supported version %d", store.FormatVersion, FormatVersion)
+	}
+	if store.Decisions == nil {
+		store.Decisions = []AmbiguityDecision{}
+	}
+
+	return &store, nil
+}
+
+// Save writes the store atomically
+func (s *Store) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	s.FormatVersion = FormatVersion
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal decision store: %w", err)
+	}
+
+	// Write to temp file first, then rename
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write decision store: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename decision store: %w", err)
+	}
+
+	return nil
+}
+
+// Load returns the decisions for a decisions.md file.
+//
+// The store is authoritative unless decisions.md was edited since it was
+// last rendered, in which case the edited file is parsed instead. Without
+// a store, decisions.md is parsed in whichever layout it uses, which makes
+// Load the read half of migrating an existing file.
+func Load(markdownPath string) (*DecisionSet, error) {
+	store, err := LoadStore(StorePath(markdownPath))
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(markdownPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read decisions file: %w", err)
+	}
+	mdExists := err == nil
+
+	if store == nil || (mdExists && hashMarkdown(string(content)) != store.MarkdownHash) {
+		if !mdExists {
+			return &DecisionSet{Decisions: []AmbiguityDecision{}}, nil
+		}
+		return ParseMarkdown(string(content))
+	}
+
+	return &DecisionSet{Decisions: store.Decisions}, nil
+}
+
+// Save writes the decision set to the store and re-renders decisions.md
+// from it.
+func Save(markdownPath string, ds *DecisionSet) error {
+	now := time.Now()
+	content := ds.RenderMarkdown(now)
+
+	if err := writeMarkdown(markdownPath, content); err != nil {
+		return err
+	}
+
+	store := &Store{
+		UpdatedAt:    now,
+		MarkdownHash: hashMarkdown(content),
+		Decisions:    ds.Decisions,
+	}
+	return store.Save(StorePath(markdownPath))
+}
+
+// writeMarkdown writes rendered decisions.md content, creating the
+// directory if needed
+func writeMarkdown(path, content string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write decisions file: %w", err)
+	}
+	return nil
+}
+
+// Migrate converts an existing decisions.md (in any supported layout) to
+// the canonical store and re-renders it. The original file is kept with a
+// .bak suffix. It returns the migrated decisions.
+func Migrate(markdownPath string, dryRun bool) (*DecisionSet, error) {
+	content, err := os.ReadFile(markdownPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read decisions file: %w", err)
+	}
+
+	ds, err := ParseMarkdown(string(content))
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return ds, nil
+	}
+
+	if err := os.WriteFile(markdownPath+".bak", content, 0644); err != nil {
+		return nil, fmt.Errorf("failed to back up decisions file: %w", err)
+	}
+	if err := Save(markdownPath, ds); err != nil {
+		return nil, err
+	}
+
+	return ds, nil
+}
+
+// hashMarkdown hashes rendered decisions.md content
+func hashMarkdown(content string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content)))
+}
+
+// FromDomain converts an interview decision to a decision log entry
+func FromDomain(d domain.Decision) AmbiguityDecision {
+	return AmbiguityDecision{
+		AmbiguityID: d.ID,
+		Question:    d.Question,
+		Answer:      d.Answer,
+		Source:      d.Source,
+		Category:    d.Category,
+		Subject:     d.Subject,
+		ParentID:    d.ParentID,
+		DecidedAt:   d.DecidedAt,
+	}
+}
+
+// ToDomain converts a decision log entry to an interview decision
+func (d AmbiguityDecision) ToDomain() domain.Decision {
+	return domain.Decision{
+		ID:        d.AmbiguityID,
+		Question:  d.Question,
+		Answer:    d.Answer,
+		DecidedAt: d.DecidedAt,
+		Source:    d.Source,
+		Category:  d.Category,
+		Subject:   d.Subject,
+		ParentID:  d.ParentID,
+	}
+}
+
+// ToDomain converts all decisions in the set to interview decisions
+func (ds *DecisionSet) ToDomain() []domain.Decision {
+	result := make([]domain.Decision, 0, len(ds.Decisions))
+	for _, d := range ds.Decisions {
+		result = append(result, d.ToDomain())
+	}
+	return result
+}
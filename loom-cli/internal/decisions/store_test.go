@@ -0,0 +1,233 @@
+package decisions
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+func TestSave_WritesStoreAndMarkdown(t *testing.T) {
+	tmpDir := t.TempDir()
+	mdPath := filepath.Join(tmpDir, "decisions.md")
+
+	ds := &DecisionSet{
+		Decisions: []AmbiguityDecision{
+			{AmbiguityID: "AMB-ENT-001", Question: "Q?", Answer: "A", Source: "user", Category: "entity"},
+		},
+	}
+
+	if err := Save(mdPath, ds); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	if _, err := os.Stat(mdPath); err != nil {
+		t.Fatalf("Expected decisions.md to be written: %v", err)
+	}
+
+	storePath := StorePath(mdPath)
+	if storePath != filepath.Join(tmpDir, ".loom", "decisions.json") {
+		t.Errorf("Unexpected store path %s", storePath)
+	}
+
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatalf("Expected store to be written: %v", err)
+	}
+
+	var store Store
+	if err := json.Unmarshal(data, &store); err != nil {
+		t.Fatalf("Store is not valid JSON: %v", err)
+	}
+	if store.FormatVersion != FormatVersion {
+		t.Errorf("Expected format version %d, got %d", FormatVersion, store.FormatVersion)
+	}
+	if !strings.HasPrefix(store.MarkdownHash, "sha256:") {
+		t.Errorf("Expected sha256 markdown hash, got '%s'", store.MarkdownHash)
+	}
+	if len(store.Decisions) != 1 {
+		t.Errorf("Expected 1 decision in store, got %d", len(store.Decisions))
+	}
+
+	// No temp file left behind
+	if _, err := os.Stat(storePath + ".tmp"); !os.IsNotExist(err) {
+		t.Error("Temp file should not exist after successful save")
+	}
+}
+
+func TestLoad_UsesStoreWhenMarkdownUnchanged(t *testing.T) {
+	tmpDir := t.TempDir()
+	mdPath := filepath.Join(tmpDir, "decisions.md")
+	decidedAt := time.Date(2025, 12, 21, 10, 0, 0, 0, time.UTC)
+
+	ds := &DecisionSet{
+		Decisions: []AmbiguityDecision{
+			{AmbiguityID: "AMB-ENT-001", Question: "Q?", Answer: "A", Source: "user", Severity: "critical", DecidedAt: decidedAt},
+		},
+	}
+	if err := Save(mdPath, ds); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(mdPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(loaded.Decisions) != 1 {
+		t.Fatalf("Expected 1 decision, got %d", len(loaded.Decisions))
+	}
+	if loaded.Decisions[0].Severity != "critical" {
+		t.Errorf("Expected severity 'critical', got '%s'", loaded.Decisions[0].Severity)
+	}
+	if !loaded.Decisions[0].DecidedAt.Equal(decidedAt) {
+		t.Errorf("Expected decided at %v, got %v", decidedAt, loaded.Decisions[0].DecidedAt)
+	}
+}
+
+func TestLoad_PicksUpManualEdits(t *testing.T) {
+	tmpDir := t.TempDir()
+	mdPath := filepath.Join(tmpDir, "decisions.md")
+
+	ds := &DecisionSet{
+		Decisions: []AmbiguityDecision{
+			{AmbiguityID: "AMB-ENT-001", Question: "Q?", Answer: "Old answer", Source: "user"},
+		},
+	}
+	if err := Save(mdPath, ds); err != nil {
+		t.Fatal(err)
+	}
+
+	// Edit decisions.md by hand
+	content, _ := os.ReadFile(mdPath)
+	edited := strings.Replace(string(content), "**Decision:** Old answer", "**Decision:** New answer", 1)
+	if err := os.WriteFile(mdPath, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(mdPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if loaded.Decisions[0].Answer != "New answer" {
+		t.Errorf("Expected edited answer 'New answer', got '%s'", loaded.Decisions[0].Answer)
+	}
+}
+
+func TestLoad_NoStoreNoMarkdown(t *testing.T) {
+	ds, err := Load(filepath.Join(t.TempDir(), "decisions.md"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ds.Decisions) != 0 {
+		t.Errorf("Expected empty set, got %d decisions", len(ds.Decisions))
+	}
+}
+
+func TestLoadStore_NewerFormatVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, StoreFileName)
+
+	if err := os.WriteFile(path, []byte(`{"format_version": 99, "decisions": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadStore(path); err == nil {
+		t.Error("Expected error for newer store format version")
+	}
+}
+
+func TestMigrate_LegacyFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	mdPath := filepath.Join(tmpDir, "decisions.md")
+
+	legacy := `## Entity Decisions
+
+- **AMB-ENT-001: Deletion behavior**
+  - Q: What happens to tasks when station deleted?
+  - A: Block deletion if tasks exist
+  - Decided: 2025-12-21 by user
+`
+	if err := os.WriteFile(mdPath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := Migrate(mdPath, false)
+	if err != nil {
+		t.Fatalf("Migration failed: %v", err)
+	}
+	if len(ds.Decisions) != 1 {
+		t.Fatalf("Expected 1 decision, got %d", len(ds.Decisions))
+	}
+
+	// Backup keeps the original content
+	backup, err := os.ReadFile(mdPath + ".bak")
+	if err != nil {
+		t.Fatalf("Expected backup: %v", err)
+	}
+	if string(backup) != legacy {
+		t.Error("Backup content differs from original")
+	}
+
+	// decisions.md is re-rendered in the canonical layout
+	content, _ := os.ReadFile(mdPath)
+	if !strings.Contains(string(content), "### AMB-ENT-001") {
+		t.Error("Expected canonical heading after migration")
+	}
+	if !strings.Contains(string(content), "**Subject:** Deletion behavior") {
+		t.Error("Expected subject to be preserved after migration")
+	}
+
+	// And loads back identically from the store
+	loaded, err := Load(mdPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := loaded.GetDecision("AMB-ENT-001")
+	if got == nil || got.Category != "entity" || got.Source != "user" {
+		t.Errorf("Unexpected migrated decision %+v", got)
+	}
+}
+
+func TestMigrate_DryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	mdPath := filepath.Join(tmpDir, "decisions.md")
+
+	legacy := "- **AMB-ENT-001: Title**\n  - Q: Q?\n  - A: A\n"
+	if err := os.WriteFile(mdPath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(mdPath, true); err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+
+	if _, err := os.Stat(StorePath(mdPath)); !os.IsNotExist(err) {
+		t.Error("Dry run should not write the store")
+	}
+	content, _ := os.ReadFile(mdPath)
+	if string(content) != legacy {
+		t.Error("Dry run should not modify decisions.md")
+	}
+}
+
+func TestDomainConversion_RoundTrip(t *testing.T) {
+	d := domain.Decision{
+		ID:        "AMB-ENT-001",
+		Question:  "Q?",
+		Answer:    "A",
+		DecidedAt: time.Date(2025, 12, 21, 0, 0, 0, 0, time.UTC),
+		Source:    "user",
+		Category:  "entity",
+		Subject:   "Station",
+		ParentID:  "AMB-ENT-000",
+	}
+
+	got := FromDomain(d).ToDomain()
+	if got != d {
+		t.Errorf("Domain conversion mismatch: got %+v, want %+v", got, d)
+	}
+}
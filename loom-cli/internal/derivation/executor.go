@@ -35,6 +35,16 @@ type Executor struct {
 	// PreserveManual keeps manual sections during re-derivation
 	PreserveManual bool
 
+	// MergeStrategy decides how hand edits inside generated sections are
+	// combined with the new generation
+	MergeStrategy MergeStrategy
+
+	// Resolver decides conflicts when MergeStrategy is MergeInteractive
+	Resolver ConflictResolver
+
+	// Tx, if set, stages the derived files instead of writing them
+	Tx *Transaction
+
 	// DeriverFunc is the function that performs actual derivation
 	// It receives the artifact and its upstream content, returns new content
 	DeriverFunc DeriverFunc
@@ -79,6 +89,7 @@ func NewExecutor(state *DerivationState, projectDir string) *Executor {
 		Hasher:         NewHasher(),
 		ProjectDir:     projectDir,
 		PreserveManual: true,
+		MergeStrategy:  MergePreserveManual,
 	}
 }
 
@@ -121,6 +132,9 @@ type DerivedArtifact struct {
 	Duration    time.Duration     `json:"duration"`
 	HasManual   bool              `json:"has_manual"`
 	ManualKept  []string          `json:"manual_kept,omitempty"`
+	Edited      bool              `json:"edited,omitempty"`    // hand edits were found and merged
+	Conflicts   int               `json:"conflicts,omitempty"` // conflicts left marked in the output
+	Written     bool              `json:"written"`
 }
 
 // SkippedArtifact describes an artifact that was skipped
@@ -299,10 +313,27 @@ func (e *Executor) executeStep(step *DerivationStep, current, total int) stepRes
 		newContent = e.restoreManualSections(newContent, manualContent)
 	}
 
-	// Write output (unless dry run)
+	// Merge with hand edits made since the last derivation
+	merged, err := e.mergeOutput(artifact, newContent)
+	if err != nil {
+		return stepResult{
+			status: "error",
+			err: DerivationError{
+				ArtifactID:  step.ArtifactID,
+				Error:       fmt.Sprintf("failed to merge output: %v", err),
+				Recoverable: true,
+			},
+		}
+	}
+
+	// Write output (unless dry run or only reporting)
 	outputFile := artifact.Location.File
-	if !e.DryRun {
-		if err := e.writeOutput(artifact, newContent); err != nil {
+	write := !e.DryRun && e.MergeStrategy != MergeReportOnly
+	newHash := e.Hasher.HashContent(merged.body)
+	upstreamHashes, _ := e.Hasher.CollectUpstreamHashes(artifact, e.State, e.ProjectDir)
+
+	if write {
+		if err := e.writeOutput(artifact, merged); err != nil {
 			return stepResult{
 				status: "error",
 				err: DerivationError{
@@ -312,17 +343,18 @@ func (e *Executor) executeStep(step *DerivationStep, current, total int) stepRes
 				},
 			}
 		}
-	}
-
-	// Update artifact state
-	upstreamHashes, _ := e.Hasher.CollectUpstreamHashes(artifact, e.State, e.ProjectDir)
-	newHash := e.Hasher.HashContent(newContent)
+		if hash, err := e.Hasher.HashArtifact(artifact, e.ProjectDir); err == nil {
+			newHash = hash
+		}
 
-	if !e.DryRun {
+		// Update artifact state
 		artifact.ContentHash = newHash
 		artifact.DerivedFromHashes = upstreamHashes
 		artifact.DerivedAt = time.Now()
 		artifact.Status = StatusCurrent
+		if merged.conflicts > 0 {
+			artifact.Status = StatusModified
+		}
 	}
 
 	return stepResult{
@@ -337,6 +369,9 @@ func (e *Executor) executeStep(step *DerivationStep, current, total int) stepRes
 			Duration:   time.Since(startTime),
 			HasManual:  step.HasManual,
 			ManualKept: mapKeys(manualContent),
+			Edited:     merged.edited,
+			Conflicts:  merged.conflicts,
+			Written:    write,
 		},
 	}
 }
@@ -361,7 +396,7 @@ func (e *Executor) collectUpstreamContent(artifact *Artifact) (map[string]string
 				filePath = filepath.Join(e.ProjectDir, filePath)
 			}
 
-			data, err := os.ReadFile(filePath)
+			data, err := e.Tx.ReadFile(filePath)
 			if err != nil {
 				return nil, fmt.Errorf("failed to read upstream %s: %w", upstreamID, err)
 			}
@@ -397,7 +432,7 @@ func (e *Executor) extractManualSections(artifact *Artifact) (map[string]string,
 		filePath = filepath.Join(e.ProjectDir, filePath)
 	}
 
-	content, err := os.ReadFile(filePath)
+	content, err := e.Tx.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -430,12 +465,89 @@ func (e *Executor) restoreManualSections(content string, manualSections map[stri
 	return content
 }
 
-func (e *Executor) writeOutput(artifact *Artifact, content string) error {
-	filePath := artifact.Location.File
-	if !filepath.IsAbs(filePath) {
-		filePath = filepath.Join(e.ProjectDir, filePath)
+// mergedOutput is the new content of an artifact's file
+type mergedOutput struct {
+	file      string // full file content
+	body      string // the artifact's part of file
+	generated string // the new generation, stored as the next base
+	section   bool   // body is a generated section within file
+	edited    bool   // the artifact was edited by hand since its last derivation
+	conflicts int
+}
+
+// mergeOutput combines the new generation of an artifact with its current
+// content. If the file has a generated section for the artifact only that
+// section is replaced, otherwise the whole file is. Hand edits, found by
+// comparing the current content with the base stored at the last
+// derivation, are handled according to MergeStrategy.
+func (e *Executor) mergeOutput(artifact *Artifact, generated string) (*mergedOutput, error) {
+	out := &mergedOutput{generated: generated}
+
+	existing := ""
+	if artifact.Location.File != "" {
+		data, err := e.Tx.ReadFile(e.resolvePath(artifact.Location.File))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", artifact.Location.File, err)
+		}
+		existing = string(data)
 	}
 
+	lines := strings.Split(existing, "\n")
+	start, end, ok := sectionBounds(existing, artifact.ID)
+	current := existing
+	if ok {
+		out.section = true
+		current = strings.Join(lines[start:end], "\n")
+		if body, found := GeneratedSections(generated)[artifact.ID]; found {
+			out.generated = body
+		}
+	}
+
+	base, hasBase, err := NewStateManager(e.ProjectDir).LoadBase(artifact.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasBase {
+		// Without a base only artifacts flagged as modified are known to
+		// carry hand edits; their whole content is merged against nothing
+		base = current
+		if artifact.Status == StatusModified {
+			base = ""
+		}
+	}
+	out.edited = current != base && current != out.generated
+
+	out.body = out.generated
+	if out.edited && e.MergeStrategy != MergeOverwriteAll {
+		var resolve ConflictResolver
+		if e.MergeStrategy == MergeInteractive && e.Resolver != nil {
+			resolve = func(c *Conflict) (string, bool, error) {
+				c.ArtifactID = artifact.ID
+				return e.Resolver(c)
+			}
+		}
+		res, err := Merge3(base, current, out.generated, resolve)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge %s: %w", artifact.ID, err)
+		}
+		out.body = res.Content
+		out.conflicts = res.Conflicts
+	}
+
+	out.file = out.body
+	if out.section {
+		var merged []string
+		merged = append(merged, lines[:start]...)
+		merged = append(merged, strings.Split(out.body, "\n")...)
+		merged = append(merged, lines[end:]...)
+		out.file = strings.Join(merged, "\n")
+	}
+	return out, nil
+}
+
+func (e *Executor) writeOutput(artifact *Artifact, merged *mergedOutput) error {
+	filePath := e.resolvePath(artifact.Location.File)
+
 	// Ensure directory exists
 	dir := filepath.Dir(filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -443,13 +555,40 @@ func (e *Executor) writeOutput(artifact *Artifact, content string) error {
 	}
 
 	// Write content
-	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+	if err := e.Tx.WriteFile(filePath, []byte(merged.file)); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
+	// The generation becomes the base of the next merge
+	base := NewStateManager(e.ProjectDir).basePath(artifact.ID)
+	if err := e.Tx.WriteFile(base, []byte(merged.generated)); err != nil {
+		return fmt.Errorf("failed to write base of %s: %w", artifact.ID, err)
+	}
+
+	if merged.section {
+		e.relocate(artifact.Location.File, merged.file)
+	}
 	return nil
 }
 
+// relocate updates the line ranges of the artifacts in a rewritten file
+func (e *Executor) relocate(file, content string) {
+	doc := NewParser().ParseContent(content, file)
+	for _, parsed := range doc.Artifacts {
+		if a := e.State.GetArtifact(parsed.ID); a != nil && a.Location.File == file {
+			a.Location.LineStart = parsed.Location.LineStart
+			a.Location.LineEnd = parsed.Location.LineEnd
+		}
+	}
+}
+
+func (e *Executor) resolvePath(path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.Join(e.ProjectDir, path)
+	}
+	return path
+}
+
 func (e *Executor) reportProgress(event ProgressEvent) {
 	if e.ProgressCallback != nil {
 		e.ProgressCallback(event)
@@ -21,16 +21,23 @@ type DependencyGraph struct {
 	// reverse maps artifact ID to its upstream dependencies
 	reverse map[string][]string
 
+	// index holds the edges for constant-time lookups
+	index map[edgeKey]bool
+
 	// mu protects concurrent access
 	mu sync.RWMutex
 }
 
+// edgeKey identifies an edge in the index
+type edgeKey struct{ from, to string }
+
 // NewDependencyGraph creates a new empty dependency graph
 func NewDependencyGraph() *DependencyGraph {
 	return &DependencyGraph{
 		Edges:     make([]DependencyEdge, 0),
 		adjacency: make(map[string][]string),
 		reverse:   make(map[string][]string),
+		index:     make(map[edgeKey]bool),
 	}
 }
 
@@ -44,11 +51,12 @@ func (g *DependencyGraph) AddEdge(from, to string, edgeType EdgeType) {
 	defer g.mu.Unlock()
 
 	// Check if edge already exists
-	for _, e := range g.Edges {
-		if e.From == from && e.To == to {
-			return // Edge already exists
-		}
+	g.ensureIndex()
+	key := edgeKey{from, to}
+	if g.index[key] {
+		return // Edge already exists
 	}
+	g.index[key] = true
 
 	// Add edge
 	g.Edges = append(g.Edges, DependencyEdge{
@@ -68,6 +76,11 @@ func (g *DependencyGraph) RemoveEdge(from, to string) {
 	defer g.mu.Unlock()
 
 	// Remove from edges list
+	g.ensureIndex()
+	if !g.index[edgeKey{from, to}] {
+		return
+	}
+	delete(g.index, edgeKey{from, to})
 	for i, e := range g.Edges {
 		if e.From == from && e.To == to {
 			g.Edges = append(g.Edges[:i], g.Edges[i+1:]...)
@@ -93,6 +106,7 @@ func (g *DependencyGraph) RemoveNode(id string) {
 		}
 	}
 	g.Edges = newEdges
+	g.index = nil
 
 	// Update adjacency lists
 	// Remove from downstream lists
@@ -111,14 +125,29 @@ func (g *DependencyGraph) RemoveNode(id string) {
 // HasEdge checks if an edge exists
 func (g *DependencyGraph) HasEdge(from, to string) bool {
 	g.mu.RLock()
-	defer g.mu.RUnlock()
+	if g.index != nil {
+		defer g.mu.RUnlock()
+		return g.index[edgeKey{from, to}]
+	}
+	g.mu.RUnlock()
 
+	// Edges were set without building the index
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ensureIndex()
+	return g.index[edgeKey{from, to}]
+}
+
+// ensureIndex builds the edge index if it is missing; g.mu must be held
+// for writing
+func (g *DependencyGraph) ensureIndex() {
+	if g.index != nil {
+		return
+	}
+	g.index = make(map[edgeKey]bool, len(g.Edges))
 	for _, e := range g.Edges {
-		if e.From == from && e.To == to {
-			return true
-		}
+		g.index[edgeKey{e.From, e.To}] = true
 	}
-	return false
 }
 
 // =============================================================================
@@ -371,10 +400,12 @@ func (g *DependencyGraph) RebuildFromEdges() {
 
 	g.adjacency = make(map[string][]string)
 	g.reverse = make(map[string][]string)
+	g.index = make(map[edgeKey]bool, len(g.Edges))
 
 	for _, e := range g.Edges {
 		g.adjacency[e.From] = append(g.adjacency[e.From], e.To)
 		g.reverse[e.To] = append(g.reverse[e.To], e.From)
+		g.index[edgeKey{e.From, e.To}] = true
 	}
 }
 
@@ -386,6 +417,7 @@ func (g *DependencyGraph) BuildFromArtifacts(artifacts map[string]*Artifact) {
 	g.Edges = make([]DependencyEdge, 0)
 	g.adjacency = make(map[string][]string)
 	g.reverse = make(map[string][]string)
+	g.index = make(map[edgeKey]bool)
 
 	for _, artifact := range artifacts {
 		// Add edges from upstream dependencies
@@ -395,6 +427,7 @@ func (g *DependencyGraph) BuildFromArtifacts(artifacts map[string]*Artifact) {
 				To:   artifact.ID,
 				Type: EdgeDerives,
 			})
+			g.index[edgeKey{upstreamID, artifact.ID}] = true
 			g.adjacency[upstreamID] = append(g.adjacency[upstreamID], artifact.ID)
 			g.reverse[artifact.ID] = append(g.reverse[artifact.ID], upstreamID)
 		}
@@ -29,6 +29,9 @@ type Hasher struct {
 
 	// SectionHasher enables per-section hashing for LOOM-marked content
 	SectionHasher bool
+
+	// Tx, if set, makes files staged in a transaction hash by their new content
+	Tx *Transaction
 }
 
 // NewHasher creates a new hasher with default settings
@@ -47,7 +50,7 @@ func NewHasher() *Hasher {
 
 // HashFile computes the hash of a file's contents
 func (h *Hasher) HashFile(path string) (string, error) {
-	content, err := os.ReadFile(path)
+	content, err := h.Tx.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
@@ -252,7 +255,7 @@ func (h *Hasher) HashArtifact(artifact *Artifact, projectDir string) (string, er
 	}
 
 	// Read file content
-	content, err := os.ReadFile(filePath)
+	content, err := h.Tx.ReadFile(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read artifact file: %w", err)
 	}
@@ -0,0 +1,411 @@
+package derivation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// Derivation History
+// =============================================================================
+
+const (
+	// HistoryDirName is the directory under .loom holding derivation runs
+	HistoryDirName = "history"
+
+	// historyObjectsDir holds file contents, stored once by content hash
+	historyObjectsDir = "objects"
+
+	// RunFileName describes a run within its directory
+	RunFileName = "run.json"
+
+	// LatestRun refers to the most recent run
+	LatestRun = "latest"
+)
+
+// Run is a snapshot taken after a derivation run: the state, the hashes of
+// the documents it tracks and what changed since the previous run
+type Run struct {
+	ID        string    `json:"id"`
+	Command   string    `json:"command"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Files maps project-relative paths to the hashes of their contents
+	Files map[string]string `json:"files"`
+
+	// Artifacts maps artifact IDs to their content hashes
+	Artifacts map[string]string `json:"artifacts"`
+
+	// Decisions lists the decisions of the added and changed artifacts
+	Decisions []string `json:"decisions,omitempty"`
+
+	Added   []string `json:"added,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// RunDiff is the artifact-level difference between two runs
+type RunDiff struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Added   []string `json:"added"`
+	Changed []string `json:"changed"`
+	Removed []string `json:"removed"`
+	Files   []string `json:"files"` // documents whose content differs
+}
+
+// RollbackResult describes a rollback
+type RollbackResult struct {
+	// Run is the run that was restored
+	Run *Run
+
+	// Restored lists the documents that were rewritten
+	Restored []string
+
+	// Recorded is the run recording the rollback itself
+	Recorded *Run
+}
+
+// History stores derivation runs under .loom/history
+type History struct {
+	sm  *StateManager
+	Dir string
+}
+
+// NewHistory creates the history of a project
+func NewHistory(sm *StateManager) *History {
+	return &History{
+		sm:  sm,
+		Dir: filepath.Join(sm.LoomDir, HistoryDirName),
+	}
+}
+
+// Record snapshots state and the documents it tracks as a new run. The run
+// is staged in tx, before it commits, so that the run ID is taken under the
+// lock and the run is stored together with the documents it describes.
+func (h *History) Record(command string, state *DerivationState, tx *Transaction) (*Run, error) {
+	runs, err := h.List()
+	if err != nil {
+		return nil, err
+	}
+
+	run := &Run{
+		ID:        "0001",
+		Command:   command,
+		CreatedAt: time.Now(),
+		Files:     make(map[string]string),
+		Artifacts: make(map[string]string),
+	}
+	if len(runs) > 0 {
+		n, _ := strconv.Atoi(runs[len(runs)-1].ID)
+		run.ID = fmt.Sprintf("%04d", n+1)
+	}
+
+	for _, path := range h.trackedFiles(state, tx) {
+		data, err := tx.ReadFile(filepath.Join(h.sm.ProjectDir, path))
+		if err != nil {
+			// Documents deleted since the last derivation are not tracked
+			continue
+		}
+		hash, err := h.storeObject(data)
+		if err != nil {
+			return nil, err
+		}
+		run.Files[path] = hash
+	}
+
+	for id, a := range state.Artifacts {
+		run.Artifacts[id] = a.ContentHash
+	}
+	var previous map[string]string
+	if len(runs) > 0 {
+		previous = runs[len(runs)-1].Artifacts
+	}
+	run.Added, run.Changed, run.Removed = diffArtifacts(previous, run.Artifacts)
+
+	decisions := make(map[string]bool)
+	for _, id := range append(append([]string{}, run.Added...), run.Changed...) {
+		for _, d := range state.Artifacts[id].Decisions {
+			decisions[d] = true
+		}
+	}
+	for d := range decisions {
+		run.Decisions = append(run.Decisions, d)
+	}
+	sort.Strings(run.Decisions)
+
+	stateData, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	runData, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal run: %w", err)
+	}
+
+	// run.json is moved in last, so a run without it is incomplete
+	dir := filepath.Join(h.Dir, run.ID)
+	if err := tx.WriteFile(filepath.Join(dir, StateFileName), stateData); err != nil {
+		return nil, fmt.Errorf("failed to stage run state: %w", err)
+	}
+	if err := tx.WriteFile(filepath.Join(dir, RunFileName), runData); err != nil {
+		return nil, fmt.Errorf("failed to stage run %s: %w", run.ID, err)
+	}
+
+	return run, nil
+}
+
+// List returns all recorded runs, oldest first
+func (h *History) List() ([]*Run, error) {
+	entries, err := os.ReadDir(h.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var runs []*Run
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == historyObjectsDir {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(h.Dir, entry.Name(), RunFileName)); err != nil {
+			continue // Staged or discarded
+		}
+		run, err := h.loadRun(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].ID < runs[j].ID
+	})
+	return runs, nil
+}
+
+// Get returns a run by ID. "latest" is the most recent run and numbers
+// may omit leading zeros.
+func (h *History) Get(ref string) (*Run, error) {
+	if ref == LatestRun {
+		runs, err := h.List()
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) == 0 {
+			return nil, fmt.Errorf("no runs recorded")
+		}
+		return runs[len(runs)-1], nil
+	}
+
+	id := ref
+	if n, err := strconv.Atoi(ref); err == nil {
+		id = fmt.Sprintf("%04d", n)
+	}
+	if _, err := os.Stat(filepath.Join(h.Dir, id, RunFileName)); os.IsNotExist(err) {
+		return nil, fmt.Errorf("run not found: %s", ref)
+	}
+	return h.loadRun(id)
+}
+
+// LoadState returns the state recorded by a run
+func (h *History) LoadState(run *Run) (*DerivationState, error) {
+	data, err := os.ReadFile(filepath.Join(h.Dir, run.ID, StateFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state of run %s: %w", run.ID, err)
+	}
+	return h.sm.decode(data)
+}
+
+// Rollback restores the documents and state recorded by a run. The
+// documents that differ and the state are written in one transaction, so
+// an interrupted rollback leaves the project as it was or fully restored.
+// The rollback is recorded as a new run, so it can itself be rolled back.
+func (h *History) Rollback(ref string) (*RollbackResult, error) {
+	tx, err := h.sm.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Abort()
+
+	run, err := h.Get(ref)
+	if err != nil {
+		return nil, err
+	}
+	state, err := h.LoadState(run)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RollbackResult{Run: run}
+
+	paths := make([]string, 0, len(run.Files))
+	for path := range run.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// Stage every document that differs
+	for _, path := range paths {
+		target := filepath.Join(h.sm.ProjectDir, path)
+		data, err := h.loadObject(run.Files[path])
+		if err != nil {
+			return nil, err
+		}
+		if current, err := os.ReadFile(target); err == nil && string(current) == string(data) {
+			continue
+		}
+		if err := tx.WriteFile(target, data); err != nil {
+			return nil, fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+		result.Restored = append(result.Restored, path)
+	}
+	if err := tx.SaveState(state); err != nil {
+		return nil, fmt.Errorf("failed to stage state: %w", err)
+	}
+	if result.Recorded, err = h.Record("rollback "+run.ID, state, tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rollback: %w", err)
+	}
+	return result, nil
+}
+
+// DiffRuns compares the artifacts and documents of two runs
+func DiffRuns(from, to *Run) *RunDiff {
+	diff := &RunDiff{From: from.ID, To: to.ID}
+	diff.Added, diff.Changed, diff.Removed = diffArtifacts(from.Artifacts, to.Artifacts)
+
+	for path, hash := range to.Files {
+		if from.Files[path] != hash {
+			diff.Files = append(diff.Files, path)
+		}
+	}
+	for path := range from.Files {
+		if _, ok := to.Files[path]; !ok {
+			diff.Files = append(diff.Files, path)
+		}
+	}
+	sort.Strings(diff.Files)
+	return diff
+}
+
+// diffArtifacts compares two artifact hash maps
+func diffArtifacts(from, to map[string]string) (added, changed, removed []string) {
+	for id, hash := range to {
+		old, ok := from[id]
+		switch {
+		case !ok:
+			added = append(added, id)
+		case old != hash:
+			changed = append(changed, id)
+		}
+	}
+	for id := range from {
+		if _, ok := to[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return added, changed, removed
+}
+
+// trackedFiles returns the project-relative documents of the artifacts in
+// state, plus the stored merge bases and those staged in tx
+func (h *History) trackedFiles(state *DerivationState, tx *Transaction) []string {
+	seen := make(map[string]bool)
+	for _, a := range state.Artifacts {
+		path := a.Location.File
+		if path == "" {
+			continue
+		}
+		if filepath.IsAbs(path) {
+			rel, err := filepath.Rel(h.sm.ProjectDir, path)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			path = rel
+		}
+		seen[filepath.ToSlash(path)] = true
+	}
+
+	baseDir := filepath.Join(h.sm.LoomDir, BaseDirName)
+	if entries, err := os.ReadDir(baseDir); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() && !strings.HasSuffix(entry.Name(), stagedSuffix) {
+				seen[filepath.ToSlash(filepath.Join(LoomDirName, BaseDirName, entry.Name()))] = true
+			}
+		}
+	}
+	if tx != nil {
+		absBase, _ := filepath.Abs(baseDir)
+		for target := range tx.staged {
+			if filepath.Dir(target) == absBase {
+				seen[filepath.ToSlash(filepath.Join(LoomDirName, BaseDirName, filepath.Base(target)))] = true
+			}
+		}
+	}
+
+	files := make([]string, 0, len(seen))
+	for path := range seen {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func (h *History) loadRun(id string) (*Run, error) {
+	data, err := os.ReadFile(filepath.Join(h.Dir, id, RunFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run %s: %w", id, err)
+	}
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse run %s: %w", id, err)
+	}
+	return &run, nil
+}
+
+// storeObject stores file content by hash, returning the hash
+func (h *History) storeObject(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := filepath.Join(h.Dir, historyObjectsDir, hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create history objects: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to store history object: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to store history object: %w", err)
+	}
+	return hash, nil
+}
+
+func (h *History) loadObject(hash string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(h.Dir, historyObjectsDir, hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history object %s: %w", hash, err)
+	}
+	return data, nil
+}
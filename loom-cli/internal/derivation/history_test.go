@@ -0,0 +1,168 @@
+package derivation
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// recordRun records a derived document as a derivation run
+func recordRun(t *testing.T, projectDir, layer, path string, decisions ...*Decision) {
+	t.Helper()
+	rec := NewRecorder(projectDir, layer)
+	for _, d := range decisions {
+		rec.AddDecision(d)
+	}
+	if err := rec.Record(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHistory_RecordAndDiff(t *testing.T) {
+	projectDir := t.TempDir()
+	acPath := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", recordL1Doc)
+	tsPath := writeRecordFile(t, projectDir, "l2/tech-specs.md", recordL2Doc)
+
+	recordRun(t, projectDir, "l1", acPath, &Decision{ID: "DEC-001", Question: "Empty carts?", Answer: "Reject"})
+	recordRun(t, projectDir, "l2", tsPath)
+	editFile(t, acPath, "at least one item", "at least two items")
+	recordRun(t, projectDir, "l1", acPath)
+
+	history := NewHistory(NewStateManager(projectDir))
+	runs, err := history.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(runs) != 3 || runs[0].ID != "0001" || runs[2].ID != "0003" {
+		t.Fatalf("Expected three runs, got %+v", runs)
+	}
+	if runs[0].Command != "derive l1" || len(runs[0].Added) != 2 {
+		t.Errorf("Unexpected first run %+v", runs[0])
+	}
+	if len(runs[0].Decisions) != 1 || runs[0].Decisions[0] != "DEC-001" {
+		t.Errorf("Expected the decisions of the derived artifacts, got %v", runs[0].Decisions)
+	}
+	if len(runs[2].Changed) != 1 || runs[2].Changed[0] != "BR-ORD-001" {
+		t.Errorf("Expected BR-ORD-001 to change in the last run, got %+v", runs[2])
+	}
+	if _, ok := runs[0].Files["l1/acceptance-criteria.md"]; !ok {
+		t.Errorf("Expected the document to be snapshotted, got %v", runs[0].Files)
+	}
+
+	latest, err := history.Get(LatestRun)
+	if err != nil || latest.ID != "0003" {
+		t.Fatalf("Expected latest to be 0003, got %v, %v", latest, err)
+	}
+	first, err := history.Get("1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	diff := DiffRuns(first, latest)
+	if strings.Join(diff.Added, ",") != "TS-BR-ORD-001" || strings.Join(diff.Changed, ",") != "BR-ORD-001" || len(diff.Removed) != 0 {
+		t.Errorf("Unexpected diff %+v", diff)
+	}
+	if !containsString(diff.Files, "l1/acceptance-criteria.md") || !containsString(diff.Files, "l2/tech-specs.md") {
+		t.Errorf("Expected both documents to differ, got %v", diff.Files)
+	}
+
+	if _, err := history.Get("42"); err == nil {
+		t.Error("Expected an error for an unknown run")
+	}
+}
+
+func TestHistory_Rollback(t *testing.T) {
+	projectDir := t.TempDir()
+	acPath := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", recordL1Doc)
+	recordRun(t, projectDir, "l1", acPath)
+	original, _ := os.ReadFile(acPath)
+
+	editFile(t, acPath, "at least one item", "at least two items")
+	recordRun(t, projectDir, "l1", acPath)
+
+	sm := NewStateManager(projectDir)
+	history := NewHistory(sm)
+	result, err := history.Rollback("1")
+	if err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if !containsString(result.Restored, "l1/acceptance-criteria.md") || !containsString(result.Restored, ".loom/base/BR-ORD-001.md") {
+		t.Errorf("Expected the document and its merge base to be restored, got %v", result.Restored)
+	}
+	if result.Recorded.ID != "0003" || result.Recorded.Command != "rollback 0001" {
+		t.Errorf("Expected the rollback to be recorded, got %+v", result.Recorded)
+	}
+
+	content, _ := os.ReadFile(acPath)
+	if string(content) != string(original) {
+		t.Errorf("Expected the original document\n%s", content)
+	}
+
+	state, err := sm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := history.Get("1")
+	if state.GetArtifact("BR-ORD-001").ContentHash != first.Artifacts["BR-ORD-001"] {
+		t.Error("Expected the state of the first run to be restored")
+	}
+	stale, err := NewTracker(state, projectDir).DetectStaleArtifacts()
+	if err != nil || len(stale) != 0 {
+		t.Errorf("Expected a consistent project after rollback, got %v, %v", stale, err)
+	}
+
+	entries, _ := os.ReadDir(projectDir + "/l1")
+	if len(entries) != 1 {
+		t.Errorf("Expected no staged files to be left, got %d entries", len(entries))
+	}
+}
+
+func TestHistory_RecordStagedInTransaction(t *testing.T) {
+	projectDir := t.TempDir()
+	acPath := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", recordL1Doc)
+	recordRun(t, projectDir, "l1", acPath)
+
+	sm := NewStateManager(projectDir)
+	history := NewHistory(sm)
+	state, err := sm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An aborted run is not recorded and does not use up its ID
+	tx, err := sm.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, err := history.Record("aborted", state, tx)
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if runs, _ := history.List(); len(runs) != 1 {
+		t.Errorf("Expected the staged run to be hidden, got %d runs", len(runs))
+	}
+	tx.Abort()
+	if runs, _ := history.List(); len(runs) != 1 || run.ID != "0002" {
+		t.Fatalf("Expected only the first run after the abort, got %d runs", len(runs))
+	}
+
+	tx, err = sm.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := history.Record("committed", state, tx); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	latest, err := history.Get(LatestRun)
+	if err != nil || latest.ID != "0002" || latest.Command != "committed" {
+		t.Fatalf("Expected run 0002 to be committed, got %v, %v", latest, err)
+	}
+	if _, err := history.LoadState(latest); err != nil {
+		t.Errorf("Expected the run state to be stored: %v", err)
+	}
+}
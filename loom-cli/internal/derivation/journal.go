@@ -0,0 +1,338 @@
+package derivation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// =============================================================================
+// Journaled Writes
+// =============================================================================
+
+const (
+	// JournalFileName is the write-ahead journal of the running derivation
+	JournalFileName = "journal.json"
+
+	// stagedSuffix marks files staged by a transaction next to their targets
+	stagedSuffix = ".loom-staged"
+)
+
+// JournalStatus is the progress of a journaled transaction
+type JournalStatus string
+
+const (
+	// JournalPending means files are being staged; recovery discards them
+	JournalPending JournalStatus = "pending"
+
+	// JournalCommitted means all files are staged; recovery moves them in
+	JournalCommitted JournalStatus = "committed"
+)
+
+// Journal lists the writes of a transaction
+type Journal struct {
+	ID        string         `json:"id"`
+	Status    JournalStatus  `json:"status"`
+	StartedAt time.Time      `json:"started_at"`
+	Entries   []JournalEntry `json:"entries"`
+}
+
+// JournalEntry is one staged file
+type JournalEntry struct {
+	Target string `json:"target"`
+	Staged string `json:"staged"`
+}
+
+// Recovery describes a transaction finished or discarded after a crash
+type Recovery struct {
+	ID string
+
+	// RolledForward is true if the transaction had committed and its files
+	// were moved in, false if its staged files were discarded
+	RolledForward bool
+
+	// Files lists the targets that were replaced
+	Files []string
+}
+
+// Transaction stages the writes of a derivation run. Documents and state
+// are written to staged files and listed in the journal; Commit marks the
+// journal committed and renames them into place. A crash before that point
+// leaves the project untouched, a crash after it is completed by the next
+// process that takes the lock.
+//
+// A nil *Transaction writes directly, each file atomically, so callers can
+// pass one optionally.
+type Transaction struct {
+	sm      *StateManager
+	journal Journal
+	staged  map[string]string // target → staged path
+	done    bool
+}
+
+// Begin takes the state lock and starts a transaction
+func (sm *StateManager) Begin() (*Transaction, error) {
+	if err := sm.Lock(); err != nil {
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	tx := &Transaction{
+		sm: sm,
+		journal: Journal{
+			ID:        time.Now().UTC().Format("20060102T150405.000000000"),
+			Status:    JournalPending,
+			StartedAt: time.Now(),
+		},
+		staged: make(map[string]string),
+	}
+	if err := tx.writeJournal(); err != nil {
+		sm.Unlock()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// WriteFile stages content for path
+func (tx *Transaction) WriteFile(path string, data []byte) error {
+	if tx == nil {
+		return writeFileDirect(path, data)
+	}
+	if tx.done {
+		return fmt.Errorf("transaction %s is already finished", tx.journal.ID)
+	}
+
+	target, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	staged, ok := tx.staged[target]
+	if !ok {
+		staged = target + "." + tx.journal.ID + stagedSuffix
+		tx.staged[target] = staged
+		tx.journal.Entries = append(tx.journal.Entries, JournalEntry{Target: target, Staged: staged})
+
+		// Journal the file before creating it so recovery can remove it
+		if err := tx.writeJournal(); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(staged), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return writeFileSynced(staged, data)
+}
+
+// ReadFile returns the staged content of path, or its content on disk
+func (tx *Transaction) ReadFile(path string) ([]byte, error) {
+	if tx != nil {
+		if target, err := filepath.Abs(path); err == nil {
+			if staged, ok := tx.staged[target]; ok {
+				return os.ReadFile(staged)
+			}
+		}
+	}
+	return os.ReadFile(path)
+}
+
+// Discard drops the staged write of path, leaving the file on disk as it
+// was. Without a transaction the file is removed.
+func (tx *Transaction) Discard(path string) error {
+	if tx == nil {
+		return os.Remove(path)
+	}
+	target, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	staged, ok := tx.staged[target]
+	if !ok {
+		return nil
+	}
+	delete(tx.staged, target)
+	if err := os.Remove(staged); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to discard %s: %w", path, err)
+	}
+	return nil
+}
+
+// SaveState stages the derivation state
+func (tx *Transaction) SaveState(state *DerivationState) error {
+	state.Version = StateVersion
+	return tx.sm.Store.Save(state, tx.WriteFile)
+}
+
+// Commit moves all staged files into place and releases the lock
+func (tx *Transaction) Commit() error {
+	if tx.done {
+		return fmt.Errorf("transaction %s is already finished", tx.journal.ID)
+	}
+	defer tx.finish()
+
+	// Writes whose staged file was removed were dropped
+	entries := tx.journal.Entries[:0]
+	for _, entry := range tx.journal.Entries {
+		if _, err := os.Stat(entry.Staged); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	tx.journal.Entries = entries
+
+	// The committed journal is the commit point
+	tx.journal.Status = JournalCommitted
+	if err := tx.writeJournal(); err != nil {
+		tx.discard()
+		return err
+	}
+	if _, err := tx.sm.applyJournal(&tx.journal); err != nil {
+		return err
+	}
+	return tx.sm.removeJournal()
+}
+
+// Abort discards the staged files and releases the lock. It does nothing
+// after Commit, so it can be deferred.
+func (tx *Transaction) Abort() error {
+	if tx == nil || tx.done {
+		return nil
+	}
+	defer tx.finish()
+	tx.discard()
+	return tx.sm.removeJournal()
+}
+
+func (tx *Transaction) discard() {
+	for _, entry := range tx.journal.Entries {
+		os.Remove(entry.Staged)
+	}
+}
+
+func (tx *Transaction) finish() {
+	tx.done = true
+	tx.sm.Unlock()
+}
+
+func (tx *Transaction) writeJournal() error {
+	data, err := json.MarshalIndent(tx.journal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal: %w", err)
+	}
+	if err := writeFileAtomic(tx.sm.journalPath(), data); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// Recovery
+// =============================================================================
+
+func (sm *StateManager) journalPath() string {
+	return filepath.Join(sm.LoomDir, JournalFileName)
+}
+
+// Recover finishes a transaction interrupted by a crash: a committed one is
+// rolled forward, a pending one is discarded. It must be called with the
+// lock held; Lock does so.
+func (sm *StateManager) Recover() (*Recovery, error) {
+	data, err := os.ReadFile(sm.journalPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var journal Journal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("failed to parse journal: %w", err)
+	}
+
+	recovery := &Recovery{ID: journal.ID}
+	if journal.Status == JournalCommitted {
+		recovery.RolledForward = true
+		if recovery.Files, err = sm.applyJournal(&journal); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, entry := range journal.Entries {
+			os.Remove(entry.Staged)
+		}
+	}
+
+	if err := sm.removeJournal(); err != nil {
+		return nil, err
+	}
+	return recovery, nil
+}
+
+// applyJournal renames the staged files of a committed journal into place.
+// Entries whose staged file is gone were already moved.
+func (sm *StateManager) applyJournal(journal *Journal) ([]string, error) {
+	var files []string
+	for _, entry := range journal.Entries {
+		if _, err := os.Stat(entry.Staged); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Rename(entry.Staged, entry.Target); err != nil {
+			return files, fmt.Errorf("failed to move %s into place: %w", entry.Target, err)
+		}
+		files = append(files, entry.Target)
+	}
+	return files, nil
+}
+
+func (sm *StateManager) removeJournal() error {
+	if err := os.Remove(sm.journalPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// Atomic Writes
+// =============================================================================
+
+// writeFileDirect writes path atomically, creating its directory
+func writeFileDirect(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic replaces path with data so that readers see either the
+// old or the new content, never a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := writeFileSynced(tmpPath, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeFileSynced writes data and flushes it to disk
+func writeFileSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
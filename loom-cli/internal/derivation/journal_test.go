@@ -0,0 +1,174 @@
+package derivation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTransaction_Commit(t *testing.T) {
+	projectDir := t.TempDir()
+	path := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", "old")
+	sm := NewStateManager(projectDir)
+
+	tx, err := sm.Begin()
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	defer tx.Abort()
+
+	if err := tx.WriteFile(path, []byte("new")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := tx.SaveState(sm.NewState()); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+
+	// Nothing is visible before the commit, except through the transaction
+	if content, _ := os.ReadFile(path); string(content) != "old" {
+		t.Errorf("Expected the document to be untouched before commit, got %q", content)
+	}
+	if content, _ := tx.ReadFile(path); string(content) != "new" {
+		t.Errorf("Expected the transaction to read its staged content, got %q", content)
+	}
+	if _, err := os.Stat(sm.StatePath); !os.IsNotExist(err) {
+		t.Error("Expected no state before commit")
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "new" {
+		t.Errorf("Expected the committed document, got %q", content)
+	}
+	if _, err := os.Stat(sm.StatePath); err != nil {
+		t.Errorf("Expected the state to be written: %v", err)
+	}
+	if _, err := os.Stat(sm.journalPath()); !os.IsNotExist(err) {
+		t.Error("Expected the journal to be removed")
+	}
+	if sm.IsLocked() {
+		t.Error("Expected the lock to be released")
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Expected no staged files to be left, got %d entries", len(entries))
+	}
+}
+
+func TestTransaction_AbortAndDiscard(t *testing.T) {
+	projectDir := t.TempDir()
+	path := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", "old")
+	other := filepath.Join(projectDir, "l1", "business-rules.md")
+	sm := NewStateManager(projectDir)
+
+	tx, err := sm.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.WriteFile(path, []byte("new"))
+	tx.WriteFile(other, []byte("rules"))
+
+	// A discarded write is dropped and the file on disk is kept
+	if err := tx.Discard(path); err != nil {
+		t.Fatalf("Discard failed: %v", err)
+	}
+	if content, _ := tx.ReadFile(path); string(content) != "old" {
+		t.Errorf("Expected the discarded write to be dropped, got %q", content)
+	}
+
+	if err := tx.Abort(); err != nil {
+		t.Fatalf("Abort failed: %v", err)
+	}
+	if _, err := os.Stat(other); !os.IsNotExist(err) {
+		t.Error("Expected aborted writes not to be applied")
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Expected staged files to be removed, got %d entries", len(entries))
+	}
+	if err := tx.Commit(); err == nil {
+		t.Error("Expected an aborted transaction not to commit")
+	}
+}
+
+// crashedJournal leaves a journal as a process dying mid-derivation would
+func crashedJournal(t *testing.T, sm *StateManager, status JournalStatus, target, content string) string {
+	t.Helper()
+	staged := target + ".crashed" + stagedSuffix
+	if err := os.WriteFile(staged, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	journal := Journal{
+		ID:        "crashed",
+		Status:    status,
+		StartedAt: time.Now(),
+		Entries:   []JournalEntry{{Target: target, Staged: staged}},
+	}
+	data, _ := json.Marshal(journal)
+	if err := os.MkdirAll(sm.LoomDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sm.journalPath(), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return staged
+}
+
+func TestStateManager_RecoverPending(t *testing.T) {
+	projectDir := t.TempDir()
+	path := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", "old")
+	sm := NewStateManager(projectDir)
+	staged := crashedJournal(t, sm, JournalPending, path, "half")
+
+	if err := sm.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	sm.Unlock()
+
+	if sm.Recovered == nil || sm.Recovered.RolledForward {
+		t.Fatalf("Expected the pending transaction to be discarded, got %+v", sm.Recovered)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "old" {
+		t.Errorf("Expected the document to be untouched, got %q", content)
+	}
+	if _, err := os.Stat(staged); !os.IsNotExist(err) {
+		t.Error("Expected the staged file to be removed")
+	}
+	if _, err := os.Stat(sm.journalPath()); !os.IsNotExist(err) {
+		t.Error("Expected the journal to be removed")
+	}
+}
+
+func TestStateManager_RecoverCommitted(t *testing.T) {
+	projectDir := t.TempDir()
+	path := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", "old")
+	sm := NewStateManager(projectDir)
+	crashedJournal(t, sm, JournalCommitted, path, "new")
+
+	// Loading the state finishes the committed transaction
+	if _, err := NewStateManager(projectDir).Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "new" {
+		t.Errorf("Expected the committed document to be moved in, got %q", content)
+	}
+	if _, err := os.Stat(sm.journalPath()); !os.IsNotExist(err) {
+		t.Error("Expected the journal to be removed")
+	}
+}
+
+func TestTransaction_NilWritesDirectly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "asyncapi.json")
+	var tx *Transaction
+
+	if err := tx.WriteFile(path, []byte("{}")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if content, err := tx.ReadFile(path); err != nil || string(content) != "{}" {
+		t.Errorf("Expected the file to be written, got %q, %v", content, err)
+	}
+	if err := tx.Abort(); err != nil {
+		t.Errorf("Expected Abort to do nothing, got %v", err)
+	}
+}
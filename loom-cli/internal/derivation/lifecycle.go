@@ -0,0 +1,559 @@
+package derivation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// =============================================================================
+// Artifact Lifecycle
+// =============================================================================
+
+// DeprecationNote starts the note left under the heading of a deprecated
+// artifact
+const DeprecationNote = "> **Deprecated:**"
+
+// LifecycleResult describes a deprecate, split, merge or delete
+type LifecycleResult struct {
+	Deprecated []string `json:"deprecated,omitempty"`
+	Created    []string `json:"created,omitempty"`
+	Deleted    []string `json:"deleted,omitempty"`
+
+	// Stale lists downstream artifacts that lost an upstream but keep others
+	Stale []string `json:"stale,omitempty"`
+
+	// Orphaned lists downstream artifacts left without any upstream
+	Orphaned []string `json:"orphaned,omitempty"`
+
+	// Affected lists artifacts further downstream
+	Affected []string `json:"affected,omitempty"`
+
+	// Files lists the project-relative documents changed
+	Files []string `json:"files,omitempty"`
+
+	// Run is the history run recording the operation
+	Run *Run `json:"run,omitempty"`
+}
+
+// DeprecatedIDs maps the deprecated artifacts to their replacements
+func (s *DerivationState) DeprecatedIDs() map[string][]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make(map[string][]string)
+	for id, a := range s.Artifacts {
+		if a.Status == StatusDeprecated {
+			ids[id] = a.ReplacedBy
+		}
+	}
+	return ids
+}
+
+// Deprecate retires an artifact, optionally in favour of replacements. It
+// stays in its document with a deprecation note; its downstream artifacts
+// are moved to the replacements and marked stale, or orphaned if nothing
+// is left upstream of them.
+func (sm *StateManager) Deprecate(id string, replacedBy []string, reason string) (*LifecycleResult, error) {
+	l, err := sm.beginLifecycle("artifact deprecate " + id)
+	if err != nil {
+		return nil, err
+	}
+	defer l.tx.Abort()
+
+	a, err := l.active(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range replacedBy {
+		if _, err := l.active(r); err != nil {
+			return nil, err
+		}
+		if r == id {
+			return nil, fmt.Errorf("%s cannot replace itself", id)
+		}
+	}
+	if err := l.deprecate(a, replacedBy, reason); err != nil {
+		return nil, err
+	}
+	return l.commit()
+}
+
+// Split replaces an artifact by new ones. Each starts as a copy of its
+// section under the new ID, to be edited apart; the original is deprecated.
+func (sm *StateManager) Split(id string, into []string) (*LifecycleResult, error) {
+	if len(into) < 2 {
+		return nil, fmt.Errorf("split needs at least two new IDs")
+	}
+	l, err := sm.beginLifecycle("artifact split " + id)
+	if err != nil {
+		return nil, err
+	}
+	defer l.tx.Abort()
+
+	a, err := l.active(id)
+	if err != nil {
+		return nil, err
+	}
+	path := l.docPath(a)
+	content, err := l.read(path)
+	if err != nil {
+		return nil, err
+	}
+	start, end, ok := sectionBounds(content, id)
+	if !ok {
+		return nil, fmt.Errorf("no generated section for %s in %s", id, a.Location.File)
+	}
+
+	lines := strings.Split(content, "\n")
+	section := strings.Join(lines[start-1:end+1], "\n")
+	copies := make([]string, 0, len(into))
+	for _, newID := range into {
+		if l.state.GetArtifact(newID) != nil {
+			return nil, fmt.Errorf("%s already exists", newID)
+		}
+		rename, err := NewIDRename(id, newID)
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, "", rename.Text(section))
+
+		created := &Artifact{
+			ID:        newID,
+			Type:      a.Type,
+			Layer:     a.Layer,
+			Location:  ArtifactLocation{File: a.Location.File},
+			Upstream:  make(map[string]string),
+			Decisions: append([]string(nil), a.Decisions...),
+			DerivedAt: time.Now(),
+			Status:    StatusNew,
+		}
+		for up, hash := range a.Upstream {
+			l.link(up, created, hash)
+		}
+		for _, decID := range created.Decisions {
+			if d := l.state.GetDecision(decID); d != nil && !containsString(d.Affects, newID) {
+				d.Affects = append(d.Affects, newID)
+			}
+		}
+		l.state.SetArtifact(created)
+		l.changed[newID] = true
+		l.result.Created = append(l.result.Created, newID)
+	}
+
+	out := append(append(append([]string{}, lines[:end+1]...), copies...), lines[end+1:]...)
+	l.write(path, strings.Join(out, "\n"))
+
+	if err := l.deprecate(a, into, "Split into "+strings.Join(into, ", ")+"."); err != nil {
+		return nil, err
+	}
+	return l.commit()
+}
+
+// Merge folds artifacts into an existing one: it takes over their upstream
+// and downstream links and is marked stale to be derived again, and they
+// are deprecated in its favour
+func (sm *StateManager) Merge(ids []string, into string) (*LifecycleResult, error) {
+	l, err := sm.beginLifecycle("artifact merge " + strings.Join(ids, " "))
+	if err != nil {
+		return nil, err
+	}
+	defer l.tx.Abort()
+
+	target, err := l.active(into)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if id == into {
+			return nil, fmt.Errorf("cannot merge %s into itself", id)
+		}
+		a, err := l.active(id)
+		if err != nil {
+			return nil, err
+		}
+		for up, hash := range a.Upstream {
+			if _, ok := target.Upstream[up]; !ok && up != into {
+				l.link(up, target, hash)
+			}
+		}
+		if err := l.deprecate(a, []string{into}, "Merged into "+into+"."); err != nil {
+			return nil, err
+		}
+	}
+	target.Status = StatusStale
+	l.result.Stale = appendUnique(l.result.Stale, into)
+	return l.commit()
+}
+
+// Delete removes an artifact from its document and the state. Downstream
+// artifacts are marked stale, or orphaned if nothing is left upstream of
+// them; with cascade orphaned artifacts are deleted as well.
+func (sm *StateManager) Delete(id string, cascade bool) (*LifecycleResult, error) {
+	l, err := sm.beginLifecycle("artifact delete " + id)
+	if err != nil {
+		return nil, err
+	}
+	defer l.tx.Abort()
+
+	if l.state.GetArtifact(id) == nil {
+		return nil, fmt.Errorf("artifact not found: %s", id)
+	}
+
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if l.state.GetArtifact(current) == nil {
+			continue
+		}
+		orphaned := l.detach(current, nil)
+		if err := l.remove(current); err != nil {
+			return nil, err
+		}
+		if cascade {
+			queue = append(queue, orphaned...)
+		}
+	}
+
+	// Cascaded artifacts are gone rather than orphaned
+	var orphaned []string
+	for _, o := range l.result.Orphaned {
+		if l.state.GetArtifact(o) != nil {
+			orphaned = append(orphaned, o)
+		}
+	}
+	l.result.Orphaned = orphaned
+	l.result.Stale = filterExisting(l.state, l.result.Stale)
+	l.result.Affected = filterExisting(l.state, l.result.Affected)
+	return l.commit()
+}
+
+// lifecycle is a lifecycle operation in progress
+type lifecycle struct {
+	sm      *StateManager
+	tx      *Transaction
+	command string // recorded in the history
+	state   *DerivationState
+	docs    map[string]string // path → updated content
+	result  *LifecycleResult
+
+	// changed lists the artifacts whose sections were rewritten
+	changed map[string]bool
+
+	// removedBases are merge bases to remove once committed
+	removedBases []string
+}
+
+// beginLifecycle takes the lock before loading the state so that a
+// concurrent save cannot be lost
+func (sm *StateManager) beginLifecycle(command string) (*lifecycle, error) {
+	tx, err := sm.Begin()
+	if err != nil {
+		return nil, err
+	}
+	state, err := sm.Load()
+	if err != nil {
+		tx.Abort()
+		return nil, err
+	}
+	return &lifecycle{
+		sm:      sm,
+		tx:      tx,
+		command: command,
+		state:   state,
+		docs:    make(map[string]string),
+		result:  &LifecycleResult{},
+		changed: make(map[string]bool),
+	}, nil
+}
+
+// active returns an artifact that is not deprecated
+func (l *lifecycle) active(id string) (*Artifact, error) {
+	a := l.state.GetArtifact(id)
+	if a == nil {
+		return nil, fmt.Errorf("artifact not found: %s", id)
+	}
+	if a.Status == StatusDeprecated {
+		return nil, fmt.Errorf("%s is deprecated", id)
+	}
+	return a, nil
+}
+
+func (l *lifecycle) docPath(a *Artifact) string {
+	if filepath.IsAbs(a.Location.File) {
+		return a.Location.File
+	}
+	return filepath.Join(l.sm.ProjectDir, a.Location.File)
+}
+
+func (l *lifecycle) read(path string) (string, error) {
+	if content, ok := l.docs[path]; ok {
+		return content, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+func (l *lifecycle) write(path, content string) {
+	l.docs[path] = content
+}
+
+// link adds an upstream edge to an artifact
+func (l *lifecycle) link(upID string, a *Artifact, hash string) {
+	a.Upstream[upID] = hash
+	l.state.DependencyGraph.AddEdge(upID, a.ID, EdgeDerives)
+	if up := l.state.GetArtifact(upID); up != nil && !containsString(up.Downstream, a.ID) {
+		up.Downstream = append(up.Downstream, a.ID)
+	}
+}
+
+// deprecate marks an artifact deprecated, notes it in its document and
+// moves its downstream artifacts to the replacements
+func (l *lifecycle) deprecate(a *Artifact, replacedBy []string, reason string) error {
+	if a.Location.LineStart > 0 {
+		path := l.docPath(a)
+		content, err := l.read(path)
+		if err != nil {
+			return err
+		}
+		if start, _, ok := sectionBounds(content, a.ID); ok {
+			lines := strings.Split(content, "\n")
+			note := []string{"", l.deprecationNote(a, replacedBy, reason)}
+			out := append(append(append([]string{}, lines[:start+1]...), note...), lines[start+1:]...)
+			l.write(path, strings.Join(out, "\n"))
+			l.changed[a.ID] = true
+		}
+	}
+
+	a.Status = StatusDeprecated
+	a.ReplacedBy = replacedBy
+	l.result.Deprecated = append(l.result.Deprecated, a.ID)
+	l.detach(a.ID, replacedBy)
+	return nil
+}
+
+// deprecationNote links the replacements relative to the document of a
+func (l *lifecycle) deprecationNote(a *Artifact, replacedBy []string, reason string) string {
+	note := DeprecationNote
+	if len(replacedBy) > 0 {
+		links := make([]string, 0, len(replacedBy))
+		for _, id := range replacedBy {
+			file := ""
+			if r := l.state.GetArtifact(id); r != nil && r.Location.File != a.Location.File {
+				if rel, err := filepath.Rel(filepath.Dir(l.docPath(a)), l.docPath(r)); err == nil {
+					file = filepath.ToSlash(rel)
+				}
+			}
+			links = append(links, formatter.ToLink(id, file))
+		}
+		note += " Replaced by " + strings.Join(links, ", ") + "."
+	}
+	if reason != "" {
+		note += " " + reason
+	}
+	return note
+}
+
+// detach cuts an artifact from its downstream artifacts, linking them to
+// the replacements instead, and returns the ones left without upstream
+func (l *lifecycle) detach(id string, replacements []string) []string {
+	graph := l.state.DependencyGraph
+	var orphaned []string
+
+	for _, downID := range graph.GetDownstream(id) {
+		graph.RemoveEdge(id, downID)
+		if a := l.state.GetArtifact(id); a != nil {
+			a.Downstream = removeFromSlice(a.Downstream, downID)
+		}
+		down := l.state.GetArtifact(downID)
+		if down == nil {
+			continue
+		}
+		delete(down.Upstream, id)
+		for _, r := range replacements {
+			if r == downID {
+				continue
+			}
+			if rep := l.state.GetArtifact(r); rep != nil {
+				l.link(r, down, rep.ContentHash)
+			}
+		}
+		if down.Status == StatusDeprecated {
+			continue
+		}
+
+		if len(down.Upstream) == 0 {
+			down.Status = StatusOrphaned
+			l.result.Orphaned = appendUnique(l.result.Orphaned, downID)
+			orphaned = append(orphaned, downID)
+		} else {
+			down.Status = StatusStale
+			l.result.Stale = appendUnique(l.result.Stale, downID)
+		}
+		for _, affectedID := range graph.GetAllDownstream(downID) {
+			if affected := l.state.GetArtifact(affectedID); affected != nil && affected.Status == StatusCurrent {
+				affected.Status = StatusAffected
+				l.result.Affected = appendUnique(l.result.Affected, affectedID)
+			}
+		}
+	}
+	return orphaned
+}
+
+// remove deletes an artifact's section, state and merge base
+func (l *lifecycle) remove(id string) error {
+	a := l.state.GetArtifact(id)
+	if a.Location.LineStart > 0 {
+		path := l.docPath(a)
+		content, err := l.read(path)
+		if err != nil {
+			return err
+		}
+		if start, end, ok := sectionBounds(content, id); ok {
+			lines := strings.Split(content, "\n")
+
+			// The blank lines and rule after a section go with it
+			next := end + 1
+			for next < len(lines) {
+				trimmed := strings.TrimSpace(lines[next])
+				if trimmed != "" && trimmed != "---" {
+					break
+				}
+				next++
+			}
+			out := append(append([]string{}, lines[:start-1]...), lines[next:]...)
+			l.write(path, strings.Join(out, "\n"))
+		}
+	}
+
+	l.state.RemoveArtifact(id)
+	l.state.DependencyGraph.RemoveNode(id)
+	for _, other := range l.state.Artifacts {
+		other.Downstream = removeFromSlice(other.Downstream, id)
+		delete(other.Upstream, id)
+	}
+	for _, d := range l.state.Decisions {
+		d.Affects = removeFromSlice(d.Affects, id)
+	}
+	delete(l.changed, id)
+	l.removedBases = append(l.removedBases, l.sm.basePath(id))
+	l.result.Deleted = append(l.result.Deleted, id)
+	return nil
+}
+
+// commit stages the documents, updates the locations and hashes of the
+// artifacts in them, refreshes the merge bases of rewritten sections and
+// commits with the state
+func (l *lifecycle) commit() (*LifecycleResult, error) {
+	hasher := NewHasher()
+	hasher.Tx = l.tx
+
+	paths := make([]string, 0, len(l.docs))
+	for path := range l.docs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	rehashed := make(map[string][2]string) // id → old, new hash
+	for _, path := range paths {
+		content := l.docs[path]
+		if err := l.tx.WriteFile(path, []byte(content)); err != nil {
+			return nil, fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(l.sm.ProjectDir, path)
+		if err != nil {
+			rel = path
+		}
+		l.result.Files = append(l.result.Files, filepath.ToSlash(rel))
+
+		bodies := GeneratedSections(content)
+		for _, parsed := range NewParser().ParseContent(content, path).Artifacts {
+			a := l.state.GetArtifact(parsed.ID)
+			if a == nil {
+				continue
+			}
+			a.Location.LineStart = parsed.Location.LineStart
+			a.Location.LineEnd = parsed.Location.LineEnd
+			a.Location.Anchor = parsed.Location.Anchor
+			if !l.changed[a.ID] {
+				continue
+			}
+
+			hash, err := hasher.HashArtifact(a, l.sm.ProjectDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to hash %s: %w", a.ID, err)
+			}
+			rehashed[a.ID] = [2]string{a.ContentHash, hash}
+			a.ContentHash = hash
+			if body, ok := bodies[a.ID]; ok {
+				if err := l.tx.WriteFile(l.sm.basePath(a.ID), []byte(body)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// A rewritten section is not a change its downstream must follow
+	for _, a := range l.state.Artifacts {
+		for _, hashes := range []map[string]string{a.Upstream, a.DerivedFromHashes} {
+			for up, recorded := range hashes {
+				if h, ok := rehashed[up]; ok && recorded == h[0] {
+					hashes[up] = h[1]
+				}
+			}
+		}
+	}
+
+	for _, path := range l.removedBases {
+		if err := l.tx.Discard(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.tx.SaveState(l.state); err != nil {
+		return nil, fmt.Errorf("failed to stage state: %w", err)
+	}
+	run, err := NewHistory(l.sm).Record(l.command, l.state, l.tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record history: %w", err)
+	}
+	l.result.Run = run
+	if err := l.tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+	for _, path := range l.removedBases {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove base: %w", err)
+		}
+	}
+
+	for _, ids := range [][]string{l.result.Stale, l.result.Orphaned, l.result.Affected} {
+		sort.Strings(ids)
+	}
+	return l.result, nil
+}
+
+func appendUnique(slice []string, value string) []string {
+	if containsString(slice, value) {
+		return slice
+	}
+	return append(slice, value)
+}
+
+func filterExisting(state *DerivationState, ids []string) []string {
+	var existing []string
+	for _, id := range ids {
+		if state.GetArtifact(id) != nil {
+			existing = append(existing, id)
+		}
+	}
+	return existing
+}
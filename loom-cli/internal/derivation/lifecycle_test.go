@@ -0,0 +1,157 @@
+package derivation
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const lifecycleL1Doc = recordL1Doc + `
+## BR-ORD-002 – Cart limit {#br-ord-002}
+
+**Rule:** An order has at most 50 items
+
+---
+`
+
+// lifecycleProject records an L1 document and the L2 tech spec derived
+// from AC-ORD-001 and BR-ORD-001
+func lifecycleProject(t *testing.T) (sm *StateManager, acPath, tsPath string) {
+	t.Helper()
+	projectDir := t.TempDir()
+	acPath = writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", lifecycleL1Doc)
+	tsPath = writeRecordFile(t, projectDir, "l2/tech-specs.md", recordL2Doc)
+	recordRun(t, projectDir, "l1", acPath)
+	recordRun(t, projectDir, "l2", tsPath)
+	return NewStateManager(projectDir), acPath, tsPath
+}
+
+func TestStateManager_Deprecate(t *testing.T) {
+	sm, acPath, _ := lifecycleProject(t)
+
+	result, err := sm.Deprecate("BR-ORD-001", []string{"BR-ORD-002"}, "Superseded.")
+	if err != nil {
+		t.Fatalf("Deprecate failed: %v", err)
+	}
+	if len(result.Stale) != 1 || result.Stale[0] != "TS-BR-ORD-001" {
+		t.Errorf("Expected the tech spec to turn stale, got %+v", result)
+	}
+
+	data, _ := os.ReadFile(acPath)
+	if !strings.Contains(string(data), "## BR-ORD-001 – Cart not empty {#br-ord-001}\n\n> **Deprecated:** Replaced by [BR-ORD-002](#br-ord-002). Superseded.") {
+		t.Errorf("Expected a deprecation note\n%s", data)
+	}
+
+	state, _ := sm.Load()
+	br := state.GetArtifact("BR-ORD-001")
+	if br.Status != StatusDeprecated || len(br.ReplacedBy) != 1 {
+		t.Errorf("Expected BR-ORD-001 deprecated, got %+v", br)
+	}
+	if current, _ := NewHasher().HashArtifact(br, sm.ProjectDir); current != br.ContentHash {
+		t.Error("Expected the deprecated section to be rehashed")
+	}
+	ts := state.GetArtifact("TS-BR-ORD-001")
+	if _, ok := ts.Upstream["BR-ORD-001"]; ok || ts.Upstream["BR-ORD-002"] == "" {
+		t.Errorf("Expected the tech spec moved to the replacement, got %v", ts.Upstream)
+	}
+	if !state.DependencyGraph.HasEdge("BR-ORD-002", "TS-BR-ORD-001") || state.DependencyGraph.HasEdge("BR-ORD-001", "TS-BR-ORD-001") {
+		t.Error("Expected the edge moved to the replacement")
+	}
+
+	stale, _ := NewTracker(state, sm.ProjectDir).DetectStaleArtifacts()
+	if len(stale) != 1 || stale[0].ID != "TS-BR-ORD-001" {
+		t.Errorf("Expected rederive to pick up the stale tech spec, got %d", len(stale))
+	}
+
+	if _, err := sm.Deprecate("BR-ORD-001", nil, ""); err == nil {
+		t.Error("Expected an error deprecating twice")
+	}
+}
+
+func TestStateManager_Split(t *testing.T) {
+	sm, acPath, _ := lifecycleProject(t)
+
+	result, err := sm.Split("AC-ORD-001", []string{"AC-ORD-002", "AC-ORD-003"})
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(result.Created) != 2 || len(result.Deprecated) != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	data, _ := os.ReadFile(acPath)
+	for _, heading := range []string{"## AC-ORD-002 – Place order {#ac-ord-002}", "## AC-ORD-003 – Place order {#ac-ord-003}"} {
+		if !strings.Contains(string(data), heading) {
+			t.Errorf("Expected a copy under %s\n%s", heading, data)
+		}
+	}
+
+	state, _ := sm.Load()
+	created := state.GetArtifact("AC-ORD-003")
+	if created == nil || created.Status != StatusNew || created.Location.LineStart == 0 {
+		t.Fatalf("Expected the new artifact located in state, got %+v", created)
+	}
+	if current, _ := NewHasher().HashArtifact(created, sm.ProjectDir); current != created.ContentHash {
+		t.Error("Expected the new artifact to be hashed")
+	}
+	ts := state.GetArtifact("TS-BR-ORD-001")
+	if ts.Status != StatusStale || ts.Upstream["AC-ORD-002"] == "" || ts.Upstream["AC-ORD-003"] == "" {
+		t.Errorf("Expected the tech spec moved to both new artifacts, got %+v", ts)
+	}
+	if _, ok, _ := sm.LoadBase("AC-ORD-002"); !ok {
+		t.Error("Expected a merge base for the new artifact")
+	}
+}
+
+func TestStateManager_Merge(t *testing.T) {
+	sm, _, _ := lifecycleProject(t)
+
+	if _, err := sm.Merge([]string{"BR-ORD-002"}, "BR-ORD-001"); err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	state, _ := sm.Load()
+	if state.GetArtifact("BR-ORD-002").Status != StatusDeprecated || state.GetArtifact("BR-ORD-001").Status != StatusStale {
+		t.Error("Expected the merged artifact deprecated and the target stale")
+	}
+	if ids := state.DeprecatedIDs(); len(ids) != 1 || ids["BR-ORD-002"][0] != "BR-ORD-001" {
+		t.Errorf("Unexpected deprecated IDs %v", ids)
+	}
+
+	if _, err := sm.Merge([]string{"BR-ORD-001"}, "BR-ORD-002"); err == nil {
+		t.Error("Expected an error merging into a deprecated artifact")
+	}
+}
+
+func TestStateManager_Delete(t *testing.T) {
+	sm, acPath, tsPath := lifecycleProject(t)
+
+	result, err := sm.Delete("AC-ORD-001", false)
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(result.Stale) != 1 || len(result.Orphaned) != 0 {
+		t.Errorf("Expected the tech spec stale while BR-ORD-001 remains, got %+v", result)
+	}
+	data, _ := os.ReadFile(acPath)
+	if strings.Contains(string(data), "AC-ORD-001") || !strings.Contains(string(data), "---\n\n<!-- LOOM:BEGIN generated id=\"BR-ORD-001\"") {
+		t.Errorf("Expected the section removed with its rule\n%s", data)
+	}
+	if _, ok, _ := sm.LoadBase("AC-ORD-001"); ok {
+		t.Error("Expected the merge base removed")
+	}
+
+	result, err = sm.Delete("BR-ORD-001", true)
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(result.Deleted) != 2 || len(result.Orphaned) != 0 {
+		t.Errorf("Expected the orphaned tech spec deleted too, got %+v", result)
+	}
+	state, _ := sm.Load()
+	if state.GetArtifact("TS-BR-ORD-001") != nil || state.DependencyGraph.EdgeCount() != 0 {
+		t.Error("Expected the cascade to clear the state")
+	}
+	if data, _ := os.ReadFile(tsPath); strings.Contains(string(data), "TS-BR-ORD-001") {
+		t.Errorf("Expected the tech spec section removed\n%s", data)
+	}
+}
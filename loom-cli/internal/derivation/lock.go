@@ -0,0 +1,440 @@
+package derivation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// File Locking
+// =============================================================================
+
+// Commands that modify the state hold the exclusive lock file; read-only
+// commands hold one shared lock file each under .loom/readers. A writer
+// creates its lock file and then waits for the readers to finish, a reader
+// creates its file and backs off if a writer holds the lock, so either
+// order of arrival ends with one side waiting.
+//
+// Holders refresh the modification time of their lock file every
+// LockHeartbeatInterval. A lock is stale when its owner is no longer
+// running on this host or its heartbeat is older than LockStaleTimeout.
+
+// LockMode is how a lock is held
+type LockMode string
+
+const (
+	// LockExclusive is held by commands that modify the state
+	LockExclusive LockMode = "exclusive"
+
+	// LockShared is held by read-only commands
+	LockShared LockMode = "shared"
+)
+
+// LockInfo describes the owner of a lock
+type LockInfo struct {
+	PID      int       `json:"pid"`
+	Hostname string    `json:"hostname"`
+	Mode     LockMode  `json:"mode,omitempty"`
+	LockedAt time.Time `json:"locked_at"`
+
+	// HeartbeatAt is when the owner last refreshed the lock
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+
+	// Path is the lock file
+	Path string `json:"path"`
+
+	// Stale is true if the owner is gone
+	Stale bool `json:"stale"`
+}
+
+// String describes the owner for messages
+func (l *LockInfo) String() string {
+	return fmt.Sprintf("pid %d on %s, since %s", l.PID, l.Hostname, l.LockedAt.Local().Format("2006-01-02 15:04:05"))
+}
+
+// LockStatus lists the locks held on a project's state
+type LockStatus struct {
+	Exclusive *LockInfo   `json:"exclusive,omitempty"`
+	Shared    []*LockInfo `json:"shared,omitempty"`
+}
+
+func (sm *StateManager) readersDir() string {
+	return filepath.Join(sm.LoomDir, ReadersDirName)
+}
+
+// Lock acquires an exclusive lock on the state file
+// This prevents concurrent modifications from multiple processes
+func (sm *StateManager) Lock() error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if sm.lockFile != nil {
+		return nil // Already locked by this process
+	}
+	if sm.sharedPath != "" {
+		return fmt.Errorf("cannot take an exclusive lock while holding a shared one")
+	}
+
+	// Ensure .loom directory exists
+	if err := os.MkdirAll(sm.LoomDir, 0755); err != nil {
+		return fmt.Errorf("failed to create .loom directory: %w", err)
+	}
+
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		f, err := os.OpenFile(sm.LockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			// Got the lock
+			sm.lockFile = f
+			json.NewEncoder(f).Encode(newLockInfo(LockExclusive))
+
+			if err := sm.waitForReaders(deadline); err != nil {
+				sm.release()
+				return err
+			}
+			sm.startHeartbeat(sm.LockPath)
+
+			// Finish a transaction interrupted by a crash
+			recovery, err := sm.Recover()
+			if err != nil {
+				sm.release()
+				return fmt.Errorf("failed to recover interrupted derivation: %w", err)
+			}
+			sm.Recovered = recovery
+
+			return nil
+		}
+
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		// Lock exists, take it over if its owner is gone
+		owner, err := readLockInfo(sm.LockPath)
+		if err == nil && owner.Stale {
+			removeStaleLock(owner)
+			continue
+		}
+
+		// Check if we've timed out
+		if time.Now().After(deadline) {
+			if owner != nil {
+				return fmt.Errorf("timeout waiting for lock held by %s (see 'loom-cli lock status')", owner)
+			}
+			return fmt.Errorf("timeout waiting for lock (another process may be running)")
+		}
+
+		// Wait and retry
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// LockShared acquires a shared lock for reading the state. Any number of
+// readers can hold one while no exclusive lock is held.
+func (sm *StateManager) LockShared() error {
+	sm.mu.Lock()
+	if sm.lockFile != nil || sm.sharedPath != "" {
+		sm.mu.Unlock()
+		return nil // Already locked by this process
+	}
+	sm.mu.Unlock()
+
+	// Without a .loom directory there is no state to protect
+	if _, err := os.Stat(sm.LoomDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	// A derivation interrupted by a crash is finished by a writer first
+	if err := sm.recoverAbandoned(); err != nil {
+		return err
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if err := os.MkdirAll(sm.readersDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create readers directory: %w", err)
+	}
+
+	deadline := time.Now().Add(LockTimeout)
+	for {
+		owner, err := sm.exclusiveOwner()
+		if err != nil {
+			return err
+		}
+		if owner == nil {
+			f, err := os.CreateTemp(sm.readersDir(), "reader-*.lock")
+			if err != nil {
+				return fmt.Errorf("failed to create shared lock file: %w", err)
+			}
+			json.NewEncoder(f).Encode(newLockInfo(LockShared))
+			f.Close()
+
+			// Back off if a writer arrived meanwhile
+			if owner, err = sm.exclusiveOwner(); err == nil && owner == nil {
+				sm.sharedPath = f.Name()
+				sm.startHeartbeat(sm.sharedPath)
+				return nil
+			}
+			os.Remove(f.Name())
+			if err != nil {
+				return err
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for lock held by %s (see 'loom-cli lock status')", owner)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Unlock releases the lock on the state file
+func (sm *StateManager) Unlock() error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.release()
+}
+
+// release drops the lock held by this manager; sm.mu must be held
+func (sm *StateManager) release() error {
+	if sm.heartbeat != nil {
+		close(sm.heartbeat)
+		sm.heartbeat = nil
+	}
+
+	if sm.sharedPath != "" {
+		path := sm.sharedPath
+		sm.sharedPath = ""
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove shared lock file: %w", err)
+		}
+		return nil
+	}
+
+	if sm.lockFile == nil {
+		return nil // Not locked
+	}
+
+	// Close and remove lock file
+	sm.lockFile.Close()
+	sm.lockFile = nil
+
+	if err := os.Remove(sm.LockPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+
+	return nil
+}
+
+// IsLocked checks if this manager holds a lock on the state
+func (sm *StateManager) IsLocked() bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.lockFile != nil || sm.sharedPath != ""
+}
+
+// LockStatus reports the locks currently held on the state
+func (sm *StateManager) LockStatus() (*LockStatus, error) {
+	status := &LockStatus{}
+
+	owner, err := readLockInfo(sm.LockPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	status.Exclusive = owner
+
+	readers, err := sm.readers()
+	if err != nil {
+		return nil, err
+	}
+	status.Shared = readers
+	return status, nil
+}
+
+// BreakLock removes stale locks. With force, locks whose owner still
+// appears to run are removed too. It returns the locks removed.
+func (sm *StateManager) BreakLock(force bool) ([]*LockInfo, error) {
+	status, err := sm.LockStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	var broken []*LockInfo
+	for _, l := range append([]*LockInfo{status.Exclusive}, status.Shared...) {
+		if l == nil || (!l.Stale && !force) {
+			continue
+		}
+		if err := os.Remove(l.Path); err != nil && !os.IsNotExist(err) {
+			return broken, fmt.Errorf("failed to remove %s: %w", l.Path, err)
+		}
+		broken = append(broken, l)
+	}
+	return broken, nil
+}
+
+// recoverAbandoned finishes an interrupted derivation if no process holds
+// the exclusive lock to finish it itself
+func (sm *StateManager) recoverAbandoned() error {
+	if _, err := os.Stat(sm.journalPath()); err != nil || sm.IsLocked() {
+		return nil
+	}
+	if owner, err := sm.exclusiveOwner(); err != nil || owner != nil {
+		return nil
+	}
+	if err := sm.Lock(); err != nil {
+		return err
+	}
+	return sm.Unlock()
+}
+
+// exclusiveOwner returns the live holder of the exclusive lock, removing
+// the lock if it is stale
+func (sm *StateManager) exclusiveOwner() (*LockInfo, error) {
+	owner, err := readLockInfo(sm.LockPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+	if owner.Stale {
+		removeStaleLock(owner)
+		return nil, nil
+	}
+	return owner, nil
+}
+
+// waitForReaders waits until no live shared locks remain
+func (sm *StateManager) waitForReaders(deadline time.Time) error {
+	for {
+		readers, err := sm.readers()
+		if err != nil {
+			return err
+		}
+		live := 0
+		for _, r := range readers {
+			if r.Stale {
+				removeStaleLock(r)
+			} else {
+				live++
+			}
+		}
+		if live == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for %d reader(s) to release the state (see 'loom-cli lock status')", live)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// readers lists the shared locks, oldest first
+func (sm *StateManager) readers() ([]*LockInfo, error) {
+	entries, err := os.ReadDir(sm.readersDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shared locks: %w", err)
+	}
+
+	var readers []*LockInfo
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".lock") {
+			continue
+		}
+		info, err := readLockInfo(filepath.Join(sm.readersDir(), entry.Name()))
+		if err != nil {
+			continue // Released or still being written
+		}
+		readers = append(readers, info)
+	}
+	sort.Slice(readers, func(i, j int) bool {
+		return readers[i].LockedAt.Before(readers[j].LockedAt)
+	})
+	return readers, nil
+}
+
+// startHeartbeat refreshes the lock file until the lock is released;
+// sm.mu must be held
+func (sm *StateManager) startHeartbeat(path string) {
+	stop := make(chan struct{})
+	sm.heartbeat = stop
+	go func() {
+		ticker := time.NewTicker(LockHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				// A lock that was broken is not recreated
+				if err := os.Chtimes(path, now, now); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+func newLockInfo(mode LockMode) *LockInfo {
+	return &LockInfo{
+		PID:      os.Getpid(),
+		Hostname: hostname(),
+		Mode:     mode,
+		LockedAt: time.Now(),
+	}
+}
+
+// readLockInfo reads a lock file and decides whether its owner is gone
+func readLockInfo(path string) (*LockInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// An unreadable lock is being written, or was left half written by a
+	// crash; only its age tells
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		info = LockInfo{LockedAt: stat.ModTime()}
+	}
+	if info.Mode == "" {
+		info.Mode = LockExclusive
+	}
+	info.Path = path
+	info.HeartbeatAt = stat.ModTime()
+	info.Stale = time.Since(info.HeartbeatAt) > LockStaleTimeout ||
+		(info.PID != 0 && info.Hostname == hostname() && !processAlive(info.PID))
+	return &info, nil
+}
+
+// removeStaleLock removes a lock file unless it was taken over since it
+// was read
+func removeStaleLock(l *LockInfo) {
+	current, err := readLockInfo(l.Path)
+	if err != nil || !current.Stale || current.PID != l.PID || !current.LockedAt.Equal(l.LockedAt) {
+		return
+	}
+	os.Remove(l.Path)
+}
+
+// hostname returns the current hostname or "unknown"
+func hostname() string {
+	h, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return h
+}
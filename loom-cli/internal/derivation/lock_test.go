@@ -0,0 +1,133 @@
+package derivation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeLock leaves a lock file as another process would
+func writeLock(t *testing.T, path string, info LockInfo) {
+	t.Helper()
+	data, _ := json.Marshal(info)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// deadPID is beyond any PID the system hands out
+const deadPID = 1 << 30
+
+func TestStateManager_LockTakesOverDeadOwner(t *testing.T) {
+	sm := NewStateManager(t.TempDir())
+	writeLock(t, sm.LockPath, LockInfo{PID: deadPID, Hostname: hostname(), LockedAt: time.Now()})
+
+	status, err := sm.LockStatus()
+	if err != nil {
+		t.Fatalf("LockStatus failed: %v", err)
+	}
+	if status.Exclusive == nil || !status.Exclusive.Stale {
+		t.Fatalf("Expected the lock of a dead process to be stale, got %+v", status.Exclusive)
+	}
+
+	if err := sm.Lock(); err != nil {
+		t.Fatalf("Expected the stale lock to be taken over, got %v", err)
+	}
+	defer sm.Unlock()
+
+	owner, err := readLockInfo(sm.LockPath)
+	if err != nil || owner.PID != os.Getpid() || owner.Mode != LockExclusive {
+		t.Errorf("Expected this process to own the lock, got %+v, %v", owner, err)
+	}
+}
+
+func TestStateManager_LiveOwnerIsNotStale(t *testing.T) {
+	sm := NewStateManager(t.TempDir())
+
+	// Another host: only the heartbeat tells
+	writeLock(t, sm.LockPath, LockInfo{PID: deadPID, Hostname: "elsewhere", LockedAt: time.Now()})
+	status, _ := sm.LockStatus()
+	if status.Exclusive.Stale {
+		t.Error("Expected a fresh lock from another host to be live")
+	}
+
+	old := time.Now().Add(-2 * LockStaleTimeout)
+	os.Chtimes(sm.LockPath, old, old)
+	status, _ = sm.LockStatus()
+	if !status.Exclusive.Stale {
+		t.Error("Expected a lock without heartbeat to be stale")
+	}
+}
+
+func TestStateManager_SharedLocks(t *testing.T) {
+	projectDir := t.TempDir()
+	reader1 := NewStateManager(projectDir)
+	reader2 := NewStateManager(projectDir)
+	writer := NewStateManager(projectDir)
+	os.MkdirAll(reader1.LoomDir, 0755)
+
+	if err := reader1.LockShared(); err != nil {
+		t.Fatalf("LockShared failed: %v", err)
+	}
+	if err := reader2.LockShared(); err != nil {
+		t.Fatalf("Expected readers to share the lock, got %v", err)
+	}
+	status, _ := writer.LockStatus()
+	if len(status.Shared) != 2 || status.Exclusive != nil {
+		t.Fatalf("Expected two shared locks, got %+v", status)
+	}
+
+	// A writer waits for the readers
+	locked := make(chan error)
+	go func() { locked <- writer.Lock() }()
+	select {
+	case err := <-locked:
+		t.Fatalf("Expected the writer to wait for the readers, got %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	reader1.Unlock()
+	reader2.Unlock()
+	select {
+	case err := <-locked:
+		if err != nil {
+			t.Fatalf("Lock failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the writer to get the lock once the readers are done")
+	}
+	writer.Unlock()
+
+	if entries, _ := os.ReadDir(reader1.readersDir()); len(entries) != 0 {
+		t.Errorf("Expected the shared locks to be removed, got %d", len(entries))
+	}
+}
+
+func TestStateManager_BreakLock(t *testing.T) {
+	sm := NewStateManager(t.TempDir())
+	writeLock(t, sm.LockPath, LockInfo{PID: os.Getpid(), Hostname: hostname(), LockedAt: time.Now()})
+	writeLock(t, filepath.Join(sm.readersDir(), "reader-1.lock"), LockInfo{PID: deadPID, Hostname: hostname(), Mode: LockShared, LockedAt: time.Now()})
+
+	broken, err := sm.BreakLock(false)
+	if err != nil {
+		t.Fatalf("BreakLock failed: %v", err)
+	}
+	if len(broken) != 1 || broken[0].Mode != LockShared {
+		t.Fatalf("Expected only the stale reader to be broken, got %+v", broken)
+	}
+	if _, err := os.Stat(sm.LockPath); err != nil {
+		t.Error("Expected the lock of a running process to be kept")
+	}
+
+	if broken, _ = sm.BreakLock(true); len(broken) != 1 {
+		t.Errorf("Expected force to break the live lock, got %+v", broken)
+	}
+	if _, err := os.Stat(sm.LockPath); !os.IsNotExist(err) {
+		t.Error("Expected the lock file to be removed")
+	}
+}
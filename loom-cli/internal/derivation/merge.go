@@ -0,0 +1,268 @@
+package derivation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// =============================================================================
+// Three-Way Merge
+// =============================================================================
+
+const (
+	// BaseDirName is the directory under .loom holding the last derived
+	// content of each generated section
+	BaseDirName = "base"
+
+	// Conflict markers written when both sides changed the same lines
+	ConflictCurrent   = "<<<<<<< current"
+	ConflictBase      = "||||||| base"
+	ConflictSeparator = "======="
+	ConflictGenerated = ">>>>>>> generated"
+)
+
+// Conflict is a region of a section that was edited by hand and changed
+// differently by the new generation
+type Conflict struct {
+	ArtifactID string
+	Base       string
+	Current    string
+	Generated  string
+}
+
+// ConflictResolver decides a conflict interactively. It returns the content
+// to keep, or ok=false to leave conflict markers in the file.
+type ConflictResolver func(c *Conflict) (resolved string, ok bool, err error)
+
+// MergeResult is the outcome of a three-way merge
+type MergeResult struct {
+	// Content is the merged content, including markers for unresolved conflicts
+	Content string
+
+	// Conflicts is the number of conflicts left unresolved
+	Conflicts int
+
+	// Resolved is the number of conflicts decided by the resolver
+	Resolved int
+}
+
+// Merge3 merges the hand edits in current and the changes in generated,
+// both relative to base, line by line. Regions changed on only one side take
+// that side; regions changed identically on both sides are kept once; other
+// regions are conflicts, passed to resolve if set and otherwise written with
+// conflict markers.
+func Merge3(base, current, generated string, resolve ConflictResolver) (*MergeResult, error) {
+	result := &MergeResult{}
+	switch {
+	case current == base || current == generated:
+		result.Content = generated
+		return result, nil
+	case generated == base:
+		result.Content = current
+		return result, nil
+	}
+
+	b := splitLines(base)
+	c := splitLines(current)
+	g := splitLines(generated)
+	mc := matchLines(b, c)
+	mg := matchLines(b, g)
+
+	var out []string
+	emit := func(bs, cs, gs []string) error {
+		switch {
+		case equalLines(cs, bs):
+			out = append(out, gs...)
+		case equalLines(gs, bs), equalLines(cs, gs):
+			out = append(out, cs...)
+		default:
+			conflict := &Conflict{
+				Base:      strings.Join(bs, "\n"),
+				Current:   strings.Join(cs, "\n"),
+				Generated: strings.Join(gs, "\n"),
+			}
+			if resolve != nil {
+				resolved, ok, err := resolve(conflict)
+				if err != nil {
+					return err
+				}
+				if ok {
+					if resolved != "" {
+						out = append(out, strings.Split(resolved, "\n")...)
+					}
+					result.Resolved++
+					return nil
+				}
+			}
+			out = append(out, ConflictCurrent)
+			out = append(out, cs...)
+			out = append(out, ConflictBase)
+			out = append(out, bs...)
+			out = append(out, ConflictSeparator)
+			out = append(out, gs...)
+			out = append(out, ConflictGenerated)
+			result.Conflicts++
+		}
+		return nil
+	}
+
+	ib, ic, ig := 0, 0, 0
+	for ib < len(b) || ic < len(c) || ig < len(g) {
+		// Lines unchanged on both sides
+		n := 0
+		for ib+n < len(b) && mc[ib+n] == ic+n && mg[ib+n] == ig+n {
+			n++
+		}
+		if n > 0 {
+			out = append(out, b[ib:ib+n]...)
+			ib, ic, ig = ib+n, ic+n, ig+n
+			continue
+		}
+
+		// The next base line kept on both sides ends the changed region
+		next := ib
+		for next < len(b) && (mc[next] < 0 || mg[next] < 0) {
+			next++
+		}
+		ec, eg := len(c), len(g)
+		if next < len(b) {
+			ec, eg = mc[next], mg[next]
+		}
+		if err := emit(b[ib:next], c[ic:ec], g[ig:eg]); err != nil {
+			return nil, err
+		}
+		ib, ic, ig = next, ec, eg
+	}
+
+	result.Content = strings.Join(out, "\n")
+	return result, nil
+}
+
+// HasConflictMarkers reports whether content contains unresolved conflicts
+func HasConflictMarkers(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if line == ConflictCurrent || line == ConflictGenerated {
+			return true
+		}
+	}
+	return false
+}
+
+// matchLines returns, for each line of a, the index of the line of b it is
+// matched with in a longest common subsequence, or -1
+func matchLines(a, b []string) []int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	match := make([]int, len(a))
+	for i := range match {
+		match[i] = -1
+	}
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			match[i] = j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// =============================================================================
+// Section Bodies
+// =============================================================================
+
+// sectionBounds locates the body of the generated section of an artifact,
+// returning the 0-indexed line range between its BEGIN and END markers
+func sectionBounds(content, id string) (start, end int, ok bool) {
+	doc := NewParser().ParseContent(content, "")
+	for _, s := range doc.Sections {
+		if s.ID == id && s.Type != "manual" && s.EndLine > s.StartLine {
+			return s.StartLine, s.EndLine - 1, true
+		}
+	}
+	return 0, 0, false
+}
+
+// GeneratedSections returns the raw bodies of the generated sections of
+// content, keyed by artifact ID, including any manual markers
+func GeneratedSections(content string) map[string]string {
+	lines := strings.Split(content, "\n")
+	bodies := make(map[string]string)
+	for _, s := range NewParser().ParseContent(content, "").Sections {
+		if s.ID != "" && s.Type != "manual" && s.EndLine > s.StartLine {
+			bodies[s.ID] = strings.Join(lines[s.StartLine:s.EndLine-1], "\n")
+		}
+	}
+	return bodies
+}
+
+// =============================================================================
+// Base Store
+// =============================================================================
+
+// basePath returns the file holding the base content of an artifact
+func (sm *StateManager) basePath(id string) string {
+	return filepath.Join(sm.LoomDir, BaseDirName, id+".md")
+}
+
+// LoadBase returns the content last derived for an artifact
+func (sm *StateManager) LoadBase(id string) (string, bool, error) {
+	data, err := os.ReadFile(sm.basePath(id))
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read base of %s: %w", id, err)
+	}
+	return string(data), true, nil
+}
+
+// SaveBase stores the content just derived for an artifact
+func (sm *StateManager) SaveBase(id, content string) error {
+	path := sm.basePath(id)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create base directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write base of %s: %w", id, err)
+	}
+	return nil
+}
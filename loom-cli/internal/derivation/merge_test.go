@@ -0,0 +1,225 @@
+package derivation
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMerge3_Clean(t *testing.T) {
+	base := "title\none\ntwo\nthree\nfour"
+	current := "title\none (edited)\ntwo\nthree\nfour"
+	generated := "title\none\ntwo\nthree\nfour\nfive"
+
+	res, err := Merge3(base, current, generated, nil)
+	if err != nil {
+		t.Fatalf("Merge3 failed: %v", err)
+	}
+	want := "title\none (edited)\ntwo\nthree\nfour\nfive"
+	if res.Content != want || res.Conflicts != 0 {
+		t.Errorf("Expected a clean merge\nwant: %q\ngot:  %q (%d conflicts)", want, res.Content, res.Conflicts)
+	}
+
+	// Identical changes on both sides are kept once
+	res, _ = Merge3(base, "title\nONE\ntwo\nthree\nfour", "title\nONE\ntwo\nthree\nfour\nfive", nil)
+	if res.Content != "title\nONE\ntwo\nthree\nfour\nfive" || res.Conflicts != 0 {
+		t.Errorf("Unexpected merge %q", res.Content)
+	}
+}
+
+func TestMerge3_Conflict(t *testing.T) {
+	base := "a\nb\nc"
+	current := "a\nb by hand\nc"
+	generated := "a\nb generated\nc"
+
+	res, err := Merge3(base, current, generated, nil)
+	if err != nil {
+		t.Fatalf("Merge3 failed: %v", err)
+	}
+	want := strings.Join([]string{"a", ConflictCurrent, "b by hand", ConflictBase, "b", ConflictSeparator, "b generated", ConflictGenerated, "c"}, "\n")
+	if res.Content != want || res.Conflicts != 1 {
+		t.Errorf("Unexpected conflict output\n%s", res.Content)
+	}
+	if !HasConflictMarkers(res.Content) {
+		t.Error("Expected conflict markers to be detected")
+	}
+
+	var seen *Conflict
+	res, err = Merge3(base, current, generated, func(c *Conflict) (string, bool, error) {
+		seen = c
+		return c.Current, true, nil
+	})
+	if err != nil {
+		t.Fatalf("Merge3 failed: %v", err)
+	}
+	if res.Content != current || res.Conflicts != 0 || res.Resolved != 1 {
+		t.Errorf("Expected the resolver to keep the hand edit, got %q", res.Content)
+	}
+	if seen == nil || seen.Base != "b" || seen.Generated != "b generated" {
+		t.Errorf("Unexpected conflict passed to the resolver: %+v", seen)
+	}
+}
+
+const mergeDoc = `# Acceptance Criteria
+
+<!-- LOOM:BEGIN generated id="AC-ORD-001" type="acceptance_criteria" -->
+## AC-ORD-001 – Place order
+
+**Given** a cart
+**When** the customer checks out
+**Then** an order is placed
+<!-- LOOM:END generated -->
+
+<!-- LOOM:BEGIN generated id="AC-ORD-002" type="acceptance_criteria" -->
+## AC-ORD-002 – Cancel order
+
+**Given** an order
+<!-- LOOM:END generated -->
+`
+
+// newMergeExecutor records mergeDoc and returns an executor whose deriver
+// regenerates AC-ORD-001 with the given body
+func newMergeExecutor(t *testing.T, body string) (*Executor, string) {
+	t.Helper()
+	projectDir := t.TempDir()
+	story := writeRecordFile(t, projectDir, "l0/stories.md", "# Stories\n")
+	path := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", mergeDoc)
+
+	rec := NewRecorder(projectDir, "l1")
+	rec.AddSource(story, ArtifactUserStory)
+	if err := rec.Record(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rec.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	state, err := NewStateManager(projectDir).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewExecutor(state, projectDir)
+	e.DeriverFunc = func(a *Artifact, upstream map[string]string, projectDir string) (string, error) {
+		return body, nil
+	}
+	return e, path
+}
+
+func editFile(t *testing.T, path, old, new string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Replace(string(content), old, new, 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const regenerated = `## AC-ORD-001 – Place order
+
+**Given** a cart with items
+**When** the customer checks out
+**Then** an order is placed`
+
+func TestExecutor_MergesHandEdits(t *testing.T) {
+	e, path := newMergeExecutor(t, regenerated)
+	editFile(t, path, "**Then** an order is placed", "**Then** an order is placed and confirmed")
+
+	result, err := e.Execute([]string{"AC-ORD-001"})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if len(result.Derived) != 1 || !result.Derived[0].Edited || result.Derived[0].Conflicts != 0 {
+		t.Fatalf("Expected a clean merge of hand edits, got %+v", result)
+	}
+
+	content, _ := os.ReadFile(path)
+	got := string(content)
+	if !strings.Contains(got, "**Given** a cart with items") || !strings.Contains(got, "an order is placed and confirmed") {
+		t.Errorf("Expected both the generation and the hand edit\n%s", got)
+	}
+	if !strings.Contains(got, "## AC-ORD-002 – Cancel order") || !strings.HasPrefix(got, "# Acceptance Criteria\n") {
+		t.Errorf("Expected content outside the section to be kept\n%s", got)
+	}
+
+	// The stored base is now the new generation
+	base, ok, err := NewStateManager(e.ProjectDir).LoadBase("AC-ORD-001")
+	if err != nil || !ok || base != regenerated {
+		t.Errorf("Expected the generation to become the base, got %q", base)
+	}
+
+	ac := e.State.GetArtifact("AC-ORD-001")
+	if ac.Status != StatusCurrent {
+		t.Errorf("Expected AC-ORD-001 to be current, got %s", ac.Status)
+	}
+	other := e.State.GetArtifact("AC-ORD-002")
+	if hash, _ := e.Hasher.HashArtifact(other, e.ProjectDir); hash != other.ContentHash {
+		t.Error("Expected AC-ORD-002 to be relocated with an unchanged hash")
+	}
+}
+
+func TestExecutor_MergeStrategies(t *testing.T) {
+	edit := func(path string) {
+		editFile(t, path, "**Given** a cart", "**Given** a full cart")
+	}
+
+	t.Run("preserve_manual", func(t *testing.T) {
+		e, path := newMergeExecutor(t, regenerated)
+		edit(path)
+		result, _ := e.Execute([]string{"AC-ORD-001"})
+		if result.Derived[0].Conflicts != 1 {
+			t.Fatalf("Expected a conflict, got %+v", result.Derived)
+		}
+		content, _ := os.ReadFile(path)
+		if !HasConflictMarkers(string(content)) {
+			t.Errorf("Expected conflict markers\n%s", content)
+		}
+		if e.State.GetArtifact("AC-ORD-001").Status != StatusModified {
+			t.Error("Expected an artifact with conflicts to stay modified")
+		}
+	})
+
+	t.Run("overwrite_all", func(t *testing.T) {
+		e, path := newMergeExecutor(t, regenerated)
+		e.MergeStrategy = MergeOverwriteAll
+		edit(path)
+		e.Execute([]string{"AC-ORD-001"})
+		content, _ := os.ReadFile(path)
+		if strings.Contains(string(content), "full cart") || !strings.Contains(string(content), "cart with items") {
+			t.Errorf("Expected the hand edit to be overwritten\n%s", content)
+		}
+	})
+
+	t.Run("interactive", func(t *testing.T) {
+		e, path := newMergeExecutor(t, regenerated)
+		e.MergeStrategy = MergeInteractive
+		e.Resolver = func(c *Conflict) (string, bool, error) {
+			if c.ArtifactID != "AC-ORD-001" {
+				t.Errorf("Unexpected conflict artifact %s", c.ArtifactID)
+			}
+			return c.Generated, true, nil
+		}
+		edit(path)
+		result, _ := e.Execute([]string{"AC-ORD-001"})
+		content, _ := os.ReadFile(path)
+		if result.Derived[0].Conflicts != 0 || HasConflictMarkers(string(content)) || !strings.Contains(string(content), "cart with items") {
+			t.Errorf("Expected the resolver's choice to be written\n%s", content)
+		}
+	})
+
+	t.Run("report_only", func(t *testing.T) {
+		e, path := newMergeExecutor(t, regenerated)
+		e.MergeStrategy = MergeReportOnly
+		edit(path)
+		before, _ := os.ReadFile(path)
+		result, _ := e.Execute([]string{"AC-ORD-001"})
+		after, _ := os.ReadFile(path)
+		if string(before) != string(after) {
+			t.Error("Expected report_only not to write")
+		}
+		if d := result.Derived[0]; d.Written || !d.Edited || d.Conflicts != 1 {
+			t.Errorf("Expected the conflict to be reported, got %+v", d)
+		}
+	})
+}
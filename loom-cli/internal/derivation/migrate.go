@@ -30,6 +30,9 @@ type Migrator struct {
 
 	// BackupDir is where to store backups (empty = no backup)
 	BackupDir string
+
+	// Mapping describes a custom ID scheme (nil = default IDs only)
+	Mapping *MigrationMapping
 }
 
 // MigrationResult holds the results of a migration operation
@@ -52,6 +55,12 @@ type MigrationResult struct {
 	// Warnings lists migration warnings
 	Warnings []string `json:"warnings,omitempty"`
 
+	// Dependencies lists the inferred upstream links
+	Dependencies []InferredDependency `json:"dependencies,omitempty"`
+
+	// Review lists sections and links to check by hand
+	Review []ReviewItem `json:"review,omitempty"`
+
 	// Statistics contains migration statistics
 	Statistics MigrationStats `json:"statistics"`
 }
@@ -75,6 +84,9 @@ type MigratedFile struct {
 
 	// SkipReason explains why the file was skipped
 	SkipReason string `json:"skip_reason,omitempty"`
+
+	// Review lists sections detected with low confidence
+	Review []ReviewItem `json:"review,omitempty"`
 }
 
 // MigrationError describes an error during migration
@@ -159,6 +171,10 @@ func (m *Migrator) MigrateProject(projectDir string) (*MigrationResult, error) {
 		}
 	}
 
+	// Infer dependencies from the documents' references
+	result.Dependencies = m.inferDependencies(result.DiscoveredArtifacts)
+	result.Review = append(result.Review, reviewDependencies(result.DiscoveredArtifacts, result.Dependencies)...)
+
 	// Create state if not dry run
 	if !m.DryRun && len(result.DiscoveredArtifacts) > 0 {
 		state, err := m.createState(projectDir, result.DiscoveredArtifacts)
@@ -206,17 +222,28 @@ func (m *Migrator) MigrateFile(filePath string) (*MigratedFile, error) {
 		}, nil
 	}
 
-	// Parse content to find artifacts
-	doc := m.Parser.ParseContent(string(content), filePath)
-
 	// Find artifact sections and add markers
-	migratedContent, markersAdded := m.addMarkers(string(content), filePath)
+	lines := strings.Split(string(content), "\n")
+	sections := m.detectSections(lines, detectLayerFromPath(filePath))
+	migratedContent, markersAdded := insertMarkers(lines, sections)
+
+	// Parse migrated content to find artifacts
+	doc := m.Parser.ParseContent(migratedContent, filePath)
 
 	result := &MigratedFile{
 		Path:          filePath,
 		ArtifactCount: len(doc.Artifacts),
 		MarkersAdded:  markersAdded,
 	}
+	for _, section := range sections {
+		if section.Review != "" {
+			id := section.ID
+			if id == "" {
+				id = section.Token
+			}
+			result.Review = append(result.Review, ReviewItem{ID: id, File: filePath, Reason: section.Review})
+		}
+	}
 
 	// Write migrated content
 	if !m.DryRun && markersAdded > 0 {
@@ -286,10 +313,11 @@ func (m *Migrator) migrateDirectory(dir string, result *MigrationResult) error {
 		}
 
 		result.MigratedFiles = append(result.MigratedFiles, *migrated)
+		result.Review = append(result.Review, migrated.Review...)
 
 		// Extract artifacts from migrated file
 		if !migrated.Skipped {
-			doc, err := m.Parser.ParseFile(path)
+			doc, _, err := m.parseMigrated(path)
 			if err == nil {
 				result.DiscoveredArtifacts = append(result.DiscoveredArtifacts, doc.Artifacts...)
 			}
@@ -302,13 +330,19 @@ func (m *Migrator) migrateDirectory(dir string, result *MigrationResult) error {
 // addMarkers adds LOOM markers to content
 func (m *Migrator) addMarkers(content, filePath string) (string, int) {
 	lines := strings.Split(content, "\n")
-	var result []string
-	markersAdded := 0
 
 	// Detect artifact sections based on headings
 	layer := detectLayerFromPath(filePath)
 	sections := m.detectSections(lines, layer)
 
+	return insertMarkers(lines, sections)
+}
+
+// insertMarkers wraps the detected sections in LOOM markers
+func insertMarkers(lines []string, sections []detectedSection) (string, int) {
+	var result []string
+	markersAdded := 0
+
 	// Track which lines have markers
 	markerLines := make(map[int]string)
 	endMarkerLines := make(map[int]string)
@@ -320,8 +354,7 @@ func (m *Migrator) addMarkers(content, filePath string) (string, int) {
 				"<!-- LOOM:BEGIN generated id=\"%s\" type=\"%s\" -->",
 				section.ID, section.Type)
 			// Add end marker after section end
-			endMarkerLines[section.EndLine] = fmt.Sprintf(
-				"<!-- LOOM:END generated -->")
+			endMarkerLines[section.EndLine] = "<!-- LOOM:END generated -->"
 			markersAdded += 2
 		}
 	}
@@ -346,85 +379,154 @@ func (m *Migrator) addMarkers(content, filePath string) (string, int) {
 	return strings.Join(result, "\n"), markersAdded
 }
 
-// detectSections finds artifact sections in lines
+var (
+	// sectionHeading is a heading that may start an artifact section
+	sectionHeading = regexp.MustCompile(`^(#{2,4})\s+(.+)$`)
+
+	// headingToken is an ID-like token at the start of a heading
+	headingToken = regexp.MustCompile(`^[A-Z][A-Z0-9]*(?:-[A-Z0-9]+)+\b`)
+)
+
+// detectSections finds artifact sections in lines. A level 2-4 heading
+// naming an ID (by the parser's patterns, including any mapping) starts
+// a section, which runs until the next heading of the same or a higher
+// level. Sections found with low confidence carry a Review reason;
+// ID-like headings matching no pattern are returned without an ID.
 func (m *Migrator) detectSections(lines []string, layer string) []detectedSection {
 	var sections []detectedSection
+	var currentSection *detectedSection
 
-	// Heading patterns for different artifact types
-	headingPatterns := []struct {
-		pattern      *regexp.Regexp
-		artifactType string
-	}{
-		{regexp.MustCompile(`^##\s+(AC-[A-Z]+-\d{3})`), "acceptance_criteria"},
-		{regexp.MustCompile(`^##\s+(BR-[A-Z]+-\d{3})`), "business_rule"},
-		{regexp.MustCompile(`^##\s+(ENT-[A-Z]+)`), "entity"},
-		{regexp.MustCompile(`^##\s+(VO-[A-Z]+)`), "value_object"},
-		{regexp.MustCompile(`^##\s+(BC-[A-Z]+)`), "bounded_context"},
-		{regexp.MustCompile(`^##\s+(TS-[A-Z]+-\d{3})`), "tech_spec"},
-		{regexp.MustCompile(`^##\s+(IC-[A-Z]+-\d{3})`), "interface_operation"},
-		{regexp.MustCompile(`^##\s+(AGG-[A-Z]+-\d{3})`), "aggregate_design"},
-		{regexp.MustCompile(`^##\s+(SEQ-[A-Z]+-\d{3})`), "sequence"},
-		{regexp.MustCompile(`^##\s+(TC-AC-[A-Z]+-\d{3}-[PNBH]\d{2})`), "test_case"},
-		{regexp.MustCompile(`^##\s+(API-[A-Z]+-\d{3})`), "api_endpoint"},
-		{regexp.MustCompile(`^##\s+(TKT-[A-Z]+-\d{3})`), "ticket"},
+	closeSection := func(endLine int) {
+		if currentSection == nil {
+			return
+		}
+		currentSection.EndLine = endLine
+		if currentSection.ID != "" && currentSection.Review == "" && !hasContent(lines[currentSection.StartLine:endLine]) {
+			currentSection.Review = "section has no content"
+		}
+		sections = append(sections, *currentSection)
+		currentSection = nil
 	}
 
-	var currentSection *detectedSection
-
 	for i, line := range lines {
 		lineNum := i + 1
 
-		// Check for artifact heading
-		for _, hp := range headingPatterns {
-			if matches := hp.pattern.FindStringSubmatch(line); matches != nil {
-				// Close previous section
-				if currentSection != nil {
-					currentSection.EndLine = lineNum - 1
-					sections = append(sections, *currentSection)
-				}
+		matches := sectionHeading.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		level := len(matches[1])
+		text := strings.TrimLeft(matches[2], "*_` ")
+		nested := currentSection != nil && level > currentSection.Level
 
-				// Start new section
-				currentSection = &detectedSection{
-					ID:        matches[1],
-					Type:      hp.artifactType,
-					StartLine: lineNum,
-				}
-				break
+		if traceabilityHeading.MatchString(line) {
+			if !nested {
+				closeSection(lineNum - 1)
 			}
+			continue
 		}
 
-		// Check for next heading (closes current section)
-		if currentSection != nil && strings.HasPrefix(line, "## ") {
-			isArtifactHeading := false
-			for _, hp := range headingPatterns {
-				if hp.pattern.MatchString(line) {
-					isArtifactHeading = true
-					break
-				}
+		id, atStart := m.headingID(text)
+		switch {
+		case id != "" && (atStart || !nested):
+			// Artifact heading - start a new section
+			closeSection(lineNum - 1)
+			currentSection = &detectedSection{
+				ID:        id,
+				Type:      string(m.artifactType(id)),
+				StartLine: lineNum,
+				Level:     level,
+			}
+			if !atStart {
+				currentSection.Review = "ID is not at the start of the heading"
 			}
-			if !isArtifactHeading {
-				// Non-artifact heading - close current section
-				currentSection.EndLine = lineNum - 1
-				sections = append(sections, *currentSection)
-				currentSection = nil
+		case nested:
+			// Subheading within the current section
+		default:
+			// Non-artifact heading - close current section
+			closeSection(lineNum - 1)
+			if token := headingToken.FindString(text); token != "" && strings.ContainsAny(token, "0123456789") {
+				sections = append(sections, detectedSection{
+					Token:     token,
+					StartLine: lineNum,
+					EndLine:   lineNum,
+					Level:     level,
+					Review:    fmt.Sprintf("heading names %s, which matches no ID pattern (add it to a mapping file)", token),
+				})
 			}
 		}
 	}
 
 	// Close final section
-	if currentSection != nil {
-		currentSection.EndLine = len(lines)
-		sections = append(sections, *currentSection)
+	closeSection(len(lines))
+
+	seen := make(map[string]bool)
+	for i := range sections {
+		if id := sections[i].ID; id != "" {
+			if seen[id] && sections[i].Review == "" {
+				sections[i].Review = "ID is defined more than once in this file"
+			}
+			seen[id] = true
+		}
 	}
 
 	return sections
 }
 
+// headingID returns the first whole ID in a heading, and whether the
+// heading starts with it. A known prefix followed by an embedded ID, such
+// as TS-BR-ORD-001, counts as an ID.
+func (m *Migrator) headingID(text string) (string, bool) {
+	if token := headingToken.FindString(text); token != "" {
+		prefix := token[:strings.Index(token, "-")]
+		if _, ok := m.Parser.IDPatterns[prefix]; ok && len(m.Parser.GetArtifactIDs(token[len(prefix)+1:])) > 0 {
+			return token, true
+		}
+	}
+
+	start, end := -1, -1
+	for _, pattern := range m.Parser.IDPatterns {
+		for _, loc := range pattern.FindAllStringIndex(text, -1) {
+			if !isWholeID(text, loc[0], loc[1]) {
+				continue
+			}
+			if start == -1 || loc[0] < start || (loc[0] == start && loc[1] > end) {
+				start, end = loc[0], loc[1]
+			}
+		}
+	}
+	if start == -1 {
+		return "", false
+	}
+	return text[start:end], start == 0
+}
+
+// isWholeID reports whether text[start:end] is not part of a longer token
+func isWholeID(text string, start, end int) bool {
+	isIDChar := func(c byte) bool {
+		return c == '-' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9'
+	}
+	return (start == 0 || !isIDChar(text[start-1])) && (end == len(text) || !isIDChar(text[end]))
+}
+
+// hasContent reports whether any line is not blank
+func hasContent(lines []string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			return true
+		}
+	}
+	return false
+}
+
 type detectedSection struct {
 	ID        string
 	Type      string
 	StartLine int
 	EndLine   int
+	Level     int    // heading level
+	Token     string // ID-like heading token matching no pattern
+	Review    string // why the section needs review, if it does
 }
 
 // backupFile creates a backup of a file
@@ -491,40 +593,20 @@ func (m *Migrator) buildDependencies(state *DerivationState, artifacts []*Artifa
 		artifactMap[artifact.ID] = artifact
 	}
 
-	// Parse each artifact file to find references
-	for _, artifact := range artifacts {
-		if artifact.Location.File == "" {
-			continue
+	for _, dep := range m.inferDependencies(artifacts) {
+		artifact, ref := artifactMap[dep.Downstream], artifactMap[dep.Upstream]
+		if artifact.Upstream == nil {
+			artifact.Upstream = make(map[string]string)
 		}
+		artifact.Upstream[ref.ID] = ref.ContentHash
+		state.DependencyGraph.AddEdge(ref.ID, artifact.ID, EdgeDerives)
+	}
 
-		content, err := os.ReadFile(artifact.Location.File)
-		if err != nil {
-			continue
-		}
-
-		// Find all artifact IDs referenced in the content
-		referencedIDs := m.Parser.GetArtifactIDs(string(content))
-
-		for _, refID := range referencedIDs {
-			if refID == artifact.ID {
-				continue // Skip self-references
-			}
-
-			// Check if referenced artifact exists
-			if ref, ok := artifactMap[refID]; ok {
-				// Determine direction based on layers
-				refLayer := layerOrder(ref.Layer)
-				artLayer := layerOrder(artifact.Layer)
-
-				if refLayer < artLayer {
-					// ref is upstream of artifact
-					if artifact.Upstream == nil {
-						artifact.Upstream = make(map[string]string)
-					}
-					artifact.Upstream[refID] = ref.ContentHash
-					state.DependencyGraph.AddEdge(refID, artifact.ID, EdgeDerives)
-				}
-			}
+	// Migrated artifacts are current with their upstream as it stands
+	for _, artifact := range artifacts {
+		artifact.DerivedFromHashes = make(map[string]string, len(artifact.Upstream))
+		for id, hash := range artifact.Upstream {
+			artifact.DerivedFromHashes[id] = hash
 		}
 	}
 }
@@ -625,6 +707,15 @@ func (m *Migrator) GenerateMigrationReport(result *MigrationResult) string {
 		sb.WriteString("\n")
 	}
 
+	// Review
+	if len(result.Review) > 0 {
+		sb.WriteString("## Needs Review\n\n")
+		for _, item := range result.Review {
+			sb.WriteString(fmt.Sprintf("- **%s** (%s): %s\n", item.ID, item.File, item.Reason))
+		}
+		sb.WriteString("\n")
+	}
+
 	// Warnings
 	if len(result.Warnings) > 0 {
 		sb.WriteString("## Warnings\n\n")
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
 )
 
 func TestMigrator_MigrateFile(t *testing.T) {
@@ -528,3 +530,243 @@ Implements US-ORD-001
 		t.Error("Graph should have edge US -> AC")
 	}
 }
+
+func TestMigrator_DetectSections_Confidence(t *testing.T) {
+	m := NewMigrator()
+
+	lines := strings.Split(`# Specs
+
+### AC-ORD-001 – Place order
+
+Given a cart
+
+#### Traceability
+
+- US-ORD-001
+
+## Cancel order (AC-ORD-002)
+
+## REQ-12 Legacy requirement
+
+Text
+`, "\n")
+
+	sections := m.detectSections(lines, "l1")
+	if len(sections) != 3 {
+		t.Fatalf("Expected 3 sections, got %+v", sections)
+	}
+
+	if s := sections[0]; s.ID != "AC-ORD-001" || s.Review != "" || s.EndLine != 10 {
+		t.Errorf("Expected AC-ORD-001 to keep its traceability block, got %+v", s)
+	}
+	if s := sections[1]; s.ID != "AC-ORD-002" || !strings.Contains(s.Review, "not at the start") {
+		t.Errorf("Expected AC-ORD-002 flagged for review, got %+v", s)
+	}
+	if s := sections[2]; s.ID != "" || s.Token != "REQ-12" || !strings.Contains(s.Review, "mapping file") {
+		t.Errorf("Expected REQ-12 flagged as an unknown ID, got %+v", s)
+	}
+}
+
+func TestMigrator_InferDependencies(t *testing.T) {
+	m := NewMigrator()
+	m.DryRun = true
+	tmpDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(tmpDir, "l1"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "l2"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "l1", "rules.md"), []byte(`# Rules
+
+## BR-ORD-001
+
+An order needs an item
+
+## BR-ORD-002
+
+An order has at most 50 items
+`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "l2", "specs.md"), []byte(`# Specs
+
+## TS-CART-001 – Cart validation
+
+Rejects empty carts, unlike BR-ORD-002 which is checked later.
+
+### Related
+
+- BR-ORD-001
+
+## TS-BR-ORD-002
+
+Checks the item limit
+`), 0644)
+
+	result, err := m.MigrateProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	deps := make(map[string]InferredDependency)
+	for _, dep := range result.Dependencies {
+		deps[dep.Upstream+">"+dep.Downstream] = dep
+	}
+	if dep := deps["BR-ORD-001>TS-CART-001"]; dep.Source != SourceTraceability || dep.Confidence != ConfidenceHigh {
+		t.Errorf("Expected a traceability link, got %+v", dep)
+	}
+	if dep := deps["BR-ORD-002>TS-CART-001"]; dep.Source != SourceMention || dep.Confidence != ConfidenceLow {
+		t.Errorf("Expected a mention, got %+v", dep)
+	}
+	if dep := deps["BR-ORD-002>TS-BR-ORD-002"]; dep.Source != SourceNaming {
+		t.Errorf("Expected a naming link, got %+v", dep)
+	}
+	if len(deps) != 3 {
+		t.Errorf("Expected 3 dependencies, got %+v", result.Dependencies)
+	}
+
+	review := make(map[string]string)
+	for _, item := range result.Review {
+		review[item.ID] = item.Reason
+	}
+	if !strings.Contains(review["TS-BR-ORD-002"], "naming") {
+		t.Errorf("Expected the naming-only link flagged for review, got %v", review)
+	}
+	if _, ok := review["TS-CART-001"]; ok {
+		t.Error("Expected a traceability link to need no review")
+	}
+	if !strings.Contains(review["BR-ORD-001"], "no upstream") {
+		t.Errorf("Expected the rule without upstream flagged for review, got %v", review)
+	}
+}
+
+// writeFormattedSpecs writes L1 rules and criteria, and the tech specs and
+// test cases the formatters generate from them
+func writeFormattedSpecs(t *testing.T, dir string) {
+	t.Helper()
+	files := map[string]string{
+		"l1/acceptance-criteria.md": "# Acceptance Criteria\n\n## AC-ORD-001\n\nAn order can be placed\n",
+		"l1/business-rules.md":      "# Business Rules\n\n## BR-ORD-001\n\nAn order needs an item\n",
+		"l2/tech-specs.md": formatter.FormatTechSpecs([]formatter.TechSpec{
+			{ID: "TS-CART-001", Name: "Cart validation", BRRef: "BR-ORD-001", RelatedACs: []string{"AC-ORD-001"}},
+		}, "2024-01-15T10:00:00Z"),
+		"l3/test-cases.md": formatter.FormatTestCases([]formatter.TestCase{
+			{ID: "TC-AC-ORD-001-P01", Name: "Place order", Category: "positive", ACRef: "AC-ORD-001", BRRefs: []string{"BR-ORD-001"}},
+		}, formatter.TDAISummary{Total: 1}, "2024-01-15T10:00:00Z"),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMigrator_InferDependencies_FormatterOutput(t *testing.T) {
+	m := NewMigrator()
+	m.DryRun = true
+	tmpDir := t.TempDir()
+	writeFormattedSpecs(t, tmpDir)
+
+	result, err := m.MigrateProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	deps := make(map[string]InferredDependency)
+	for _, dep := range result.Dependencies {
+		deps[dep.Upstream+">"+dep.Downstream] = dep
+	}
+	for _, key := range []string{"BR-ORD-001>TS-CART-001", "AC-ORD-001>TS-CART-001", "BR-ORD-001>TC-AC-ORD-001-P01", "AC-ORD-001>TC-AC-ORD-001-P01"} {
+		if dep := deps[key]; dep.Source != SourceTraceability || dep.Confidence != ConfidenceHigh {
+			t.Errorf("Expected %s from the **Traceability:** list, got %+v", key, dep)
+		}
+	}
+	for _, item := range result.Review {
+		if strings.HasPrefix(item.ID, "TS-") || strings.HasPrefix(item.ID, "TC-") {
+			t.Errorf("Expected formatter traceability to need no review, got %+v", item)
+		}
+	}
+}
+
+func TestMigrator_MigrateProject_NotStale(t *testing.T) {
+	m := NewMigrator()
+	tmpDir := t.TempDir()
+	writeFormattedSpecs(t, tmpDir)
+
+	if _, err := m.MigrateProject(tmpDir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	state, err := NewStateManager(tmpDir).Load()
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	if ts := state.GetArtifact("TS-CART-001"); ts == nil || len(ts.Upstream) != 2 {
+		t.Fatalf("Expected TS-CART-001 with two upstream artifacts, got %+v", ts)
+	}
+
+	stale, err := NewTracker(state, tmpDir).DetectStaleArtifacts()
+	if err != nil {
+		t.Fatalf("DetectStaleArtifacts failed: %v", err)
+	}
+	for _, a := range stale {
+		t.Errorf("Expected a freshly migrated tree to be current, %s is stale", a.ID)
+	}
+}
+
+func TestMigrator_Mapping(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	mappingPath := filepath.Join(tmpDir, "mapping.json")
+	os.WriteFile(mappingPath, []byte(`{
+  "patterns": [
+    {"prefix": "REQ", "pattern": "REQ-\\d+", "type": "acceptance_criteria", "layer": "l1"},
+    {"prefix": "DES", "pattern": "DES-\\d+", "type": "tech_spec", "layer": "l2"}
+  ],
+  "edges": {"DES-2": ["REQ-1"]}
+}`), 0644)
+
+	mapping, err := LoadMigrationMapping(mappingPath)
+	if err != nil {
+		t.Fatalf("LoadMigrationMapping failed: %v", err)
+	}
+
+	os.MkdirAll(filepath.Join(tmpDir, "specs", "l1"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "specs", "l1", "legacy.md"), []byte(`# Legacy
+
+## REQ-1 Login
+
+Users log in
+
+## DES-1 Login form
+
+Implements REQ-1
+
+## DES-2 Session store
+
+Keeps sessions
+`), 0644)
+
+	m := NewMigrator()
+	m.UseMapping(mapping)
+	result, err := m.MigrateProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	state := result.CreatedState
+	if state == nil {
+		t.Fatalf("Expected a state, got errors %+v", result.Errors)
+	}
+	des := state.GetArtifact("DES-1")
+	if des == nil || des.Type != ArtifactTechSpec || des.Layer != "l2" {
+		t.Fatalf("Expected DES-1 mapped to an L2 tech spec, got %+v", des)
+	}
+	if !state.DependencyGraph.HasEdge("REQ-1", "DES-1") || !state.DependencyGraph.HasEdge("REQ-1", "DES-2") {
+		t.Error("Expected edges from the reference and the mapping")
+	}
+
+	if _, err := LoadMigrationMapping(filepath.Join(tmpDir, "missing.json")); err == nil {
+		t.Error("Expected an error for a missing mapping file")
+	}
+}
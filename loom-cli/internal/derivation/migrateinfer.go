@@ -0,0 +1,338 @@
+package derivation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// =============================================================================
+// Migration Mapping
+// =============================================================================
+
+// MigrationMapping describes a project's own ID scheme for migration
+type MigrationMapping struct {
+	// Patterns recognizes custom artifact IDs
+	Patterns []IDMapping `json:"patterns"`
+
+	// Edges lists known upstream IDs per artifact ID, for links the
+	// documents don't spell out
+	Edges map[string][]string `json:"edges,omitempty"`
+}
+
+// IDMapping maps a custom ID pattern to an artifact type and layer
+type IDMapping struct {
+	// Prefix names the pattern, e.g. REQ
+	Prefix string `json:"prefix"`
+
+	// Pattern is the regex matching the IDs, e.g. REQ-\d+
+	Pattern string `json:"pattern"`
+
+	// Type is the artifact type of matching IDs
+	Type ArtifactType `json:"type"`
+
+	// Layer overrides the layer detected from the file path
+	Layer string `json:"layer,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// LoadMigrationMapping reads a mapping file
+func LoadMigrationMapping(path string) (*MigrationMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mapping: %w", err)
+	}
+
+	var mapping MigrationMapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping: %w", err)
+	}
+
+	for i := range mapping.Patterns {
+		p := &mapping.Patterns[i]
+		if p.Prefix == "" || p.Pattern == "" {
+			return nil, fmt.Errorf("mapping pattern %d needs a prefix and a pattern", i+1)
+		}
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mapping pattern %q: %w", p.Pattern, err)
+		}
+		p.re = re
+	}
+
+	return &mapping, nil
+}
+
+// UseMapping makes the migrator recognize the mapping's IDs
+func (m *Migrator) UseMapping(mapping *MigrationMapping) {
+	m.Mapping = mapping
+	for _, p := range mapping.Patterns {
+		m.Parser.IDPatterns[p.Prefix] = p.re
+	}
+}
+
+// lookup returns the mapping entry matching the whole ID
+func (mm *MigrationMapping) lookup(id string) *IDMapping {
+	if mm == nil {
+		return nil
+	}
+	for i, p := range mm.Patterns {
+		if loc := p.re.FindStringIndex(id); loc != nil && loc[0] == 0 && loc[1] == len(id) {
+			return &mm.Patterns[i]
+		}
+	}
+	return nil
+}
+
+// artifactType returns the type of an ID, preferring the mapping
+func (m *Migrator) artifactType(id string) ArtifactType {
+	if p := m.Mapping.lookup(id); p != nil && p.Type != "" {
+		return p.Type
+	}
+	return m.Parser.detectArtifactType(id)
+}
+
+// =============================================================================
+// Dependency Inference
+// =============================================================================
+
+// Confidence levels of inferred dependencies
+const (
+	ConfidenceHigh = "high"
+	ConfidenceLow  = "low"
+)
+
+// Sources an inferred dependency can come from
+const (
+	SourceTraceability = "traceability" // listed in a Traceability/Related section or line
+	SourceReference    = "reference"    // named after a keyword such as "implements" or "see"
+	SourceMention      = "mention"      // mentioned in passing
+	SourceNaming       = "naming"       // embedded in the artifact's own ID
+	SourceMapping      = "mapping"      // listed in the mapping file
+)
+
+// InferredDependency is an upstream link found during migration
+type InferredDependency struct {
+	Upstream   string `json:"upstream"`
+	Downstream string `json:"downstream"`
+	Source     string `json:"source"`
+	Confidence string `json:"confidence"`
+}
+
+// ReviewItem flags something migration could not settle with confidence
+type ReviewItem struct {
+	ID     string `json:"id"`
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+}
+
+var (
+	// traceabilityHeading opens a block listing an artifact's links
+	traceabilityHeading = regexp.MustCompile(`(?i)^#{2,6}\s+\W*(?:traceability|related(?:\s+\w+)?|references|upstream|sources?|derive[sd]\s+from)\b`)
+
+	// traceabilityLabel is a line listing links, e.g. **Related:** BR-ORD-001,
+	// or opening a list of them, e.g. **Traceability:**
+	traceabilityLabel = regexp.MustCompile(`(?i)^\s*(?:[-+]\s*)?(?:traceability|related(?:\s+to)?|upstream|sources?|derive[sd]\s+from)\s*:`)
+
+	// listItem is a Markdown list item
+	listItem = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s`)
+
+	// referenceKeyword names a reference explicitly
+	referenceKeyword = regexp.MustCompile(`(?i)\b(?:see|refs?|references?|implements?|realizes?|tests?|verifies|satisfies|covers|derive[sd]?\s+from)\b`)
+)
+
+// inferReferences classifies the references the parser found in a
+// migrated document. Downstream is the referencing artifact; the
+// direction is settled by layer in resolveDependencies.
+func (m *Migrator) inferReferences(doc *ParsedDocument, content string) []InferredDependency {
+	lines := strings.Split(content, "\n")
+	inBlock := make([]bool, len(lines))
+	block := false
+	// A label line such as **Traceability:** opens a block for the list
+	// that follows it, up to the first line that is not a list item
+	labelled, listed := false, false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			block = traceabilityHeading.MatchString(trimmed)
+			labelled = false
+		case strings.HasPrefix(trimmed, MarkerBegin) || strings.HasPrefix(trimmed, MarkerEnd):
+			block, labelled = false, false
+		case labelled && listItem.MatchString(trimmed):
+			listed = true
+		case labelled && trimmed == "" && !listed:
+		default:
+			label := strings.NewReplacer("*", "", "_", "").Replace(trimmed)
+			labelled = traceabilityLabel.MatchString(label) && strings.HasSuffix(label, ":")
+			listed = false
+		}
+		inBlock[i] = block || labelled
+	}
+
+	var refs []InferredDependency
+	for _, ref := range doc.References {
+		if ref.Line < 1 || ref.Line > len(lines) {
+			continue
+		}
+		line := lines[ref.Line-1]
+		source := SourceMention
+		switch {
+		case inBlock[ref.Line-1] || traceabilityLabel.MatchString(strings.NewReplacer("*", "", "_", "").Replace(line)):
+			source = SourceTraceability
+		case referenceKeyword.MatchString(line):
+			source = SourceReference
+		}
+		refs = append(refs, InferredDependency{
+			Upstream:   ref.ToID,
+			Downstream: ref.FromID,
+			Source:     source,
+		})
+	}
+	return refs
+}
+
+// inferDependencies infers the upstream links of the artifacts from the
+// references in their files, the mapping's edges and, failing those,
+// IDs embedded in their own IDs
+func (m *Migrator) inferDependencies(artifacts []*Artifact) []InferredDependency {
+	var refs []InferredDependency
+	parsed := make(map[string]bool)
+	for _, artifact := range artifacts {
+		path := artifact.Location.File
+		if path == "" || parsed[path] {
+			continue
+		}
+		parsed[path] = true
+		doc, content, err := m.parseMigrated(path)
+		if err != nil {
+			continue
+		}
+		refs = append(refs, m.inferReferences(doc, content)...)
+	}
+
+	if m.Mapping != nil {
+		for downstream, upstream := range m.Mapping.Edges {
+			for _, id := range upstream {
+				refs = append(refs, InferredDependency{Upstream: id, Downstream: downstream, Source: SourceMapping})
+			}
+		}
+	}
+
+	for _, artifact := range artifacts {
+		parts := strings.Split(artifact.ID, "-")
+		for i := 0; i < len(parts); i++ {
+			for j := i + 2; j <= len(parts); j++ {
+				if id := strings.Join(parts[i:j], "-"); id != artifact.ID {
+					refs = append(refs, InferredDependency{Upstream: id, Downstream: artifact.ID, Source: SourceNaming})
+				}
+			}
+		}
+	}
+
+	return resolveDependencies(artifacts, refs)
+}
+
+// resolveDependencies keeps the references between known artifacts that
+// point to a lower layer (mapping edges are kept regardless), one per
+// pair at its best confidence
+func resolveDependencies(artifacts []*Artifact, refs []InferredDependency) []InferredDependency {
+	artifactMap := make(map[string]*Artifact)
+	for _, artifact := range artifacts {
+		artifactMap[artifact.ID] = artifact
+	}
+
+	best := make(map[[2]string]InferredDependency)
+	for _, ref := range refs {
+		up, down := artifactMap[ref.Upstream], artifactMap[ref.Downstream]
+		if up == nil || down == nil || up.ID == down.ID {
+			continue
+		}
+		if ref.Source != SourceMapping && layerOrder(up.Layer) >= layerOrder(down.Layer) {
+			continue
+		}
+		ref.Confidence = ConfidenceHigh
+		if ref.Source == SourceMention || ref.Source == SourceNaming {
+			ref.Confidence = ConfidenceLow
+		}
+		key := [2]string{ref.Upstream, ref.Downstream}
+		if prev, ok := best[key]; !ok || (prev.Confidence == ConfidenceLow && ref.Confidence == ConfidenceHigh) {
+			best[key] = ref
+		}
+	}
+
+	deps := make([]InferredDependency, 0, len(best))
+	for _, dep := range best {
+		deps = append(deps, dep)
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Downstream != deps[j].Downstream {
+			return deps[i].Downstream < deps[j].Downstream
+		}
+		return deps[i].Upstream < deps[j].Upstream
+	})
+	return deps
+}
+
+// reviewDependencies flags artifacts above L0 with no upstream, or whose
+// upstream rests only on low-confidence evidence
+func reviewDependencies(artifacts []*Artifact, deps []InferredDependency) []ReviewItem {
+	byDownstream := make(map[string][]InferredDependency)
+	for _, dep := range deps {
+		byDownstream[dep.Downstream] = append(byDownstream[dep.Downstream], dep)
+	}
+
+	var items []ReviewItem
+	for _, artifact := range artifacts {
+		if artifact.Layer == "l0" {
+			continue
+		}
+		upstream := byDownstream[artifact.ID]
+		if len(upstream) == 0 {
+			items = append(items, ReviewItem{ID: artifact.ID, File: artifact.Location.File, Reason: "no upstream references found"})
+			continue
+		}
+		var weak []string
+		for _, dep := range upstream {
+			if dep.Confidence != ConfidenceLow {
+				weak = nil
+				break
+			}
+			weak = append(weak, dep.Upstream)
+		}
+		if len(weak) > 0 {
+			items = append(items, ReviewItem{
+				ID:     artifact.ID,
+				File:   artifact.Location.File,
+				Reason: "upstream inferred only from naming or passing mentions: " + strings.Join(weak, ", "),
+			})
+		}
+	}
+	return items
+}
+
+// parseMigrated parses a file as it reads once migrated, adding the
+// markers in memory when the file has none yet
+func (m *Migrator) parseMigrated(path string) (*ParsedDocument, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	content := string(data)
+	if !strings.Contains(content, "LOOM:BEGIN") {
+		content, _ = m.addMarkers(content, path)
+	}
+
+	doc := m.Parser.ParseContent(content, path)
+	for _, artifact := range doc.Artifacts {
+		if p := m.Mapping.lookup(artifact.ID); p != nil && p.Layer != "" {
+			artifact.Layer = p.Layer
+		}
+	}
+	return doc, content, nil
+}
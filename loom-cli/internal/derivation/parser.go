@@ -393,22 +393,27 @@ func (p *Parser) extractIDFromContent(content string) string {
 func (p *Parser) detectArtifactType(id string) ArtifactType {
 	// Map of ID prefixes to artifact types
 	prefixMap := map[string]ArtifactType{
-		"US":  ArtifactUserStory,
-		"AC":  ArtifactAcceptanceCrit,
-		"BR":  ArtifactBusinessRule,
-		"ENT": ArtifactEntity,
-		"VO":  ArtifactValueObject,
-		"BC":  ArtifactBoundedContext,
-		"TS":  ArtifactTechSpec,
-		"IC":  ArtifactInterfaceOp,
-		"AGG": ArtifactAggregateDesign,
-		"SEQ": ArtifactSequence,
-		"DT":  ArtifactDataTable,
-		"TC":  ArtifactTestCase,
-		"API": ArtifactAPIEndpoint,
-		"EVT": ArtifactType("event"),
-		"CMD": ArtifactType("command"),
-		"TKT": ArtifactTicket,
+		"US":   ArtifactUserStory,
+		"AC":   ArtifactAcceptanceCrit,
+		"BR":   ArtifactBusinessRule,
+		"ENT":  ArtifactEntity,
+		"VO":   ArtifactValueObject,
+		"BC":   ArtifactBoundedContext,
+		"TS":   ArtifactTechSpec,
+		"IC":   ArtifactInterfaceOp,
+		"AGG":  ArtifactAggregateDesign,
+		"SEQ":  ArtifactSequence,
+		"DT":   ArtifactDataTable,
+		"TBL":  ArtifactDataTable,
+		"TC":   ArtifactTestCase,
+		"API":  ArtifactAPIEndpoint,
+		"EVT":  ArtifactType("event"),
+		"CMD":  ArtifactType("command"),
+		"INT":  ArtifactEvent,
+		"TKT":  ArtifactTicket,
+		"FDT":  ArtifactTicket,
+		"SKEL": ArtifactCodeSkeleton,
+		"SVC":  ArtifactService,
 	}
 
 	// Find matching prefix
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package derivation
+
+// processAlive cannot check processes on this platform; locks expire by
+// their heartbeat only
+func processAlive(pid int) bool {
+	return true
+}
@@ -0,0 +1,485 @@
+package derivation
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// Section Marking
+// =============================================================================
+
+var (
+	// markHeadingPattern matches headings that start with an artifact ID,
+	// e.g. "## AC-ORD-001 – Title" or "### EVT-ORD-001: OrderPlaced"
+	markHeadingPattern = regexp.MustCompile(`^(#{2,4})\s+([A-Z][A-Z0-9]*(?:-[A-Za-z0-9]+)+)(?:[\s:]|$)`)
+
+	// idTokenPattern matches ID-like tokens in section content
+	idTokenPattern = regexp.MustCompile(`[A-Za-z0-9]+(?:-[A-Za-z0-9]+)+`)
+
+	sourceSlugSeparator = regexp.MustCompile(`[^A-Z0-9]+`)
+)
+
+// MarkSections wraps every section whose heading starts with an artifact ID
+// in LOOM:BEGIN/END markers. A section runs to the next heading of the same
+// or a higher level; trailing blank lines and rules stay outside of it.
+// Sections are not nested, headings of unknown artifact types and headings
+// inside code blocks are left alone, and content that already has markers
+// is returned unchanged.
+func MarkSections(content string) string {
+	if strings.Contains(content, MarkerBegin) {
+		return content
+	}
+
+	p := NewParser()
+	lines := strings.Split(content, "\n")
+
+	type span struct {
+		id      string
+		artType ArtifactType
+		start   int
+		end     int
+	}
+	var spans []span
+	var current *span
+	level := 0
+	inFence := false
+
+	closeAt := func(next int) {
+		end := next - 1
+		for end > current.start {
+			trimmed := strings.TrimSpace(lines[end])
+			if trimmed != "" && trimmed != "---" {
+				break
+			}
+			end--
+		}
+		current.end = end
+		spans = append(spans, *current)
+		current = nil
+	}
+
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		rest := strings.TrimLeft(line, "#")
+		if inFence || rest == line || (rest != "" && rest[0] != ' ') {
+			continue
+		}
+
+		hashes := len(line) - len(rest)
+		if current != nil && hashes <= level {
+			closeAt(i)
+		}
+		if current != nil {
+			continue
+		}
+
+		m := markHeadingPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		artType := p.detectArtifactType(m[2])
+		if artType == ArtifactType("unknown") {
+			continue
+		}
+		current = &span{id: m[2], artType: artType, start: i}
+		level = len(m[1])
+	}
+	if current != nil {
+		closeAt(len(lines))
+	}
+
+	if len(spans) == 0 {
+		return content
+	}
+
+	var out []string
+	next := 0
+	for i, line := range lines {
+		if next < len(spans) && spans[next].start == i {
+			out = append(out, fmt.Sprintf("<!-- LOOM:BEGIN generated id=\"%s\" type=\"%s\" -->", spans[next].id, spans[next].artType))
+		}
+		out = append(out, line)
+		if next < len(spans) && spans[next].end == i {
+			out = append(out, "<!-- LOOM:END generated -->")
+			next++
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// =============================================================================
+// Recorder
+// =============================================================================
+
+// Recorder registers the files a generator writes in the derivation state:
+// their artifacts, content hashes, upstream hashes, decisions and
+// dependency edges
+type Recorder struct {
+	// ProjectDir is the project whose state is updated
+	ProjectDir string
+
+	// Layer is the layer of the recorded artifacts
+	Layer string
+
+	// Parser is used to read the marked sections
+	Parser *Parser
+
+	// Hasher is used for content hashing
+	Hasher *Hasher
+
+	// Tx is the transaction the generator staged its files in. Without one
+	// Commit runs its own.
+	Tx *Transaction
+
+	files     []string
+	marked    map[string]string
+	sources   []*Artifact
+	decisions []*Decision
+}
+
+// RecordResult summarizes a recorder commit
+type RecordResult struct {
+	// Artifacts lists the recorded artifact IDs
+	Artifacts []string
+
+	// Edges is the number of upstream edges recorded
+	Edges int
+
+	// Removed lists artifacts no longer present in their file
+	Removed []string
+}
+
+// NewRecorder creates a recorder for artifacts of a layer
+func NewRecorder(projectDir, layer string) *Recorder {
+	return &Recorder{
+		ProjectDir: projectDir,
+		Layer:      layer,
+		Parser:     NewParser(),
+		Hasher:     NewHasher(),
+	}
+}
+
+// AddSource registers an input file as a whole-file artifact that every
+// recorded artifact is derived from, e.g. the L0 user stories
+func (r *Recorder) AddSource(path string, artType ArtifactType) {
+	prefix := map[ArtifactType]string{
+		ArtifactUserStory:  "US",
+		ArtifactNFR:        "NFR",
+		ArtifactVocabulary: "VOC",
+	}[artType]
+	if prefix == "" {
+		prefix = "SRC"
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	slug := strings.Trim(sourceSlugSeparator.ReplaceAllString(strings.ToUpper(name), "-"), "-")
+
+	r.sources = append(r.sources, &Artifact{
+		ID:       prefix + "-" + slug,
+		Type:     artType,
+		Layer:    artType.Layer(),
+		Location: ArtifactLocation{File: r.relPath(path)},
+		Status:   StatusCurrent,
+	})
+}
+
+// AddDecision registers a decision; it is linked to the recorded artifacts
+// that reference it
+func (r *Recorder) AddDecision(d *Decision) {
+	r.decisions = append(r.decisions, d)
+}
+
+// Record queues a written file for the commit, which adds LOOM markers to it
+func (r *Recorder) Record(path string) error {
+	content, err := r.Tx.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if r.marked == nil {
+		r.marked = make(map[string]string)
+	}
+	if marked := MarkSections(string(content)); marked != string(content) {
+		r.marked[path] = marked
+	}
+	r.files = append(r.files, path)
+	return nil
+}
+
+// Commit registers the recorded files in the derivation state. Artifacts
+// previously recorded for these files that are gone are removed. The marked
+// files, the state and the merge bases are written in one transaction.
+func (r *Recorder) Commit() (*RecordResult, error) {
+	tx := r.Tx
+	if tx == nil {
+		var err error
+		if tx, err = NewStateManager(r.ProjectDir).Begin(); err != nil {
+			return nil, err
+		}
+	}
+	defer tx.Abort()
+	sm := tx.sm
+	r.Hasher.Tx = tx
+
+	for _, path := range r.files {
+		if marked, ok := r.marked[path]; ok {
+			if err := tx.WriteFile(path, []byte(marked)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	state, err := sm.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	result, err := r.apply(state, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.SaveState(state); err != nil {
+		return nil, fmt.Errorf("failed to save state: %w", err)
+	}
+
+	// The recorded sections are the bases for merging later hand edits
+	for _, path := range r.files {
+		content, err := tx.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		for id, body := range GeneratedSections(string(content)) {
+			if err := tx.WriteFile(sm.basePath(id), []byte(body)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := NewHistory(sm).Record("derive "+r.Layer, state, tx); err != nil {
+		return nil, fmt.Errorf("failed to record history: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit derivation: %w", err)
+	}
+	return result, nil
+}
+
+// apply updates state with the recorded files
+func (r *Recorder) apply(state *DerivationState, tx *Transaction) (*RecordResult, error) {
+	result := &RecordResult{}
+	now := time.Now()
+
+	for _, src := range r.sources {
+		hash, err := r.Hasher.HashArtifact(src, r.ProjectDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", src.ID, err)
+		}
+		src.ContentHash = hash
+		src.DerivedAt = now
+		r.replace(state, src)
+	}
+
+	// Register the artifacts first so that upstream lookups within the
+	// batch see the new hashes
+	var recorded []*Artifact
+	sections := make(map[string]string)
+	for _, path := range r.files {
+		rel := r.relPath(path)
+
+		content, err := tx.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		doc := r.Parser.ParseContent(string(content), path)
+		present := make(map[string]bool)
+		for _, section := range doc.Sections {
+			if section.Type != "manual" && section.ID != "" {
+				sections[section.ID] = section.Content
+			}
+		}
+		for _, a := range doc.Artifacts {
+			a.Layer = r.Layer
+			a.Location.File = rel
+			hash, err := r.Hasher.HashArtifact(a, r.ProjectDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to hash %s: %w", a.ID, err)
+			}
+			a.ContentHash = hash
+			a.DerivedAt = now
+			present[a.ID] = true
+			r.replace(state, a)
+			recorded = append(recorded, a)
+		}
+
+		for id, a := range state.Artifacts {
+			if a.Location.File == rel && !present[id] {
+				r.remove(state, id)
+				result.Removed = append(result.Removed, id)
+			}
+		}
+	}
+
+	for _, a := range recorded {
+		for _, up := range r.upstreamOf(state, a, sections[a.ID]) {
+			a.Upstream[up.ID] = up.ContentHash
+			state.DependencyGraph.AddEdge(up.ID, a.ID, EdgeDerives)
+			if !containsString(up.Downstream, a.ID) {
+				up.Downstream = append(up.Downstream, a.ID)
+			}
+			result.Edges++
+		}
+		a.DerivedFromHashes = make(map[string]string, len(a.Upstream))
+		for id, hash := range a.Upstream {
+			a.DerivedFromHashes[id] = hash
+		}
+		result.Artifacts = append(result.Artifacts, a.ID)
+	}
+
+	// Link decisions, both new and already known, to the artifacts whose
+	// sections mention them
+	for _, d := range r.decisions {
+		if d.Layer == "" {
+			d.Layer = r.Layer
+		}
+		if existing := state.GetDecision(d.ID); existing != nil {
+			d.Affects = existing.Affects
+		}
+		state.SetDecision(d)
+	}
+	for _, a := range recorded {
+		for _, d := range state.Decisions {
+			d.Affects = removeFromSlice(d.Affects, a.ID)
+		}
+	}
+	for _, a := range recorded {
+		tokens := idTokens(sections[a.ID])
+		a.Decisions = nil
+		for id, d := range state.Decisions {
+			if !tokens[id] {
+				continue
+			}
+			a.Decisions = append(a.Decisions, id)
+			if !containsString(d.Affects, a.ID) {
+				d.Affects = append(d.Affects, a.ID)
+			}
+		}
+		sort.Strings(a.Decisions)
+	}
+
+	sort.Strings(result.Removed)
+	return result, nil
+}
+
+// upstreamOf returns the artifacts of lower layers a section references,
+// plus the recorder's sources. A reference is an ID-like token naming a
+// known artifact; the artifact's own ID and tokens no artifact is named
+// after are searched for embedded IDs instead, so that TS-BR-ORD-001
+// yields BR-ORD-001 and TC-AC-ORD-001-P01 yields AC-ORD-001.
+func (r *Recorder) upstreamOf(state *DerivationState, a *Artifact, content string) []*Artifact {
+	seen := make(map[string]bool)
+	var upstream []*Artifact
+	add := func(id string) {
+		ref := state.GetArtifact(id)
+		if ref == nil || seen[id] || layerOrder(ref.Layer) >= layerOrder(a.Layer) {
+			return
+		}
+		seen[id] = true
+		upstream = append(upstream, ref)
+	}
+
+	for _, src := range r.sources {
+		add(src.ID)
+	}
+	for token := range idTokens(a.ID + "\n" + content) {
+		if token != a.ID && state.GetArtifact(token) != nil {
+			add(token)
+			continue
+		}
+		parts := strings.Split(token, "-")
+		for i := 0; i < len(parts); i++ {
+			for j := i + 2; j <= len(parts); j++ {
+				if id := strings.Join(parts[i:j], "-"); id != token {
+					add(id)
+				}
+			}
+		}
+	}
+
+	sort.Slice(upstream, func(i, j int) bool { return upstream[i].ID < upstream[j].ID })
+	return upstream
+}
+
+// replace stores an artifact, keeping the downstream links of the version
+// it replaces and dropping its old upstream edges
+func (r *Recorder) replace(state *DerivationState, a *Artifact) {
+	if old := state.GetArtifact(a.ID); old != nil {
+		a.Downstream = old.Downstream
+		for _, up := range state.DependencyGraph.GetUpstream(a.ID) {
+			state.DependencyGraph.RemoveEdge(up, a.ID)
+			if upArt := state.GetArtifact(up); upArt != nil {
+				upArt.Downstream = removeFromSlice(upArt.Downstream, a.ID)
+			}
+		}
+	}
+	if a.Upstream == nil {
+		a.Upstream = make(map[string]string)
+	}
+	state.SetArtifact(a)
+}
+
+// remove drops an artifact, its edges and the links to it
+func (r *Recorder) remove(state *DerivationState, id string) {
+	state.RemoveArtifact(id)
+	state.DependencyGraph.RemoveNode(id)
+	for _, a := range state.Artifacts {
+		a.Downstream = removeFromSlice(a.Downstream, id)
+	}
+	for _, d := range state.Decisions {
+		d.Affects = removeFromSlice(d.Affects, id)
+	}
+}
+
+// relPath returns path relative to the project directory where possible
+func (r *Recorder) relPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	root, err := filepath.Abs(r.ProjectDir)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return abs
+	}
+	return filepath.ToSlash(rel)
+}
+
+// idTokens returns the set of ID-like tokens in content
+func idTokens(content string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, t := range idTokenPattern.FindAllString(content, -1) {
+		tokens[t] = true
+	}
+	return tokens
+}
+
+func containsString(slice []string, value string) bool {
+	for _, s := range slice {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package derivation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const recordL1Doc = `---
+title: "Acceptance Criteria"
+---
+
+# Acceptance Criteria
+
+---
+
+## AC-ORD-001 – Place order {#ac-ord-001}
+
+**Given** a cart
+**When** the customer checks out
+**Then** an order is placed
+
+**Traceability:**
+- Decision: [DEC-001](decisions.md#dec-001)
+
+---
+
+## BR-ORD-001 – Cart not empty {#br-ord-001}
+
+**Rule:** An order needs at least one item
+
+---
+`
+
+const recordL2Doc = `# Tech Specs
+
+## TS-BR-ORD-001 – Cart check {#ts-br-ord-001}
+
+**Related ACs:** AC-ORD-001
+
+` + "```go\n## AC-ORD-999 not a heading\n```" + `
+
+## Summary
+
+Nothing to see.
+`
+
+func writeRecordFile(t *testing.T, dir, rel, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMarkSections(t *testing.T) {
+	marked := MarkSections(recordL1Doc)
+
+	doc := NewParser().ParseContent(marked, "l1/acceptance-criteria.md")
+	if len(doc.Errors) > 0 {
+		t.Fatalf("Unexpected parse errors: %+v", doc.Errors)
+	}
+	if len(doc.Artifacts) != 2 {
+		t.Fatalf("Expected 2 artifacts, got %d", len(doc.Artifacts))
+	}
+	ac := doc.Artifacts[0]
+	if ac.ID != "AC-ORD-001" || ac.Type != ArtifactAcceptanceCrit {
+		t.Errorf("Unexpected artifact %+v", ac)
+	}
+	if !strings.Contains(marked, "- Decision: [DEC-001](decisions.md#dec-001)\n<!-- LOOM:END generated -->\n\n---") {
+		t.Errorf("Expected the section to end before the rule\n%s", marked)
+	}
+
+	if MarkSections(marked) != marked {
+		t.Error("Expected marked content to be left unchanged")
+	}
+
+	l2 := MarkSections(recordL2Doc)
+	if strings.Count(l2, MarkerBegin) != 1 {
+		t.Errorf("Expected only TS-BR-ORD-001 to be marked\n%s", l2)
+	}
+	if !strings.Contains(l2, "```\n<!-- LOOM:END generated -->\n\n## Summary") {
+		t.Errorf("Expected the section to end before the next heading\n%s", l2)
+	}
+
+	events := "## Domain Events\n\n### EVT-ORD-001: OrderPlaced\n\n- a\n\n## Commands\n\n### DEP-ORD-001: x\n"
+	marked = MarkSections(events)
+	if !strings.Contains(marked, "<!-- LOOM:BEGIN generated id=\"EVT-ORD-001\" type=\"event\" -->\n### EVT-ORD-001: OrderPlaced\n\n- a\n<!-- LOOM:END generated -->\n\n## Commands") {
+		t.Errorf("Unexpected event marking\n%s", marked)
+	}
+	if strings.Contains(marked, "DEP-ORD-001\"") {
+		t.Error("Expected headings of unknown artifact types to stay unmarked")
+	}
+}
+
+func TestRecorder_Commit(t *testing.T) {
+	projectDir := t.TempDir()
+	storyPath := writeRecordFile(t, projectDir, "l0/order-stories.md", "# Stories\n\nAs a customer I want to order.\n")
+	acPath := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", recordL1Doc)
+	tsPath := writeRecordFile(t, projectDir, "l2/tech-specs.md", recordL2Doc)
+
+	rec := NewRecorder(projectDir, "l1")
+	rec.AddSource(storyPath, ArtifactUserStory)
+	rec.AddDecision(&Decision{ID: "DEC-001", Question: "Empty carts?", Answer: "Reject"})
+	if err := rec.Record(acPath); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if _, err := rec.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	rec = NewRecorder(projectDir, "l2")
+	if err := rec.Record(tsPath); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	res, err := rec.Commit()
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if len(res.Artifacts) != 1 || res.Edges != 2 {
+		t.Errorf("Expected one artifact with two upstream links, got %+v", res)
+	}
+
+	state, err := NewStateManager(projectDir).Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	story := state.GetArtifact("US-ORDER-STORIES")
+	if story == nil || story.Layer != "l0" || story.Location.File != "l0/order-stories.md" {
+		t.Fatalf("Expected the L0 source, got %+v", story)
+	}
+	ac := state.GetArtifact("AC-ORD-001")
+	if ac == nil || ac.Location.File != "l1/acceptance-criteria.md" || ac.ContentHash == "" {
+		t.Fatalf("Expected AC-ORD-001 to be recorded, got %+v", ac)
+	}
+	if _, ok := ac.DerivedFromHashes["US-ORDER-STORIES"]; !ok {
+		t.Errorf("Expected AC-ORD-001 to derive from the stories, got %v", ac.DerivedFromHashes)
+	}
+	if len(ac.Decisions) != 1 || ac.Decisions[0] != "DEC-001" {
+		t.Errorf("Expected DEC-001 on AC-ORD-001, got %v", ac.Decisions)
+	}
+	if d := state.GetDecision("DEC-001"); d == nil || d.Layer != "l1" || len(d.Affects) != 1 || d.Affects[0] != "AC-ORD-001" {
+		t.Errorf("Expected DEC-001 to affect AC-ORD-001, got %+v", d)
+	}
+	if br := state.GetArtifact("BR-ORD-001"); br == nil || len(br.Decisions) != 0 {
+		t.Errorf("Expected BR-ORD-001 without decisions, got %+v", br)
+	}
+
+	ts := state.GetArtifact("TS-BR-ORD-001")
+	if ts == nil {
+		t.Fatal("Expected TS-BR-ORD-001 to be recorded")
+	}
+	for _, up := range []string{"AC-ORD-001", "BR-ORD-001"} {
+		if ts.DerivedFromHashes[up] != state.GetArtifact(up).ContentHash {
+			t.Errorf("Expected the hash of %s to be recorded, got %v", up, ts.DerivedFromHashes)
+		}
+		if !state.DependencyGraph.HasEdge(up, ts.ID) {
+			t.Errorf("Expected an edge %s → %s", up, ts.ID)
+		}
+	}
+	if _, ok := ts.Upstream["AC-ORD-999"]; ok {
+		t.Error("Expected code blocks not to create artifacts")
+	}
+
+	tracker := NewTracker(state, projectDir)
+	stale, err := tracker.DetectStaleArtifacts()
+	if err != nil {
+		t.Fatalf("DetectStaleArtifacts failed: %v", err)
+	}
+	if len(stale) != 0 {
+		t.Errorf("Expected nothing stale right after derivation, got %d", len(stale))
+	}
+
+	content, _ := os.ReadFile(acPath)
+	os.WriteFile(acPath, []byte(strings.Replace(string(content), "at least one item", "at least two items", 1)), 0644)
+	stale, err = tracker.DetectStaleArtifacts()
+	if err != nil {
+		t.Fatalf("DetectStaleArtifacts failed: %v", err)
+	}
+	if len(stale) != 1 || stale[0].ID != "TS-BR-ORD-001" {
+		t.Errorf("Expected TS-BR-ORD-001 to be stale after editing BR-ORD-001, got %v", stale)
+	}
+}
+
+func TestRecorder_RemovesGoneArtifacts(t *testing.T) {
+	projectDir := t.TempDir()
+	acPath := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", recordL1Doc)
+	tsPath := writeRecordFile(t, projectDir, "l2/tech-specs.md", recordL2Doc)
+
+	for _, step := range []struct {
+		layer string
+		path  string
+	}{{"l1", acPath}, {"l2", tsPath}} {
+		rec := NewRecorder(projectDir, step.layer)
+		if err := rec.Record(step.path); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rec.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Re-derive L1 without the business rule
+	without := recordL1Doc[:strings.Index(recordL1Doc, "## BR-ORD-001")]
+	writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", without)
+	rec := NewRecorder(projectDir, "l1")
+	if err := rec.Record(acPath); err != nil {
+		t.Fatal(err)
+	}
+	res, err := rec.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Removed) != 1 || res.Removed[0] != "BR-ORD-001" {
+		t.Errorf("Expected BR-ORD-001 to be removed, got %v", res.Removed)
+	}
+
+	state, _ := NewStateManager(projectDir).Load()
+	if state.GetArtifact("BR-ORD-001") != nil {
+		t.Error("Expected BR-ORD-001 to be gone from state")
+	}
+	ac := state.GetArtifact("AC-ORD-001")
+	if len(ac.Downstream) != 1 || ac.Downstream[0] != "TS-BR-ORD-001" {
+		t.Errorf("Expected the re-recorded AC to keep its downstream, got %v", ac.Downstream)
+	}
+	if state.DependencyGraph.HasEdge("BR-ORD-001", "TS-BR-ORD-001") {
+		t.Error("Expected the edge from the removed artifact to be gone")
+	}
+
+	stale, err := NewTracker(state, projectDir).DetectStaleArtifacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0].ID != "TS-BR-ORD-001" {
+		t.Errorf("Expected TS-BR-ORD-001 to be stale once its upstream is gone, got %v", stale)
+	}
+}
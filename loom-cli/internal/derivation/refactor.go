@@ -0,0 +1,468 @@
+package derivation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// =============================================================================
+// Rename Refactoring
+// =============================================================================
+
+var (
+	// idToken matches anything shaped like an artifact ID
+	idToken = regexp.MustCompile(`\b[A-Z][A-Z0-9]*(?:-[A-Z0-9]+)+\b`)
+
+	// anchorToken matches a markdown anchor, as written by formatter.ToAnchor
+	anchorToken = regexp.MustCompile(`#[a-z][a-z0-9]*(?:-[a-z0-9]+)+\b`)
+
+	// domainCode is a valid domain code, e.g. ORD
+	domainCode = regexp.MustCompile(`^[A-Z][A-Z0-9]*$`)
+)
+
+// refactorExts are the documents a rename rewrites
+var refactorExts = map[string]bool{
+	".md": true, ".yaml": true, ".yml": true, ".json": true, ".feature": true,
+}
+
+// Rename replaces an artifact ID, or the domain code within every ID.
+// Matching is by ID segments, so renaming AC-ORD-001 also renames the test
+// cases derived from it (TC-AC-ORD-001-P01), but never AC-ORD-0010.
+type Rename struct {
+	Old string
+	New string
+
+	// Domain renames the domain code segment instead of a whole ID
+	Domain bool
+}
+
+// NewIDRename returns the rename of one artifact or decision ID
+func NewIDRename(oldID, newID string) (*Rename, error) {
+	for _, id := range []string{oldID, newID} {
+		if idToken.FindString(id) != id {
+			return nil, fmt.Errorf("invalid ID: %q", id)
+		}
+	}
+	if oldID == newID {
+		return nil, fmt.Errorf("old and new ID are the same: %s", oldID)
+	}
+	return &Rename{Old: oldID, New: newID}, nil
+}
+
+// NewDomainRename returns the rename of a domain code, e.g. ORD to ORDER
+func NewDomainRename(oldCode, newCode string) (*Rename, error) {
+	for _, code := range []string{oldCode, newCode} {
+		if !domainCode.MatchString(code) {
+			return nil, fmt.Errorf("invalid domain code: %q (expected upper case letters and digits)", code)
+		}
+	}
+	if oldCode == newCode {
+		return nil, fmt.Errorf("old and new domain are the same: %s", oldCode)
+	}
+	return &Rename{Old: oldCode, New: newCode, Domain: true}, nil
+}
+
+// ID returns the renamed form of an ID
+func (r *Rename) ID(id string) string {
+	return renameSegments(id, strings.Split(r.Old, "-"), r.New, r.Domain)
+}
+
+// Text renames every ID and anchor in text
+func (r *Rename) Text(text string) string {
+	text = idToken.ReplaceAllStringFunc(text, r.ID)
+
+	old := strings.Split(strings.ToLower(r.Old), "-")
+	return anchorToken.ReplaceAllStringFunc(text, func(anchor string) string {
+		return "#" + renameSegments(anchor[1:], old, strings.ToLower(r.New), r.Domain)
+	})
+}
+
+// command describes the rename in the history, as the refactor command
+func (r *Rename) command() string {
+	action := "rename-id"
+	if r.Domain {
+		action = "rename-domain"
+	}
+	return fmt.Sprintf("%s %s %s", action, r.Old, r.New)
+}
+
+// renameSegments replaces every run of the segments old in the dash
+// separated token. The type prefix, the first segment, is never a domain.
+func renameSegments(token string, old []string, replacement string, domain bool) string {
+	segments := strings.Split(token, "-")
+	start := 0
+	if domain {
+		start = 1
+	}
+
+	var out []string
+	out = append(out, segments[:start]...)
+	for i := start; i < len(segments); {
+		if i+len(old) <= len(segments) && equalSegments(segments[i:i+len(old)], old) {
+			out = append(out, replacement)
+			i += len(old)
+			continue
+		}
+		out = append(out, segments[i])
+		i++
+	}
+	return strings.Join(out, "-")
+}
+
+func equalSegments(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// LineChange is one line of a document changed by a rename
+type LineChange struct {
+	Line int    `json:"line"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// DocumentChange lists the changed lines of a document
+type DocumentChange struct {
+	Path  string       `json:"path"` // project-relative
+	Lines []LineChange `json:"lines"`
+}
+
+// RefactorResult describes a rename
+type RefactorResult struct {
+	Files []*DocumentChange `json:"files"`
+
+	// IDs maps the renamed artifact and decision IDs to their new ID
+	IDs map[string]string `json:"ids"`
+
+	// Run is the history run recording the rename
+	Run *Run `json:"run,omitempty"`
+}
+
+// Refactor applies a rename to the project documents, the stored merge
+// bases and the state in one transaction. With dryRun nothing is written.
+func (sm *StateManager) Refactor(r *Rename, dryRun bool) (*RefactorResult, error) {
+	// The state and documents are read under the lock they are written
+	// with, so that a concurrent change is not lost
+	readFile := os.ReadFile
+	var tx *Transaction
+	if !dryRun {
+		var err error
+		if tx, err = sm.Begin(); err != nil {
+			return nil, err
+		}
+		defer tx.Abort()
+		readFile = tx.ReadFile
+	}
+
+	state, err := sm.Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRenameTarget(state, r); err != nil {
+		return nil, err
+	}
+
+	result := &RefactorResult{IDs: make(map[string]string)}
+	for _, id := range stateIDs(state) {
+		if renamed := r.ID(id); renamed != id {
+			result.IDs[id] = renamed
+		}
+	}
+
+	docs, err := sm.refactorDocuments()
+	if err != nil {
+		return nil, err
+	}
+	contents := make(map[string][]byte)
+	for _, path := range docs {
+		data, err := readFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		renamed := r.Text(string(data))
+		if renamed == string(data) {
+			continue
+		}
+		contents[path] = []byte(renamed)
+		rel, _ := filepath.Rel(sm.ProjectDir, path)
+		result.Files = append(result.Files, &DocumentChange{
+			Path:  filepath.ToSlash(rel),
+			Lines: changedLines(string(data), renamed),
+		})
+	}
+
+	if dryRun || len(result.Files)+len(result.IDs) == 0 {
+		return result, nil
+	}
+
+	for _, path := range sortedPaths(contents) {
+		if err := tx.WriteFile(path, contents[path]); err != nil {
+			return nil, fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+	}
+
+	// Merge bases are stored by artifact ID
+	var movedBases []string
+	for oldID, newID := range result.IDs {
+		data, err := tx.ReadFile(sm.basePath(oldID))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read base of %s: %w", oldID, err)
+		}
+		if err := tx.WriteFile(sm.basePath(newID), data); err != nil {
+			return nil, fmt.Errorf("failed to stage base of %s: %w", newID, err)
+		}
+		if err := tx.Discard(sm.basePath(oldID)); err != nil {
+			return nil, err
+		}
+		movedBases = append(movedBases, sm.basePath(oldID))
+	}
+
+	if err := renameState(state, r); err != nil {
+		return nil, err
+	}
+	sm.rehashRenamed(tx, state, contents)
+
+	if err := tx.SaveState(state); err != nil {
+		return nil, fmt.Errorf("failed to stage state: %w", err)
+	}
+	if result.Run, err = NewHistory(sm).Record(r.command(), state, tx); err != nil {
+		return nil, fmt.Errorf("failed to record history: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rename: %w", err)
+	}
+
+	for _, path := range movedBases {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove old base: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// checkRenameTarget refuses renames onto IDs or domains already in use
+func checkRenameTarget(state *DerivationState, r *Rename) error {
+	for _, id := range stateIDs(state) {
+		if r.Domain {
+			for _, segment := range strings.Split(id, "-")[1:] {
+				if segment == r.New {
+					return fmt.Errorf("domain %s is already used by %s", r.New, id)
+				}
+			}
+		} else if id == r.New {
+			return fmt.Errorf("%s already exists", r.New)
+		}
+	}
+	return nil
+}
+
+// stateIDs returns the artifact and decision IDs in state
+func stateIDs(state *DerivationState) []string {
+	var ids []string
+	for id := range state.Artifacts {
+		ids = append(ids, id)
+	}
+	for id := range state.Decisions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// refactorDocuments returns the documents in the project a rename may
+// touch, plus the stored merge bases
+func (sm *StateManager) refactorDocuments() ([]string, error) {
+	var docs []string
+	err := filepath.WalkDir(sm.ProjectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != sm.ProjectDir && (strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if refactorExts[filepath.Ext(path)] {
+			docs = append(docs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan project: %w", err)
+	}
+
+	// Merge bases hold derived content too, and move with their artifact
+	bases, err := os.ReadDir(filepath.Join(sm.LoomDir, BaseDirName))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read merge bases: %w", err)
+	}
+	for _, entry := range bases {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".md" {
+			docs = append(docs, filepath.Join(sm.LoomDir, BaseDirName, entry.Name()))
+		}
+	}
+	return docs, nil
+}
+
+// renameState renames every ID held in state
+func renameState(state *DerivationState, r *Rename) error {
+	renameKeys := func(m map[string]string) map[string]string {
+		if m == nil {
+			return nil
+		}
+		renamed := make(map[string]string, len(m))
+		for k, v := range m {
+			renamed[r.ID(k)] = v
+		}
+		return renamed
+	}
+	renameList := func(ids []string) {
+		for i, id := range ids {
+			ids[i] = r.ID(id)
+		}
+	}
+
+	artifacts := make(map[string]*Artifact, len(state.Artifacts))
+	for _, a := range state.Artifacts {
+		a.ID = r.ID(a.ID)
+		a.Location.Anchor = strings.TrimPrefix(r.Text("#"+a.Location.Anchor), "#")
+		a.Upstream = renameKeys(a.Upstream)
+		a.DerivedFromHashes = renameKeys(a.DerivedFromHashes)
+		renameList(a.Downstream)
+		renameList(a.Decisions)
+		artifacts[a.ID] = a
+	}
+	state.Artifacts = artifacts
+
+	decisions := make(map[string]*Decision, len(state.Decisions))
+	for _, d := range state.Decisions {
+		d.ID = r.ID(d.ID)
+		d.Question = r.Text(d.Question)
+		d.Answer = r.Text(d.Answer)
+		renameList(d.Affects)
+		decisions[d.ID] = d
+	}
+	state.Decisions = decisions
+
+	if g := state.DependencyGraph; g != nil {
+		for i := range g.Edges {
+			g.Edges[i].From = r.ID(g.Edges[i].From)
+			g.Edges[i].To = r.ID(g.Edges[i].To)
+		}
+		g.RebuildFromEdges()
+	}
+
+	if state.TestResults != nil {
+		results := make(map[string]*TestResult, len(state.TestResults))
+		for id, res := range state.TestResults {
+			res.ACRef = r.ID(res.ACRef)
+			renameList(res.BRRefs)
+			results[r.ID(id)] = res
+		}
+		state.TestResults = results
+	}
+
+	for tracker, tickets := range state.TrackerTickets {
+		renamed := make(map[string]*TrackerTicket, len(tickets))
+		for id, ticket := range tickets {
+			renamed[r.ID(id)] = ticket
+		}
+		state.TrackerTickets[tracker] = renamed
+	}
+
+	for name, snapshot := range state.Snapshots {
+		renamed := json.RawMessage(r.Text(string(snapshot)))
+		if !json.Valid(renamed) {
+			return fmt.Errorf("failed to rename snapshot %s", name)
+		}
+		state.Snapshots[name] = renamed
+	}
+	return nil
+}
+
+// rehashRenamed updates the hashes of artifacts in rewritten documents, and
+// the upstream hashes recorded against them unless those were already stale
+func (sm *StateManager) rehashRenamed(tx *Transaction, state *DerivationState, contents map[string][]byte) {
+	hasher := NewHasher()
+	hasher.Tx = tx
+
+	rewritten := make(map[string]bool)
+	for path := range contents {
+		rewritten[path] = true
+		rel, err := filepath.Rel(sm.ProjectDir, path)
+		if err == nil {
+			// Cached hashes of rewritten files are recomputed when needed
+			delete(state.FileHashes, filepath.ToSlash(rel))
+			delete(state.FileHashes, rel)
+		}
+		delete(state.FileHashes, path)
+	}
+
+	rehashed := make(map[string][2]string) // id → old, new hash
+	for id, a := range state.Artifacts {
+		path := a.Location.File
+		if path == "" {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(sm.ProjectDir, path)
+		}
+		if !rewritten[path] {
+			continue
+		}
+		hash, err := hasher.HashArtifact(a, sm.ProjectDir)
+		if err != nil || hash == a.ContentHash {
+			continue
+		}
+		rehashed[id] = [2]string{a.ContentHash, hash}
+		a.ContentHash = hash
+	}
+
+	for _, a := range state.Artifacts {
+		for _, hashes := range []map[string]string{a.Upstream, a.DerivedFromHashes} {
+			for up, recorded := range hashes {
+				if h, ok := rehashed[up]; ok && recorded == h[0] {
+					hashes[up] = h[1]
+				}
+			}
+		}
+	}
+}
+
+// changedLines compares two versions of a document line by line; a rename
+// never adds or removes lines
+func changedLines(before, after string) []LineChange {
+	old := strings.Split(before, "\n")
+	updated := strings.Split(after, "\n")
+	var changes []LineChange
+	for i := range old {
+		if i < len(updated) && old[i] != updated[i] {
+			changes = append(changes, LineChange{Line: i + 1, Old: old[i], New: updated[i]})
+		}
+	}
+	return changes
+}
+
+func sortedPaths(m map[string][]byte) []string {
+	paths := make([]string, 0, len(m))
+	for path := range m {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,123 @@
+package derivation
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRename_Text(t *testing.T) {
+	id, _ := NewIDRename("AC-ORD-001", "AC-ORD-010")
+	got := id.Text("AC-ORD-001, AC-ORD-0010, TC-AC-ORD-001-P01, [AC-ORD-001](l1/ac.md#ac-ord-001) {#ac-ord-001}")
+	want := "AC-ORD-010, AC-ORD-0010, TC-AC-ORD-010-P01, [AC-ORD-010](l1/ac.md#ac-ord-010) {#ac-ord-010}"
+	if got != want {
+		t.Errorf("Unexpected ID rename\ngot:  %s\nwant: %s", got, want)
+	}
+
+	domain, _ := NewDomainRename("ORD", "ORDER")
+	got = domain.Text("ENT-ORD, BR-ORD-003, TC-AC-ORD-001-P01, ORD-001 and #br-ord-003; BR-ORDX-001")
+	want = "ENT-ORDER, BR-ORDER-003, TC-AC-ORDER-001-P01, ORD-001 and #br-order-003; BR-ORDX-001"
+	if got != want {
+		t.Errorf("Unexpected domain rename\ngot:  %s\nwant: %s", got, want)
+	}
+
+	if _, err := NewDomainRename("ord", "ORDER"); err == nil {
+		t.Error("Expected an error for a lower case domain code")
+	}
+}
+
+func TestStateManager_RefactorRenameID(t *testing.T) {
+	projectDir := t.TempDir()
+	acPath := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", recordL1Doc)
+	tsPath := writeRecordFile(t, projectDir, "l2/tech-specs.md", recordL2Doc)
+	recordRun(t, projectDir, "l1", acPath)
+	recordRun(t, projectDir, "l2", tsPath)
+
+	sm := NewStateManager(projectDir)
+	rename, _ := NewIDRename("AC-ORD-001", "AC-ORD-010")
+	recorded, _ := os.ReadFile(acPath)
+
+	preview, err := sm.Refactor(rename, true)
+	if err != nil {
+		t.Fatalf("Refactor dry run failed: %v", err)
+	}
+	// Both documents and their merge bases
+	if len(preview.Files) != 4 || preview.IDs["AC-ORD-001"] != "AC-ORD-010" {
+		t.Fatalf("Unexpected preview %+v", preview)
+	}
+	if data, _ := os.ReadFile(acPath); string(data) != string(recorded) {
+		t.Fatal("Expected a dry run to leave the documents unchanged")
+	}
+
+	if _, err := sm.Refactor(rename, false); err != nil {
+		t.Fatalf("Refactor failed: %v", err)
+	}
+	if _, ok, _ := sm.LoadBase("AC-ORD-010"); !ok {
+		t.Error("Expected the merge base to move with the artifact")
+	}
+	if _, ok, _ := sm.LoadBase("AC-ORD-001"); ok {
+		t.Error("Expected the old merge base to be removed")
+	}
+	data, _ := os.ReadFile(acPath)
+	if !strings.Contains(string(data), "## AC-ORD-010 – Place order {#ac-ord-010}") || strings.Contains(string(data), "AC-ORD-001") {
+		t.Errorf("Expected the heading and anchor renamed\n%s", data)
+	}
+
+	state, err := sm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ac := state.GetArtifact("AC-ORD-010")
+	if ac == nil || state.GetArtifact("AC-ORD-001") != nil {
+		t.Fatalf("Expected the artifact renamed in state, got %v", state.Artifacts)
+	}
+	current, _ := NewHasher().HashArtifact(ac, projectDir)
+	if ac.ContentHash != current {
+		t.Error("Expected the renamed artifact to be rehashed")
+	}
+	if ts := state.GetArtifact("TS-BR-ORD-001"); ts != nil {
+		if _, ok := ts.Upstream["AC-ORD-001"]; ok {
+			t.Errorf("Expected upstream references renamed, got %v", ts.Upstream)
+		}
+	}
+	for _, e := range state.DependencyGraph.Edges {
+		if e.From == "AC-ORD-001" || e.To == "AC-ORD-001" {
+			t.Errorf("Expected edges renamed, got %+v", e)
+		}
+	}
+	if len(state.GetStaleArtifacts()) != 0 {
+		t.Errorf("Expected no artifact to turn stale, got %d", len(state.GetStaleArtifacts()))
+	}
+
+	if _, err := sm.Refactor(&Rename{Old: "BR-ORD-001", New: "AC-ORD-010"}, true); err == nil {
+		t.Error("Expected an error renaming onto an existing ID")
+	}
+}
+
+func TestStateManager_RefactorRenameDomain(t *testing.T) {
+	projectDir := t.TempDir()
+	acPath := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", recordL1Doc)
+	recordRun(t, projectDir, "l1", acPath, &Decision{ID: "DEC-001", Question: "Empty carts?"})
+
+	sm := NewStateManager(projectDir)
+	rename, _ := NewDomainRename("ORD", "ORDER")
+	result, err := sm.Refactor(rename, false)
+	if err != nil {
+		t.Fatalf("Refactor failed: %v", err)
+	}
+	if len(result.IDs) != 2 {
+		t.Errorf("Expected both artifacts renamed, got %v", result.IDs)
+	}
+
+	state, _ := sm.Load()
+	if state.GetArtifact("AC-ORDER-001") == nil || state.GetArtifact("BR-ORDER-001") == nil {
+		t.Errorf("Expected the domain renamed in state, got %v", state.Artifacts)
+	}
+	if affects := state.GetDecision("DEC-001").Affects; len(affects) != 1 || affects[0] != "AC-ORDER-001" {
+		t.Errorf("Expected decision references renamed, got %v", affects)
+	}
+
+	if _, err := sm.Refactor(&Rename{Old: "ORDER", New: "ORDER", Domain: true}, true); err == nil {
+		t.Error("Expected an error renaming onto a domain in use")
+	}
+}
@@ -29,8 +29,15 @@ const (
 	// LockTimeout is how long to wait for a lock
 	LockTimeout = 30 * time.Second
 
-	// LockStaleTimeout is how long before a lock is considered stale
-	LockStaleTimeout = 5 * time.Minute
+	// LockHeartbeatInterval is how often a held lock is refreshed
+	LockHeartbeatInterval = 5 * time.Second
+
+	// LockStaleTimeout is how long after its last heartbeat a lock is
+	// considered stale
+	LockStaleTimeout = 30 * time.Second
+
+	// ReadersDirName is the directory under .loom holding shared locks
+	ReadersDirName = "readers"
 )
 
 // =============================================================================
@@ -63,6 +70,18 @@ type DerivationState struct {
 	// FileHashes caches file content hashes for incremental updates
 	FileHashes map[string]*FileHashInfo `json:"file_hashes,omitempty"`
 
+	// Snapshots holds the last input of generators that diff against their
+	// previous run, e.g. the data model behind gen-sql migrations
+	Snapshots map[string]json.RawMessage `json:"snapshots,omitempty"`
+
+	// TrackerTickets maps tracker names to the issues feature tickets were
+	// exported as, keyed by ticket ID
+	TrackerTickets map[string]map[string]*TrackerTicket `json:"tracker_tickets,omitempty"`
+
+	// TestResults maps test case IDs to the outcome of the last ingested
+	// test run
+	TestResults map[string]*TestResult `json:"test_results,omitempty"`
+
 	// mu protects concurrent access to the state
 	mu sync.RWMutex `json:"-"`
 }
@@ -75,6 +94,34 @@ type FileHashInfo struct {
 	Size    int64     `json:"size"`
 }
 
+// TrackerTicket records the issue a feature ticket was exported as
+type TrackerTicket struct {
+	ExternalID string    `json:"external_id"`
+	Key        string    `json:"key"` // human-facing reference, e.g. #12 or PROJ-3
+	URL        string    `json:"url,omitempty"`
+	Hash       string    `json:"hash"` // hash of the exported content
+	SyncedAt   time.Time `json:"synced_at"`
+}
+
+// Test result statuses
+const (
+	TestPass    = "pass"
+	TestFail    = "fail"
+	TestSkip    = "skip"
+	TestMissing = "missing" // no test referencing the test case ran
+)
+
+// TestResult records how the tests implementing a test case fared
+type TestResult struct {
+	Status   string    `json:"status"`
+	Tests    []string  `json:"tests,omitempty"` // test names referencing the test case
+	ACRef    string    `json:"ac_ref,omitempty"`
+	BRRefs   []string  `json:"br_refs,omitempty"`
+	Message  string    `json:"message,omitempty"` // failure output
+	Duration float64   `json:"duration_seconds,omitempty"`
+	RunAt    time.Time `json:"run_at"`
+}
+
 // =============================================================================
 // State Manager
 // =============================================================================
@@ -87,15 +134,28 @@ type StateManager struct {
 	// LoomDir is the path to the .loom directory
 	LoomDir string
 
-	// StatePath is the path to the state file
+	// StatePath is the path to the state file of the JSON backend
 	StatePath string
 
 	// LockPath is the path to the lock file
 	LockPath string
 
+	// Store persists the state; the backend is detected from .loom
+	Store StateStore
+
+	// Recovered describes the interrupted transaction finished when the
+	// lock was last taken, if any
+	Recovered *Recovery
+
 	// lockFile holds the lock file handle when locked
 	lockFile *os.File
 
+	// sharedPath is the shared lock file when locked for reading
+	sharedPath string
+
+	// heartbeat stops refreshing the held lock when closed
+	heartbeat chan struct{}
+
 	// mu protects the lock state
 	mu sync.Mutex
 }
@@ -108,6 +168,7 @@ func NewStateManager(projectDir string) *StateManager {
 		LoomDir:    loomDir,
 		StatePath:  filepath.Join(loomDir, StateFileName),
 		LockPath:   filepath.Join(loomDir, LockFileName),
+		Store:      detectStore(loomDir),
 	}
 }
 
@@ -118,26 +179,38 @@ func NewStateManager(projectDir string) *StateManager {
 // Load loads the derivation state from disk
 // Returns a new empty state if the file doesn't exist
 func (sm *StateManager) Load() (*DerivationState, error) {
-	// Check if state file exists
-	if _, err := os.Stat(sm.StatePath); os.IsNotExist(err) {
+	// Finish a derivation interrupted by a crash, unless another process
+	// is still running it
+	if err := sm.recoverAbandoned(); err != nil {
+		return nil, err
+	}
+
+	// Check if a state was saved
+	if !sm.Store.Exists() {
 		return sm.NewState(), nil
 	}
 
-	// Read the file
-	data, err := os.ReadFile(sm.StatePath)
+	state, err := sm.Store.Load()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read state file: %w", err)
+		return nil, err
 	}
+	return sm.normalize(state)
+}
 
-	// Parse JSON
+// decode parses a state serialized as JSON
+func (sm *StateManager) decode(data []byte) (*DerivationState, error) {
 	var state DerivationState
 	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, fmt.Errorf("failed to parse state file: %w", err)
 	}
+	return sm.normalize(&state)
+}
 
+// normalize migrates a loaded state and initializes its maps
+func (sm *StateManager) normalize(state *DerivationState) (*DerivationState, error) {
 	// Migrate if needed
 	if state.Version != StateVersion {
-		if err := sm.migrateState(&state); err != nil {
+		if err := sm.migrateState(state); err != nil {
 			return nil, fmt.Errorf("failed to migrate state: %w", err)
 		}
 	}
@@ -154,9 +227,12 @@ func (sm *StateManager) Load() (*DerivationState, error) {
 	}
 	if state.DependencyGraph == nil {
 		state.DependencyGraph = NewDependencyGraph()
+	} else {
+		// Adjacency lists are not serialized
+		state.DependencyGraph.RebuildFromEdges()
 	}
 
-	return &state, nil
+	return state, nil
 }
 
 // NewState creates a new empty derivation state
@@ -194,124 +270,8 @@ func (sm *StateManager) Save(state *DerivationState) error {
 	// Update version
 	state.Version = StateVersion
 
-	// Marshal to JSON
-	data, err := json.MarshalIndent(state, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal state: %w", err)
-	}
-
-	// Write atomically using temp file + rename
-	tmpPath := sm.StatePath + ".tmp"
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write temp state file: %w", err)
-	}
-
-	if err := os.Rename(tmpPath, sm.StatePath); err != nil {
-		os.Remove(tmpPath) // Clean up temp file
-		return fmt.Errorf("failed to rename state file: %w", err)
-	}
-
-	return nil
-}
-
-// =============================================================================
-// File Locking
-// =============================================================================
-
-// Lock acquires an exclusive lock on the state file
-// This prevents concurrent modifications from multiple processes
-func (sm *StateManager) Lock() error {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
-	if sm.lockFile != nil {
-		return nil // Already locked by this process
-	}
-
-	// Ensure .loom directory exists
-	if err := os.MkdirAll(sm.LoomDir, 0755); err != nil {
-		return fmt.Errorf("failed to create .loom directory: %w", err)
-	}
-
-	// Check for stale lock
-	if info, err := os.Stat(sm.LockPath); err == nil {
-		if time.Since(info.ModTime()) > LockStaleTimeout {
-			// Lock is stale, remove it
-			os.Remove(sm.LockPath)
-		}
-	}
-
-	// Try to create lock file exclusively
-	deadline := time.Now().Add(LockTimeout)
-	for {
-		f, err := os.OpenFile(sm.LockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
-		if err == nil {
-			// Got the lock
-			sm.lockFile = f
-
-			// Write lock info
-			lockInfo := struct {
-				PID       int       `json:"pid"`
-				Hostname  string    `json:"hostname"`
-				LockedAt  time.Time `json:"locked_at"`
-			}{
-				PID:      os.Getpid(),
-				Hostname: hostname(),
-				LockedAt: time.Now(),
-			}
-			json.NewEncoder(f).Encode(lockInfo)
-
-			return nil
-		}
-
-		if !os.IsExist(err) {
-			return fmt.Errorf("failed to create lock file: %w", err)
-		}
-
-		// Lock exists, check if we've timed out
-		if time.Now().After(deadline) {
-			return fmt.Errorf("timeout waiting for lock (another process may be running)")
-		}
-
-		// Wait and retry
-		time.Sleep(100 * time.Millisecond)
-	}
-}
-
-// Unlock releases the lock on the state file
-func (sm *StateManager) Unlock() error {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
-	if sm.lockFile == nil {
-		return nil // Not locked
-	}
-
-	// Close and remove lock file
-	sm.lockFile.Close()
-	sm.lockFile = nil
-
-	if err := os.Remove(sm.LockPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove lock file: %w", err)
-	}
-
-	return nil
-}
-
-// IsLocked checks if the state is currently locked
-func (sm *StateManager) IsLocked() bool {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-	return sm.lockFile != nil
-}
-
-// hostname returns the current hostname or "unknown"
-func hostname() string {
-	h, err := os.Hostname()
-	if err != nil {
-		return "unknown"
-	}
-	return h
+	// Each file is written atomically using temp file + rename
+	return sm.Store.Save(state, writeFileDirect)
 }
 
 // =============================================================================
@@ -409,6 +369,75 @@ func (s *DerivationState) SetFileHash(info *FileHashInfo) {
 	s.FileHashes[info.Path] = info
 }
 
+// GetSnapshot decodes a snapshot into v. It reports false if there is none.
+func (s *DerivationState) GetSnapshot(name string, v interface{}) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.Snapshots[name]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse snapshot %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// SetSnapshot stores v as the snapshot with the given name
+func (s *DerivationState) SetSnapshot(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Snapshots == nil {
+		s.Snapshots = make(map[string]json.RawMessage)
+	}
+	s.Snapshots[name] = data
+	return nil
+}
+
+// GetTrackerTicket returns the issue a ticket was exported as, or nil
+func (s *DerivationState) GetTrackerTicket(tracker, ticketID string) *TrackerTicket {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.TrackerTickets[tracker][ticketID]
+}
+
+// SetTrackerTicket records the issue a ticket was exported as
+func (s *DerivationState) SetTrackerTicket(tracker, ticketID string, t *TrackerTicket) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.TrackerTickets == nil {
+		s.TrackerTickets = make(map[string]map[string]*TrackerTicket)
+	}
+	if s.TrackerTickets[tracker] == nil {
+		s.TrackerTickets[tracker] = make(map[string]*TrackerTicket)
+	}
+	s.TrackerTickets[tracker][ticketID] = t
+}
+
+// GetTestResults returns a copy of the ingested test results
+func (s *DerivationState) GetTestResults() map[string]*TestResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	results := make(map[string]*TestResult, len(s.TestResults))
+	for id, r := range s.TestResults {
+		results[id] = r
+	}
+	return results
+}
+
+// SetTestResults replaces the ingested test results with those of a new run
+func (s *DerivationState) SetTestResults(results map[string]*TestResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.TestResults = results
+}
+
 // =============================================================================
 // State Statistics
 // =============================================================================
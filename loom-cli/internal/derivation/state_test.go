@@ -337,3 +337,37 @@ func TestStateManager_AtomicWrite(t *testing.T) {
 		t.Error("Temp file should not exist after successful save")
 	}
 }
+
+func TestDerivationState_Snapshots(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewStateManager(tmpDir)
+	state := sm.NewState()
+
+	type model struct {
+		Tables []string `json:"tables"`
+	}
+
+	var got model
+	if ok, err := state.GetSnapshot("data_model", &got); ok || err != nil {
+		t.Fatalf("Expected no snapshot, got %v, %v", ok, err)
+	}
+
+	if err := state.SetSnapshot("data_model", model{Tables: []string{"orders"}}); err != nil {
+		t.Fatalf("SetSnapshot failed: %v", err)
+	}
+	if err := sm.Save(state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+
+	loaded, err := sm.Load()
+	if err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	ok, err := loaded.GetSnapshot("data_model", &got)
+	if !ok || err != nil {
+		t.Fatalf("Expected snapshot, got %v, %v", ok, err)
+	}
+	if len(got.Tables) != 1 || got.Tables[0] != "orders" {
+		t.Errorf("Unexpected snapshot %+v", got)
+	}
+}
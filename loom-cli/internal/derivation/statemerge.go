@@ -0,0 +1,459 @@
+package derivation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// =============================================================================
+// Canonical Form
+// =============================================================================
+
+// Canonicalize orders the slices of the state so that equal states always
+// serialize to the same bytes, and states changed on two branches differ
+// only in the lines that changed
+func (s *DerivationState) Canonicalize() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.Artifacts {
+		sort.Strings(a.Downstream)
+		sort.Strings(a.Decisions)
+	}
+	for _, d := range s.Decisions {
+		sort.Strings(d.Affects)
+	}
+	if g := s.DependencyGraph; g != nil {
+		g.mu.Lock()
+		sort.SliceStable(g.Edges, func(i, j int) bool {
+			if g.Edges[i].From != g.Edges[j].From {
+				return g.Edges[i].From < g.Edges[j].From
+			}
+			return g.Edges[i].To < g.Edges[j].To
+		})
+		g.mu.Unlock()
+	}
+}
+
+// =============================================================================
+// Three-Way State Merge
+// =============================================================================
+
+// Kinds of state records
+const (
+	RecordArtifact      = "artifact"
+	RecordDecision      = "decision"
+	RecordFileHash      = "file_hash"
+	RecordSnapshot      = "snapshot"
+	RecordTrackerTicket = "tracker_ticket"
+	RecordTestResult    = "test_result"
+)
+
+// StateConflict is a record both sides changed differently
+type StateConflict struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+
+	// Resolution describes how the conflict was resolved; empty if it was
+	// not and ours was kept
+	Resolution string `json:"resolution,omitempty"`
+}
+
+// StateMergeResult is the outcome of MergeStates
+type StateMergeResult struct {
+	State     *DerivationState `json:"-"`
+	Conflicts []StateConflict  `json:"conflicts,omitempty"`
+}
+
+// Unresolved returns the conflicts that were not resolved
+func (r *StateMergeResult) Unresolved() []StateConflict {
+	var unresolved []StateConflict
+	for _, c := range r.Conflicts {
+		if c.Resolution == "" {
+			unresolved = append(unresolved, c)
+		}
+	}
+	return unresolved
+}
+
+// ArtifactHashFunc returns the current content hash of an artifact on disk
+type ArtifactHashFunc func(a *Artifact) (string, error)
+
+// MergeStates merges the states of two branches record by record against
+// their common ancestor. A record changed on one side only takes that
+// change; edges, artifact downstream links and the artifacts a decision
+// affects are merged as sets. Artifacts changed on both sides are
+// resolved by hashing their current content with hash: the side whose
+// recorded hash matches wins, and if neither does the artifact is kept as
+// modified. Without hash such artifacts stay unresolved.
+func MergeStates(base, ours, theirs *DerivationState, hash ArtifactHashFunc) *StateMergeResult {
+	res := &StateMergeResult{}
+	merged := &DerivationState{
+		Version:     StateVersion,
+		Project:     pick3(base.Project, ours.Project, theirs.Project),
+		LoomVersion: pick3(base.LoomVersion, ours.LoomVersion, theirs.LoomVersion),
+	}
+	merged.LastFullDerive = ours.LastFullDerive
+	if theirs.LastFullDerive.After(merged.LastFullDerive) {
+		merged.LastFullDerive = theirs.LastFullDerive
+	}
+
+	merged.Artifacts = merge3(res, RecordArtifact, base.Artifacts, ours.Artifacts, theirs.Artifacts,
+		func(id string, o, t *Artifact) (*Artifact, string) {
+			b := base.Artifacts[id]
+			if a, ok := mergeWithLinks(b, o, t, artifactDownstream); ok {
+				return a, "merged the downstream links of both sides"
+			}
+			a, resolution := resolveArtifact(o, t, hash)
+			if a != nil && o != nil && t != nil {
+				// Keep both sides' links, in line with the merged edges
+				var baseLinks []string
+				if b != nil {
+					baseLinks = b.Downstream
+				}
+				copied := *a
+				copied.Downstream = mergeLinks(baseLinks, o.Downstream, t.Downstream)
+				a = &copied
+			}
+			return a, resolution
+		})
+
+	merged.Decisions = merge3(res, RecordDecision, base.Decisions, ours.Decisions, theirs.Decisions,
+		func(id string, o, t *Decision) (*Decision, string) {
+			if d, ok := mergeWithLinks(base.Decisions[id], o, t, decisionAffects); ok {
+				return d, "merged the affected artifacts of both sides"
+			}
+			return o, ""
+		})
+
+	// Cached hashes are recomputed when missing
+	merged.FileHashes = merge3(res, RecordFileHash, base.FileHashes, ours.FileHashes, theirs.FileHashes,
+		func(path string, o, t *FileHashInfo) (*FileHashInfo, string) {
+			return nil, "dropped from the hash cache"
+		})
+
+	merged.Snapshots = merge3(res, RecordSnapshot, base.Snapshots, ours.Snapshots, theirs.Snapshots, nil)
+
+	merged.TestResults = merge3(res, RecordTestResult, base.TestResults, ours.TestResults, theirs.TestResults,
+		func(id string, o, t *TestResult) (*TestResult, string) {
+			if o == nil || (t != nil && t.RunAt.After(o.RunAt)) {
+				return t, "kept the later test run"
+			}
+			return o, "kept the later test run"
+		})
+
+	tickets := merge3(res, RecordTrackerTicket,
+		flattenTickets(base.TrackerTickets), flattenTickets(ours.TrackerTickets), flattenTickets(theirs.TrackerTickets),
+		func(key string, o, t *TrackerTicket) (*TrackerTicket, string) {
+			if o == nil || (t != nil && t.SyncedAt.After(o.SyncedAt)) {
+				return t, "kept the later sync"
+			}
+			return o, "kept the later sync"
+		})
+	merged.TrackerTickets = unflattenTickets(tickets)
+
+	merged.DependencyGraph = NewDependencyGraph()
+	for _, e := range mergeEdges(base, ours, theirs) {
+		merged.DependencyGraph.AddEdge(e.From, e.To, e.Type)
+	}
+
+	merged.Canonicalize()
+	res.State = merged
+	sortConflicts(res.Conflicts)
+	return res
+}
+
+// merge3 merges one kind of record. resolve settles records changed on
+// both sides and describes how; a nil record removes it. Without resolve
+// ours is kept and the conflict stays unresolved.
+func merge3[V any](res *StateMergeResult, kind string, base, ours, theirs map[string]V, resolve func(id string, o, t V) (V, string)) map[string]V {
+	merged := make(map[string]V)
+	keys := make(map[string]bool)
+	for _, m := range []map[string]V{base, ours, theirs} {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+
+	for k := range keys {
+		b, inBase := base[k]
+		o, inOurs := ours[k]
+		t, inTheirs := theirs[k]
+
+		var v V
+		var keep bool
+		switch {
+		case sameRecord(o, inOurs, t, inTheirs):
+			v, keep = o, inOurs
+		case sameRecord(o, inOurs, b, inBase):
+			v, keep = t, inTheirs
+		case sameRecord(t, inTheirs, b, inBase):
+			v, keep = o, inOurs
+		default:
+			conflict := StateConflict{Kind: kind, ID: k}
+			v, keep = o, inOurs
+			if resolve != nil {
+				v, conflict.Resolution = resolve(k, o, t)
+				keep = !isNilRecord(v)
+			}
+			res.Conflicts = append(res.Conflicts, conflict)
+		}
+		if keep {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// sameRecord compares two optional records by their serialization
+func sameRecord[V any](a V, inA bool, b V, inB bool) bool {
+	if inA != inB {
+		return false
+	}
+	if !inA {
+		return true
+	}
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
+
+func isNilRecord(v interface{}) bool {
+	switch r := v.(type) {
+	case *Artifact:
+		return r == nil
+	case *FileHashInfo:
+		return r == nil
+	case *TestResult:
+		return r == nil
+	case *TrackerTicket:
+		return r == nil
+	case *Decision:
+		return r == nil
+	}
+	return false
+}
+
+// resolveArtifact settles an artifact changed on both sides by its content
+func resolveArtifact(o, t *Artifact, hash ArtifactHashFunc) (*Artifact, string) {
+	if hash == nil {
+		return o, ""
+	}
+	probe := o
+	if probe == nil {
+		probe = t
+	}
+	current, err := hash(probe)
+	if err != nil {
+		// The artifact is gone from its document
+		if o == nil || t == nil {
+			return nil, "removed, its content no longer exists"
+		}
+		return o, ""
+	}
+
+	switch {
+	case o != nil && o.ContentHash == current:
+		return o, "kept ours, it matches the content"
+	case t != nil && t.ContentHash == current:
+		return t, "kept theirs, it matches the content"
+	}
+
+	// The content was merged by hand: keep the later derivation, flagged
+	keep := o
+	if keep == nil || (t != nil && t.DerivedAt.After(keep.DerivedAt)) {
+		keep = t
+	}
+	copied := *keep
+	copied.Status = StatusModified
+	return &copied, "content matches neither side, marked modified"
+}
+
+// mergeWithLinks settles a record both sides changed when, apart from the
+// link list field returns (e.g. Decision.Affects), only one side changed
+// it. The links are merged with mergeLinks.
+func mergeWithLinks[R any](b, o, t *R, field func(*R) *[]string) (*R, bool) {
+	if o == nil || t == nil {
+		return nil, false
+	}
+	links := func(r *R) []string {
+		if r == nil {
+			return nil
+		}
+		return *field(r)
+	}
+	unlinked := func(r *R) *R {
+		if r == nil {
+			return nil
+		}
+		copied := *r
+		*field(&copied) = nil
+		return &copied
+	}
+	ub, uo, ut := unlinked(b), unlinked(o), unlinked(t)
+
+	var keep *R
+	switch {
+	case sameRecord(uo, true, ut, true), sameRecord(ut, true, ub, b != nil):
+		keep = o
+	case sameRecord(uo, true, ub, b != nil):
+		keep = t
+	default:
+		return nil, false
+	}
+	merged := *keep
+	*field(&merged) = mergeLinks(links(b), links(o), links(t))
+	return &merged, true
+}
+
+func artifactDownstream(a *Artifact) *[]string { return &a.Downstream }
+
+func decisionAffects(d *Decision) *[]string { return &d.Affects }
+
+// mergeLinks merges lists of IDs as sets, like mergeEdges: an ID is kept if
+// both sides have it or one side added it, and dropped if either side
+// removed it
+func mergeLinks(base, ours, theirs []string) []string {
+	in := func(ids []string) map[string]bool {
+		m := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			m[id] = true
+		}
+		return m
+	}
+	b, o, t := in(base), in(ours), in(theirs)
+
+	var merged []string
+	for id := range o {
+		if t[id] || !b[id] {
+			merged = append(merged, id)
+		}
+	}
+	for id := range t {
+		if !o[id] && !b[id] {
+			merged = append(merged, id)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// mergeEdges merges the dependency edges as sets: an edge is kept if both
+// sides have it or one side added it, and dropped if either side removed it
+func mergeEdges(base, ours, theirs *DerivationState) []DependencyEdge {
+	set := func(s *DerivationState) map[edgeKey]DependencyEdge {
+		m := make(map[edgeKey]DependencyEdge)
+		if s.DependencyGraph != nil {
+			for _, e := range s.DependencyGraph.Edges {
+				m[edgeKey{e.From, e.To}] = e
+			}
+		}
+		return m
+	}
+	b, o, t := set(base), set(ours), set(theirs)
+
+	var edges []DependencyEdge
+	for k, e := range o {
+		if _, inTheirs := t[k]; inTheirs {
+			edges = append(edges, e)
+		} else if _, inBase := b[k]; !inBase {
+			edges = append(edges, e)
+		}
+	}
+	for k, e := range t {
+		_, inOurs := o[k]
+		_, inBase := b[k]
+		if !inOurs && !inBase {
+			edges = append(edges, e)
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// pick3 merges a scalar: a change on one side wins, ours on conflict
+func pick3(base, ours, theirs string) string {
+	if ours == base {
+		return theirs
+	}
+	return ours
+}
+
+// ticketKeySep joins tracker and ticket IDs in flattened keys
+const ticketKeySep = "\x00"
+
+func flattenTickets(trackers map[string]map[string]*TrackerTicket) map[string]*TrackerTicket {
+	flat := make(map[string]*TrackerTicket)
+	for tracker, tickets := range trackers {
+		for id, ticket := range tickets {
+			flat[tracker+ticketKeySep+id] = ticket
+		}
+	}
+	return flat
+}
+
+func unflattenTickets(flat map[string]*TrackerTicket) map[string]map[string]*TrackerTicket {
+	if len(flat) == 0 {
+		return nil
+	}
+	trackers := make(map[string]map[string]*TrackerTicket)
+	for key, ticket := range flat {
+		tracker, id, _ := strings.Cut(key, ticketKeySep)
+		if trackers[tracker] == nil {
+			trackers[tracker] = make(map[string]*TrackerTicket)
+		}
+		trackers[tracker][id] = ticket
+	}
+	return trackers
+}
+
+func sortConflicts(conflicts []StateConflict) {
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Kind != conflicts[j].Kind {
+			return conflicts[i].Kind < conflicts[j].Kind
+		}
+		return conflicts[i].ID < conflicts[j].ID
+	})
+}
+
+// MergeFiles merges the JSON state files of a git merge: base is the common
+// ancestor, ours the current branch and theirs the merged one. The result is
+// written to ours in canonical form, so it can serve as a git merge driver.
+// Artifact conflicts are checked against the content in the project.
+func (sm *StateManager) MergeFiles(basePath, oursPath, theirsPath string) (*StateMergeResult, error) {
+	var states [3]*DerivationState
+	for i, path := range []string{basePath, oursPath, theirsPath} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state file: %w", err)
+		}
+		// git passes an empty ancestor when both branches added the file
+		if len(bytes.TrimSpace(data)) == 0 {
+			states[i] = sm.NewState()
+			continue
+		}
+		if states[i], err = sm.decode(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	hasher := NewHasher()
+	res := MergeStates(states[0], states[1], states[2], func(a *Artifact) (string, error) {
+		return hasher.HashArtifact(a, sm.ProjectDir)
+	})
+
+	out := &jsonStore{path: oursPath}
+	if err := out.Save(res.State, writeFileDirect); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,170 @@
+package derivation
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// branchState copies a state through its serialization
+func branchState(t *testing.T, sm *StateManager, state *DerivationState) *DerivationState {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), StateFileName)
+	if err := (&jsonStore{path: path}).Save(state, writeFileDirect); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	copied, err := sm.decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return copied
+}
+
+func TestMergeStates_DisjointChanges(t *testing.T) {
+	sm := NewStateManager(t.TempDir())
+	base := sampleState(sm)
+
+	ours := branchState(t, sm, base)
+	ours.GetArtifact("AC-ORD-001").ContentHash = "a2"
+	ours.DependencyGraph.RemoveEdge("US-001", "AC-ORD-001")
+
+	theirs := branchState(t, sm, base)
+	theirs.SetArtifact(&Artifact{ID: "TC-ORD-002", Type: ArtifactTestCase, ContentHash: "t2"})
+	theirs.DependencyGraph.AddEdge("AC-ORD-001", "TC-ORD-002", EdgeDerives)
+	theirs.RemoveArtifact("TC-ORD-001")
+
+	res := MergeStates(base, ours, theirs, nil)
+	if len(res.Conflicts) != 0 {
+		t.Fatalf("Expected no conflicts, got %+v", res.Conflicts)
+	}
+	merged := res.State
+	if merged.GetArtifact("AC-ORD-001").ContentHash != "a2" || merged.GetArtifact("TC-ORD-002") == nil || merged.GetArtifact("TC-ORD-001") != nil {
+		t.Errorf("Expected the changes of both branches, got %+v", merged.Artifacts)
+	}
+	graph := merged.DependencyGraph
+	if graph.HasEdge("US-001", "AC-ORD-001") || !graph.HasEdge("AC-ORD-001", "TC-ORD-002") || !graph.HasEdge("AC-ORD-001", "TC-ORD-001") {
+		t.Errorf("Expected the edges merged as sets, got %+v", graph.Edges)
+	}
+}
+
+func TestMergeStates_ResolvesByContentHash(t *testing.T) {
+	sm := NewStateManager(t.TempDir())
+	base := sampleState(sm)
+	ours := branchState(t, sm, base)
+	theirs := branchState(t, sm, base)
+	ours.GetArtifact("AC-ORD-001").ContentHash = "ours"
+	theirs.GetArtifact("AC-ORD-001").ContentHash = "theirs"
+	ours.GetArtifact("TC-ORD-001").ContentHash = "ours"
+	theirs.GetArtifact("TC-ORD-001").ContentHash = "theirs"
+	theirs.GetArtifact("TC-ORD-001").DerivedAt = time.Now()
+	ours.SetDecision(&Decision{ID: "DEC-001", Answer: "Allow"})
+	theirs.SetDecision(&Decision{ID: "DEC-001", Answer: "Warn"})
+
+	current := map[string]string{"AC-ORD-001": "theirs", "TC-ORD-001": "hand-merged"}
+	res := MergeStates(base, ours, theirs, func(a *Artifact) (string, error) {
+		return current[a.ID], nil
+	})
+
+	if got := res.State.GetArtifact("AC-ORD-001"); got.ContentHash != "theirs" {
+		t.Errorf("Expected the side matching the content, got %s", got.ContentHash)
+	}
+	if got := res.State.GetArtifact("TC-ORD-001"); got.ContentHash != "theirs" || got.Status != StatusModified {
+		t.Errorf("Expected the later derivation marked modified, got %+v", got)
+	}
+	unresolved := res.Unresolved()
+	if len(res.Conflicts) != 3 || len(unresolved) != 1 || unresolved[0].Kind != RecordDecision {
+		t.Errorf("Expected only the decision unresolved, got %+v", res.Conflicts)
+	}
+	if res.State.GetDecision("DEC-001").Answer != "Allow" {
+		t.Error("Expected ours to be kept for an unresolved conflict")
+	}
+}
+
+func TestMergeStates_MergesLinksUnderSharedDecision(t *testing.T) {
+	sm := NewStateManager(t.TempDir())
+	base := sampleState(sm)
+	base.GetArtifact("AC-ORD-001").Downstream = []string{"TC-ORD-001"}
+	base.GetDecision("DEC-001").Affects = []string{"AC-ORD-001"}
+
+	// Each branch re-derives a different test case from the same decision
+	branch := func(tc string) *DerivationState {
+		s := branchState(t, sm, base)
+		s.SetArtifact(&Artifact{ID: tc, Type: ArtifactTestCase, ContentHash: tc, Upstream: map[string]string{"AC-ORD-001": "a1"}})
+		s.DependencyGraph.AddEdge("AC-ORD-001", tc, EdgeDerives)
+		ac := s.GetArtifact("AC-ORD-001")
+		ac.Downstream = append(ac.Downstream, tc)
+		d := s.GetDecision("DEC-001")
+		d.Affects = append(d.Affects, tc)
+		return s
+	}
+	ours, theirs := branch("TC-ORD-002"), branch("TC-ORD-003")
+	theirs.GetDecision("DEC-001").Category = "validation"
+
+	res := MergeStates(base, ours, theirs, nil)
+	if unresolved := res.Unresolved(); len(unresolved) != 0 {
+		t.Fatalf("Expected the shared records merged, got %+v", unresolved)
+	}
+
+	d := res.State.GetDecision("DEC-001")
+	if strings.Join(d.Affects, ",") != "AC-ORD-001,TC-ORD-002,TC-ORD-003" || d.Category != "validation" {
+		t.Errorf("Expected the affected artifacts of both branches and theirs' category, got %+v", d)
+	}
+	ac := res.State.GetArtifact("AC-ORD-001")
+	if strings.Join(ac.Downstream, ",") != "TC-ORD-001,TC-ORD-002,TC-ORD-003" {
+		t.Errorf("Expected the downstream links of both branches, got %v", ac.Downstream)
+	}
+	for _, tc := range []string{"TC-ORD-002", "TC-ORD-003"} {
+		if !res.State.DependencyGraph.HasEdge("AC-ORD-001", tc) {
+			t.Errorf("Expected edge AC-ORD-001 -> %s", tc)
+		}
+	}
+
+	// A link one branch removed stays removed
+	theirs.GetDecision("DEC-001").Affects = []string{"TC-ORD-003"}
+	d = MergeStates(base, ours, theirs, nil).State.GetDecision("DEC-001")
+	if strings.Join(d.Affects, ",") != "TC-ORD-002,TC-ORD-003" {
+		t.Errorf("Expected AC-ORD-001 removed from the affected artifacts, got %v", d.Affects)
+	}
+}
+
+func TestStateManager_MergeFiles(t *testing.T) {
+	projectDir := t.TempDir()
+	sm := NewStateManager(projectDir)
+	base := sampleState(sm)
+	ours := branchState(t, sm, base)
+	ours.SetArtifact(&Artifact{ID: "AC-ORD-002", ContentHash: "a2"})
+	theirs := branchState(t, sm, base)
+	theirs.SetArtifact(&Artifact{ID: "AC-ORD-003", ContentHash: "a3"})
+
+	dir := t.TempDir()
+	paths := make([]string, 3)
+	for i, state := range []*DerivationState{base, ours, theirs} {
+		paths[i] = filepath.Join(dir, []string{"base", "ours", "theirs"}[i])
+		(&jsonStore{path: paths[i]}).Save(state, writeFileDirect)
+	}
+
+	res, err := sm.MergeFiles(paths[0], paths[1], paths[2])
+	if err != nil {
+		t.Fatalf("MergeFiles failed: %v", err)
+	}
+	if len(res.Conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %+v", res.Conflicts)
+	}
+
+	merged, _ := os.ReadFile(paths[1])
+	state, err := sm.decode(merged)
+	if err != nil || state.GetArtifact("AC-ORD-002") == nil || state.GetArtifact("AC-ORD-003") == nil {
+		t.Fatalf("Expected both new artifacts in ours, got %v", err)
+	}
+
+	// The serialization is canonical
+	again := filepath.Join(dir, "again")
+	(&jsonStore{path: again}).Save(state, writeFileDirect)
+	if data, _ := os.ReadFile(again); !bytes.Equal(data, merged) {
+		t.Error("Expected saving the same state to produce the same bytes")
+	}
+}
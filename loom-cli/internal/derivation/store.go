@@ -0,0 +1,571 @@
+package derivation
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// =============================================================================
+// State Storage
+// =============================================================================
+
+// Storage backends
+const (
+	// BackendJSON stores the state as one JSON document
+	BackendJSON = "json"
+
+	// BackendKV stores the state as records in an append-only log, so that
+	// saves only write what changed
+	BackendKV = "kv"
+)
+
+// Backends lists the supported storage backends
+var Backends = []string{BackendJSON, BackendKV}
+
+// WriteFunc writes a file, directly or staged in a transaction
+type WriteFunc func(path string, data []byte) error
+
+// StateStore persists the derivation state of a project
+type StateStore interface {
+	// Backend names the storage backend
+	Backend() string
+
+	// Path is the file or directory holding the state
+	Path() string
+
+	// Exists reports whether a state was saved
+	Exists() bool
+
+	// Load reads the saved state; callers normalize it
+	Load() (*DerivationState, error)
+
+	// Save writes state through write
+	Save(state *DerivationState, write WriteFunc) error
+
+	// Remove deletes the saved state
+	Remove() error
+}
+
+// NewStateStore returns the store of a backend for the .loom directory
+func NewStateStore(backend, loomDir string) (StateStore, error) {
+	switch backend {
+	case BackendJSON:
+		return &jsonStore{path: filepath.Join(loomDir, StateFileName)}, nil
+	case BackendKV:
+		return &kvStore{dir: filepath.Join(loomDir, KVDirName)}, nil
+	}
+	return nil, fmt.Errorf("unknown state backend: %s (supported: %s)", backend, strings.Join(Backends, ", "))
+}
+
+// detectStore returns the store the project's state is saved in. Projects
+// without a key-value store use the JSON file.
+func detectStore(loomDir string) StateStore {
+	kv := &kvStore{dir: filepath.Join(loomDir, KVDirName)}
+	if info, err := os.Stat(kv.dir); err == nil && info.IsDir() {
+		return kv
+	}
+	return &jsonStore{path: filepath.Join(loomDir, StateFileName)}
+}
+
+// MigrateStore moves the state to another backend. The new store is
+// written completely before the old one is removed.
+func (sm *StateManager) MigrateStore(backend string) error {
+	if backend == sm.Store.Backend() {
+		return fmt.Errorf("state is already stored as %s", backend)
+	}
+	target, err := NewStateStore(backend, sm.LoomDir)
+	if err != nil {
+		return err
+	}
+
+	if err := sm.Lock(); err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer sm.Unlock()
+
+	state, err := sm.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	if err := target.Save(state, writeFileDirect); err != nil {
+		return fmt.Errorf("failed to write %s store: %w", backend, err)
+	}
+	if err := sm.Store.Remove(); err != nil {
+		return fmt.Errorf("failed to remove %s store: %w", sm.Store.Backend(), err)
+	}
+	sm.Store = target
+	return nil
+}
+
+// =============================================================================
+// JSON Backend
+// =============================================================================
+
+// jsonStore keeps the state in .loom/derivation-state.json
+type jsonStore struct {
+	path string
+}
+
+func (s *jsonStore) Backend() string { return BackendJSON }
+
+func (s *jsonStore) Path() string { return s.path }
+
+func (s *jsonStore) Exists() bool {
+	_, err := os.Stat(s.path)
+	return err == nil
+}
+
+func (s *jsonStore) Load() (*DerivationState, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	var state DerivationState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	return &state, nil
+}
+
+func (s *jsonStore) Save(state *DerivationState, write WriteFunc) error {
+	// A canonical, line-per-field file keeps diffs between branches small
+	state.Canonicalize()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	data = append(data, '\n')
+	if err := write(s.path, data); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+func (s *jsonStore) Remove() error {
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove state file: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// Key-Value Backend
+// =============================================================================
+
+// The key-value store is a directory of numbered segment files, each one
+// JSON record per line. A snapshot segment (.snap) holds every record, a
+// delta segment (.seg) the records changed or deleted since the previous
+// segment. Loading replays the latest snapshot and the deltas after it.
+// Segments are only ever added, so a save is one new file and can be staged
+// in a transaction like any document.
+
+const (
+	// KVDirName is the directory under .loom holding the key-value store
+	KVDirName = "state.kv"
+
+	// KVCompactAfter is the number of deltas after which a save writes a
+	// new snapshot instead
+	KVCompactAfter = 16
+
+	snapshotExt = ".snap"
+	deltaExt    = ".seg"
+)
+
+// Record key prefixes
+const (
+	kvMeta          = "meta"
+	kvArtifact      = "artifact/"
+	kvDecision      = "decision/"
+	kvEdge          = "edge/"
+	kvFileHash      = "filehash/"
+	kvSnapshot      = "snapshot/"
+	kvTrackerTicket = "tracker/"
+	kvTestResult    = "testresult/"
+)
+
+// kvRecord is one line of a segment
+type kvRecord struct {
+	Key     string          `json:"k"`
+	Value   json.RawMessage `json:"v,omitempty"`
+	Deleted bool            `json:"d,omitempty"`
+}
+
+// kvMetaRecord holds the scalar fields of the state
+type kvMetaRecord struct {
+	Version        string    `json:"version"`
+	Project        string    `json:"project"`
+	LastFullDerive time.Time `json:"last_full_derive,omitempty"`
+	LoomVersion    string    `json:"loom_version"`
+}
+
+// kvSegment is a segment file
+type kvSegment struct {
+	seq      int
+	snapshot bool
+	path     string
+}
+
+// kvStore keeps the state in .loom/state.kv
+type kvStore struct {
+	dir string
+
+	// known maps the keys saved so far to the hash of their value
+	known map[string][32]byte
+
+	// lastSeq is the number of the last segment written
+	lastSeq int
+}
+
+func (s *kvStore) Backend() string { return BackendKV }
+
+func (s *kvStore) Path() string { return s.dir }
+
+func (s *kvStore) Exists() bool {
+	segments, err := s.segments()
+	return err == nil && len(segments) > 0
+}
+
+func (s *kvStore) Load() (*DerivationState, error) {
+	records, err := s.replay()
+	if err != nil {
+		return nil, err
+	}
+	return stateFromRecords(records)
+}
+
+func (s *kvStore) Save(state *DerivationState, write WriteFunc) error {
+	records, err := stateRecords(state)
+	if err != nil {
+		return err
+	}
+
+	s.prune()
+	segments, err := s.segments()
+	if err != nil {
+		return err
+	}
+
+	// Deltas are relative to what is on disk, which another manager may
+	// have saved since this one loaded
+	if n := len(segments); s.known == nil || (n > 0 && segments[n-1].seq != s.lastSeq) {
+		if _, err := s.replay(); err != nil {
+			return err
+		}
+	}
+
+	// Write a snapshot when there is none yet or the deltas pile up
+	current := live(segments)
+	snapshot := len(current) == 0 || !current[0].snapshot || len(current) > KVCompactAfter
+	var lines []kvRecord
+	for _, key := range sortedKeys(records) {
+		if snapshot || s.known[key] != sha256.Sum256(records[key]) {
+			lines = append(lines, kvRecord{Key: key, Value: records[key]})
+		}
+	}
+	if !snapshot {
+		for key := range s.known {
+			if _, ok := records[key]; !ok {
+				lines = append(lines, kvRecord{Key: key, Deleted: true})
+			}
+		}
+		if len(lines) == 0 {
+			return nil // Nothing changed
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, line := range lines {
+		if err := enc.Encode(line); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", line.Key, err)
+		}
+	}
+
+	seq := s.lastSeq + 1
+	if n := len(segments); n > 0 && segments[n-1].seq >= seq {
+		seq = segments[n-1].seq + 1
+	}
+	ext := deltaExt
+	if snapshot {
+		ext = snapshotExt
+	}
+	if err := write(filepath.Join(s.dir, fmt.Sprintf("%08d%s", seq, ext)), buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write state segment: %w", err)
+	}
+
+	s.lastSeq = seq
+	s.known = hashRecords(records)
+	return nil
+}
+
+func (s *kvStore) Remove() error {
+	if err := os.RemoveAll(s.dir); err != nil {
+		return fmt.Errorf("failed to remove state store: %w", err)
+	}
+	s.known = nil
+	return nil
+}
+
+// segments lists the segment files in order. Staged and temporary files
+// are not segments.
+func (s *kvStore) segments() ([]kvSegment, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list state store: %w", err)
+	}
+
+	var segments []kvSegment
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if ext != snapshotExt && ext != deltaExt {
+			continue
+		}
+		seq, err := strconv.Atoi(strings.TrimSuffix(name, ext))
+		if err != nil {
+			continue
+		}
+		segments = append(segments, kvSegment{seq: seq, snapshot: ext == snapshotExt, path: filepath.Join(s.dir, name)})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].seq < segments[j].seq })
+	return segments, nil
+}
+
+// live returns the latest snapshot and the deltas after it
+func live(segments []kvSegment) []kvSegment {
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i].snapshot {
+			return segments[i:]
+		}
+	}
+	return segments
+}
+
+// replay reads the live segments into records
+func (s *kvStore) replay() (map[string][]byte, error) {
+	segments, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+	segments = live(segments)
+
+	records := make(map[string][]byte)
+	for _, seg := range segments {
+		if err := readSegment(seg.path, records); err != nil {
+			return nil, err
+		}
+	}
+
+	s.known = hashRecords(records)
+	if n := len(segments); n > 0 && segments[n-1].seq > s.lastSeq {
+		s.lastSeq = segments[n-1].seq
+	}
+	return records, nil
+}
+
+// prune removes segments superseded by the latest snapshot
+func (s *kvStore) prune() {
+	segments, err := s.segments()
+	if err != nil {
+		return
+	}
+	current := live(segments)
+	for _, seg := range segments[:len(segments)-len(current)] {
+		os.Remove(seg.path)
+	}
+}
+
+func readSegment(path string, records map[string][]byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read state segment: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec kvRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+		}
+		if rec.Deleted {
+			delete(records, rec.Key)
+		} else {
+			records[rec.Key] = []byte(rec.Value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// stateRecords splits a state into records
+func stateRecords(state *DerivationState) (map[string][]byte, error) {
+	records := make(map[string][]byte)
+	put := func(key string, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", key, err)
+		}
+		records[key] = data
+		return nil
+	}
+
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	if err := put(kvMeta, kvMetaRecord{
+		Version:        state.Version,
+		Project:        state.Project,
+		LastFullDerive: state.LastFullDerive,
+		LoomVersion:    state.LoomVersion,
+	}); err != nil {
+		return nil, err
+	}
+	for id, a := range state.Artifacts {
+		if err := put(kvArtifact+id, a); err != nil {
+			return nil, err
+		}
+	}
+	for id, d := range state.Decisions {
+		if err := put(kvDecision+id, d); err != nil {
+			return nil, err
+		}
+	}
+	if state.DependencyGraph != nil {
+		for _, e := range state.DependencyGraph.Edges {
+			if err := put(kvEdge+e.From+"\x00"+e.To, e); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for path, h := range state.FileHashes {
+		if err := put(kvFileHash+path, h); err != nil {
+			return nil, err
+		}
+	}
+	for name, snap := range state.Snapshots {
+		records[kvSnapshot+name] = snap
+	}
+	for tracker, tickets := range state.TrackerTickets {
+		if err := put(kvTrackerTicket+tracker, tickets); err != nil {
+			return nil, err
+		}
+	}
+	for id, r := range state.TestResults {
+		if err := put(kvTestResult+id, r); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// stateFromRecords assembles a state from its records
+func stateFromRecords(records map[string][]byte) (*DerivationState, error) {
+	state := &DerivationState{
+		Artifacts: make(map[string]*Artifact),
+		Decisions: make(map[string]*Decision),
+	}
+	var edges []DependencyEdge
+
+	for _, key := range sortedKeys(records) {
+		data := records[key]
+		var err error
+		switch {
+		case key == kvMeta:
+			var meta kvMetaRecord
+			if err = json.Unmarshal(data, &meta); err == nil {
+				state.Version = meta.Version
+				state.Project = meta.Project
+				state.LastFullDerive = meta.LastFullDerive
+				state.LoomVersion = meta.LoomVersion
+			}
+		case strings.HasPrefix(key, kvArtifact):
+			var a Artifact
+			if err = json.Unmarshal(data, &a); err == nil {
+				state.Artifacts[strings.TrimPrefix(key, kvArtifact)] = &a
+			}
+		case strings.HasPrefix(key, kvDecision):
+			var d Decision
+			if err = json.Unmarshal(data, &d); err == nil {
+				state.Decisions[strings.TrimPrefix(key, kvDecision)] = &d
+			}
+		case strings.HasPrefix(key, kvEdge):
+			var e DependencyEdge
+			if err = json.Unmarshal(data, &e); err == nil {
+				edges = append(edges, e)
+			}
+		case strings.HasPrefix(key, kvFileHash):
+			var h FileHashInfo
+			if err = json.Unmarshal(data, &h); err == nil {
+				if state.FileHashes == nil {
+					state.FileHashes = make(map[string]*FileHashInfo)
+				}
+				state.FileHashes[strings.TrimPrefix(key, kvFileHash)] = &h
+			}
+		case strings.HasPrefix(key, kvSnapshot):
+			if state.Snapshots == nil {
+				state.Snapshots = make(map[string]json.RawMessage)
+			}
+			state.Snapshots[strings.TrimPrefix(key, kvSnapshot)] = json.RawMessage(data)
+		case strings.HasPrefix(key, kvTrackerTicket):
+			var tickets map[string]*TrackerTicket
+			if err = json.Unmarshal(data, &tickets); err == nil {
+				if state.TrackerTickets == nil {
+					state.TrackerTickets = make(map[string]map[string]*TrackerTicket)
+				}
+				state.TrackerTickets[strings.TrimPrefix(key, kvTrackerTicket)] = tickets
+			}
+		case strings.HasPrefix(key, kvTestResult):
+			var r TestResult
+			if err = json.Unmarshal(data, &r); err == nil {
+				if state.TestResults == nil {
+					state.TestResults = make(map[string]*TestResult)
+				}
+				state.TestResults[strings.TrimPrefix(key, kvTestResult)] = &r
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse state record %s: %w", key, err)
+		}
+	}
+
+	if edges != nil {
+		state.DependencyGraph = &DependencyGraph{Edges: edges}
+	}
+	return state, nil
+}
+
+func hashRecords(records map[string][]byte) map[string][32]byte {
+	hashes := make(map[string][32]byte, len(records))
+	for key, data := range records {
+		hashes[key] = sha256.Sum256(data)
+	}
+	return hashes
+}
+
+func sortedKeys(records map[string][]byte) []string {
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,178 @@
+package derivation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newKVManager returns a state manager using the key-value backend
+func newKVManager(t *testing.T, projectDir string) *StateManager {
+	t.Helper()
+	sm := NewStateManager(projectDir)
+	store, err := NewStateStore(BackendKV, sm.LoomDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm.Store = store
+	return sm
+}
+
+func sampleState(sm *StateManager) *DerivationState {
+	state := sm.NewState()
+	state.SetArtifact(&Artifact{ID: "AC-ORD-001", Type: ArtifactAcceptanceCrit, ContentHash: "a1", Upstream: map[string]string{"US-001": "u1"}})
+	state.SetArtifact(&Artifact{ID: "TC-ORD-001", Type: ArtifactTestCase, ContentHash: "t1", Upstream: map[string]string{"AC-ORD-001": "a1"}})
+	state.SetDecision(&Decision{ID: "DEC-001", Question: "Empty carts?", Answer: "Reject"})
+	state.DependencyGraph.AddEdge("US-001", "AC-ORD-001", EdgeDerives)
+	state.DependencyGraph.AddEdge("AC-ORD-001", "TC-ORD-001", EdgeDerives)
+	state.TestResults = map[string]*TestResult{"TC-ORD-001": {Status: TestPass}}
+	return state
+}
+
+func segmentNames(t *testing.T, sm *StateManager) []string {
+	t.Helper()
+	entries, err := os.ReadDir(sm.Store.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestKVStore_RoundTrip(t *testing.T) {
+	projectDir := t.TempDir()
+	sm := newKVManager(t, projectDir)
+	if err := sm.Save(sampleState(sm)); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A fresh manager detects the backend
+	loaded := NewStateManager(projectDir)
+	if loaded.Store.Backend() != BackendKV {
+		t.Fatalf("Expected the kv backend to be detected, got %s", loaded.Store.Backend())
+	}
+	state, err := loaded.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(state.Artifacts) != 2 || state.GetDecision("DEC-001").Answer != "Reject" {
+		t.Errorf("Unexpected state %+v", state)
+	}
+	if !state.DependencyGraph.HasEdge("AC-ORD-001", "TC-ORD-001") || len(state.DependencyGraph.GetDownstream("US-001")) != 1 {
+		t.Error("Expected the dependency graph to be restored")
+	}
+	if state.GetTestResults()["TC-ORD-001"].Status != TestPass {
+		t.Error("Expected the test results to be restored")
+	}
+}
+
+func TestKVStore_IncrementalSave(t *testing.T) {
+	projectDir := t.TempDir()
+	sm := newKVManager(t, projectDir)
+	state := sampleState(sm)
+	sm.Save(state)
+
+	// Saving an unchanged state writes nothing
+	sm.Save(state)
+	if names := segmentNames(t, sm); len(names) != 1 || !strings.HasSuffix(names[0], snapshotExt) {
+		t.Fatalf("Expected a single snapshot, got %v", names)
+	}
+
+	state.GetArtifact("TC-ORD-001").ContentHash = "t2"
+	state.RemoveArtifact("AC-ORD-001")
+	if err := sm.Save(state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	delta, err := os.ReadFile(filepath.Join(sm.Store.Path(), "00000002"+deltaExt))
+	if err != nil {
+		t.Fatalf("Expected a delta segment: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(delta)), "\n")
+	if len(lines) != 2 || !strings.Contains(string(delta), `"k":"artifact/TC-ORD-001"`) || !strings.Contains(string(delta), `"d":true`) {
+		t.Errorf("Expected only the change and the deletion in the delta\n%s", delta)
+	}
+
+	loaded, err := NewStateManager(projectDir).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.GetArtifact("AC-ORD-001") != nil || loaded.GetArtifact("TC-ORD-001").ContentHash != "t2" {
+		t.Error("Expected the delta to be replayed")
+	}
+}
+
+func TestKVStore_Compaction(t *testing.T) {
+	projectDir := t.TempDir()
+	sm := newKVManager(t, projectDir)
+	state := sampleState(sm)
+	sm.Save(state)
+
+	for i := 0; i <= KVCompactAfter+1; i++ {
+		state.GetArtifact("TC-ORD-001").ContentHash = string(rune('a' + i))
+		if err := sm.Save(state); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := segmentNames(t, sm)
+	if len(names) > KVCompactAfter+1 || !strings.HasSuffix(names[0], snapshotExt) {
+		t.Errorf("Expected old segments to be compacted, got %v", names)
+	}
+	loaded, _ := NewStateManager(projectDir).Load()
+	if loaded.GetArtifact("TC-ORD-001").ContentHash != state.GetArtifact("TC-ORD-001").ContentHash {
+		t.Error("Expected the latest change after compaction")
+	}
+}
+
+func TestStateManager_MigrateStore(t *testing.T) {
+	projectDir := t.TempDir()
+	sm := NewStateManager(projectDir)
+	sm.Save(sampleState(sm))
+
+	if err := sm.MigrateStore(BackendKV); err != nil {
+		t.Fatalf("MigrateStore failed: %v", err)
+	}
+	if _, err := os.Stat(sm.StatePath); !os.IsNotExist(err) {
+		t.Error("Expected the JSON state to be removed")
+	}
+	state, err := NewStateManager(projectDir).Load()
+	if err != nil || len(state.Artifacts) != 2 || state.DependencyGraph.EdgeCount() != 2 {
+		t.Fatalf("Expected the state in the kv store, got %+v, %v", state, err)
+	}
+
+	if err := sm.MigrateStore(BackendKV); err == nil {
+		t.Error("Expected an error migrating to the current backend")
+	}
+	if err := sm.MigrateStore(BackendJSON); err != nil {
+		t.Fatalf("MigrateStore back failed: %v", err)
+	}
+	if NewStateManager(projectDir).Store.Backend() != BackendJSON {
+		t.Error("Expected the JSON backend after migrating back")
+	}
+}
+
+func TestKVStore_Transaction(t *testing.T) {
+	projectDir := t.TempDir()
+	acPath := writeRecordFile(t, projectDir, "l1/acceptance-criteria.md", recordL1Doc)
+	sm := newKVManager(t, projectDir)
+	sm.Save(sm.NewState())
+
+	recordRun(t, projectDir, "l1", acPath)
+
+	state, err := NewStateManager(projectDir).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.GetArtifact("BR-ORD-001") == nil {
+		t.Error("Expected the recorded artifacts in the kv store")
+	}
+	for _, name := range segmentNames(t, sm) {
+		if strings.HasSuffix(name, stagedSuffix) {
+			t.Errorf("Expected no staged segments to be left, got %s", name)
+		}
+	}
+}
@@ -49,6 +49,13 @@ func (t *Tracker) DetectStaleArtifacts() ([]*Artifact, error) {
 			continue
 		}
 
+		// Artifacts marked stale, e.g. after an upstream was retired, are
+		// stale regardless of hashes
+		if artifact.Status == StatusStale {
+			stale = append(stale, artifact)
+			continue
+		}
+
 		if artifact.Status == StatusCurrent || artifact.Status == StatusModified {
 			isStale, err := t.isArtifactStale(artifact)
 			if err != nil {
@@ -105,6 +112,9 @@ func (t *Tracker) UpdateStatuses() error {
 	// Phase 1: Mark directly stale artifacts
 	staleIDs := make(map[string]bool)
 	for _, artifact := range t.State.Artifacts {
+		if artifact.Status == StatusDeprecated {
+			continue
+		}
 		isStale, err := t.isArtifactStale(artifact)
 		if err != nil {
 			return err
@@ -90,6 +90,10 @@ const (
 
 	// StatusOrphaned means the artifact's sources have been deleted
 	StatusOrphaned ArtifactStatus = "orphaned"
+
+	// StatusDeprecated means the artifact was retired on purpose; it is
+	// kept for traceability but no longer derived
+	StatusDeprecated ArtifactStatus = "deprecated"
 )
 
 // IsActionRequired returns true if the status requires user action
@@ -163,6 +167,9 @@ type Artifact struct {
 
 	// Status is the current status of this artifact
 	Status ArtifactStatus `json:"status"`
+
+	// ReplacedBy lists the artifacts that supersede a deprecated artifact
+	ReplacedBy []string `json:"replaced_by,omitempty"`
 }
 
 // IsStale returns true if any upstream artifact has changed since derivation
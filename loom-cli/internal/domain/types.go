@@ -63,6 +63,20 @@ type Ambiguity struct {
 	Options         []string        `json:"options,omitempty"`
 	ChecklistItem   string          `json:"checklist_item"`
 	DependsOn       []SkipCondition `json:"depends_on,omitempty"` // Skip conditions
+	PriorDecisions  []PriorDecision `json:"prior_decisions,omitempty"` // Similar decisions from other projects
+	ParentID        string          `json:"parent_id,omitempty"`       // Question whose answer raised this follow-up
+	Depth           int             `json:"depth,omitempty"`           // Follow-up depth (0 = from analysis)
+	Impact          int             `json:"impact,omitempty"`          // Entities/operations the answer affects
+}
+
+// PriorDecision is a similar question already decided in another project
+type PriorDecision struct {
+	Project    string  `json:"project"`
+	QuestionID string  `json:"question_id"`
+	Question   string  `json:"question"`
+	Answer     string  `json:"answer"`
+	Similarity float64 `json:"similarity"` // 0..1 lexical similarity
+	Summary    string  `json:"summary"`    // e.g. previously decided as "X" in project Y
 }
 
 // InterviewState holds the state of an ongoing interview
@@ -75,6 +89,16 @@ type InterviewState struct {
 	Skipped         []string     `json:"skipped"`          // skipped question IDs
 	InputContent    string       `json:"input_content"`    // original L0 content
 	Complete        bool         `json:"complete"`         // interview done?
+	Adaptive        *AdaptiveSettings `json:"adaptive,omitempty"` // follow-up generation (nil = off)
+	Budget          int          `json:"budget,omitempty"`         // max questions to ask; the rest are defaulted
+	CriticalOnly    bool         `json:"critical_only,omitempty"`  // only ask critical questions
+}
+
+// AdaptiveSettings controls model-proposed follow-up questions
+type AdaptiveSettings struct {
+	MaxDepth      int `json:"max_depth"`      // follow-ups of follow-ups, up to this depth
+	MaxFollowUps  int `json:"max_follow_ups"` // total follow-ups per interview
+	FollowUpCount int `json:"follow_up_count"` // follow-ups added so far
 }
 
 // QuestionGroup represents a group of related questions
@@ -94,6 +118,7 @@ type InterviewOutput struct {
 	RemainingCount  int            `json:"remaining_count"`
 	SkippedCount    int            `json:"skipped_count"`
 	Message         string         `json:"message,omitempty"`
+	Defaulted       []Decision     `json:"defaulted,omitempty"`       // Questions answered with defaults (on completion)
 }
 
 // Decision represents a resolved ambiguity
@@ -105,6 +130,7 @@ type Decision struct {
 	Source     string    `json:"source"` // "user", "default", "existing", "user_accepted_suggested"
 	Category   string    `json:"category"`
 	Subject    string    `json:"subject"`
+	ParentID   string    `json:"parent_id,omitempty"` // set for follow-up questions
 }
 
 // AcceptanceCriteria represents a derived AC
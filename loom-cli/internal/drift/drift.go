@@ -0,0 +1,336 @@
+package drift
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ikadar/loom-cli/internal/codegen"
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// Kinds of compared items
+const (
+	KindType   = codegen.PromiseType
+	KindMethod = codegen.PromiseMethod
+	KindError  = codegen.PromiseError
+	KindRoute  = "route"
+	KindTest   = "test"
+)
+
+// renameThreshold is the minimum name similarity of a rename
+const renameThreshold = 0.6
+
+// Spec is what the code is compared against
+type Spec struct {
+	Aggregates []formatter.AggregateDesign
+	Contracts  []formatter.InterfaceContract
+	TestCases  []formatter.TestCase
+	ACIDs      []string // all acceptance criteria; derived from the other artifacts when empty
+}
+
+// Item is a spec element missing from the code, or a code element the spec
+// does not know
+type Item struct {
+	Kind     string    `json:"kind"`
+	Name     string    `json:"name"`
+	SpecID   string    `json:"spec_id,omitempty"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// Rename pairs a missing spec element with the code element it most likely
+// became
+type Rename struct {
+	Kind     string   `json:"kind"`
+	SpecName string   `json:"spec_name"`
+	CodeName string   `json:"code_name"`
+	SpecID   string   `json:"spec_id,omitempty"`
+	Location Location `json:"location"`
+}
+
+// Report is the result of a drift check
+type Report struct {
+	Checked     int      `json:"checked"`
+	Missing     []Item   `json:"missing"`
+	Extra       []Item   `json:"extra"`
+	Renamed     []Rename `json:"renamed"`
+	UntestedACs []string `json:"untested_acs"`
+}
+
+// HasDrift reports whether code and spec diverged
+func (r *Report) HasDrift() bool {
+	return len(r.Missing) > 0 || len(r.Extra) > 0 || len(r.Renamed) > 0 || len(r.UntestedACs) > 0
+}
+
+// conventionalMethods are implemented for Go interfaces, not for the spec
+var conventionalMethods = map[string]bool{
+	"Error": true, "Is": true, "As": true, "Unwrap": true, "String": true, "GoString": true,
+	"MarshalJSON": true, "UnmarshalJSON": true, "MarshalText": true, "UnmarshalText": true,
+	"Scan": true, "Value": true,
+}
+
+// Detect compares the indexed code with the spec
+func Detect(spec Spec, code *CodeIndex) *Report {
+	r := &Report{Missing: []Item{}, Extra: []Item{}, Renamed: []Rename{}, UntestedACs: []string{}}
+
+	promises := codegen.GoPromises(spec.Aggregates, spec.Contracts)
+	promised := make(map[string]bool, len(promises))
+	owners := make(map[string]bool)
+	for _, p := range promises {
+		promised[p.Kind+":"+p.Name] = true
+		if p.Kind == codegen.PromiseType {
+			owners[p.Name] = true
+		}
+	}
+
+	// Identifiers
+	var missing []Item
+	for _, p := range promises {
+		r.Checked++
+		var found bool
+		switch p.Kind {
+		case KindType:
+			_, found = code.Types[p.Name]
+		case KindMethod:
+			_, found = code.Methods[p.Name]
+		case KindError:
+			_, found = code.Vars[p.Name]
+			if !found {
+				_, found = code.Strings[p.SpecID] // the code as a literal
+			}
+		}
+		if !found {
+			missing = append(missing, Item{Kind: p.Kind, Name: p.Name, SpecID: p.SpecID})
+		}
+	}
+
+	// Rename candidates and extras
+	var typeCandidates, extraMethods, extraErrors []Item
+	for name, loc := range code.Types {
+		if !promised[KindType+":"+name] && isExported(name) {
+			typeCandidates = append(typeCandidates, Item{Kind: KindType, Name: name, Location: locPtr(loc)})
+		}
+	}
+	for name, loc := range code.Methods {
+		owner, method, _ := strings.Cut(name, ".")
+		if !owners[owner] || promised[KindMethod+":"+name] || !isExported(method) || conventionalMethods[method] {
+			continue
+		}
+		extraMethods = append(extraMethods, Item{Kind: KindMethod, Name: name, Location: locPtr(loc)})
+	}
+	for name, loc := range code.Vars {
+		if strings.HasPrefix(name, "Err") && len(name) > 3 && !promised[KindError+":"+name] {
+			extraErrors = append(extraErrors, Item{Kind: KindError, Name: name, Location: locPtr(loc)})
+		}
+	}
+	sortItems(typeCandidates)
+	sortItems(extraMethods)
+	sortItems(extraErrors)
+
+	for _, m := range missing {
+		var pool *[]Item
+		switch m.Kind {
+		case KindType:
+			pool = &typeCandidates
+		case KindMethod:
+			pool = &extraMethods
+		case KindError:
+			pool = &extraErrors
+		}
+		if rn, ok := takeRename(m, pool); ok {
+			r.Renamed = append(r.Renamed, rn)
+			continue
+		}
+		r.Missing = append(r.Missing, m)
+	}
+	r.Extra = append(r.Extra, extraMethods...)
+	r.Extra = append(r.Extra, extraErrors...)
+
+	// Routes
+	claimed := make([]bool, len(code.Routes))
+	var missingRoutes []Item
+	for _, ic := range spec.Contracts {
+		for _, op := range ic.Operations {
+			method := strings.ToUpper(strings.TrimSpace(op.Method))
+			path := NormalizePath(strings.TrimRight(ic.BaseURL, "/") + "/" + strings.TrimLeft(op.Path, "/"))
+			r.Checked++
+			found := false
+			for i, cr := range code.Routes {
+				if (cr.Method == "" || method == "" || cr.Method == method) && pathMatches(path, cr.Path) {
+					claimed[i] = true
+					found = true
+				}
+			}
+			if !found {
+				missingRoutes = append(missingRoutes, Item{Kind: KindRoute, Name: strings.TrimSpace(method + " " + path), SpecID: op.ID})
+			}
+		}
+	}
+	var extraRoutes []Item
+	for i, cr := range code.Routes {
+		if !claimed[i] {
+			extraRoutes = append(extraRoutes, Item{Kind: KindRoute, Name: strings.TrimSpace(cr.Method + " " + cr.Path), Location: locPtr(cr.Location)})
+		}
+	}
+	for _, m := range missingRoutes {
+		if rn, ok := takeRename(m, &extraRoutes); ok {
+			r.Renamed = append(r.Renamed, rn)
+			continue
+		}
+		r.Missing = append(r.Missing, m)
+	}
+	r.Extra = append(r.Extra, extraRoutes...)
+
+	// Tests
+	knownTCs := make(map[string]bool, len(spec.TestCases))
+	coveredACs := make(map[string]bool)
+	for _, tc := range spec.TestCases {
+		knownTCs[tc.ID] = true
+		r.Checked++
+		if _, ok := code.TestIDs[tc.ID]; ok {
+			coveredACs[codegen.ACForTestCase(tc)] = true
+		} else {
+			r.Missing = append(r.Missing, Item{Kind: KindTest, Name: tc.ID, SpecID: codegen.ACForTestCase(tc)})
+		}
+	}
+	var testIDs []string
+	for id := range code.TestIDs {
+		testIDs = append(testIDs, id)
+	}
+	sort.Strings(testIDs)
+	for _, id := range testIDs {
+		if strings.HasPrefix(id, "TC-") && !knownTCs[id] {
+			r.Extra = append(r.Extra, Item{Kind: KindTest, Name: id, Location: locPtr(code.TestIDs[id])})
+		}
+		coveredACs[id] = true // ACs referenced by tests directly
+	}
+
+	// Acceptance criteria without tests
+	acIDs := spec.ACIDs
+	if len(acIDs) == 0 {
+		seen := make(map[string]bool)
+		for _, tc := range spec.TestCases {
+			if ac := codegen.ACForTestCase(tc); ac != codegen.UnmappedGroup && !seen[ac] {
+				seen[ac] = true
+				acIDs = append(acIDs, ac)
+			}
+		}
+		for _, ic := range spec.Contracts {
+			for _, op := range ic.Operations {
+				for _, ac := range op.RelatedACs {
+					if !seen[ac] {
+						seen[ac] = true
+						acIDs = append(acIDs, ac)
+					}
+				}
+			}
+		}
+		sort.Strings(acIDs)
+	}
+	for _, ac := range acIDs {
+		if !coveredACs[ac] {
+			r.UntestedACs = append(r.UntestedACs, ac)
+		}
+	}
+
+	return r
+}
+
+// takeRename removes and returns the candidate most similar to the missing
+// item, if any is similar enough. Methods only rename within their type.
+func takeRename(m Item, pool *[]Item) (Rename, bool) {
+	best, bestScore := -1, 0.0
+	for i, c := range *pool {
+		a, b := m.Name, c.Name
+		if m.Kind == KindMethod {
+			ownerA, methodA, _ := strings.Cut(a, ".")
+			ownerB, methodB, _ := strings.Cut(b, ".")
+			if ownerA != ownerB {
+				continue
+			}
+			a, b = methodA, methodB
+		}
+		if m.Kind == KindRoute {
+			ma, pa, _ := strings.Cut(a, " ")
+			mb, pb, _ := strings.Cut(b, " ")
+			if ma != mb || strings.Count(pa, "/") != strings.Count(pb, "/") {
+				continue
+			}
+		}
+		if s := similarity(a, b); s >= renameThreshold && s > bestScore {
+			best, bestScore = i, s
+		}
+	}
+	if best < 0 {
+		return Rename{}, false
+	}
+	c := (*pool)[best]
+	*pool = append((*pool)[:best], (*pool)[best+1:]...)
+	return Rename{Kind: m.Kind, SpecName: m.Name, CodeName: c.Name, SpecID: m.SpecID, Location: *c.Location}, true
+}
+
+// pathMatches reports whether a code route serves the spec path. Code
+// routes whose mount prefix the scan could not resolve are registered
+// relative to it, so a code path matching the trailing segments of the
+// spec path counts as long as it has a literal segment: a lone /{} would
+// match every route ending in a parameter.
+func pathMatches(specPath, codePath string) bool {
+	if specPath == codePath {
+		return true
+	}
+	specSegs := strings.Split(strings.Trim(specPath, "/"), "/")
+	codeSegs := strings.Split(strings.Trim(codePath, "/"), "/")
+	if codePath == "/" || len(codeSegs) > len(specSegs) {
+		return false
+	}
+
+	literal := false
+	for i, seg := range codeSegs {
+		if seg != specSegs[len(specSegs)-len(codeSegs)+i] {
+			return false
+		}
+		literal = literal || seg != "{}"
+	}
+	return literal
+}
+
+// similarity returns 1 - normalized edit distance of the lower-cased names
+func similarity(a, b string) float64 {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+func isExported(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+func locPtr(l Location) *Location {
+	return &l
+}
+
+func sortItems(items []Item) {
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+}
@@ -0,0 +1,265 @@
+package drift
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/codegen"
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+func testSpec() Spec {
+	return Spec{
+		Aggregates: []formatter.AggregateDesign{{
+			ID:        "AGG-ORD-001",
+			Name:      "Order",
+			Root:      formatter.AggRoot{Entity: "Order", Identity: "orderId: OrderId"},
+			Behaviors: []formatter.AggBehavior{{Name: "cancel"}, {Name: "addLine"}},
+			Repository: formatter.AggRepository{Methods: []formatter.RepoMethod{
+				{Name: "findById", Params: "orderId: OrderId", Returns: "Order"},
+				{Name: "save", Params: "Order", Returns: "void"},
+			}},
+		}},
+		Contracts: []formatter.InterfaceContract{{
+			ID:          "IC-ORD-001",
+			ServiceName: "OrderService",
+			BaseURL:     "/api/v1",
+			Operations: []formatter.ContractOperation{
+				{ID: "OP-1", Name: "placeOrder", Method: "POST", Path: "/orders", Errors: []formatter.ContractError{{Code: "EMPTY_ORDER", HTTPStatus: 400}}, RelatedACs: []string{"AC-ORD-002"}},
+				{ID: "OP-2", Name: "getOrder", Method: "GET", Path: "/orders/:orderId"},
+			},
+		}},
+		TestCases: []formatter.TestCase{
+			{ID: "TC-AC-ORD-001-P01", ACRef: "AC-ORD-001"},
+			{ID: "TC-AC-ORD-001-N01", ACRef: "AC-ORD-001"},
+		},
+	}
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const orderCode = `package order
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+type Order struct{ ID string }
+
+func (o *Order) Cancel() error        { return nil }
+func (o *Order) AppendLine() error    { return nil }
+func (o *Order) Archive() error       { return nil }
+func (o *Order) String() string       { return o.ID }
+
+type OrderRepository interface {
+	FindByID(ctx context.Context, orderID string) (*Order, error)
+	Save(ctx context.Context, order *Order) error
+}
+
+type OrderService interface {
+	PlaceOrder(ctx context.Context) error
+	GetOrder(ctx context.Context) error
+}
+
+var ErrEmptyOrder = errors.New("EMPTY_ORDER")
+var ErrOrderLocked = errors.New("ORDER_LOCKED")
+
+func Routes(mux *http.ServeMux, h http.HandlerFunc) {
+	mux.HandleFunc("POST /api/v1/orders", h)
+	mux.HandleFunc("GET /api/v1/orders/{id}", h)
+	mux.HandleFunc("DELETE /api/v1/orders/{id}", h)
+}
+`
+
+const orderTest = `package order
+
+import "testing"
+
+// Covers AC-ORD-003 directly
+func TestTC_AC_ORD_001_P01(t *testing.T) {
+	t.Run("TC-AC-ORD-009-P01", func(t *testing.T) {})
+}
+`
+
+func TestDetect(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"order/order.go":      orderCode,
+		"order/order_test.go": orderTest,
+		"vendor/x/x.go":       "package x\n\ntype Order struct{}\n",
+	})
+
+	code, err := ScanGoCode(dir)
+	if err != nil {
+		t.Fatalf("ScanGoCode failed: %v", err)
+	}
+	r := Detect(testSpec(), code)
+
+	has := func(items []Item, kind, name string) bool {
+		for _, it := range items {
+			if it.Kind == kind && it.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(r.Renamed) != 1 || r.Renamed[0].SpecName != "Order.AddLine" || r.Renamed[0].CodeName != "Order.AppendLine" {
+		t.Errorf("Expected AddLine renamed to AppendLine, got %+v", r.Renamed)
+	}
+	if !has(r.Missing, KindTest, "TC-AC-ORD-001-N01") || has(r.Missing, KindTest, "TC-AC-ORD-001-P01") {
+		t.Errorf("Unexpected missing tests: %+v", r.Missing)
+	}
+	for _, it := range r.Missing {
+		if it.Kind != KindTest {
+			t.Errorf("Unexpected missing item %+v", it)
+		}
+	}
+
+	for _, want := range []Item{
+		{Kind: KindMethod, Name: "Order.Archive"},
+		{Kind: KindError, Name: "ErrOrderLocked"},
+		{Kind: KindRoute, Name: "DELETE /api/v1/orders/{}"},
+		{Kind: KindTest, Name: "TC-AC-ORD-009-P01"},
+	} {
+		if !has(r.Extra, want.Kind, want.Name) {
+			t.Errorf("Expected extra %s %s, got %+v", want.Kind, want.Name, r.Extra)
+		}
+	}
+	if has(r.Extra, KindMethod, "Order.String") {
+		t.Errorf("Conventional methods must not be reported")
+	}
+
+	// AC-ORD-001 is covered through its TC, AC-ORD-002 only appears in the contract
+	if strings.Join(r.UntestedACs, ",") != "AC-ORD-002" {
+		t.Errorf("Unexpected untested ACs: %v", r.UntestedACs)
+	}
+	if !r.HasDrift() {
+		t.Errorf("Expected drift")
+	}
+}
+
+func TestDetect_GeneratedSkeletonsHaveNoDrift(t *testing.T) {
+	s := testSpec()
+	s.TestCases = nil
+	s.Contracts[0].Operations[0].RelatedACs = nil
+
+	skeletons, err := codegen.GenerateGoSkeletons(s.Aggregates, s.Contracts, nil, "l2-output.json")
+	if err != nil {
+		t.Fatalf("GenerateGoSkeletons failed: %v", err)
+	}
+	files := map[string]string{
+		"api/routes.go": "package api\n\nimport \"net/http\"\n\nfunc Routes(r *http.ServeMux, h http.HandlerFunc) {\n\tr.HandleFunc(\"POST /orders\", h)\n\tr.HandleFunc(\"GET /orders/{orderId}\", h)\n}\n",
+	}
+	for _, sk := range skeletons {
+		files[sk.Generated.Path] = string(sk.Generated.Content)
+		if len(sk.Stubs.Stubs) > 0 {
+			content, _ := codegen.MergeStubs(nil, nil, sk.Stubs)
+			files[sk.Stubs.Path] = string(content)
+		}
+	}
+
+	code, err := ScanGoCode(writeFiles(t, files))
+	if err != nil {
+		t.Fatalf("ScanGoCode failed: %v", err)
+	}
+	if r := Detect(s, code); r.HasDrift() {
+		t.Errorf("Expected no drift for generated code, got %+v", r)
+	}
+}
+
+func TestDetect_MountedRoutes(t *testing.T) {
+	s := Spec{Contracts: []formatter.InterfaceContract{{
+		ID:      "IC-SHOP-001",
+		BaseURL: "/api/v1",
+		Operations: []formatter.ContractOperation{
+			{ID: "OP-1", Method: "GET", Path: "/orders/{orderId}"},
+			{ID: "OP-2", Method: "GET", Path: "/customers/{customerId}"},
+			{ID: "OP-3", Method: "GET", Path: "/products/{productId}"},
+		},
+	}}}
+	dir := writeFiles(t, map[string]string{
+		"api/routes.go": `package api
+
+func Routes(r chi.Router, h http.HandlerFunc) {
+	r.Route("/api/v1/orders", func(r chi.Router) {
+		r.Get("/{id}", h)
+	})
+	r.Mount("/api/v1/customers", customerRoutes(h))
+}
+`,
+		"api/customers.go": `package api
+
+func customerRoutes(h http.HandlerFunc) http.Handler {
+	r := chi.NewRouter()
+	r.Get("/{id}", h)
+	return r
+}
+`,
+		// Mounted somewhere the scan cannot see
+		"api/products.go": `package api
+
+func productRoutes(r chi.Router, h http.HandlerFunc) {
+	r.Get("/{id}", h)
+}
+`,
+	})
+
+	code, err := ScanGoCode(dir)
+	if err != nil {
+		t.Fatalf("ScanGoCode failed: %v", err)
+	}
+	var paths []string
+	for _, r := range code.Routes {
+		paths = append(paths, r.Path)
+	}
+	if strings.Join(paths, ",") != "/api/v1/customers/{},/{},/api/v1/orders/{}" {
+		t.Errorf("Unexpected scanned routes: %v", paths)
+	}
+
+	r := Detect(s, code)
+	var unmatched []string
+	for _, it := range append(r.Missing, r.Extra...) {
+		if it.Kind == KindRoute {
+			unmatched = append(unmatched, it.Name)
+		}
+	}
+	for _, rn := range r.Renamed {
+		unmatched = append(unmatched, rn.SpecName)
+	}
+	if strings.Join(unmatched, ",") != "GET /api/v1/products/{},GET /{}" {
+		t.Errorf("Expected only the products route unmatched by the bare /{id}, got %v", unmatched)
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	tests := map[string]string{
+		"/orders/:id":          "/orders/{}",
+		"/orders/{orderId}/":   "/orders/{}",
+		"/files/*path":         "/files/{}",
+		"/":                    "/",
+		"/orders/{$}":          "/orders",
+		"/users/<int:id>/info": "/users/{}/info",
+	}
+	for in, want := range tests {
+		if got := NormalizePath(in); got != want {
+			t.Errorf("NormalizePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
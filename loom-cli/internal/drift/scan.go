@@ -0,0 +1,377 @@
+// Package drift compares an implementation code base with the spec it was
+// derived from and reports identifiers, routes and tests that diverged.
+package drift
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/codegen"
+)
+
+// Location is a position in the code base, relative to its root
+type Location struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// Route is an HTTP route registered in code
+type Route struct {
+	Method   string   `json:"method,omitempty"` // upper-case, "" when any
+	Path     string   `json:"path"`             // normalized, parameters as {}
+	Location Location `json:"location"`
+
+	fn string // top-level function registering the route
+}
+
+// CodeIndex holds what the Go code base declares
+type CodeIndex struct {
+	Types   map[string]Location // exported and unexported type names
+	Methods map[string]Location // Type.Method, including interface methods
+	Vars    map[string]Location // package-level vars and consts
+	Strings map[string]Location // string literals outside tests
+	Routes  []Route
+	TestIDs map[string]Location // spec IDs referenced by tests
+
+	mounts map[string]string // function name -> prefix it is mounted under
+}
+
+var (
+	// specIDPattern finds spec IDs such as TC-AC-ORD-001-P01 or AC_ORD_001
+	specIDPattern = regexp.MustCompile(`\b[A-Z]{2,}(?:[-_][A-Z0-9]+)*[-_][0-9]+(?:[-_][A-Z]?[0-9]+)?\b`)
+
+	httpMethods = map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "HEAD": true, "OPTIONS": true}
+)
+
+// routeFuncs are the router methods whose first argument is a path
+var routeFuncs = map[string]bool{
+	"Handle": true, "HandleFunc": true,
+	"Get": true, "Post": true, "Put": true, "Patch": true, "Delete": true, "Head": true, "Options": true,
+	"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "HEAD": true, "OPTIONS": true,
+}
+
+// ScanGoCode indexes the Go files under root. Vendor, testdata and hidden
+// directories are skipped.
+func ScanGoCode(root string) (*CodeIndex, error) {
+	idx := &CodeIndex{
+		Types:   make(map[string]Location),
+		Methods: make(map[string]Location),
+		Vars:    make(map[string]Location),
+		Strings: make(map[string]Location),
+		TestIDs: make(map[string]Location),
+		mounts:  make(map[string]string),
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read code directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	fset := token.NewFileSet()
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		idx.indexFile(fset, file, filepath.ToSlash(rel), strings.HasSuffix(name, "_test.go"))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Routers built by a function mounted elsewhere serve under its prefix
+	for i, r := range idx.Routes {
+		if prefix, ok := idx.mounts[r.fn]; ok {
+			idx.Routes[i].Path = NormalizePath(prefix + r.Path)
+		}
+	}
+
+	return idx, nil
+}
+
+func (idx *CodeIndex) indexFile(fset *token.FileSet, file *ast.File, rel string, isTest bool) {
+	loc := func(p token.Pos) Location {
+		return Location{File: rel, Line: fset.Position(p).Line}
+	}
+	record := func(m map[string]Location, key string, p token.Pos) {
+		if _, ok := m[key]; !ok {
+			m[key] = loc(p)
+		}
+	}
+	recordIDs := func(text string, p token.Pos) {
+		for _, id := range specIDPattern.FindAllString(text, -1) {
+			record(idx.TestIDs, strings.ReplaceAll(id, "_", "-"), p)
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				record(idx.Methods, codegen.ReceiverType(d.Recv.List[0].Type)+"."+d.Name.Name, d.Pos())
+			} else if isTest && strings.HasPrefix(d.Name.Name, "Test") {
+				recordIDs(strings.TrimPrefix(d.Name.Name, "Test"), d.Pos())
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					record(idx.Types, s.Name.Name, s.Pos())
+					if it, ok := s.Type.(*ast.InterfaceType); ok {
+						for _, m := range it.Methods.List {
+							for _, n := range m.Names {
+								record(idx.Methods, s.Name.Name+"."+n.Name, n.Pos())
+							}
+						}
+					}
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						record(idx.Vars, n.Name, n.Pos())
+					}
+				}
+			}
+		}
+	}
+
+	if isTest {
+		for _, cg := range file.Comments {
+			recordIDs(cg.Text(), cg.Pos())
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.BasicLit:
+			if x.Kind != token.STRING {
+				return true
+			}
+			s, err := strconv.Unquote(x.Value)
+			if err != nil {
+				return true
+			}
+			if isTest {
+				recordIDs(s, x.Pos())
+			} else {
+				record(idx.Strings, s, x.Pos())
+			}
+		}
+		return true
+	})
+
+	if !isTest {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				idx.indexRoutes(fn.Body, fn.Name.Name, "", make(map[string]string), loc)
+			}
+		}
+	}
+}
+
+// indexRoutes records the routes registered in a function body under the
+// prefix of the chi Route, gin/echo Group or gorilla PathPrefix they are
+// registered on. Functions passed to Mount are recorded in idx.mounts.
+func (idx *CodeIndex) indexRoutes(body ast.Node, fn, prefix string, groups map[string]string, loc func(token.Pos) Location) {
+	prefixOf := func(recv string) string {
+		if p, ok := groups[recv]; ok {
+			return p
+		}
+		return prefix
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.AssignStmt:
+			if len(x.Lhs) == 1 && len(x.Rhs) == 1 {
+				if id, ok := x.Lhs[0].(*ast.Ident); ok {
+					if recv, p, ok := groupPrefix(x.Rhs[0]); ok {
+						groups[id.Name] = prefixOf(recv) + p
+					}
+				}
+			}
+		case *ast.CallExpr:
+			sel, ok := x.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if (sel.Sel.Name == "Route" || sel.Sel.Name == "Mount") && len(x.Args) == 2 {
+				p, ok := stringArg(x.Args[0])
+				if !ok {
+					return true
+				}
+				p = prefixOf(ident(sel.X)) + p
+				switch h := x.Args[1].(type) {
+				case *ast.FuncLit:
+					idx.indexRoutes(h.Body, fn, p, groups, loc)
+					return false
+				case *ast.CallExpr:
+					if name := ident(h.Fun); name != "" {
+						idx.mounts[name] = p
+					}
+				case *ast.Ident:
+					idx.mounts[h.Name] = p
+				}
+				return true
+			}
+			if r, ok := routeFromCall(x); ok {
+				r.Path = NormalizePath(prefixOf(routeReceiver(x)) + r.Path)
+				r.Location = loc(x.Pos())
+				r.fn = fn
+				idx.Routes = append(idx.Routes, r)
+				// A gorilla Methods chain wraps the registration already recorded
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// groupPrefix recognizes route groups such as r.Group("/orders") and
+// r.PathPrefix("/orders").Subrouter(), returning the receiver and prefix
+func groupPrefix(expr ast.Expr) (string, string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return "", "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", "", false
+	}
+	if sel.Sel.Name == "Subrouter" {
+		return groupPrefix(sel.X)
+	}
+	if (sel.Sel.Name != "Group" && sel.Sel.Name != "PathPrefix") || len(call.Args) == 0 {
+		return "", "", false
+	}
+	p, ok := stringArg(call.Args[0])
+	if !ok || !strings.HasPrefix(p, "/") {
+		return "", "", false
+	}
+	return ident(sel.X), p, true
+}
+
+// routeReceiver returns the router a route is registered on, looking
+// through a gorilla Methods chain
+func routeReceiver(call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	if inner, ok := sel.X.(*ast.CallExpr); ok && sel.Sel.Name == "Methods" {
+		return routeReceiver(inner)
+	}
+	return ident(sel.X)
+}
+
+func ident(expr ast.Expr) string {
+	if id, ok := expr.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// routeFromCall recognizes route registrations such as
+// mux.HandleFunc("POST /orders/{id}", h), r.Post("/orders", h),
+// g.GET("/orders/:id", h) and r.HandleFunc("/orders", h).Methods("POST")
+func routeFromCall(call *ast.CallExpr) (Route, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return Route{}, false
+	}
+
+	// gorilla/mux: the method is chained after the registration
+	if sel.Sel.Name == "Methods" {
+		inner, ok := sel.X.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return Route{}, false
+		}
+		r, ok := routeFromCall(inner)
+		if !ok {
+			return Route{}, false
+		}
+		if m, ok := stringArg(call.Args[0]); ok {
+			r.Method = strings.ToUpper(m)
+		}
+		return r, true
+	}
+
+	if !routeFuncs[sel.Sel.Name] || len(call.Args) == 0 {
+		return Route{}, false
+	}
+	pattern, ok := stringArg(call.Args[0])
+	if !ok {
+		return Route{}, false
+	}
+
+	method := ""
+	if upper := strings.ToUpper(sel.Sel.Name); httpMethods[upper] {
+		method = upper
+	}
+	if i := strings.Index(pattern, " "); i > 0 && httpMethods[pattern[:i]] {
+		method = pattern[:i]
+		pattern = strings.TrimSpace(pattern[i+1:])
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		return Route{}, false
+	}
+	return Route{Method: method, Path: NormalizePath(pattern)}, true
+}
+
+// NormalizePath normalizes an HTTP path for comparison: parameters in any
+// router syntax ({id}, :id, <id>, *rest) become {}
+func NormalizePath(p string) string {
+	var segs []string
+	for _, seg := range strings.Split(p, "/") {
+		switch {
+		case seg == "" || seg == "{$}":
+			continue
+		case strings.HasPrefix(seg, "{") || strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "<") || strings.HasPrefix(seg, "*"):
+			segs = append(segs, "{}")
+		default:
+			segs = append(segs, seg)
+		}
+	}
+	return "/" + strings.Join(segs, "/")
+}
+
+func stringArg(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
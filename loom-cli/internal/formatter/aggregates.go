@@ -85,6 +85,14 @@ func formatAggregate(agg AggregateDesign) string {
 		sb.WriteString("\n")
 	}
 
+	// Lifecycle
+	if diagram := StateDiagram(agg); diagram != "" {
+		sb.WriteString("### Lifecycle\n\n")
+		sb.WriteString("```mermaid\n")
+		sb.WriteString(diagram)
+		sb.WriteString("```\n\n")
+	}
+
 	// Repository
 	sb.WriteString(fmt.Sprintf("### Repository: %s\n\n", agg.Repository.Name))
 	sb.WriteString(fmt.Sprintf("- Load Strategy: %s\n", agg.Repository.LoadStrategy))
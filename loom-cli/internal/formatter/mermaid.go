@@ -0,0 +1,323 @@
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// mermaidKeywords cannot be used as participant or state IDs
+var mermaidKeywords = map[string]bool{
+	"end": true, "participant": true, "actor": true, "as": true, "loop": true, "alt": true,
+	"else": true, "opt": true, "par": true, "and": true, "rect": true, "note": true,
+	"break": true, "critical": true, "option": true, "box": true, "autonumber": true,
+	"activate": true, "deactivate": true, "title": true, "state": true, "direction": true,
+}
+
+var (
+	// stateConditionPattern finds state checks such as "status is PLACED",
+	// "state = draft", "status in [PLACED, PAID]" or "status == 'pending'";
+	// group 1 is the negation
+	stateConditionPattern = regexp.MustCompile(`(?i)\b(?:status|state)\s+(?:(is\s+not\b|!=|not\s+in\b)|is\s+in\b|is\b|==?|in\b|becomes\b|changes\s+to\b|set\s+to\b|transitions\s+to\b|moves\s+to\b)\s*[\[(]?\s*((?:['"]?[A-Za-z][A-Za-z0-9_]*['"]?)(?:\s*(?:,|/|\||\bor\b)\s*['"]?[A-Za-z][A-Za-z0-9_]*['"]?)*)`)
+	stateListSeparator    = regexp.MustCompile(`\s*(?:,|/|\||\bor\b)\s*`)
+)
+
+// mermaidIDs hands out unique Mermaid identifiers for display names
+type mermaidIDs struct {
+	byName map[string]string
+	used   map[string]bool
+}
+
+func newMermaidIDs() *mermaidIDs {
+	return &mermaidIDs{byName: make(map[string]string), used: make(map[string]bool)}
+}
+
+// id returns the identifier for name, creating it on first use
+func (m *mermaidIDs) id(name string) (string, bool) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if id, ok := m.byName[key]; ok {
+		return id, false
+	}
+	var sb strings.Builder
+	upper := false
+	for _, r := range strings.TrimSpace(name) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if upper {
+				r = unicode.ToUpper(r)
+			}
+			sb.WriteRune(r)
+			upper = false
+		case r == '_':
+			sb.WriteRune(r)
+		default:
+			upper = sb.Len() > 0
+		}
+	}
+	base := sb.String()
+	if base == "" || unicode.IsDigit(rune(base[0])) {
+		base = "P" + base
+	}
+	if mermaidKeywords[strings.ToLower(base)] {
+		base += "_"
+	}
+	id := base
+	for i := 2; m.used[id]; i++ {
+		id = fmt.Sprintf("%s%d", base, i)
+	}
+	m.used[id] = true
+	m.byName[key] = id
+	return id, true
+}
+
+// mermaidText makes free text safe for a message or label: one line, with
+// the characters Mermaid treats as syntax written as entity codes
+func mermaidText(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.NewReplacer("#", "#35;", ";", "#59;", "%%", "#37;#37;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
+
+// mermaidLabel quotes a display name for an alias or state description
+func mermaidLabel(s string) string {
+	return strings.ReplaceAll(mermaidText(s), `"`, "#quot;")
+}
+
+// SequenceDiagram renders a sequence as a Mermaid sequenceDiagram. Declared
+// participants come first, in order, followed by any only named in steps.
+// Exceptions are drawn as break blocks after the step they interrupt.
+func SequenceDiagram(seq SequenceDesign) string {
+	var sb strings.Builder
+	ids := newMermaidIDs()
+
+	sb.WriteString("sequenceDiagram\n")
+	declare := func(name, typ string) string {
+		id, created := ids.id(name)
+		if created {
+			keyword := "participant"
+			if t := strings.ToLower(typ); strings.Contains(t, "actor") || strings.Contains(t, "user") {
+				keyword = "actor"
+			}
+			if id == name {
+				sb.WriteString(fmt.Sprintf("    %s %s\n", keyword, id))
+			} else {
+				sb.WriteString(fmt.Sprintf("    %s %s as %s\n", keyword, id, mermaidLabel(name)))
+			}
+		}
+		return id
+	}
+	for _, p := range seq.Participants {
+		declare(p.Name, p.Type)
+	}
+	for _, step := range seq.Steps {
+		declare(step.Actor, "")
+		declare(step.Target, "")
+	}
+
+	exceptions := make(map[int][]SequenceException)
+	steps := make(map[int]bool)
+	for _, step := range seq.Steps {
+		steps[step.Step] = true
+	}
+	var unplaced []SequenceException
+	for _, ex := range seq.Exceptions {
+		if steps[ex.Step] {
+			exceptions[ex.Step] = append(exceptions[ex.Step], ex)
+		} else {
+			unplaced = append(unplaced, ex)
+		}
+	}
+
+	for _, step := range seq.Steps {
+		actor, _ := ids.id(step.Actor)
+		target, _ := ids.id(step.Target)
+		sb.WriteString(fmt.Sprintf("    %s->>%s: %s\n", actor, target, mermaidText(step.Action)))
+		if step.Event != "" {
+			sb.WriteString(fmt.Sprintf("    Note over %s: emits %s\n", target, mermaidText(step.Event)))
+		}
+		if step.Returns != "" {
+			sb.WriteString(fmt.Sprintf("    %s-->>%s: %s\n", target, actor, mermaidText(step.Returns)))
+		}
+		for _, ex := range exceptions[step.Step] {
+			sb.WriteString(fmt.Sprintf("    break %s\n", mermaidText(ex.Condition)))
+			sb.WriteString(fmt.Sprintf("        %s-->>%s: %s\n", target, actor, mermaidText(ex.Handling)))
+			sb.WriteString("    end\n")
+		}
+	}
+
+	// Exceptions pointing at unknown steps still belong in the picture
+	if len(unplaced) > 0 && len(seq.Steps) > 0 {
+		first, _ := ids.id(seq.Steps[0].Actor)
+		for _, ex := range unplaced {
+			sb.WriteString(fmt.Sprintf("    break %s\n", mermaidText(ex.Condition)))
+			sb.WriteString(fmt.Sprintf("        Note over %s: %s\n", first, mermaidText(ex.Handling)))
+			sb.WriteString("    end\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// stateTransition is an edge of an aggregate lifecycle
+type stateTransition struct {
+	from, to, label string
+}
+
+// StateDiagram renders the lifecycle of an aggregate as a Mermaid
+// stateDiagram-v2, or "" when no states can be derived. States come from
+// the status/state checks in behavior pre- and postconditions. A behavior
+// without a target state in its postconditions moves to the state its
+// event names, e.g. OrderCancelled → Cancelled, when the event is the
+// aggregate name plus a state some condition checks; otherwise it stays
+// in its source states. Behaviors without a source state start from [*].
+func StateDiagram(agg AggregateDesign) string {
+	root := agg.Root.Entity
+	if root == "" {
+		root = agg.Name
+	}
+
+	// Display names of states, first spelling wins
+	names := make(map[string]string)
+	canonical := func(s string) string {
+		key := strings.ToLower(s)
+		if n, ok := names[key]; ok {
+			return n
+		}
+		names[key] = s
+		return s
+	}
+
+	// States the conditions check, so that events such as OrderRenamed
+	// don't invent states
+	known := make(map[string]bool)
+	for _, b := range agg.Behaviors {
+		for _, c := range append(append([]string{}, b.Preconditions...), b.Postconditions...) {
+			for _, st := range conditionStates(c) {
+				known[strings.ToLower(st)] = true
+			}
+		}
+	}
+
+	var transitions []stateTransition
+	seen := make(map[stateTransition]bool)
+	for _, b := range agg.Behaviors {
+		label := b.Name
+		if label == "" {
+			label = b.Command
+		}
+		if b.Emits != "" {
+			label += " / " + b.Emits
+		}
+
+		var from, to []string
+		for _, c := range b.Preconditions {
+			from = append(from, conditionStates(c)...)
+		}
+		for _, c := range b.Postconditions {
+			to = append(to, conditionStates(c)...)
+		}
+		if len(to) == 0 {
+			if s := eventState(b.Emits, root, agg.Name); known[strings.ToLower(s)] {
+				to = []string{s}
+			}
+		}
+		if len(from) == 0 && len(to) == 0 {
+			continue
+		}
+		if len(from) == 0 {
+			from = []string{"[*]"}
+		}
+		add := func(tr stateTransition) {
+			if !seen[tr] {
+				seen[tr] = true
+				transitions = append(transitions, tr)
+			}
+		}
+
+		for _, f := range from {
+			if f != "[*]" {
+				f = canonical(f)
+			}
+			if len(to) == 0 {
+				// Allowed in this state, does not change it
+				add(stateTransition{from: f, to: f, label: label})
+				continue
+			}
+			for _, t := range to {
+				add(stateTransition{from: f, to: canonical(t), label: label})
+			}
+		}
+	}
+	if len(transitions) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("stateDiagram-v2\n")
+
+	ids := newMermaidIDs()
+	keys := make([]string, 0, len(names))
+	for k := range names {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := names[k]
+		if id, _ := ids.id(name); id != name {
+			sb.WriteString(fmt.Sprintf("    state \"%s\" as %s\n", mermaidLabel(name), id))
+		}
+	}
+
+	stateID := func(s string) string {
+		if s == "[*]" {
+			return s
+		}
+		id, _ := ids.id(s)
+		return id
+	}
+	for _, tr := range transitions {
+		sb.WriteString(fmt.Sprintf("    %s --> %s : %s\n", stateID(tr.from), stateID(tr.to), mermaidText(tr.label)))
+	}
+
+	return sb.String()
+}
+
+// conditionStates returns the states a condition requires or establishes;
+// negated checks ("status is not PAID") name no state
+func conditionStates(condition string) []string {
+	var states []string
+	for _, m := range stateConditionPattern.FindAllStringSubmatch(condition, -1) {
+		if m[1] != "" {
+			continue
+		}
+		for _, s := range stateListSeparator.Split(strings.TrimSpace(m[2]), -1) {
+			if s = strings.Trim(s, `'"`); s != "" {
+				states = append(states, s)
+			}
+		}
+	}
+	return states
+}
+
+// eventState derives a state from an event named after the aggregate, e.g.
+// OrderShipped → Shipped
+func eventState(event string, names ...string) string {
+	for _, n := range names {
+		n = strings.ReplaceAll(n, " ", "")
+		if n == "" || !strings.HasPrefix(event, n) {
+			continue
+		}
+		rest := strings.TrimPrefix(event, n)
+		if rest == "" || !unicode.IsUpper(rune(rest[0])) {
+			continue
+		}
+		for _, r := range rest[1:] {
+			if !unicode.IsLower(r) {
+				return ""
+			}
+		}
+		return rest
+	}
+	return ""
+}
@@ -0,0 +1,242 @@
+package formatter
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	mermaidIDPattern      = `[A-Za-z_][A-Za-z0-9_]*`
+	seqDeclarePattern     = regexp.MustCompile(`^    (?:participant|actor) (` + mermaidIDPattern + `)(?: as (.+))?$`)
+	seqMessagePattern     = regexp.MustCompile(`^\s+(` + mermaidIDPattern + `)(?:->>|-->>)(` + mermaidIDPattern + `): (.*)$`)
+	seqNotePattern        = regexp.MustCompile(`^\s+Note over (` + mermaidIDPattern + `): (.*)$`)
+	seqBreakPattern       = regexp.MustCompile(`^    break (.+)$`)
+	stateDeclarePattern   = regexp.MustCompile(`^    state "([^"]*)" as (` + mermaidIDPattern + `)$`)
+	stateTransitionRegexp = regexp.MustCompile(`^    (\[\*\]|` + mermaidIDPattern + `) --> (` + mermaidIDPattern + `) : (.*)$`)
+	entityCodePattern     = regexp.MustCompile(`#\w+;`)
+)
+
+// checkMermaidText fails on text that would end a statement or start a
+// comment early
+func checkMermaidText(t *testing.T, line, text string) {
+	t.Helper()
+	rest := entityCodePattern.ReplaceAllString(text, "")
+	if strings.ContainsAny(rest, ";#<>") || strings.Contains(rest, "%%") {
+		t.Errorf("Unescaped syntax characters in %q", line)
+	}
+}
+
+// checkSequenceDiagram is a small syntax check for the sequenceDiagram
+// subset the formatter writes
+func checkSequenceDiagram(t *testing.T, diagram string) {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(diagram, "\n"), "\n")
+	if lines[0] != "sequenceDiagram" {
+		t.Fatalf("Expected sequenceDiagram header, got %q", lines[0])
+	}
+	declared := make(map[string]bool)
+	depth := 0
+	for _, line := range lines[1:] {
+		if m := seqDeclarePattern.FindStringSubmatch(line); m != nil {
+			if declared[m[1]] || mermaidKeywords[strings.ToLower(m[1])] {
+				t.Errorf("Invalid participant ID in %q", line)
+			}
+			declared[m[1]] = true
+			checkMermaidText(t, line, m[2])
+			continue
+		}
+		if m := seqMessagePattern.FindStringSubmatch(line); m != nil {
+			if !declared[m[1]] || !declared[m[2]] {
+				t.Errorf("Undeclared participant in %q", line)
+			}
+			checkMermaidText(t, line, m[3])
+			continue
+		}
+		if m := seqNotePattern.FindStringSubmatch(line); m != nil {
+			if !declared[m[1]] {
+				t.Errorf("Undeclared participant in %q", line)
+			}
+			checkMermaidText(t, line, m[2])
+			continue
+		}
+		if m := seqBreakPattern.FindStringSubmatch(line); m != nil {
+			checkMermaidText(t, line, m[1])
+			depth++
+			continue
+		}
+		if line == "    end" {
+			depth--
+			if depth < 0 {
+				t.Errorf("Unbalanced end")
+			}
+			continue
+		}
+		t.Errorf("Unexpected line %q", line)
+	}
+	if depth != 0 {
+		t.Errorf("Unclosed blocks: %d", depth)
+	}
+}
+
+// checkStateDiagram is a small syntax check for the stateDiagram-v2 subset
+// the formatter writes
+func checkStateDiagram(t *testing.T, diagram string) {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(diagram, "\n"), "\n")
+	if lines[0] != "stateDiagram-v2" {
+		t.Fatalf("Expected stateDiagram-v2 header, got %q", lines[0])
+	}
+	for _, line := range lines[1:] {
+		if m := stateDeclarePattern.FindStringSubmatch(line); m != nil {
+			checkMermaidText(t, line, m[1])
+			continue
+		}
+		if m := stateTransitionRegexp.FindStringSubmatch(line); m != nil {
+			for _, id := range m[1:3] {
+				if mermaidKeywords[strings.ToLower(id)] {
+					t.Errorf("Keyword used as state ID in %q", line)
+				}
+			}
+			checkMermaidText(t, line, m[3])
+			continue
+		}
+		t.Errorf("Unexpected line %q", line)
+	}
+}
+
+func testSequence() SequenceDesign {
+	return SequenceDesign{
+		ID:   "SEQ-ORD-001",
+		Name: "Place Order",
+		Participants: []SeqParticipant{
+			{Name: "Customer", Type: "actor"},
+			{Name: "Order Service", Type: "service"},
+			{Name: "end", Type: "system"},
+		},
+		Steps: []SequenceStep{
+			{Step: 1, Actor: "Customer", Target: "Order Service", Action: "placeOrder(items); confirm #1", Returns: "orderId"},
+			{Step: 2, Actor: "Order Service", Target: "Payment Gateway", Action: "charge <amount>", Event: "PaymentRequested"},
+			{Step: 3, Actor: "Order Service", Target: "end", Action: "notify"},
+		},
+		Exceptions: []SequenceException{
+			{Condition: "Payment declined", Step: 2, Handling: "Return 402; order stays PENDING"},
+			{Condition: "Timeout", Step: 9, Handling: "Retry later"},
+		},
+	}
+}
+
+func TestSequenceDiagram(t *testing.T) {
+	diagram := SequenceDiagram(testSequence())
+	checkSequenceDiagram(t, diagram)
+
+	expected := []string{
+		"    actor Customer\n",
+		"    participant OrderService as Order Service\n",
+		"    participant end_ as end\n",
+		"    participant PaymentGateway as Payment Gateway\n",
+		"    Customer->>OrderService: placeOrder(items)#59; confirm #35;1\n",
+		"    OrderService-->>Customer: orderId\n",
+		"    Note over PaymentGateway: emits PaymentRequested\n",
+		"    break Payment declined\n        PaymentGateway-->>OrderService: Return 402#59; order stays PENDING\n    end\n",
+		"    break Timeout\n        Note over Customer: Retry later\n    end\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(diagram, e) {
+			t.Errorf("Expected diagram to contain %q\n%s", e, diagram)
+		}
+	}
+	if SequenceDiagram(testSequence()) != diagram {
+		t.Error("Expected the same diagram for the same sequence")
+	}
+}
+
+func testLifecycleAggregate() AggregateDesign {
+	return AggregateDesign{
+		ID:   "AGG-ORD-001",
+		Name: "Order",
+		Root: AggRoot{Entity: "Order"},
+		Behaviors: []AggBehavior{
+			{Name: "place", Postconditions: []string{"status is PLACED"}, Emits: "OrderPlaced"},
+			{Name: "pay", Preconditions: []string{"status is Placed"}, Emits: "OrderPaid"},
+			{Name: "cancel", Preconditions: []string{"status in [PLACED, PAID]", "status is not SHIPPED"}, Postconditions: []string{"status becomes CANCELLED"}, Emits: "OrderCancelled"},
+			{Name: "changeAddress", Preconditions: []string{"status is PLACED or PAID"}, Emits: "OrderAddressChanged"},
+			{Name: "addNote", Emits: "OrderNoteAdded"},
+			{Name: "rename", Emits: "OrderRenamed"},
+			{Name: "end", Preconditions: []string{"status = PAID"}, Postconditions: []string{"state is end"}},
+		},
+	}
+}
+
+func TestStateDiagram(t *testing.T) {
+	diagram := StateDiagram(testLifecycleAggregate())
+	checkStateDiagram(t, diagram)
+
+	expected := "stateDiagram-v2\n" +
+		"    state \"end\" as end_\n" +
+		"    [*] --> PLACED : place / OrderPlaced\n" +
+		"    PLACED --> Paid : pay / OrderPaid\n" +
+		"    PLACED --> CANCELLED : cancel / OrderCancelled\n" +
+		"    Paid --> CANCELLED : cancel / OrderCancelled\n" +
+		"    PLACED --> PLACED : changeAddress / OrderAddressChanged\n" +
+		"    Paid --> Paid : changeAddress / OrderAddressChanged\n" +
+		"    Paid --> end_ : end\n"
+	if diagram != expected {
+		t.Errorf("Unexpected diagram\n%s\nwant\n%s", diagram, expected)
+	}
+
+	if got := StateDiagram(AggregateDesign{Name: "Cart", Behaviors: []AggBehavior{{Name: "addItem", Emits: "ItemAdded"}}}); got != "" {
+		t.Errorf("Expected no diagram without states, got\n%s", got)
+	}
+}
+
+func TestConditionStates(t *testing.T) {
+	tests := map[string]string{
+		"status is PLACED":                   "PLACED",
+		"Order status == draft":              "draft",
+		"status in (PLACED, PAID)":           "PLACED,PAID",
+		"status is PLACED or PAID":           "PLACED,PAID",
+		"state transitions to SHIPPED":       "SHIPPED",
+		"status is not SHIPPED":              "",
+		"status is inactive":                 "inactive",
+		"status information is recorded":     "",
+		"total > 0":                          "",
+		"status is PAID and total is > zero": "PAID",
+		"status == 'pending'":                "pending",
+		"status in ['pending', 'confirmed']": "pending,confirmed",
+		`state is "draft" or "open"`:         "draft,open",
+	}
+	for cond, want := range tests {
+		if got := strings.Join(conditionStates(cond), ","); got != want {
+			t.Errorf("conditionStates(%q) = %q, want %q", cond, got, want)
+		}
+	}
+}
+
+func TestFormatDesigns_MermaidBlocks(t *testing.T) {
+	docs := []string{
+		FormatSequenceDesign([]SequenceDesign{testSequence()}, "2024-01-15T10:00:00Z"),
+		FormatAggregateDesign([]AggregateDesign{testLifecycleAggregate()}, "2024-01-15T10:00:00Z"),
+	}
+	blocks := 0
+	for _, doc := range docs {
+		for _, part := range strings.Split(doc, "```mermaid\n")[1:] {
+			diagram, _, ok := strings.Cut(part, "```")
+			if !ok {
+				t.Fatal("Unterminated mermaid block")
+			}
+			blocks++
+			if strings.HasPrefix(diagram, "sequenceDiagram") {
+				checkSequenceDiagram(t, diagram)
+			} else {
+				checkStateDiagram(t, diagram)
+			}
+		}
+	}
+	if blocks != 2 {
+		t.Errorf("Expected a sequence and a state diagram, got %d blocks", blocks)
+	}
+	if !strings.Contains(docs[1], "### Lifecycle\n\n```mermaid\nstateDiagram-v2\n") {
+		t.Error("Expected a Lifecycle section in the aggregate design")
+	}
+}
@@ -56,16 +56,8 @@ func formatSequence(seq SequenceDesign) string {
 
 	// Mermaid Diagram
 	sb.WriteString("### Sequence Diagram\n\n")
-	sb.WriteString("```mermaid\nsequenceDiagram\n")
-	for _, p := range seq.Participants {
-		sb.WriteString(fmt.Sprintf("    participant %s\n", p.Name))
-	}
-	for _, step := range seq.Steps {
-		sb.WriteString(fmt.Sprintf("    %s->>%s: %s\n", step.Actor, step.Target, step.Action))
-		if step.Returns != "" {
-			sb.WriteString(fmt.Sprintf("    %s-->>%s: %s\n", step.Target, step.Actor, step.Returns))
-		}
-	}
+	sb.WriteString("```mermaid\n")
+	sb.WriteString(SequenceDiagram(seq))
 	sb.WriteString("```\n\n")
 
 	// Outcome
@@ -2,6 +2,8 @@ package formatter
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -125,3 +127,188 @@ func formatTestCase(tc TestCase) string {
 
 	return sb.String()
 }
+
+var (
+	testCaseHeadingPattern = regexp.MustCompile(`^###\s+(TC-[A-Za-z0-9-]+)\s+–\s+(.*?)(?:\s+\{#[^}]*\})?\s*$`)
+	markdownLinkPattern    = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	numberedItemPattern    = regexp.MustCompile(`^\d+\.\s+(.*)$`)
+	intPattern             = regexp.MustCompile(`^-?(0|[1-9]\d*)$`)
+	floatPattern           = regexp.MustCompile(`^-?(0|[1-9]\d*)\.\d+([eE][-+]?\d+)?$`)
+)
+
+// testCaseCategories maps the category section titles of test-cases.md back
+// to test case categories
+var testCaseCategories = map[string]string{
+	"Positive Tests (Happy Path)":    "positive",
+	"Negative Tests (Error Cases)":   "negative",
+	"Boundary Tests":                 "boundary",
+	"Hallucination Prevention Tests": "hallucination",
+}
+
+// ParseTestCases reads test cases back from test-cases.md as written by
+// FormatTestCases. Test data values are typed again where they look like
+// numbers or booleans.
+func ParseTestCases(content string) []TestCase {
+	var cases []TestCase
+	var current *TestCase
+	category := ""
+	block := ""
+
+	flush := func() {
+		if current != nil {
+			cases = append(cases, *current)
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "## ") {
+			flush()
+			category = testCaseCategories[strings.TrimSpace(strings.TrimPrefix(trimmed, "## "))]
+			continue
+		}
+		if m := testCaseHeadingPattern.FindStringSubmatch(trimmed); m != nil {
+			flush()
+			current = &TestCase{ID: m[1], Name: m[2], Category: category}
+			block = ""
+			continue
+		}
+		if current == nil || trimmed == "" || trimmed == "---" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "**⚠️ Should NOT:**"):
+			current.ShouldNot = strings.TrimSpace(strings.TrimPrefix(trimmed, "**⚠️ Should NOT:**"))
+			continue
+		case trimmed == "**Preconditions:**":
+			block = "preconditions"
+			continue
+		case trimmed == "**Test Data:**":
+			block = "data"
+			continue
+		case trimmed == "**Steps:**":
+			block = "steps"
+			continue
+		case trimmed == "**Expected Result:**":
+			block = "expected"
+			continue
+		case trimmed == "**Traceability:**":
+			block = "trace"
+			continue
+		}
+
+		switch block {
+		case "preconditions":
+			if item, ok := listItem(trimmed); ok {
+				current.Preconditions = append(current.Preconditions, item)
+			}
+		case "data":
+			if td, ok := parseTestDataRow(trimmed); ok {
+				current.TestData = append(current.TestData, td)
+			}
+		case "steps":
+			if m := numberedItemPattern.FindStringSubmatch(trimmed); m != nil {
+				current.Steps = append(current.Steps, m[1])
+			}
+		case "expected":
+			if item, ok := listItem(trimmed); ok {
+				current.ExpectedResults = append(current.ExpectedResults, item)
+			}
+		case "trace":
+			item, ok := listItem(trimmed)
+			if !ok {
+				continue
+			}
+			refs := linkTexts(item)
+			switch {
+			case strings.HasPrefix(item, "AC:") && len(refs) > 0:
+				current.ACRef = refs[0]
+			case strings.HasPrefix(item, "BR:"):
+				current.BRRefs = append(current.BRRefs, refs...)
+			}
+		}
+	}
+	flush()
+
+	return cases
+}
+
+// listItem returns the text of a "- item" line
+func listItem(line string) (string, bool) {
+	if !strings.HasPrefix(line, "- ") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "- ")), true
+}
+
+// linkTexts returns the link texts of markdown links, or the comma
+// separated values if the line has no links
+func linkTexts(item string) []string {
+	var refs []string
+	for _, m := range markdownLinkPattern.FindAllStringSubmatch(item, -1) {
+		refs = append(refs, m[1])
+	}
+	if len(refs) > 0 {
+		return refs
+	}
+	if i := strings.Index(item, ":"); i >= 0 {
+		item = item[i+1:]
+	}
+	for _, v := range strings.Split(item, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			refs = append(refs, v)
+		}
+	}
+	return refs
+}
+
+// parseTestDataRow parses a "| Field | Value | Notes |" row, skipping the
+// table header and separator
+func parseTestDataRow(line string) (TestData, bool) {
+	if !strings.HasPrefix(line, "|") {
+		return TestData{}, false
+	}
+	cells := strings.Split(strings.Trim(line, "|"), "|")
+	if len(cells) < 2 {
+		return TestData{}, false
+	}
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	if cells[0] == "Field" || strings.HasPrefix(cells[0], "---") {
+		return TestData{}, false
+	}
+
+	// Values containing "|" were split; the last cell is the notes
+	td := TestData{Field: cells[0], Value: parseTestDataValue(strings.Join(cells[1:max(2, len(cells)-1)], "|"))}
+	if len(cells) > 2 {
+		td.Notes = cells[len(cells)-1]
+	}
+	return td, true
+}
+
+// parseTestDataValue restores the type of a value formatted with %v
+func parseTestDataValue(s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "<nil>":
+		return nil
+	}
+	if intPattern.MatchString(s) {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	}
+	if floatPattern.MatchString(s) {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return s
+}
@@ -0,0 +1,88 @@
+package formatter
+
+import (
+	"testing"
+)
+
+func sampleTestCases() []TestCase {
+	return []TestCase{
+		{
+			ID:            "TC-AC-ORD-001-P01",
+			Name:          "Place order with valid items",
+			Category:      "positive",
+			ACRef:         "AC-ORD-001",
+			BRRefs:        []string{"BR-ORD-001", "BR-ORD-002"},
+			Preconditions: []string{"Customer is logged in"},
+			TestData: []TestData{
+				{Field: "quantity", Value: int64(5), Notes: "Minimum valid"},
+				{Field: "price", Value: 9.99},
+				{Field: "express", Value: true, Notes: "Express shipping"},
+				{Field: "zip", Value: "01234", Notes: "Leading zero"},
+			},
+			Steps:           []string{"Add items to cart", "Submit order"},
+			ExpectedResults: []string{"Order is created", "Confirmation is sent"},
+		},
+		{
+			ID:              "TC-AC-ORD-001-H01",
+			Name:            "No discount applied",
+			Category:        "hallucination",
+			ACRef:           "AC-ORD-001",
+			Preconditions:   []string{"Cart has items"},
+			Steps:           []string{"Submit order"},
+			ExpectedResults: []string{"Total equals item sum"},
+			ShouldNot:       "Apply an automatic discount",
+		},
+	}
+}
+
+func TestParseTestCases_RoundTrip(t *testing.T) {
+	original := sampleTestCases()
+	content := FormatTestCases(original, TDAISummary{Total: 2}, "2025-12-21T10:00:00Z")
+
+	parsed := ParseTestCases(content)
+
+	if len(parsed) != 2 {
+		t.Fatalf("Expected 2 test cases, got %d", len(parsed))
+	}
+
+	tc := parsed[0]
+	if tc.ID != "TC-AC-ORD-001-P01" || tc.Name != "Place order with valid items" || tc.Category != "positive" {
+		t.Errorf("Unexpected header fields %+v", tc)
+	}
+	if tc.ACRef != "AC-ORD-001" {
+		t.Errorf("Expected AC ref AC-ORD-001, got %s", tc.ACRef)
+	}
+	if len(tc.BRRefs) != 2 || tc.BRRefs[1] != "BR-ORD-002" {
+		t.Errorf("Expected 2 BR refs, got %v", tc.BRRefs)
+	}
+	if len(tc.Preconditions) != 1 || len(tc.Steps) != 2 || len(tc.ExpectedResults) != 2 {
+		t.Errorf("Unexpected list lengths %d/%d/%d", len(tc.Preconditions), len(tc.Steps), len(tc.ExpectedResults))
+	}
+	if tc.Steps[1] != "Submit order" {
+		t.Errorf("Expected step 'Submit order', got '%s'", tc.Steps[1])
+	}
+
+	if len(tc.TestData) != 4 {
+		t.Fatalf("Expected 4 test data rows, got %d", len(tc.TestData))
+	}
+	if v, ok := tc.TestData[0].Value.(int64); !ok || v != 5 {
+		t.Errorf("Expected int64 5, got %#v", tc.TestData[0].Value)
+	}
+	if v, ok := tc.TestData[1].Value.(float64); !ok || v != 9.99 {
+		t.Errorf("Expected float64 9.99, got %#v", tc.TestData[1].Value)
+	}
+	if v, ok := tc.TestData[2].Value.(bool); !ok || !v {
+		t.Errorf("Expected bool true, got %#v", tc.TestData[2].Value)
+	}
+	if v, ok := tc.TestData[3].Value.(string); !ok || v != "01234" {
+		t.Errorf("Expected string '01234', got %#v", tc.TestData[3].Value)
+	}
+	if tc.TestData[0].Notes != "Minimum valid" {
+		t.Errorf("Expected notes 'Minimum valid', got '%s'", tc.TestData[0].Notes)
+	}
+
+	h := parsed[1]
+	if h.Category != "hallucination" || h.ShouldNot != "Apply an automatic discount" {
+		t.Errorf("Unexpected hallucination test %+v", h)
+	}
+}
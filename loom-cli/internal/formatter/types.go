@@ -287,3 +287,55 @@ type DataEnum struct {
 	Name   string   `json:"name"`
 	Values []string `json:"values"`
 }
+
+// Event Design types
+type DomainEvent struct {
+	ID                  string       `json:"id"`
+	Name                string       `json:"name"`
+	Purpose             string       `json:"purpose"`
+	Trigger             string       `json:"trigger"`
+	Aggregate           string       `json:"aggregate"`
+	Payload             []EventField `json:"payload"`
+	InvariantsReflected []string     `json:"invariants_reflected"`
+	Consumers           []string     `json:"consumers"`
+	Version             string       `json:"version"`
+}
+
+type EventField struct {
+	Field string `json:"field"`
+	Type  string `json:"type"`
+}
+
+type Command struct {
+	ID                string       `json:"id"`
+	Name              string       `json:"name"`
+	Intent            string       `json:"intent"`
+	RequiredData      []EventField `json:"required_data"`
+	ExpectedOutcome   string       `json:"expected_outcome"`
+	FailureConditions []string     `json:"failure_conditions"`
+}
+
+type IntegrationEvent struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Purpose   string   `json:"purpose"`
+	Source    string   `json:"source"`
+	Consumers []string `json:"consumers"`
+	Payload   []string `json:"payload"`
+}
+
+// Feature Ticket types
+type FeatureTicket struct {
+	ID                     string   `json:"id"`
+	Title                  string   `json:"title"`
+	Status                 string   `json:"status"`
+	BusinessGoal           string   `json:"business_goal"`
+	UserStory              string   `json:"user_story"`
+	AcceptanceCriteriaRefs []string `json:"acceptance_criteria_refs"`
+	NFR                    []string `json:"nfr"`
+	Dependencies           []string `json:"dependencies"`
+	ImpactAreas            []string `json:"impact_areas"`
+	OutOfScope             []string `json:"out_of_scope"`
+	Priority               string   `json:"priority"`
+	EstimatedComplexity    string   `json:"estimated_complexity"`
+}
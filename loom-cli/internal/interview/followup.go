@@ -0,0 +1,180 @@
+package interview
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/claude"
+	"github.com/ikadar/loom-cli/internal/domain"
+	"github.com/ikadar/loom-cli/prompts"
+)
+
+// Defaults for adaptive interviews
+const (
+	DefaultMaxFollowUpDepth = 2  // follow-ups of follow-ups of analysis questions
+	DefaultMaxFollowUps     = 15 // total follow-ups per interview
+	MaxFollowUpsPerAnswer   = 3  // follow-ups accepted for a single answer
+)
+
+// FollowUpGenerator asks the model for follow-up questions raised by answers
+type FollowUpGenerator struct {
+	Client claude.ClaudeClient
+}
+
+// NewFollowUpGenerator creates a follow-up generator
+func NewFollowUpGenerator(client claude.ClaudeClient) *FollowUpGenerator {
+	return &FollowUpGenerator{Client: client}
+}
+
+// followUpAnswer is the answered question sent to the model
+type followUpAnswer struct {
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	Subject  string `json:"subject"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// ProposeFollowUps sends the given answers to the model and appends the
+// follow-up questions it proposes to state.Questions, within the limits of
+// state.Adaptive. Each follow-up records its parent question and depth.
+// It returns the follow-ups that were added.
+func (g *FollowUpGenerator) ProposeFollowUps(state *domain.InterviewState, answered []domain.Decision) ([]domain.Ambiguity, error) {
+	settings := state.Adaptive
+	if settings == nil || settings.FollowUpCount >= settings.MaxFollowUps {
+		return nil, nil
+	}
+
+	// Only answers to questions below the depth cap can have follow-ups
+	byID := make(map[string]*domain.Ambiguity, len(state.Questions))
+	for i := range state.Questions {
+		byID[state.Questions[i].ID] = &state.Questions[i]
+	}
+
+	var eligible []followUpAnswer
+	parents := make(map[string]domain.Ambiguity)
+	for _, d := range answered {
+		q, ok := byID[d.ID]
+		if !ok || q.Depth >= settings.MaxDepth {
+			continue
+		}
+		eligible = append(eligible, followUpAnswer{
+			ID:       q.ID,
+			Category: q.Category,
+			Subject:  q.Subject,
+			Question: q.Question,
+			Answer:   d.Answer,
+		})
+		parents[q.ID] = *q
+	}
+	if len(eligible) == 0 {
+		return nil, nil
+	}
+
+	var result struct {
+		FollowUps []domain.Ambiguity `json:"follow_ups"`
+	}
+	if err := g.Client.CallJSON(buildFollowUpPrompt(eligible, state.Questions), &result); err != nil {
+		return nil, fmt.Errorf("failed to propose follow-ups: %w", err)
+	}
+
+	return AppendFollowUps(state, parents, result.FollowUps), nil
+}
+
+// AppendFollowUps validates proposed follow-ups and appends them to the
+// interview. Proposals without a known parent, duplicates of existing
+// questions and proposals beyond the per-answer or total caps are dropped.
+func AppendFollowUps(state *domain.InterviewState, parents map[string]domain.Ambiguity, proposed []domain.Ambiguity) []domain.Ambiguity {
+	settings := state.Adaptive
+	if settings == nil {
+		return nil
+	}
+
+	// Single parent: attribute unlabelled proposals to it
+	var onlyParent string
+	if len(parents) == 1 {
+		for id := range parents {
+			onlyParent = id
+		}
+	}
+
+	seen := make(map[string]bool, len(state.Questions))
+	children := make(map[string]int)
+	for _, q := range state.Questions {
+		seen[normalizeQuestion(q.Question)] = true
+		if q.ParentID != "" {
+			children[q.ParentID]++
+		}
+	}
+
+	perParent := make(map[string]int)
+	var added []domain.Ambiguity
+
+	for _, f := range proposed {
+		if settings.FollowUpCount >= settings.MaxFollowUps {
+			break
+		}
+
+		parentID := f.ParentID
+		if parentID == "" {
+			parentID = onlyParent
+		}
+		parent, ok := parents[parentID]
+		if !ok || perParent[parentID] >= MaxFollowUpsPerAnswer {
+			continue
+		}
+
+		key := normalizeQuestion(f.Question)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		children[parentID]++
+		perParent[parentID]++
+
+		f.ID = fmt.Sprintf("%s-F%d", parentID, children[parentID])
+		f.ParentID = parentID
+		f.Depth = parent.Depth + 1
+		f.PriorDecisions = nil
+		f.DependsOn = nil
+		if f.Category == "" {
+			f.Category = parent.Category
+		}
+		if f.Subject == "" {
+			f.Subject = parent.Subject
+		}
+		switch f.Severity {
+		case domain.SeverityCritical, domain.SeverityImportant, domain.SeverityMinor:
+		default:
+			f.Severity = domain.SeverityImportant
+		}
+
+		state.Questions = append(state.Questions, f)
+		settings.FollowUpCount++
+		added = append(added, f)
+	}
+
+	return added
+}
+
+// buildFollowUpPrompt builds the follow-up prompt for the answered questions
+func buildFollowUpPrompt(answered []followUpAnswer, existing []domain.Ambiguity) string {
+	answeredJSON, _ := json.MarshalIndent(answered, "", "  ")
+
+	var sb strings.Builder
+	sb.WriteString(prompts.InterviewFollowUp)
+	sb.WriteString("ANSWERED QUESTIONS:\n")
+	sb.Write(answeredJSON)
+	sb.WriteString("\n\nEXISTING QUESTIONS:\n")
+	for _, q := range existing {
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", q.ID, q.Question))
+	}
+	return sb.String()
+}
+
+// normalizeQuestion lowercases and collapses whitespace for duplicate detection
+func normalizeQuestion(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
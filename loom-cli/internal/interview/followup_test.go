@@ -0,0 +1,160 @@
+package interview
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/claude"
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+func newAdaptiveState(maxDepth, maxFollowUps int) *domain.InterviewState {
+	return &domain.InterviewState{
+		Questions: []domain.Ambiguity{
+			{ID: "AMB-ENT-001", Category: "entity", Subject: "Order", Question: "Can orders be cancelled?", Severity: domain.SeverityCritical},
+			{ID: "AMB-ENT-002", Category: "entity", Subject: "Order", Question: "Is the order total stored?", Severity: domain.SeverityMinor},
+		},
+		Adaptive: &domain.AdaptiveSettings{MaxDepth: maxDepth, MaxFollowUps: maxFollowUps},
+	}
+}
+
+func TestProposeFollowUps_AppendsWithProvenance(t *testing.T) {
+	mock := claude.NewMockClient()
+	mock.AddContainsResponse("ANSWERED QUESTIONS", `{"follow_ups": [
+		{"parent_id": "AMB-ENT-001", "question": "Which line items can be cancelled?", "severity": "critical"},
+		{"parent_id": "AMB-ENT-001", "question": "Is a refund issued per cancelled item?"}
+	]}`)
+
+	state := newAdaptiveState(2, 10)
+	answered := []domain.Decision{{ID: "AMB-ENT-001", Answer: "Yes, orders can be partially cancelled"}}
+
+	added, err := NewFollowUpGenerator(mock).ProposeFollowUps(state, answered)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(added) != 2 {
+		t.Fatalf("Expected 2 follow-ups, got %d", len(added))
+	}
+	if len(state.Questions) != 4 {
+		t.Errorf("Expected 4 questions in queue, got %d", len(state.Questions))
+	}
+
+	first := state.Questions[2]
+	if first.ID != "AMB-ENT-001-F1" {
+		t.Errorf("Expected ID 'AMB-ENT-001-F1', got '%s'", first.ID)
+	}
+	if first.ParentID != "AMB-ENT-001" {
+		t.Errorf("Expected parent 'AMB-ENT-001', got '%s'", first.ParentID)
+	}
+	if first.Depth != 1 {
+		t.Errorf("Expected depth 1, got %d", first.Depth)
+	}
+	if first.Subject != "Order" || first.Category != "entity" {
+		t.Errorf("Expected subject/category inherited from parent, got %s/%s", first.Subject, first.Category)
+	}
+	if state.Questions[3].Severity != domain.SeverityImportant {
+		t.Errorf("Expected default severity 'important', got '%s'", state.Questions[3].Severity)
+	}
+	if state.Adaptive.FollowUpCount != 2 {
+		t.Errorf("Expected follow-up count 2, got %d", state.Adaptive.FollowUpCount)
+	}
+
+	// The prompt carries the answer and the existing questions
+	prompt := mock.CallLog[0].Prompt
+	if !strings.Contains(prompt, "partially cancelled") {
+		t.Error("Expected answer in prompt")
+	}
+	if !strings.Contains(prompt, "AMB-ENT-002: Is the order total stored?") {
+		t.Error("Expected existing questions in prompt")
+	}
+}
+
+func TestProposeFollowUps_DepthCap(t *testing.T) {
+	mock := claude.NewMockClient()
+	state := newAdaptiveState(1, 10)
+	state.Questions = append(state.Questions, domain.Ambiguity{
+		ID: "AMB-ENT-001-F1", ParentID: "AMB-ENT-001", Depth: 1, Question: "Which items?",
+	})
+
+	added, err := NewFollowUpGenerator(mock).ProposeFollowUps(state, []domain.Decision{{ID: "AMB-ENT-001-F1", Answer: "Any"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(added) != 0 {
+		t.Errorf("Expected no follow-ups beyond max depth, got %d", len(added))
+	}
+	if len(mock.CallLog) != 0 {
+		t.Error("Expected no model call when all answers are at max depth")
+	}
+}
+
+func TestProposeFollowUps_Disabled(t *testing.T) {
+	mock := claude.NewMockClient()
+	state := newAdaptiveState(2, 10)
+	state.Adaptive = nil
+
+	added, err := NewFollowUpGenerator(mock).ProposeFollowUps(state, []domain.Decision{{ID: "AMB-ENT-001", Answer: "Yes"}})
+	if err != nil || added != nil {
+		t.Errorf("Expected no-op when adaptive mode is off, got %v, %v", added, err)
+	}
+	if len(mock.CallLog) != 0 {
+		t.Error("Expected no model call when adaptive mode is off")
+	}
+}
+
+func TestAppendFollowUps_Caps(t *testing.T) {
+	state := newAdaptiveState(2, 4)
+	parents := map[string]domain.Ambiguity{
+		"AMB-ENT-001": state.Questions[0],
+		"AMB-ENT-002": state.Questions[1],
+	}
+
+	proposed := []domain.Ambiguity{
+		{ParentID: "AMB-ENT-001", Question: "Q1?"},
+		{ParentID: "AMB-ENT-001", Question: "Q2?"},
+		{ParentID: "AMB-ENT-001", Question: "Q3?"},
+		{ParentID: "AMB-ENT-001", Question: "Q4?"},                       // per-answer cap
+		{ParentID: "AMB-ENT-002", Question: "can orders  BE cancelled?"}, // duplicate
+		{ParentID: "AMB-ENT-999", Question: "Unknown parent?"},           // unknown parent
+		{Question: "No parent?"},                                         // ambiguous parent
+		{ParentID: "AMB-ENT-002", Question: "Q5?"},
+		{ParentID: "AMB-ENT-002", Question: "Q6?"}, // total cap
+	}
+
+	added := AppendFollowUps(state, parents, proposed)
+
+	if len(added) != 4 {
+		t.Fatalf("Expected 4 follow-ups, got %d", len(added))
+	}
+	ids := []string{"AMB-ENT-001-F1", "AMB-ENT-001-F2", "AMB-ENT-001-F3", "AMB-ENT-002-F1"}
+	for i, id := range ids {
+		if added[i].ID != id {
+			t.Errorf("Expected follow-up %d to be %s, got %s", i, id, added[i].ID)
+		}
+	}
+	if state.Adaptive.FollowUpCount != 4 {
+		t.Errorf("Expected follow-up count 4, got %d", state.Adaptive.FollowUpCount)
+	}
+
+	// Total cap reached: nothing more is added
+	more := AppendFollowUps(state, parents, []domain.Ambiguity{{ParentID: "AMB-ENT-002", Question: "Q7?"}})
+	if len(more) != 0 {
+		t.Errorf("Expected no follow-ups after total cap, got %d", len(more))
+	}
+}
+
+func TestAppendFollowUps_SingleParentAttribution(t *testing.T) {
+	state := newAdaptiveState(2, 10)
+	state.Questions = append(state.Questions, domain.Ambiguity{ID: "AMB-ENT-002-F1", ParentID: "AMB-ENT-002", Depth: 1, Question: "Earlier follow-up?"})
+	parents := map[string]domain.Ambiguity{"AMB-ENT-002": state.Questions[1]}
+
+	added := AppendFollowUps(state, parents, []domain.Ambiguity{{Question: "Unlabelled follow-up?"}})
+
+	if len(added) != 1 {
+		t.Fatalf("Expected 1 follow-up, got %d", len(added))
+	}
+	if added[0].ID != "AMB-ENT-002-F2" {
+		t.Errorf("Expected numbering to continue at F2, got %s", added[0].ID)
+	}
+}
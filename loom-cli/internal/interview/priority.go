@@ -0,0 +1,257 @@
+package interview
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+// DefaultAnswerFallback is recorded when a question is defaulted but has
+// neither a suggested answer nor options
+const DefaultAnswerFallback = "No answer given; derivation uses a reasonable default"
+
+// severityRank orders severities, most important first
+func severityRank(s domain.Severity) int {
+	switch s {
+	case domain.SeverityCritical:
+		return 0
+	case domain.SeverityImportant:
+		return 1
+	case domain.SeverityMinor:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// ImpactScore counts the entities and operations of the domain model that
+// an answer to the question affects: the question's subject, operations
+// targeting it or performed by it, entities related to it, and any other
+// entity or operation named in the question text.
+func ImpactScore(q domain.Ambiguity, dm *domain.Domain) int {
+	if dm == nil {
+		return 0
+	}
+
+	subject := strings.ToLower(strings.TrimSpace(q.Subject))
+	text := strings.ToLower(q.Question)
+	affected := make(map[string]bool)
+
+	mentions := func(name string) bool {
+		n := strings.ToLower(name)
+		return n != "" && (n == subject || strings.Contains(text, n))
+	}
+
+	for _, e := range dm.Entities {
+		if mentions(e.Name) {
+			affected["entity:"+strings.ToLower(e.Name)] = true
+		}
+	}
+	for _, op := range dm.Operations {
+		if mentions(op.Name) || (subject != "" && (strings.ToLower(op.Target) == subject || strings.ToLower(op.Actor) == subject)) {
+			affected["operation:"+strings.ToLower(op.Name)] = true
+		}
+	}
+	for _, r := range dm.Relationships {
+		from, to := strings.ToLower(r.From), strings.ToLower(r.To)
+		if subject == "" {
+			continue
+		}
+		if from == subject && to != "" {
+			affected["entity:"+to] = true
+		}
+		if to == subject && from != "" {
+			affected["entity:"+from] = true
+		}
+	}
+
+	return len(affected)
+}
+
+// PrioritizeQuestions computes the impact of each question and orders the
+// questions by severity, then impact (highest first). Original order breaks
+// ties, and a question never precedes a question it depends on.
+func PrioritizeQuestions(questions []domain.Ambiguity, dm *domain.Domain) []domain.Ambiguity {
+	result := make([]domain.Ambiguity, len(questions))
+	copy(result, questions)
+
+	for i := range result {
+		result[i].Impact = ImpactScore(result[i], dm)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		ri, rj := severityRank(result[i].Severity), severityRank(result[j].Severity)
+		if ri != rj {
+			return ri < rj
+		}
+		return result[i].Impact > result[j].Impact
+	})
+
+	return orderAfterDependencies(result)
+}
+
+// orderAfterDependencies moves questions behind the questions they depend
+// on, keeping the order otherwise unchanged
+func orderAfterDependencies(questions []domain.Ambiguity) []domain.Ambiguity {
+	present := make(map[string]bool, len(questions))
+	for _, q := range questions {
+		present[q.ID] = true
+	}
+
+	emitted := make(map[string]bool, len(questions))
+	ready := func(q domain.Ambiguity) bool {
+		for _, dep := range q.DependsOn {
+			if present[dep.QuestionID] && !emitted[dep.QuestionID] && dep.QuestionID != q.ID {
+				return false
+			}
+		}
+		return true
+	}
+
+	result := make([]domain.Ambiguity, 0, len(questions))
+	var pending []domain.Ambiguity
+
+	emit := func(q domain.Ambiguity) {
+		result = append(result, q)
+		emitted[q.ID] = true
+
+		// Release pending questions that are now ready
+		for progress := true; progress; {
+			progress = false
+			for i := 0; i < len(pending); i++ {
+				if ready(pending[i]) {
+					p := pending[i]
+					pending = append(pending[:i], pending[i+1:]...)
+					result = append(result, p)
+					emitted[p.ID] = true
+					progress = true
+					break
+				}
+			}
+		}
+	}
+
+	for _, q := range questions {
+		if ready(q) {
+			emit(q)
+		} else {
+			pending = append(pending, q)
+		}
+	}
+
+	// Cyclic dependencies: keep remaining questions in their current order
+	return append(result, pending...)
+}
+
+// PrioritizeRemaining re-orders the not yet reached part of the question
+// queue (from CurrentIndex on), e.g. after follow-ups were appended
+func PrioritizeRemaining(state *domain.InterviewState) {
+	if state.CurrentIndex >= len(state.Questions) {
+		return
+	}
+	tail := PrioritizeQuestions(state.Questions[state.CurrentIndex:], state.DomainModel)
+	copy(state.Questions[state.CurrentIndex:], tail)
+}
+
+// AskedCount returns the number of questions answered during this interview
+// (excluding pre-existing and defaulted answers)
+func AskedCount(decisions []domain.Decision) int {
+	count := 0
+	for _, d := range decisions {
+		if d.Source != "existing" && d.Source != "default" {
+			count++
+		}
+	}
+	return count
+}
+
+// ShouldDefault reports whether a question is answered with its default
+// instead of being asked, because of --critical-only or an exhausted --budget
+func ShouldDefault(state *domain.InterviewState, q domain.Ambiguity) bool {
+	if state.CriticalOnly && q.Severity != domain.SeverityCritical {
+		return true
+	}
+	if state.Budget > 0 && AskedCount(state.Decisions) >= state.Budget {
+		return true
+	}
+	return false
+}
+
+// ApplyDefaults records default answers for the candidates that will not be
+// asked because of --critical-only or the remaining --budget, and returns
+// the candidates that are still to be asked (in order). Skip conditions are
+// evaluated first, against the answers and defaults recorded so far, so a
+// skipped question does not use the budget. A question depending on one
+// still to be asked is left pending rather than defaulted.
+func ApplyDefaults(state *domain.InterviewState, candidates []domain.Ambiguity) []domain.Ambiguity {
+	allowed := -1
+	if state.Budget > 0 {
+		allowed = state.Budget - AskedCount(state.Decisions)
+		if allowed < 0 {
+			allowed = 0
+		}
+	}
+
+	var keep []domain.Ambiguity
+	asked := make(map[string]bool)
+	for _, q := range candidates {
+		if shouldSkipQuestion(&q, state.Decisions) {
+			continue
+		}
+		if (state.CriticalOnly && q.Severity != domain.SeverityCritical) || (allowed >= 0 && len(keep) >= allowed) {
+			if !dependsOnAny(q, asked) {
+				state.Decisions = append(state.Decisions, DefaultDecision(q))
+			}
+			continue
+		}
+		keep = append(keep, q)
+		asked[q.ID] = true
+	}
+	return keep
+}
+
+// dependsOnAny reports whether a question depends on one of the given ones
+func dependsOnAny(q domain.Ambiguity, ids map[string]bool) bool {
+	for _, dep := range q.DependsOn {
+		if ids[dep.QuestionID] {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultDecision records the default answer for a question
+func DefaultDecision(q domain.Ambiguity) domain.Decision {
+	answer := q.SuggestedAnswer
+	if answer == "" && len(q.Options) > 0 {
+		answer = q.Options[0]
+	}
+	if answer == "" {
+		answer = DefaultAnswerFallback
+	}
+
+	return domain.Decision{
+		ID:        q.ID,
+		Question:  q.Question,
+		Answer:    answer,
+		DecidedAt: time.Now(),
+		Source:    "default",
+		Category:  q.Category,
+		Subject:   q.Subject,
+		ParentID:  q.ParentID,
+	}
+}
+
+// DefaultedDecisions returns the decisions that were defaulted rather than
+// answered, i.e. the assumptions derivation relies on
+func DefaultedDecisions(decisions []domain.Decision) []domain.Decision {
+	var result []domain.Decision
+	for _, d := range decisions {
+		if d.Source == "default" {
+			result = append(result, d)
+		}
+	}
+	return result
+}
@@ -0,0 +1,183 @@
+package interview
+
+import (
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+func testDomain() *domain.Domain {
+	return &domain.Domain{
+		Entities: []domain.Entity{
+			{Name: "Order"},
+			{Name: "Customer"},
+			{Name: "Invoice"},
+		},
+		Operations: []domain.Operation{
+			{Name: "PlaceOrder", Actor: "Customer", Target: "Order"},
+			{Name: "CancelOrder", Actor: "Customer", Target: "Order"},
+			{Name: "SendInvoice", Target: "Invoice"},
+		},
+		Relationships: []domain.Relationship{
+			{From: "Customer", To: "Order", Type: "has_many"},
+			{From: "Order", To: "Invoice", Type: "has_one"},
+		},
+	}
+}
+
+func TestImpactScore(t *testing.T) {
+	dm := testDomain()
+
+	order := domain.Ambiguity{Subject: "Order", Question: "Can an order be cancelled?"}
+	// Order entity, PlaceOrder, CancelOrder, Customer and Invoice (related)
+	if got := ImpactScore(order, dm); got != 5 {
+		t.Errorf("Expected impact 5 for Order question, got %d", got)
+	}
+
+	invoice := domain.Ambiguity{Subject: "Invoice", Question: "What currency is used?"}
+	// Invoice entity, SendInvoice, Order (related)
+	if got := ImpactScore(invoice, dm); got != 3 {
+		t.Errorf("Expected impact 3 for Invoice question, got %d", got)
+	}
+
+	if got := ImpactScore(order, nil); got != 0 {
+		t.Errorf("Expected impact 0 without domain model, got %d", got)
+	}
+}
+
+func TestPrioritizeQuestions_SeverityThenImpact(t *testing.T) {
+	questions := []domain.Ambiguity{
+		{ID: "Q1", Subject: "Invoice", Question: "Invoice currency?", Severity: domain.SeverityMinor},
+		{ID: "Q2", Subject: "Invoice", Question: "Invoice numbering?", Severity: domain.SeverityCritical},
+		{ID: "Q3", Subject: "Order", Question: "Order cancellation?", Severity: domain.SeverityCritical},
+		{ID: "Q4", Subject: "Order", Question: "Order notes length?", Severity: domain.SeverityImportant},
+	}
+
+	result := PrioritizeQuestions(questions, testDomain())
+
+	expected := []string{"Q3", "Q2", "Q4", "Q1"}
+	for i, id := range expected {
+		if result[i].ID != id {
+			t.Errorf("Position %d: expected %s, got %s", i, id, result[i].ID)
+		}
+	}
+	if result[0].Impact == 0 {
+		t.Error("Expected impact to be recorded on questions")
+	}
+
+	// Input is not modified
+	if questions[0].ID != "Q1" || questions[0].Impact != 0 {
+		t.Error("Expected input slice to be left unchanged")
+	}
+}
+
+func TestPrioritizeQuestions_RespectsDependencies(t *testing.T) {
+	questions := []domain.Ambiguity{
+		{ID: "Q1", Subject: "Order", Question: "Can orders be deleted?", Severity: domain.SeverityMinor},
+		{ID: "Q2", Subject: "Order", Question: "What happens upon deletion?", Severity: domain.SeverityCritical,
+			DependsOn: []domain.SkipCondition{{QuestionID: "Q1"}}},
+		{ID: "Q3", Subject: "Order", Question: "Order status values?", Severity: domain.SeverityImportant},
+	}
+
+	result := PrioritizeQuestions(questions, nil)
+
+	pos := make(map[string]int)
+	for i, q := range result {
+		pos[q.ID] = i
+	}
+	if pos["Q2"] < pos["Q1"] {
+		t.Errorf("Expected Q2 after the question it depends on, got order %v", pos)
+	}
+	if len(result) != 3 {
+		t.Errorf("Expected 3 questions, got %d", len(result))
+	}
+}
+
+func TestShouldDefault(t *testing.T) {
+	critical := domain.Ambiguity{ID: "Q1", Severity: domain.SeverityCritical}
+	minor := domain.Ambiguity{ID: "Q2", Severity: domain.SeverityMinor}
+
+	state := &domain.InterviewState{CriticalOnly: true}
+	if ShouldDefault(state, critical) {
+		t.Error("Critical question should be asked in critical-only mode")
+	}
+	if !ShouldDefault(state, minor) {
+		t.Error("Minor question should be defaulted in critical-only mode")
+	}
+
+	state = &domain.InterviewState{
+		Budget: 1,
+		Decisions: []domain.Decision{
+			{ID: "E1", Source: "existing"},
+			{ID: "D1", Source: "default"},
+		},
+	}
+	if ShouldDefault(state, minor) {
+		t.Error("Existing and defaulted answers should not consume the budget")
+	}
+	state.Decisions = append(state.Decisions, domain.Decision{ID: "A1", Source: "user"})
+	if !ShouldDefault(state, critical) {
+		t.Error("Expected default once the budget is used up")
+	}
+}
+
+func TestApplyDefaults_Budget(t *testing.T) {
+	state := &domain.InterviewState{Budget: 2}
+	candidates := []domain.Ambiguity{
+		{ID: "Q1", Severity: domain.SeverityCritical},
+		{ID: "Q2", Severity: domain.SeverityImportant},
+		{ID: "Q3", Severity: domain.SeverityMinor, SuggestedAnswer: "30 days"},
+		{ID: "Q4", Severity: domain.SeverityMinor, Options: []string{"A", "B"}},
+		{ID: "Q5", Severity: domain.SeverityMinor},
+	}
+
+	keep := ApplyDefaults(state, candidates)
+
+	if len(keep) != 2 || keep[0].ID != "Q1" || keep[1].ID != "Q2" {
+		t.Fatalf("Expected Q1 and Q2 to be asked, got %v", keep)
+	}
+
+	defaulted := DefaultedDecisions(state.Decisions)
+	if len(defaulted) != 3 {
+		t.Fatalf("Expected 3 defaulted decisions, got %d", len(defaulted))
+	}
+	if defaulted[0].Answer != "30 days" {
+		t.Errorf("Expected suggested answer as default, got '%s'", defaulted[0].Answer)
+	}
+	if defaulted[1].Answer != "A" {
+		t.Errorf("Expected first option as default, got '%s'", defaulted[1].Answer)
+	}
+	if defaulted[2].Answer != DefaultAnswerFallback {
+		t.Errorf("Expected fallback default, got '%s'", defaulted[2].Answer)
+	}
+}
+
+func TestApplyDefaults_BudgetAfterSkipConditions(t *testing.T) {
+	state := &domain.InterviewState{Budget: 1}
+	candidates := []domain.Ambiguity{
+		{ID: "Q1", Severity: domain.SeverityCritical},
+		{ID: "Q2", Severity: domain.SeverityImportant, DependsOn: []domain.SkipCondition{{QuestionID: "Q1", SkipIfAnswer: []string{"no"}}}},
+		{ID: "Q3", Severity: domain.SeverityMinor, SuggestedAnswer: "No"},
+		{ID: "Q4", Severity: domain.SeverityMinor, DependsOn: []domain.SkipCondition{{QuestionID: "Q3", SkipIfAnswer: []string{"no"}}}},
+	}
+
+	keep := ApplyDefaults(state, candidates)
+
+	if len(keep) != 1 || keep[0].ID != "Q1" {
+		t.Fatalf("Expected only Q1 to be asked, got %v", keep)
+	}
+	if len(state.Decisions) != 1 || state.Decisions[0].ID != "Q3" {
+		t.Fatalf("Expected only Q3 to be defaulted, got %v", state.Decisions)
+	}
+
+	// Q1's answer skips Q2, which the budget must not default
+	state.Decisions = append(state.Decisions, domain.Decision{ID: "Q1", Answer: "No", Source: "user"})
+	keep = ApplyDefaults(state, []domain.Ambiguity{candidates[1], candidates[3]})
+
+	if len(keep) != 0 {
+		t.Errorf("Expected nothing left to ask, got %v", keep)
+	}
+	if defaulted := DefaultedDecisions(state.Decisions); len(defaulted) != 1 {
+		t.Errorf("Expected skipped questions not to be defaulted, got %v", defaulted)
+	}
+}
@@ -0,0 +1,42 @@
+package interview
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ikadar/loom-cli/internal/decisions"
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+// MaxPriorDecisions is the maximum number of prior decisions attached to a question
+const MaxPriorDecisions = 3
+
+// AttachPriorDecisions annotates each question with similar decisions taken
+// in other projects, so the interviewer can keep answers consistent.
+func AttachPriorDecisions(questions []domain.Ambiguity, corpus *decisions.Corpus, minSimilarity float64) []domain.Ambiguity {
+	result := make([]domain.Ambiguity, len(questions))
+	copy(result, questions)
+
+	if corpus == nil {
+		return result
+	}
+
+	for i := range result {
+		q := &result[i]
+		q.PriorDecisions = nil
+
+		for _, m := range corpus.Similar(q.Question, MaxPriorDecisions, minSimilarity) {
+			d := m.Entry.Decision
+			q.PriorDecisions = append(q.PriorDecisions, domain.PriorDecision{
+				Project:    m.Entry.Project,
+				QuestionID: d.AmbiguityID,
+				Question:   d.Question,
+				Answer:     d.Answer,
+				Similarity: math.Round(m.Similarity*100) / 100,
+				Summary:    fmt.Sprintf("previously decided as %q in project %s", d.Answer, m.Entry.Project),
+			})
+		}
+	}
+
+	return result
+}
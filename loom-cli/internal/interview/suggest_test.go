@@ -0,0 +1,55 @@
+package interview
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/decisions"
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+func TestAttachPriorDecisions_SimilarAboveThreshold(t *testing.T) {
+	root := t.TempDir()
+	ds := &decisions.DecisionSet{Decisions: []decisions.AmbiguityDecision{
+		{AmbiguityID: "AMB-ENT-001", Question: "Can orders be partially cancelled?", Answer: "Yes, per line item", Source: "user"},
+		{AmbiguityID: "AMB-ENT-002", Question: "What currency do invoices use?", Answer: "EUR only", Source: "user"},
+	}}
+	if err := ds.WriteToFile(filepath.Join(root, "billing", decisions.CorpusFileName)); err != nil {
+		t.Fatal(err)
+	}
+	corpus, err := decisions.BuildCorpus([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	questions := []domain.Ambiguity{
+		{ID: "AMB-ENT-010", Question: "Can an order be partially cancelled by the customer?"},
+		{ID: "AMB-ENT-011", Question: "How long are tracking events retained?"},
+	}
+	result := AttachPriorDecisions(questions, corpus, decisions.DefaultMinSimilarity)
+
+	priors := result[0].PriorDecisions
+	if len(priors) != 1 {
+		t.Fatalf("Expected one prior decision, got %v", priors)
+	}
+	p := priors[0]
+	if p.Project != "billing" || p.QuestionID != "AMB-ENT-001" || p.Answer != "Yes, per line item" {
+		t.Errorf("Unexpected prior decision: %+v", p)
+	}
+	if p.Similarity < decisions.DefaultMinSimilarity || !strings.Contains(p.Summary, `"Yes, per line item"`) {
+		t.Errorf("Unexpected similarity or summary: %+v", p)
+	}
+	if len(result[1].PriorDecisions) != 0 {
+		t.Errorf("Expected no prior decision below the threshold, got %v", result[1].PriorDecisions)
+	}
+	if questions[0].PriorDecisions != nil {
+		t.Error("Expected the input questions to be left unchanged")
+	}
+
+	// A threshold above every similarity attaches nothing
+	result = AttachPriorDecisions(questions, corpus, 1.01)
+	if len(result[0].PriorDecisions) != 0 {
+		t.Errorf("Expected no prior decision above the threshold, got %v", result[0].PriorDecisions)
+	}
+}
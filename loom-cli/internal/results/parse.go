@@ -0,0 +1,227 @@
+// Package results reads test run reports and maps the tests back to the
+// L3 test cases, acceptance criteria and business rules they verify.
+package results
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Report formats
+const (
+	FormatAuto   = "auto"
+	FormatGoTest = "gotest" // go test -json
+	FormatJUnit  = "junit"
+)
+
+// Outcome statuses
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// maxMessageLines is how many output lines of a failing test are kept
+const maxMessageLines = 10
+
+// Outcome is the result of one test in a report
+type Outcome struct {
+	Name     string  // full test name, subtests separated by /
+	Status   string  // pass, fail or skip
+	Message  string  // failure output
+	Duration float64 // seconds
+}
+
+// Parse reads a report in the given format; FormatAuto detects JUnit XML
+// by its leading '<'
+func Parse(data []byte, format string) ([]Outcome, error) {
+	switch format {
+	case FormatAuto, "":
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+			return ParseJUnit(bytes.NewReader(data))
+		}
+		return ParseGoTestJSON(bytes.NewReader(data))
+	case FormatGoTest:
+		return ParseGoTestJSON(bytes.NewReader(data))
+	case FormatJUnit:
+		return ParseJUnit(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported format: %s (supported: auto, gotest, junit)", format)
+	}
+}
+
+// testEvent is a line of go test -json output
+type testEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// ParseGoTestJSON reads go test -json output. Package-level events are
+// ignored; tests of a package that failed to build are simply absent.
+func ParseGoTestJSON(r io.Reader) ([]Outcome, error) {
+	var outcomes []Outcome
+	output := make(map[string][]string)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '{' {
+			// go test prints build errors as plain text around the events
+			continue
+		}
+		var ev testEvent
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return nil, fmt.Errorf("failed to parse test event on line %d: %w", lineNum, err)
+		}
+		if ev.Test == "" {
+			continue
+		}
+
+		key := ev.Package + "\x00" + ev.Test
+		switch ev.Action {
+		case "output":
+			if text := strings.TrimRight(ev.Output, "\n"); !isTestFrameLine(text) {
+				output[key] = append(output[key], text)
+			}
+		case "pass", "fail", "skip":
+			o := Outcome{Name: ev.Test, Status: ev.Action, Duration: ev.Elapsed}
+			if ev.Action == StatusFail {
+				o.Message = lastLines(output[key], maxMessageLines)
+			}
+			outcomes = append(outcomes, o)
+			delete(output, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read test events: %w", err)
+	}
+
+	return outcomes, nil
+}
+
+// isTestFrameLine reports whether a line is go test's own bookkeeping
+// rather than output of the test
+func isTestFrameLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{"=== RUN", "=== PAUSE", "=== CONT", "=== NAME", "--- PASS", "--- FAIL", "--- SKIP"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return trimmed == ""
+}
+
+func lastLines(lines []string, n int) string {
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// junitSuites covers both a <testsuites> root and a single <testsuite>
+type junitSuites struct {
+	Suites []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Cases  []junitCase  `xml:"testcase"`
+	Suites []junitSuite `xml:"testsuite"` // nested suites
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Error     *junitMessage `xml:"error"`
+	Skipped   *junitMessage `xml:"skipped"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ParseJUnit reads JUnit XML. Errors count as failures.
+func ParseJUnit(r io.Reader) ([]Outcome, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JUnit report: %w", err)
+	}
+
+	var suites []junitSuite
+	var root struct{ XMLName xml.Name }
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse JUnit report: %w", err)
+	}
+	switch root.XMLName.Local {
+	case "testsuites":
+		var all junitSuites
+		if err := xml.Unmarshal(data, &all); err != nil {
+			return nil, fmt.Errorf("failed to parse JUnit report: %w", err)
+		}
+		suites = all.Suites
+	case "testsuite":
+		var one junitSuite
+		if err := xml.Unmarshal(data, &one); err != nil {
+			return nil, fmt.Errorf("failed to parse JUnit report: %w", err)
+		}
+		suites = []junitSuite{one}
+	default:
+		return nil, fmt.Errorf("unexpected JUnit root element <%s>", root.XMLName.Local)
+	}
+
+	var outcomes []Outcome
+	var walk func(s junitSuite)
+	walk = func(s junitSuite) {
+		for _, c := range s.Cases {
+			o := Outcome{Name: c.Name, Status: StatusPass, Duration: c.Time}
+			if c.Classname != "" {
+				o.Name = c.Classname + "/" + c.Name
+			}
+			switch {
+			case c.Failure != nil:
+				o.Status, o.Message = StatusFail, c.Failure.text()
+			case c.Error != nil:
+				o.Status, o.Message = StatusFail, c.Error.text()
+			case c.Skipped != nil:
+				o.Status = StatusSkip
+			}
+			outcomes = append(outcomes, o)
+		}
+		for _, nested := range s.Suites {
+			walk(nested)
+		}
+	}
+	for _, s := range suites {
+		walk(s)
+	}
+
+	return outcomes, nil
+}
+
+func (m *junitMessage) text() string {
+	text := strings.TrimSpace(m.Text)
+	if text == "" {
+		return m.Message
+	}
+	return lastLines(strings.Split(text, "\n"), maxMessageLines)
+}
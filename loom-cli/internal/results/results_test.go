@@ -0,0 +1,176 @@
+package results
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+const goTestJSON = `{"Action":"start","Package":"example.com/order"}
+{"Action":"run","Package":"example.com/order","Test":"TestAC_ORD_001"}
+{"Action":"run","Package":"example.com/order","Test":"TestAC_ORD_001/TC-AC-ORD-001-P01"}
+{"Action":"output","Package":"example.com/order","Test":"TestAC_ORD_001/TC-AC-ORD-001-P01","Output":"=== RUN   TestAC_ORD_001/TC-AC-ORD-001-P01\n"}
+{"Action":"pass","Package":"example.com/order","Test":"TestAC_ORD_001/TC-AC-ORD-001-P01","Elapsed":0.01}
+{"Action":"run","Package":"example.com/order","Test":"TestAC_ORD_001/TC-AC-ORD-001-N01"}
+{"Action":"output","Package":"example.com/order","Test":"TestAC_ORD_001/TC-AC-ORD-001-N01","Output":"    order_test.go:42: expected EMPTY_CART, got nil\n"}
+{"Action":"output","Package":"example.com/order","Test":"TestAC_ORD_001/TC-AC-ORD-001-N01","Output":"--- FAIL: TestAC_ORD_001/TC-AC-ORD-001-N01 (0.00s)\n"}
+{"Action":"fail","Package":"example.com/order","Test":"TestAC_ORD_001/TC-AC-ORD-001-N01","Elapsed":0}
+{"Action":"fail","Package":"example.com/order","Test":"TestAC_ORD_001","Elapsed":0.02}
+{"Action":"skip","Package":"example.com/order","Test":"TestTC_AC_ORD_002_P01","Elapsed":0}
+{"Action":"fail","Package":"example.com/order","Elapsed":0.03}
+`
+
+const junitXML = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="order">
+    <testcase classname="order.CheckoutTest" name="TC-AC-ORD-001-P01 places the order" time="0.5"/>
+    <testcase classname="order.CheckoutTest" name="TC-AC-ORD-001-N01 rejects an empty cart" time="0.1">
+      <failure message="expected 400">expected 400, got 201</failure>
+    </testcase>
+    <testsuite name="nested">
+      <testcase classname="order.CheckoutTest" name="testTC_AC_ORD_002_P01"><skipped/></testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+
+func testCases() []formatter.TestCase {
+	return []formatter.TestCase{
+		{ID: "TC-AC-ORD-001-P01", ACRef: "AC-ORD-001", BRRefs: []string{"BR-ORD-001"}},
+		{ID: "TC-AC-ORD-001-N01", ACRef: "AC-ORD-001"},
+		{ID: "TC-AC-ORD-002-P01", ACRef: "AC-ORD-002"},
+		{ID: "TC-AC-ORD-003-P01", ACRef: "AC-ORD-003"},
+	}
+}
+
+func TestParseGoTestJSON(t *testing.T) {
+	outcomes, err := Parse([]byte(goTestJSON), FormatAuto)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(outcomes) != 4 {
+		t.Fatalf("Expected 4 test outcomes, got %d: %+v", len(outcomes), outcomes)
+	}
+
+	failed := outcomes[1]
+	if failed.Name != "TestAC_ORD_001/TC-AC-ORD-001-N01" || failed.Status != StatusFail {
+		t.Errorf("Unexpected outcome %+v", failed)
+	}
+	if failed.Message != "order_test.go:42: expected EMPTY_CART, got nil" {
+		t.Errorf("Expected the failure output without go test framing, got %q", failed.Message)
+	}
+	if outcomes[3].Status != StatusSkip {
+		t.Errorf("Expected skip, got %+v", outcomes[3])
+	}
+}
+
+func TestParseJUnit(t *testing.T) {
+	outcomes, err := Parse([]byte(junitXML), FormatAuto)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(outcomes) != 3 {
+		t.Fatalf("Expected 3 test outcomes, got %d", len(outcomes))
+	}
+	if outcomes[0].Name != "order.CheckoutTest/TC-AC-ORD-001-P01 places the order" || outcomes[0].Duration != 0.5 {
+		t.Errorf("Unexpected outcome %+v", outcomes[0])
+	}
+	if outcomes[1].Status != StatusFail || outcomes[1].Message != "expected 400, got 201" {
+		t.Errorf("Unexpected failure %+v", outcomes[1])
+	}
+	if outcomes[2].Status != StatusSkip {
+		t.Errorf("Expected nested skipped test, got %+v", outcomes[2])
+	}
+
+	single := `<testsuite name="x"><testcase name="TestA"><error message="panic"/></testcase></testsuite>`
+	outcomes, err = ParseJUnit(strings.NewReader(single))
+	if err != nil || len(outcomes) != 1 || outcomes[0].Status != StatusFail || outcomes[0].Message != "panic" {
+		t.Errorf("Expected a single failed test, got %+v, %v", outcomes, err)
+	}
+
+	if _, err := Parse([]byte(junitXML), "tap"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestMap(t *testing.T) {
+	runAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for name, report := range map[string]string{"gotest": goTestJSON, "junit": junitXML} {
+		t.Run(name, func(t *testing.T) {
+			outcomes, err := Parse([]byte(report), FormatAuto)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			in := Map(testCases(), outcomes, runAt)
+
+			want := map[string]string{
+				"TC-AC-ORD-001-P01": derivation.TestPass,
+				"TC-AC-ORD-001-N01": derivation.TestFail,
+				"TC-AC-ORD-002-P01": derivation.TestSkip,
+				"TC-AC-ORD-003-P01": derivation.TestMissing,
+			}
+			for id, status := range want {
+				if got := in.Results[id].Status; got != status {
+					t.Errorf("%s: expected %s, got %s", id, status, got)
+				}
+			}
+			if r := in.Results["TC-AC-ORD-001-P01"]; r.ACRef != "AC-ORD-001" || len(r.BRRefs) != 1 || !r.RunAt.Equal(runAt) {
+				t.Errorf("Expected AC and BR refs to be carried over, got %+v", r)
+			}
+			if !strings.Contains(in.Results["TC-AC-ORD-001-N01"].Message, "expected") {
+				t.Errorf("Expected the failure message, got %q", in.Results["TC-AC-ORD-001-N01"].Message)
+			}
+			if len(in.Unmatched) != 0 {
+				t.Errorf("Expected parent tests not to be reported as unmatched, got %v", in.Unmatched)
+			}
+		})
+	}
+}
+
+func TestMap_IDBoundaries(t *testing.T) {
+	tcs := []formatter.TestCase{{ID: "TC-AC-ORD-001-P01"}, {ID: "TC-AC-ORD-001-P010"}}
+	outcomes := []Outcome{
+		{Name: "TestOrders/TC-AC-ORD-001-P010", Status: StatusFail},
+		{Name: "TestOrders/XTC-AC-ORD-001-P01", Status: StatusPass},
+	}
+	in := Map(tcs, outcomes, time.Time{})
+
+	if got := in.Results["TC-AC-ORD-001-P010"].Status; got != derivation.TestFail {
+		t.Errorf("Expected P010 to fail, got %s", got)
+	}
+	if got := in.Results["TC-AC-ORD-001-P01"].Status; got != derivation.TestMissing {
+		t.Errorf("Expected P01 to be missing, got %s", got)
+	}
+	if len(in.Unmatched) != 1 || in.Unmatched[0] != "TestOrders/XTC-AC-ORD-001-P01" {
+		t.Errorf("Expected one unmatched test, got %v", in.Unmatched)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	outcomes, _ := ParseGoTestJSON(strings.NewReader(goTestJSON))
+	in := Map(testCases(), outcomes, time.Now())
+	in.Results["TC-UNMAPPED"] = &derivation.TestResult{Status: derivation.TestPass}
+
+	coverage := Coverage(in.Results)
+	if len(coverage) != 3 {
+		t.Fatalf("Expected 3 ACs, got %+v", coverage)
+	}
+
+	ord1 := coverage[0]
+	if ord1.AC != "AC-ORD-001" || ord1.Total != 2 || ord1.Passed != 1 || ord1.Failed != 1 || ord1.Verified() {
+		t.Errorf("Unexpected coverage %+v", ord1)
+	}
+	if len(ord1.Failing) != 1 || ord1.Failing[0] != "TC-AC-ORD-001-N01" {
+		t.Errorf("Expected the failing TC, got %v", ord1.Failing)
+	}
+	if coverage[1].Skipped != 1 || coverage[2].Missing != 1 {
+		t.Errorf("Unexpected coverage %+v", coverage[1:])
+	}
+
+	in.Results["TC-AC-ORD-001-N01"].Status = derivation.TestPass
+	if !Coverage(in.Results)[0].Verified() {
+		t.Error("Expected AC-ORD-001 to be verified once all its tests pass")
+	}
+}
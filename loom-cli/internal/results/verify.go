@@ -0,0 +1,200 @@
+package results
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ikadar/loom-cli/internal/codegen"
+	"github.com/ikadar/loom-cli/internal/derivation"
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// Ingestion is a test report mapped onto the test cases
+type Ingestion struct {
+	Results   map[string]*derivation.TestResult // by TC ID; every test case has one
+	Unmatched []string                          // tests referencing no known test case
+}
+
+// Counts returns how many test cases have each status
+func (in *Ingestion) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, r := range in.Results {
+		counts[r.Status]++
+	}
+	return counts
+}
+
+// Map matches the test names of a report against the test case IDs. A name
+// references a test case when one of its segments contains the ID, written
+// with - or _ and optionally prefixed by Test, e.g. TestAC_ORD_001/
+// TC-AC-ORD-001-P01 or TestTC_AC_ORD_001_N01. Test cases no test
+// references are recorded as missing.
+func Map(testCases []formatter.TestCase, outcomes []Outcome, runAt time.Time) *Ingestion {
+	in := &Ingestion{Results: make(map[string]*derivation.TestResult, len(testCases))}
+
+	ids := make([]string, 0, len(testCases))
+	for _, tc := range testCases {
+		if tc.ID == "" {
+			continue
+		}
+		ids = append(ids, tc.ID)
+		in.Results[tc.ID] = &derivation.TestResult{
+			Status: derivation.TestMissing,
+			ACRef:  codegen.ACForTestCase(tc),
+			BRRefs: tc.BRRefs,
+			RunAt:  runAt,
+		}
+	}
+	// Longer IDs first so TC-...-P010 is not read as TC-...-P01
+	sort.Slice(ids, func(i, j int) bool { return len(ids[i]) > len(ids[j]) })
+
+	var matchedNames []string
+	var unmatched []string
+	for _, o := range outcomes {
+		norm := normalizeTestName(o.Name)
+		found := false
+		for _, id := range ids {
+			if !containsID(norm, strings.ToUpper(id)) {
+				continue
+			}
+			found = true
+			record(in.Results[id], o)
+		}
+		if found {
+			matchedNames = append(matchedNames, o.Name)
+		} else {
+			unmatched = append(unmatched, o.Name)
+		}
+	}
+
+	// Parent tests of matched subtests are not worth reporting
+	for _, name := range unmatched {
+		parent := false
+		for _, m := range matchedNames {
+			if strings.HasPrefix(m, name+"/") {
+				parent = true
+				break
+			}
+		}
+		if !parent {
+			in.Unmatched = append(in.Unmatched, name)
+		}
+	}
+	sort.Strings(in.Unmatched)
+
+	return in
+}
+
+// record folds a test outcome into the test case result: any failure fails
+// the test case, a pass beats a skip
+func record(r *derivation.TestResult, o Outcome) {
+	r.Tests = append(r.Tests, o.Name)
+	r.Duration += o.Duration
+	switch {
+	case o.Status == StatusFail:
+		r.Status = derivation.TestFail
+		if o.Message != "" {
+			if r.Message != "" {
+				r.Message += "\n"
+			}
+			r.Message += fmt.Sprintf("%s: %s", o.Name, o.Message)
+		}
+	case o.Status == StatusPass && r.Status != derivation.TestFail:
+		r.Status = derivation.TestPass
+	case o.Status == StatusSkip && r.Status == derivation.TestMissing:
+		r.Status = derivation.TestSkip
+	}
+}
+
+// normalizeTestName upper-cases a test name, drops Test prefixes of its
+// segments and writes separators as -
+func normalizeTestName(name string) string {
+	segs := strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '.' })
+	for i, seg := range segs {
+		if len(seg) > 4 && strings.EqualFold(seg[:4], "test") {
+			seg = strings.TrimLeft(seg[4:], "_")
+		}
+		segs[i] = strings.ToUpper(strings.NewReplacer("_", "-", " ", "-").Replace(seg))
+	}
+	return "/" + strings.Join(segs, "/") + "/"
+}
+
+// containsID reports whether id occurs in s as a whole token
+func containsID(s, id string) bool {
+	for start := 0; ; {
+		i := strings.Index(s[start:], id)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(id)
+		if !isIDChar(s[i-1]) && (end == len(s) || !isIDChar(s[end])) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isIDChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// ACCoverage summarizes the verification of one acceptance criterion
+type ACCoverage struct {
+	AC      string   `json:"ac"`
+	Total   int      `json:"total"`
+	Passed  int      `json:"passed"`
+	Failed  int      `json:"failed"`
+	Skipped int      `json:"skipped"`
+	Missing int      `json:"missing"`
+	Failing []string `json:"failing,omitempty"` // TC IDs
+}
+
+// Verified reports whether every test case of the AC passed
+func (c ACCoverage) Verified() bool {
+	return c.Total > 0 && c.Passed == c.Total
+}
+
+// Coverage groups test results by acceptance criterion, sorted by AC ID.
+// Test cases without an AC are left out.
+func Coverage(results map[string]*derivation.TestResult) []ACCoverage {
+	byAC := make(map[string]*ACCoverage)
+	tcIDs := make([]string, 0, len(results))
+	for id := range results {
+		tcIDs = append(tcIDs, id)
+	}
+	sort.Strings(tcIDs)
+
+	for _, id := range tcIDs {
+		r := results[id]
+		if r.ACRef == "" || r.ACRef == codegen.UnmappedGroup {
+			continue
+		}
+		c := byAC[r.ACRef]
+		if c == nil {
+			c = &ACCoverage{AC: r.ACRef}
+			byAC[r.ACRef] = c
+		}
+		c.Total++
+		switch r.Status {
+		case derivation.TestPass:
+			c.Passed++
+		case derivation.TestFail:
+			c.Failed++
+			c.Failing = append(c.Failing, id)
+		case derivation.TestSkip:
+			c.Skipped++
+		default:
+			c.Missing++
+		}
+	}
+
+	coverage := make([]ACCoverage, 0, len(byAC))
+	for _, c := range byAC {
+		coverage = append(coverage, *c)
+	}
+	sort.Slice(coverage, func(i, j int) bool { return coverage[i].AC < coverage[j].AC })
+	return coverage
+}
@@ -0,0 +1,208 @@
+// Package spec reads the generated LOOM specification documents back into
+// structured form, for exporters and generators working from the spec.
+package spec
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/domain"
+)
+
+// BoundedContext is a bounded context as listed in bounded-context-map.md
+type BoundedContext struct {
+	ID           string
+	Name         string
+	CoreEntities []string
+	Aggregates   []string
+}
+
+var (
+	// ## AC-CUST-001 – Title {#ac-cust-001}
+	sectionHeadingPattern = regexp.MustCompile(`^(#{2,3})\s+([A-Z][A-Za-z0-9]*-[A-Za-z0-9-]+)\s+–\s+(.*?)(?:\s+\{#[^}]*\})?\s*$`)
+	linkTextPattern       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+)
+
+// ParseAcceptanceCriteria reads acceptance criteria from
+// acceptance-criteria.md as written by derive
+func ParseAcceptanceCriteria(content string) []domain.AcceptanceCriteria {
+	var acs []domain.AcceptanceCriteria
+	var current *domain.AcceptanceCriteria
+	block := ""
+
+	flush := func() {
+		if current != nil {
+			acs = append(acs, *current)
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if m := sectionHeadingPattern.FindStringSubmatch(trimmed); m != nil && strings.HasPrefix(m[2], "AC-") {
+			flush()
+			current = &domain.AcceptanceCriteria{ID: m[2], Title: m[3]}
+			block = ""
+			continue
+		}
+		if current == nil || trimmed == "" || trimmed == "---" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "**Given**"):
+			current.Given = strings.TrimSpace(strings.TrimPrefix(trimmed, "**Given**"))
+			continue
+		case strings.HasPrefix(trimmed, "**When**"):
+			current.When = strings.TrimSpace(strings.TrimPrefix(trimmed, "**When**"))
+			continue
+		case strings.HasPrefix(trimmed, "**Then**"):
+			current.Then = strings.TrimSpace(strings.TrimPrefix(trimmed, "**Then**"))
+			continue
+		case trimmed == "**Error Cases:**":
+			block = "errors"
+			continue
+		case trimmed == "**Traceability:**":
+			block = "trace"
+			continue
+		case strings.HasPrefix(trimmed, "**"):
+			block = ""
+			continue
+		}
+
+		if !strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		item := strings.TrimSpace(strings.TrimPrefix(trimmed, "- "))
+
+		switch block {
+		case "errors":
+			current.ErrorCases = append(current.ErrorCases, item)
+		case "trace":
+			switch {
+			case strings.HasPrefix(item, "Source:"):
+				current.SourceRefs = append(current.SourceRefs, strings.TrimSpace(strings.TrimPrefix(item, "Source:")))
+			case strings.HasPrefix(item, "Decision:"):
+				current.DecisionRefs = append(current.DecisionRefs, linkTexts(strings.TrimPrefix(item, "Decision:"))...)
+			}
+		}
+	}
+	flush()
+
+	return acs
+}
+
+// ParseBoundedContexts reads the bounded contexts from bounded-context-map.md
+func ParseBoundedContexts(content string) []BoundedContext {
+	var contexts []BoundedContext
+	var current *BoundedContext
+
+	flush := func() {
+		if current != nil {
+			contexts = append(contexts, *current)
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "## ") {
+			flush()
+			continue
+		}
+		if m := sectionHeadingPattern.FindStringSubmatch(trimmed); m != nil && strings.HasPrefix(m[2], "BC-") {
+			flush()
+			current = &BoundedContext{ID: m[2], Name: m[3]}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "**Core Entities:**"):
+			current.CoreEntities = parseList(strings.TrimPrefix(trimmed, "**Core Entities:**"))
+		case strings.HasPrefix(trimmed, "**Aggregates:**"):
+			current.Aggregates = parseList(strings.TrimPrefix(trimmed, "**Aggregates:**"))
+		}
+	}
+	flush()
+
+	return contexts
+}
+
+// parseList parses a list written with %v ("[A B]") or comma separated
+func parseList(s string) []string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+
+	var fields []string
+	if strings.Contains(s, ",") {
+		fields = strings.Split(s, ",")
+	} else {
+		fields = strings.Fields(s)
+	}
+
+	var result []string
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// linkTexts returns the link texts of markdown links, or the trimmed text
+// if there are none
+func linkTexts(s string) []string {
+	var refs []string
+	for _, m := range linkTextPattern.FindAllStringSubmatch(s, -1) {
+		refs = append(refs, m[1])
+	}
+	if len(refs) == 0 {
+		if s = strings.TrimSpace(s); s != "" {
+			refs = append(refs, s)
+		}
+	}
+	return refs
+}
+
+// ContextForID finds the bounded context an ID such as AC-ORD-001 belongs
+// to, by matching its abbreviation (ORD) against the context's ID, name,
+// entities and aggregates. It returns nil if no context matches.
+func ContextForID(id string, contexts []BoundedContext) *BoundedContext {
+	parts := strings.Split(id, "-")
+	if len(parts) < 3 {
+		return nil
+	}
+	abbrev := normalizeName(strings.Join(parts[1:len(parts)-1], ""))
+	if abbrev == "" {
+		return nil
+	}
+
+	for i := range contexts {
+		bc := &contexts[i]
+		names := []string{strings.TrimPrefix(bc.ID, "BC-"), bc.Name}
+		names = append(names, bc.CoreEntities...)
+		names = append(names, bc.Aggregates...)
+		for _, name := range names {
+			if n := normalizeName(name); n != "" && strings.HasPrefix(n, abbrev) {
+				return bc
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeName uppercases and drops everything but letters and digits
+func normalizeName(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,123 @@
+package spec
+
+import (
+	"testing"
+)
+
+const acDoc = `---
+title: "Acceptance Criteria"
+level: L1
+---
+
+# Acceptance Criteria
+
+---
+
+## AC-ORD-001 – Place order {#ac-ord-001}
+
+**Given** a customer with items in the cart
+**When** the customer submits the order
+**Then** the order is created
+
+**Error Cases:**
+- Empty cart is rejected
+
+**Traceability:**
+- Source: US-001
+- Decision: [AMB-ENT-001](decisions.md#amb-ent-001)
+
+---
+
+## AC-CUST-001 – Register {#ac-cust-001}
+
+**Given** a visitor
+**When** the visitor registers
+**Then** a customer account exists
+
+**Traceability:**
+- Source: US-002
+
+---
+`
+
+const bcDoc = `# Bounded Context Map
+
+## Bounded Contexts
+
+### BC-Ordering – Ordering Context {#bc-ordering}
+
+**Purpose:** Orders
+
+**Core Entities:** [Order OrderLine]
+
+**Aggregates:** [Order]
+
+---
+
+### BC-Customer – Customer Context {#bc-customer}
+
+**Core Entities:** [Customer]
+
+---
+
+## Context Relationships
+`
+
+func TestParseAcceptanceCriteria(t *testing.T) {
+	acs := ParseAcceptanceCriteria(acDoc)
+
+	if len(acs) != 2 {
+		t.Fatalf("Expected 2 ACs, got %d", len(acs))
+	}
+
+	ac := acs[0]
+	if ac.ID != "AC-ORD-001" || ac.Title != "Place order" {
+		t.Errorf("Unexpected heading fields %s / %s", ac.ID, ac.Title)
+	}
+	if ac.Given != "a customer with items in the cart" || ac.When != "the customer submits the order" || ac.Then != "the order is created" {
+		t.Errorf("Unexpected Given/When/Then: %+v", ac)
+	}
+	if len(ac.ErrorCases) != 1 || ac.ErrorCases[0] != "Empty cart is rejected" {
+		t.Errorf("Unexpected error cases %v", ac.ErrorCases)
+	}
+	if len(ac.SourceRefs) != 1 || ac.SourceRefs[0] != "US-001" {
+		t.Errorf("Unexpected source refs %v", ac.SourceRefs)
+	}
+	if len(ac.DecisionRefs) != 1 || ac.DecisionRefs[0] != "AMB-ENT-001" {
+		t.Errorf("Unexpected decision refs %v", ac.DecisionRefs)
+	}
+}
+
+func TestParseBoundedContexts(t *testing.T) {
+	contexts := ParseBoundedContexts(bcDoc)
+
+	if len(contexts) != 2 {
+		t.Fatalf("Expected 2 contexts, got %d", len(contexts))
+	}
+	if contexts[0].ID != "BC-Ordering" || contexts[0].Name != "Ordering Context" {
+		t.Errorf("Unexpected context %+v", contexts[0])
+	}
+	if len(contexts[0].CoreEntities) != 2 || contexts[0].CoreEntities[1] != "OrderLine" {
+		t.Errorf("Unexpected core entities %v", contexts[0].CoreEntities)
+	}
+	if len(contexts[0].Aggregates) != 1 {
+		t.Errorf("Unexpected aggregates %v", contexts[0].Aggregates)
+	}
+}
+
+func TestContextForID(t *testing.T) {
+	contexts := ParseBoundedContexts(bcDoc)
+
+	if bc := ContextForID("AC-ORD-001", contexts); bc == nil || bc.ID != "BC-Ordering" {
+		t.Errorf("Expected AC-ORD-001 in BC-Ordering, got %v", bc)
+	}
+	if bc := ContextForID("AC-CUST-001", contexts); bc == nil || bc.ID != "BC-Customer" {
+		t.Errorf("Expected AC-CUST-001 in BC-Customer, got %v", bc)
+	}
+	if bc := ContextForID("AC-PAY-001", contexts); bc != nil {
+		t.Errorf("Expected no context for AC-PAY-001, got %v", bc)
+	}
+	if bc := ContextForID("AC", contexts); bc != nil {
+		t.Errorf("Expected no context for malformed ID, got %v", bc)
+	}
+}
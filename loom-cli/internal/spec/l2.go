@@ -0,0 +1,39 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// L2OutputFile is the machine-readable L2 output written by derive-l2
+const L2OutputFile = "l2-output.json"
+
+// L2Output is the content of l2-output.json
+type L2Output struct {
+	TechSpecs          []formatter.TechSpec          `json:"tech_specs"`
+	InterfaceContracts []formatter.InterfaceContract `json:"interface_contracts"`
+	SharedTypes        []formatter.SharedType        `json:"shared_types"`
+	Aggregates         []formatter.AggregateDesign   `json:"aggregates"`
+	Sequences          []formatter.SequenceDesign    `json:"sequences"`
+	Tables             []formatter.DataTable         `json:"tables"`
+	Enums              []formatter.DataEnum          `json:"enums"`
+}
+
+// LoadL2Output reads l2-output.json from an L2 directory
+func LoadL2Output(l2Dir string) (*L2Output, error) {
+	path := filepath.Join(l2Dir, L2OutputFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var out L2Output
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &out, nil
+}
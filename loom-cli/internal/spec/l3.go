@@ -0,0 +1,142 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// L3OutputFile is the machine-readable L3 output written by derive-l3
+const L3OutputFile = "l3-output.json"
+
+// L3Output is the part of l3-output.json read by the generators
+type L3Output struct {
+	DomainEvents      []formatter.DomainEvent      `json:"domain_events"`
+	Commands          []formatter.Command          `json:"commands"`
+	IntegrationEvents []formatter.IntegrationEvent `json:"integration_events"`
+	FeatureTickets    []formatter.FeatureTicket    `json:"feature_tickets"`
+}
+
+// FeatureTicketsFile is the feature ticket document written by derive-l3
+const FeatureTicketsFile = "feature-tickets.md"
+
+// LoadL3Output reads l3-output.json from an L3 directory
+func LoadL3Output(l3Dir string) (*L3Output, error) {
+	path := filepath.Join(l3Dir, L3OutputFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var out L3Output
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &out, nil
+}
+
+// LoadFeatureTickets reads the feature tickets of an L3 directory from
+// l3-output.json, falling back to parsing feature-tickets.md for projects
+// derived before the tickets were recorded there
+func LoadFeatureTickets(l3Dir string) ([]formatter.FeatureTicket, error) {
+	if out, err := LoadL3Output(l3Dir); err == nil && len(out.FeatureTickets) > 0 {
+		return out.FeatureTickets, nil
+	}
+
+	path := filepath.Join(l3Dir, FeatureTicketsFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return ParseFeatureTickets(string(data)), nil
+}
+
+var (
+	ticketHeadingPattern = regexp.MustCompile(`^## ([A-Z][A-Z0-9]*(?:-[A-Z0-9]+)+):\s*(.*)$`)
+	ticketMetaPattern    = regexp.MustCompile(`\*\*(Status|Priority|Complexity):\*\*\s*([^|]*)`)
+)
+
+// ParseFeatureTickets parses feature-tickets.md
+func ParseFeatureTickets(content string) []formatter.FeatureTicket {
+	var tickets []formatter.FeatureTicket
+	var current *formatter.FeatureTicket
+	section := ""
+	var text []string
+
+	flushText := func() {
+		if current == nil {
+			return
+		}
+		value := strings.TrimSpace(strings.Join(text, "\n"))
+		switch section {
+		case "business goal":
+			current.BusinessGoal = value
+		case "user story":
+			current.UserStory = value
+		}
+		text = nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := ticketHeadingPattern.FindStringSubmatch(trimmed); m != nil {
+			flushText()
+			tickets = append(tickets, formatter.FeatureTicket{ID: m[1], Title: strings.TrimSpace(m[2])})
+			current = &tickets[len(tickets)-1]
+			section = ""
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "### "):
+			flushText()
+			section = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, "### ")))
+		case trimmed == "---":
+			flushText()
+			section = ""
+		case strings.HasPrefix(trimmed, "<!--"):
+			// LOOM markers
+		case strings.HasPrefix(trimmed, "**Status:**"):
+			for _, m := range ticketMetaPattern.FindAllStringSubmatch(trimmed, -1) {
+				value := strings.TrimSpace(m[2])
+				switch m[1] {
+				case "Status":
+					current.Status = value
+				case "Priority":
+					current.Priority = value
+				case "Complexity":
+					current.EstimatedComplexity = value
+				}
+			}
+		case strings.HasPrefix(trimmed, "- "):
+			item := strings.TrimSpace(strings.TrimPrefix(trimmed, "- "))
+			switch section {
+			case "acceptance criteria references":
+				current.AcceptanceCriteriaRefs = append(current.AcceptanceCriteriaRefs, item)
+			case "non-functional requirements":
+				current.NFR = append(current.NFR, item)
+			case "dependencies":
+				current.Dependencies = append(current.Dependencies, item)
+			case "impact areas":
+				current.ImpactAreas = append(current.ImpactAreas, item)
+			case "out of scope":
+				current.OutOfScope = append(current.OutOfScope, item)
+			default:
+				text = append(text, line)
+			}
+		default:
+			text = append(text, line)
+		}
+	}
+	flushText()
+
+	return tickets
+}
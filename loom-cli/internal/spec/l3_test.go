@@ -0,0 +1,91 @@
+package spec
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+)
+
+const sampleFeatureTickets = `---
+title: "Feature Definition Tickets"
+---
+
+# Feature Definition Tickets
+
+---
+
+## FDT-001: Checkout
+
+**Status:** draft | **Priority:** high | **Complexity:** medium
+
+### Business Goal
+Let customers pay.
+
+### User Story
+As a customer I want to pay.
+
+### Acceptance Criteria References
+- AC-ORD-001
+- AC-ORD-002
+
+### Dependencies
+- FDT-002
+
+---
+
+## FDT-002: Cart
+
+**Status:** draft | **Priority:** low | **Complexity:** low
+
+### Business Goal
+Collect items.
+
+---
+`
+
+func TestParseFeatureTickets(t *testing.T) {
+	tickets := ParseFeatureTickets(sampleFeatureTickets)
+	if len(tickets) != 2 {
+		t.Fatalf("Expected 2 tickets, got %d", len(tickets))
+	}
+
+	ft := tickets[0]
+	if ft.ID != "FDT-001" || ft.Title != "Checkout" || ft.Priority != "high" || ft.EstimatedComplexity != "medium" || ft.Status != "draft" {
+		t.Errorf("Unexpected ticket header: %+v", ft)
+	}
+	if ft.BusinessGoal != "Let customers pay." || ft.UserStory != "As a customer I want to pay." {
+		t.Errorf("Unexpected text sections: %q %q", ft.BusinessGoal, ft.UserStory)
+	}
+	if !reflect.DeepEqual(ft.AcceptanceCriteriaRefs, []string{"AC-ORD-001", "AC-ORD-002"}) || !reflect.DeepEqual(ft.Dependencies, []string{"FDT-002"}) {
+		t.Errorf("Unexpected lists: %v %v", ft.AcceptanceCriteriaRefs, ft.Dependencies)
+	}
+}
+
+func TestParseFeatureTickets_Marked(t *testing.T) {
+	marked := derivation.MarkSections(sampleFeatureTickets)
+	if marked == sampleFeatureTickets {
+		t.Fatal("Expected the tickets to be marked")
+	}
+	if got, want := ParseFeatureTickets(marked), ParseFeatureTickets(sampleFeatureTickets); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected LOOM markers to be ignored\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestLoadFeatureTickets_PrefersJSON(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, FeatureTicketsFile), []byte(sampleFeatureTickets), 0644)
+
+	tickets, err := LoadFeatureTickets(dir)
+	if err != nil || len(tickets) != 2 {
+		t.Fatalf("Expected markdown fallback, got %d tickets, %v", len(tickets), err)
+	}
+
+	os.WriteFile(filepath.Join(dir, L3OutputFile), []byte(`{"feature_tickets": [{"id": "FDT-009", "title": "From JSON"}]}`), 0644)
+	tickets, err = LoadFeatureTickets(dir)
+	if err != nil || len(tickets) != 1 || tickets[0].ID != "FDT-009" {
+		t.Errorf("Expected l3-output.json tickets, got %+v, %v", tickets, err)
+	}
+}
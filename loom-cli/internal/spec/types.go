@@ -0,0 +1,117 @@
+package spec
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TypeKind classifies a spec type
+type TypeKind int
+
+const (
+	// TypeNamed is a plain or declared type; Name holds it
+	TypeNamed TypeKind = iota
+
+	// TypeNullable is "X?", "X | null" or "Optional<X>"; Elem is X
+	TypeNullable
+
+	// TypeList is "X[]" or "List<X>"; Elem is X
+	TypeList
+
+	// TypeMap is "Map<K, V>"; Key is K and Elem is V
+	TypeMap
+
+	// TypeUnion is "A | B"; Alternatives holds both
+	TypeUnion
+
+	// TypeEnum is "enum(a, b)"; Values holds the values
+	TypeEnum
+
+	// TypeGeneric is any other generic, such as Result<X>
+	TypeGeneric
+)
+
+// Type is a spec type as written by the model in L2/L3 documents
+type Type struct {
+	Kind TypeKind
+
+	// Raw is the type as written, trimmed
+	Raw string
+
+	// Name is the base name of a named type, without arguments:
+	// varchar(255) has the name varchar
+	Name string
+
+	Elem         *Type
+	Key          *Type
+	Alternatives []*Type
+	Values       []string
+}
+
+var (
+	genericTypePattern = regexp.MustCompile(`^(\w+)\s*<\s*(.+)\s*>$`)
+	enumTypePattern    = regexp.MustCompile(`(?i)^enum\s*[\(\[](.*)[\)\]]$`)
+)
+
+// ParseType parses a spec type. It returns nil for an empty type.
+func ParseType(s string) *Type {
+	t := strings.TrimSpace(s)
+	if t == "" {
+		return nil
+	}
+
+	// Nullable: "X?", "X | null", "Optional<X>"
+	if strings.HasSuffix(t, "?") {
+		return &Type{Kind: TypeNullable, Raw: t, Elem: ParseType(strings.TrimSuffix(t, "?"))}
+	}
+	if parts := strings.Split(t, "|"); len(parts) == 2 {
+		a, b := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if isNullType(b) {
+			return &Type{Kind: TypeNullable, Raw: t, Elem: ParseType(a)}
+		}
+		if isNullType(a) {
+			return &Type{Kind: TypeNullable, Raw: t, Elem: ParseType(b)}
+		}
+		return &Type{Kind: TypeUnion, Raw: t, Alternatives: []*Type{ParseType(a), ParseType(b)}}
+	}
+
+	if e := enumTypePattern.FindStringSubmatch(t); e != nil {
+		enum := &Type{Kind: TypeEnum, Raw: t}
+		for _, v := range strings.Split(e[1], ",") {
+			if v = strings.Trim(strings.TrimSpace(v), `'"`); v != "" {
+				enum.Values = append(enum.Values, v)
+			}
+		}
+		return enum
+	}
+
+	// Collections: "X[]", "List<X>", "Map<K, V>"
+	if strings.HasSuffix(t, "[]") {
+		return &Type{Kind: TypeList, Raw: t, Elem: ParseType(strings.TrimSuffix(t, "[]"))}
+	}
+	if g := genericTypePattern.FindStringSubmatch(t); g != nil {
+		switch strings.ToLower(g[1]) {
+		case "list", "array", "set", "collection", "seq":
+			return &Type{Kind: TypeList, Raw: t, Elem: ParseType(g[2])}
+		case "optional", "maybe", "nullable":
+			return &Type{Kind: TypeNullable, Raw: t, Elem: ParseType(g[2])}
+		case "map", "dict", "record":
+			if kv := strings.SplitN(g[2], ",", 2); len(kv) == 2 {
+				return &Type{Kind: TypeMap, Raw: t, Key: ParseType(kv[0]), Elem: ParseType(kv[1])}
+			}
+		}
+		return &Type{Kind: TypeGeneric, Raw: t}
+	}
+
+	// varchar(255) / decimal(10,2)
+	name := t
+	if i := strings.Index(name, "("); i > 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+	return &Type{Kind: TypeNamed, Raw: t, Name: name}
+}
+
+// isNullType reports whether a union member stands for "no value"
+func isNullType(t string) bool {
+	return strings.EqualFold(t, "null") || strings.EqualFold(t, "nil") || strings.EqualFold(t, "undefined")
+}
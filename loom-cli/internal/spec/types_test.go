@@ -0,0 +1,43 @@
+package spec
+
+import "testing"
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		in   string
+		kind TypeKind
+		elem string
+	}{
+		{"Money?", TypeNullable, "Money"},
+		{"string | null", TypeNullable, "string"},
+		{"Address | undefined", TypeNullable, "Address"},
+		{"nil | int", TypeNullable, "int"},
+		{"Optional<Date>", TypeNullable, "Date"},
+		{"OrderLine[]", TypeList, "OrderLine"},
+		{"List<uuid>", TypeList, "uuid"},
+		{"Map<string, int>", TypeMap, "int"},
+		{"Card | Invoice", TypeUnion, ""},
+		{"Result<Order>", TypeGeneric, ""},
+		{"varchar(255)", TypeNamed, ""},
+	}
+	for _, tt := range tests {
+		got := ParseType(tt.in)
+		if got.Kind != tt.kind {
+			t.Errorf("ParseType(%q).Kind = %d, want %d", tt.in, got.Kind, tt.kind)
+			continue
+		}
+		if tt.elem != "" && (got.Elem == nil || got.Elem.Name != tt.elem) {
+			t.Errorf("ParseType(%q).Elem = %+v, want %s", tt.in, got.Elem, tt.elem)
+		}
+	}
+
+	if got := ParseType("varchar(255)"); got.Name != "varchar" || got.Raw != "varchar(255)" {
+		t.Errorf("Expected the base name without arguments, got %+v", got)
+	}
+	if got := ParseType("enum('card', \"invoice\")"); got.Kind != TypeEnum || len(got.Values) != 2 || got.Values[0] != "card" {
+		t.Errorf("Expected enum values, got %+v", got)
+	}
+	if ParseType("  ") != nil {
+		t.Error("Expected nil for an empty type")
+	}
+}
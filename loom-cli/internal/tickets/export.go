@@ -0,0 +1,234 @@
+package tickets
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// Export formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// ExportOptions configures an export
+type ExportOptions struct {
+	Tracker string
+	Format  string
+	Project string // Jira project key
+	TeamID  string // Linear team ID
+}
+
+// ExportedIssue is one entry of a JSON export: the tracker's native create
+// payload plus the ticket links the tracker cannot express at import time
+type ExportedIssue struct {
+	TicketID  string      `json:"ticket_id"`
+	DependsOn []string    `json:"depends_on,omitempty"`
+	Payload   interface{} `json:"payload"`
+}
+
+// Export renders tickets as an import file for the tracker
+func Export(tickets []formatter.FeatureTicket, opts ExportOptions) ([]byte, error) {
+	if !ValidTracker(opts.Tracker) {
+		return nil, fmt.Errorf("unsupported tracker: %s (supported: %s)", opts.Tracker, strings.Join(Trackers, ", "))
+	}
+
+	issues := make([]Issue, 0, len(tickets))
+	for _, t := range tickets {
+		issues = append(issues, BuildIssue(t, nil))
+	}
+
+	switch opts.Format {
+	case FormatJSON:
+		return exportJSON(issues, opts)
+	case FormatCSV:
+		return exportCSV(issues, opts)
+	}
+	return nil, fmt.Errorf("unsupported format: %s (supported: csv, json)", opts.Format)
+}
+
+func exportJSON(issues []Issue, opts ExportOptions) ([]byte, error) {
+	out := struct {
+		Tracker string          `json:"tracker"`
+		Issues  []ExportedIssue `json:"issues"`
+	}{Tracker: opts.Tracker, Issues: []ExportedIssue{}}
+
+	for _, issue := range issues {
+		var payload interface{}
+		switch opts.Tracker {
+		case TrackerGitHub:
+			payload = githubPayload(issue)
+		case TrackerJira:
+			payload = map[string]interface{}{"fields": jiraFields(issue, opts.Project, true)}
+		case TrackerLinear:
+			payload = linearInput(issue, opts.TeamID)
+		}
+		out.Issues = append(out.Issues, ExportedIssue{TicketID: issue.TicketID, DependsOn: issue.Dependencies, Payload: payload})
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal export: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func exportCSV(issues []Issue, opts ExportOptions) ([]byte, error) {
+	var rows [][]string
+	switch opts.Tracker {
+	case TrackerGitHub:
+		rows = append(rows, []string{"ID", "Title", "Body", "Labels", "Depends On"})
+		for _, issue := range issues {
+			rows = append(rows, []string{issue.TicketID, issue.Title, issue.Body, strings.Join(issue.Labels, ","), strings.Join(issue.Dependencies, ",")})
+		}
+
+	case TrackerJira:
+		// Jira's CSV importer takes multi-value fields as repeated columns
+		// and links issues of the same import through their Issue Id
+		maxLabels, maxLinks := 0, 0
+		for _, issue := range issues {
+			maxLabels = max(maxLabels, len(issue.Labels))
+			maxLinks = max(maxLinks, len(issue.Dependencies))
+		}
+		header := []string{"Issue Id", "Summary", "Description", "Issue Type", "Priority", "Story Points"}
+		header = append(header, repeat("Labels", maxLabels)...)
+		header = append(header, repeat("Inward issue link (Blocks)", maxLinks)...)
+		rows = append(rows, header)
+
+		issueIDs := make(map[string]string, len(issues))
+		for i, issue := range issues {
+			issueIDs[issue.TicketID] = strconv.Itoa(i + 1)
+		}
+		for i, issue := range issues {
+			row := []string{strconv.Itoa(i + 1), issue.Title, issue.Body, "Story", jiraPriority(issue.Priority), estimateCell(issue.Estimate)}
+			row = append(row, pad(issue.Labels, maxLabels)...)
+			var links []string
+			for _, dep := range issue.Dependencies {
+				if id, ok := issueIDs[dep]; ok {
+					links = append(links, id)
+				}
+			}
+			row = append(row, pad(links, maxLinks)...)
+			rows = append(rows, row)
+		}
+
+	case TrackerLinear:
+		rows = append(rows, []string{"Title", "Description", "Priority", "Estimate", "Labels"})
+		for _, issue := range issues {
+			rows = append(rows, []string{issue.Title, issue.Body, linearPriorityName(issue.Priority), estimateCell(issue.Estimate), strings.Join(issue.Labels, ",")})
+		}
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// githubPayload is the body of POST /repos/{owner}/{repo}/issues
+func githubPayload(issue Issue) map[string]interface{} {
+	return map[string]interface{}{
+		"title":  issue.Title,
+		"body":   issue.Body,
+		"labels": issue.Labels,
+	}
+}
+
+// jiraFields are the fields of a Jira create or edit request. Project and
+// issue type are only sent on create.
+func jiraFields(issue Issue, project string, create bool) map[string]interface{} {
+	fields := map[string]interface{}{
+		"summary":     issue.Title,
+		"description": issue.Body,
+		"labels":      issue.Labels,
+	}
+	if p := jiraPriority(issue.Priority); p != "" {
+		fields["priority"] = map[string]string{"name": p}
+	}
+	if create {
+		fields["project"] = map[string]string{"key": project}
+		fields["issuetype"] = map[string]string{"name": "Story"}
+	}
+	return fields
+}
+
+// linearInput is the IssueCreateInput/IssueUpdateInput of Linear's API
+func linearInput(issue Issue, teamID string) map[string]interface{} {
+	input := map[string]interface{}{
+		"title":       issue.Title,
+		"description": issue.Body,
+		"priority":    linearPriority(issue.Priority),
+	}
+	if issue.Estimate > 0 {
+		input["estimate"] = issue.Estimate
+	}
+	if teamID != "" {
+		input["teamId"] = teamID
+	}
+	return input
+}
+
+func jiraPriority(p string) string {
+	switch p {
+	case PriorityUrgent:
+		return "Highest"
+	case PriorityHigh:
+		return "High"
+	case PriorityMedium:
+		return "Medium"
+	case PriorityLow:
+		return "Low"
+	}
+	return ""
+}
+
+// linearPriority returns Linear's numeric priority (0 = none, 1 = urgent)
+func linearPriority(p string) int {
+	switch p {
+	case PriorityUrgent:
+		return 1
+	case PriorityHigh:
+		return 2
+	case PriorityMedium:
+		return 3
+	case PriorityLow:
+		return 4
+	}
+	return 0
+}
+
+func linearPriorityName(p string) string {
+	if p == PriorityNone {
+		return "No priority"
+	}
+	return strings.ToUpper(p[:1]) + p[1:]
+}
+
+func estimateCell(estimate int) string {
+	if estimate == 0 {
+		return ""
+	}
+	return strconv.Itoa(estimate)
+}
+
+func repeat(s string, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = s
+	}
+	return out
+}
+
+func pad(values []string, n int) []string {
+	out := make([]string, n)
+	copy(out, values)
+	return out
+}
@@ -0,0 +1,162 @@
+// Package tickets exports feature tickets to issue trackers, as import files
+// or by syncing them through the tracker's API.
+package tickets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// Supported trackers
+const (
+	TrackerGitHub = "github"
+	TrackerJira   = "jira"
+	TrackerLinear = "linear"
+)
+
+// Trackers lists the supported tracker names
+var Trackers = []string{TrackerGitHub, TrackerJira, TrackerLinear}
+
+// Normalized priorities
+const (
+	PriorityUrgent = "urgent"
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+	PriorityNone   = ""
+)
+
+// Issue is the tracker-neutral form of a feature ticket
+type Issue struct {
+	TicketID     string
+	Title        string
+	Body         string
+	Labels       []string
+	Priority     string   // normalized priority
+	Estimate     int      // story points derived from the complexity, 0 if unknown
+	Dependencies []string // ticket IDs this ticket depends on
+}
+
+// ValidTracker reports whether name is a supported tracker
+func ValidTracker(name string) bool {
+	for _, t := range Trackers {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildIssue converts a feature ticket to an issue. refs maps ticket IDs
+// to their tracker references (e.g. #12); dependencies without one are
+// referenced by ticket ID.
+func BuildIssue(t formatter.FeatureTicket, refs map[string]string) Issue {
+	issue := Issue{
+		TicketID:     t.ID,
+		Title:        fmt.Sprintf("[%s] %s", t.ID, t.Title),
+		Priority:     NormalizePriority(t.Priority),
+		Estimate:     ComplexityEstimate(t.EstimatedComplexity),
+		Dependencies: t.Dependencies,
+		Labels:       []string{"loom"},
+	}
+	if c := labelValue(t.EstimatedComplexity); c != "" {
+		issue.Labels = append(issue.Labels, "complexity:"+c)
+	}
+	for _, area := range t.ImpactAreas {
+		if a := labelValue(area); a != "" {
+			issue.Labels = append(issue.Labels, "area:"+a)
+		}
+	}
+
+	var sb strings.Builder
+	if t.BusinessGoal != "" {
+		fmt.Fprintf(&sb, "## Business Goal\n\n%s\n\n", t.BusinessGoal)
+	}
+	if t.UserStory != "" {
+		fmt.Fprintf(&sb, "## User Story\n\n%s\n\n", t.UserStory)
+	}
+	writeList(&sb, "Acceptance Criteria", t.AcceptanceCriteriaRefs)
+	writeList(&sb, "Non-Functional Requirements", t.NFR)
+	if len(t.Dependencies) > 0 {
+		deps := make([]string, 0, len(t.Dependencies))
+		for _, dep := range t.Dependencies {
+			if ref, ok := refs[dep]; ok && ref != "" {
+				deps = append(deps, fmt.Sprintf("%s (%s)", ref, dep))
+			} else {
+				deps = append(deps, dep)
+			}
+		}
+		writeList(&sb, "Depends On", deps)
+	}
+	writeList(&sb, "Impact Areas", t.ImpactAreas)
+	writeList(&sb, "Out of Scope", t.OutOfScope)
+	fmt.Fprintf(&sb, "<!-- loom-ticket: %s -->\n", t.ID)
+	issue.Body = sb.String()
+
+	return issue
+}
+
+// Hash returns a hash of the exported content, used to skip unchanged
+// issues on re-sync
+func (i Issue) Hash() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%d", i.Title, i.Body, strings.Join(i.Labels, ","), i.Priority, i.Estimate)
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// NormalizePriority maps spec priorities (high, P1, must, ...) to the
+// normalized priorities
+func NormalizePriority(p string) string {
+	switch strings.ToLower(strings.TrimSpace(p)) {
+	case "critical", "urgent", "blocker", "highest", "p0":
+		return PriorityUrgent
+	case "high", "p1", "must", "must have":
+		return PriorityHigh
+	case "medium", "normal", "p2", "should", "should have":
+		return PriorityMedium
+	case "low", "lowest", "minor", "p3", "p4", "could", "could have":
+		return PriorityLow
+	}
+	return PriorityNone
+}
+
+// ComplexityEstimate maps an estimated complexity to story points
+func ComplexityEstimate(c string) int {
+	switch strings.ToLower(strings.TrimSpace(c)) {
+	case "trivial", "xs":
+		return 1
+	case "low", "small", "s":
+		return 2
+	case "medium", "m":
+		return 3
+	case "high", "large", "l":
+		return 5
+	case "very_high", "very high", "xl":
+		return 8
+	}
+	return 0
+}
+
+func writeList(sb *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "## %s\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(sb, "- %s\n", item)
+	}
+	sb.WriteString("\n")
+}
+
+// labelValue turns free text into a label-safe value
+func labelValue(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}), "-")
+	return s
+}
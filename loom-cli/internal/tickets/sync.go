@@ -0,0 +1,127 @@
+package tickets
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+// Remote identifies an issue in a tracker
+type Remote struct {
+	ExternalID string // ID used by the API to update the issue
+	Key        string // human-facing reference, e.g. #12 or PROJ-3
+	URL        string
+}
+
+// Tracker creates and updates issues through a tracker's API
+type Tracker interface {
+	Name() string
+	Create(ctx context.Context, issue Issue) (*Remote, error)
+	Update(ctx context.Context, externalID string, issue Issue) error
+}
+
+// SyncResult lists the ticket IDs by what the sync did with them
+type SyncResult struct {
+	Created   []string
+	Updated   []string
+	Unchanged []string
+}
+
+// Sync creates issues for tickets not exported yet and updates the ones
+// whose content changed since the last sync. Exported issues are recorded
+// in state as soon as they are created, so a failed sync can be re-run
+// without duplicating issues; the caller saves state even on error.
+func Sync(ctx context.Context, tracker Tracker, tickets []formatter.FeatureTicket, state *derivation.DerivationState, dryRun bool) (*SyncResult, error) {
+	name := tracker.Name()
+	result := &SyncResult{}
+	changed := make(map[string]bool)
+
+	refs := func() map[string]string {
+		m := make(map[string]string)
+		for _, t := range tickets {
+			if tt := state.GetTrackerTicket(name, t.ID); tt != nil {
+				m[t.ID] = tt.Key
+			}
+		}
+		return m
+	}
+
+	// First pass: create missing issues, update changed ones
+	for _, t := range tickets {
+		issue := BuildIssue(t, refs())
+		existing := state.GetTrackerTicket(name, t.ID)
+
+		switch {
+		case existing == nil:
+			if dryRun {
+				result.Created = append(result.Created, t.ID)
+				continue
+			}
+			remote, err := tracker.Create(ctx, issue)
+			if err != nil {
+				return result, fmt.Errorf("failed to create issue for %s: %w", t.ID, err)
+			}
+			state.SetTrackerTicket(name, t.ID, &derivation.TrackerTicket{
+				ExternalID: remote.ExternalID,
+				Key:        remote.Key,
+				URL:        remote.URL,
+				Hash:       issue.Hash(),
+				SyncedAt:   time.Now(),
+			})
+			result.Created = append(result.Created, t.ID)
+			changed[t.ID] = true
+
+		case existing.Hash != issue.Hash():
+			if !dryRun {
+				if err := tracker.Update(ctx, existing.ExternalID, issue); err != nil {
+					return result, fmt.Errorf("failed to update issue %s for %s: %w", existing.Key, t.ID, err)
+				}
+				existing.Hash = issue.Hash()
+				existing.SyncedAt = time.Now()
+			}
+			result.Updated = append(result.Updated, t.ID)
+			changed[t.ID] = true
+		}
+	}
+
+	// Second pass: tickets created before their dependencies existed now
+	// get the dependencies' tracker references
+	if !dryRun {
+		for _, t := range tickets {
+			existing := state.GetTrackerTicket(name, t.ID)
+			issue := BuildIssue(t, refs())
+			if existing.Hash == issue.Hash() {
+				continue
+			}
+			if err := tracker.Update(ctx, existing.ExternalID, issue); err != nil {
+				return result, fmt.Errorf("failed to link dependencies of %s: %w", t.ID, err)
+			}
+			existing.Hash = issue.Hash()
+			existing.SyncedAt = time.Now()
+			if !changed[t.ID] {
+				result.Updated = append(result.Updated, t.ID)
+				changed[t.ID] = true
+			}
+		}
+	}
+
+	for _, t := range tickets {
+		if !changed[t.ID] && !contains(result.Created, t.ID) {
+			result.Unchanged = append(result.Unchanged, t.ID)
+		}
+	}
+
+	return result, nil
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package tickets
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ikadar/loom-cli/internal/derivation"
+	"github.com/ikadar/loom-cli/internal/formatter"
+)
+
+func testTickets() []formatter.FeatureTicket {
+	return []formatter.FeatureTicket{
+		{
+			ID:                     "FDT-001",
+			Title:                  "Checkout",
+			BusinessGoal:           "Let customers pay",
+			AcceptanceCriteriaRefs: []string{"AC-ORD-001"},
+			Dependencies:           []string{"FDT-002"},
+			Priority:               "high",
+			EstimatedComplexity:    "medium",
+		},
+		{ID: "FDT-002", Title: "Cart", Priority: "low", EstimatedComplexity: "very_high"},
+	}
+}
+
+// fakeGitHub is a local stand-in for the GitHub issues API
+type fakeGitHub struct {
+	mu      sync.Mutex
+	issues  map[int]map[string]interface{}
+	creates int
+	updates int
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/shop/issues":
+		f.creates++
+		n := len(f.issues) + 1
+		f.issues[n] = body
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"number": %d, "html_url": "https://github.test/acme/shop/issues/%d"}`, n, n)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/repos/acme/shop/issues/"):
+		var n int
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/repos/acme/shop/issues/"), "%d", &n)
+		if _, ok := f.issues[n]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.updates++
+		f.issues[n] = body
+		w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSync_GitHubIdempotent(t *testing.T) {
+	fake := &fakeGitHub{issues: make(map[int]map[string]interface{})}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	tracker, err := NewTracker(TrackerConfig{Tracker: TrackerGitHub, BaseURL: srv.URL, Token: "secret", Repo: "acme/shop"})
+	if err != nil {
+		t.Fatalf("NewTracker failed: %v", err)
+	}
+	state := derivation.NewStateManager(t.TempDir()).NewState()
+	fts := testTickets()
+
+	result, err := Sync(context.Background(), tracker, fts, state, false)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(result.Created) != 2 || fake.creates != 2 {
+		t.Fatalf("expected 2 created issues, got %+v (%d requests)", result, fake.creates)
+	}
+
+	// FDT-001 was created before FDT-002 existed; its body must now link #2
+	body := fake.issues[1]["body"].(string)
+	if !strings.Contains(body, "- #2 (FDT-002)") {
+		t.Errorf("dependency not linked:\n%s", body)
+	}
+	if got := state.GetTrackerTicket(TrackerGitHub, "FDT-002"); got == nil || got.Key != "#2" {
+		t.Errorf("unexpected tracked ticket: %+v", got)
+	}
+
+	// Re-sync: nothing to do
+	creates, updates := fake.creates, fake.updates
+	result, err = Sync(context.Background(), tracker, fts, state, false)
+	if err != nil {
+		t.Fatalf("re-sync failed: %v", err)
+	}
+	if fake.creates != creates || fake.updates != updates || len(result.Unchanged) != 2 {
+		t.Errorf("re-sync must not touch the tracker: %+v", result)
+	}
+
+	// A changed ticket is updated, not duplicated
+	fts[1].Title = "Shopping cart"
+	result, err = Sync(context.Background(), tracker, fts, state, false)
+	if err != nil {
+		t.Fatalf("sync after change failed: %v", err)
+	}
+	if fake.creates != creates || len(result.Updated) != 1 || result.Updated[0] != "FDT-002" {
+		t.Errorf("expected one update of FDT-002, got %+v (%d creates)", result, fake.creates)
+	}
+	if fake.issues[2]["title"] != "[FDT-002] Shopping cart" {
+		t.Errorf("issue not updated: %v", fake.issues[2]["title"])
+	}
+}
+
+func TestSync_DryRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("dry run must not call the API: %s %s", r.Method, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	tracker, _ := NewTracker(TrackerConfig{Tracker: TrackerGitHub, BaseURL: srv.URL, Repo: "acme/shop"})
+	state := derivation.NewStateManager(t.TempDir()).NewState()
+
+	result, err := Sync(context.Background(), tracker, testTickets(), state, true)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(result.Created) != 2 || state.GetTrackerTicket(TrackerGitHub, "FDT-001") != nil {
+		t.Errorf("unexpected dry run result: %+v", result)
+	}
+}
+
+func TestSync_JiraCreate(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "me" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"id": "10001", "key": "SHOP-7"}`))
+	}))
+	defer srv.Close()
+
+	tracker, err := NewTracker(TrackerConfig{Tracker: TrackerJira, BaseURL: srv.URL, Token: "secret", User: "me", Project: "SHOP"})
+	if err != nil {
+		t.Fatalf("NewTracker failed: %v", err)
+	}
+	remote, err := tracker.Create(context.Background(), BuildIssue(testTickets()[0], nil))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if remote.Key != "SHOP-7" || remote.URL != srv.URL+"/browse/SHOP-7" {
+		t.Errorf("unexpected remote: %+v", remote)
+	}
+	fields := got["fields"].(map[string]interface{})
+	if fields["project"].(map[string]interface{})["key"] != "SHOP" || fields["priority"].(map[string]interface{})["name"] != "High" {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+}
+
+func TestExport_JiraCSVLinks(t *testing.T) {
+	data, err := Export(testTickets(), ExportOptions{Tracker: TrackerJira, Format: FormatCSV})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	header := rows[0]
+	if header[len(header)-1] != "Inward issue link (Blocks)" {
+		t.Fatalf("missing link column: %v", header)
+	}
+	if rows[1][0] != "1" || rows[1][len(header)-1] != "2" {
+		t.Errorf("FDT-001 should be blocked by issue 2: %v", rows[1])
+	}
+	if rows[2][4] != "Low" || rows[2][5] != "8" {
+		t.Errorf("unexpected priority/estimate: %v", rows[2])
+	}
+}
+
+func TestExport_JSONPayloads(t *testing.T) {
+	data, err := Export(testTickets(), ExportOptions{Tracker: TrackerLinear, Format: FormatJSON, TeamID: "team-1"})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	var out struct {
+		Tracker string `json:"tracker"`
+		Issues  []struct {
+			TicketID  string                 `json:"ticket_id"`
+			DependsOn []string               `json:"depends_on"`
+			Payload   map[string]interface{} `json:"payload"`
+		} `json:"issues"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if out.Tracker != "linear" || len(out.Issues) != 2 {
+		t.Fatalf("unexpected export: %+v", out)
+	}
+	first := out.Issues[0]
+	if first.TicketID != "FDT-001" || len(first.DependsOn) != 1 || first.Payload["teamId"] != "team-1" || first.Payload["priority"] != float64(2) {
+		t.Errorf("unexpected issue: %+v", first)
+	}
+
+	if _, err := Export(testTickets(), ExportOptions{Tracker: "trello", Format: FormatJSON}); err == nil {
+		t.Errorf("expected error for unsupported tracker")
+	}
+}
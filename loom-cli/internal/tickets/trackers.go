@@ -0,0 +1,271 @@
+package tickets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default API endpoints
+const (
+	DefaultGitHubURL = "https://api.github.com"
+	DefaultLinearURL = "https://api.linear.app"
+)
+
+// TrackerConfig configures an API tracker
+type TrackerConfig struct {
+	Tracker string
+	BaseURL string // API base URL (required for Jira)
+	Token   string
+	User    string // Jira user for basic auth; bearer auth when empty
+	Repo    string // GitHub owner/repo
+	Project string // Jira project key
+	TeamID  string // Linear team ID
+
+	// HTTPClient overrides the default client
+	HTTPClient *http.Client
+}
+
+// NewTracker creates the API tracker described by cfg
+func NewTracker(cfg TrackerConfig) (Tracker, error) {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	api := &apiClient{client: client, baseURL: strings.TrimRight(cfg.BaseURL, "/")}
+
+	switch cfg.Tracker {
+	case TrackerGitHub:
+		if cfg.Repo == "" || !strings.Contains(cfg.Repo, "/") {
+			return nil, fmt.Errorf("github sync requires --repo owner/repo")
+		}
+		if api.baseURL == "" {
+			api.baseURL = DefaultGitHubURL
+		}
+		api.auth = "Bearer " + cfg.Token
+		return &githubTracker{api: api, repo: cfg.Repo}, nil
+
+	case TrackerJira:
+		if api.baseURL == "" {
+			return nil, fmt.Errorf("jira sync requires --url")
+		}
+		if cfg.Project == "" {
+			return nil, fmt.Errorf("jira sync requires --project")
+		}
+		api.auth = "Bearer " + cfg.Token
+		if cfg.User != "" {
+			api.basicUser, api.basicPass = cfg.User, cfg.Token
+		}
+		return &jiraTracker{api: api, project: cfg.Project}, nil
+
+	case TrackerLinear:
+		if cfg.TeamID == "" {
+			return nil, fmt.Errorf("linear sync requires --team")
+		}
+		if api.baseURL == "" {
+			api.baseURL = DefaultLinearURL
+		}
+		api.auth = cfg.Token // Linear API keys are sent without a scheme
+		return &linearTracker{api: api, teamID: cfg.TeamID}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported tracker: %s (supported: %s)", cfg.Tracker, strings.Join(Trackers, ", "))
+}
+
+// =============================================================================
+// GitHub
+// =============================================================================
+
+type githubTracker struct {
+	api  *apiClient
+	repo string
+}
+
+func (g *githubTracker) Name() string { return TrackerGitHub }
+
+func (g *githubTracker) Create(ctx context.Context, issue Issue) (*Remote, error) {
+	var resp struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := g.api.do(ctx, http.MethodPost, "/repos/"+g.repo+"/issues", githubPayload(issue), &resp); err != nil {
+		return nil, err
+	}
+	n := strconv.Itoa(resp.Number)
+	return &Remote{ExternalID: n, Key: "#" + n, URL: resp.HTMLURL}, nil
+}
+
+func (g *githubTracker) Update(ctx context.Context, externalID string, issue Issue) error {
+	return g.api.do(ctx, http.MethodPatch, "/repos/"+g.repo+"/issues/"+externalID, githubPayload(issue), nil)
+}
+
+// =============================================================================
+// Jira
+// =============================================================================
+
+type jiraTracker struct {
+	api     *apiClient
+	project string
+}
+
+func (j *jiraTracker) Name() string { return TrackerJira }
+
+func (j *jiraTracker) Create(ctx context.Context, issue Issue) (*Remote, error) {
+	var resp struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	body := map[string]interface{}{"fields": jiraFields(issue, j.project, true)}
+	if err := j.api.do(ctx, http.MethodPost, "/rest/api/2/issue", body, &resp); err != nil {
+		return nil, err
+	}
+	return &Remote{ExternalID: resp.Key, Key: resp.Key, URL: j.api.baseURL + "/browse/" + resp.Key}, nil
+}
+
+func (j *jiraTracker) Update(ctx context.Context, externalID string, issue Issue) error {
+	body := map[string]interface{}{"fields": jiraFields(issue, j.project, false)}
+	return j.api.do(ctx, http.MethodPut, "/rest/api/2/issue/"+externalID, body, nil)
+}
+
+// =============================================================================
+// Linear
+// =============================================================================
+
+type linearTracker struct {
+	api    *apiClient
+	teamID string
+}
+
+const (
+	linearCreateMutation = `mutation IssueCreate($input: IssueCreateInput!) { issueCreate(input: $input) { success issue { id identifier url } } }`
+	linearUpdateMutation = `mutation IssueUpdate($id: String!, $input: IssueUpdateInput!) { issueUpdate(id: $id, input: $input) { success } }`
+)
+
+func (l *linearTracker) Name() string { return TrackerLinear }
+
+func (l *linearTracker) Create(ctx context.Context, issue Issue) (*Remote, error) {
+	var resp struct {
+		Data struct {
+			IssueCreate struct {
+				Success bool `json:"success"`
+				Issue   struct {
+					ID         string `json:"id"`
+					Identifier string `json:"identifier"`
+					URL        string `json:"url"`
+				} `json:"issue"`
+			} `json:"issueCreate"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	req := map[string]interface{}{
+		"query":     linearCreateMutation,
+		"variables": map[string]interface{}{"input": linearInput(issue, l.teamID)},
+	}
+	if err := l.api.do(ctx, http.MethodPost, "/graphql", req, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		return nil, fmt.Errorf("linear: %s", resp.Errors[0].Message)
+	}
+	if !resp.Data.IssueCreate.Success {
+		return nil, fmt.Errorf("linear: issueCreate was not successful")
+	}
+	created := resp.Data.IssueCreate.Issue
+	return &Remote{ExternalID: created.ID, Key: created.Identifier, URL: created.URL}, nil
+}
+
+func (l *linearTracker) Update(ctx context.Context, externalID string, issue Issue) error {
+	var resp struct {
+		Data struct {
+			IssueUpdate struct {
+				Success bool `json:"success"`
+			} `json:"issueUpdate"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	input := linearInput(issue, "")
+	req := map[string]interface{}{
+		"query":     linearUpdateMutation,
+		"variables": map[string]interface{}{"id": externalID, "input": input},
+	}
+	if err := l.api.do(ctx, http.MethodPost, "/graphql", req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("linear: %s", resp.Errors[0].Message)
+	}
+	if !resp.Data.IssueUpdate.Success {
+		return fmt.Errorf("linear: issueUpdate was not successful")
+	}
+	return nil
+}
+
+// =============================================================================
+// HTTP
+// =============================================================================
+
+type apiClient struct {
+	client    *http.Client
+	baseURL   string
+	auth      string
+	basicUser string
+	basicPass string
+}
+
+// do sends a JSON request and decodes the JSON response into out
+func (c *apiClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.basicUser != "" {
+		req.SetBasicAuth(c.basicUser, c.basicPass)
+	} else if strings.TrimSpace(strings.TrimPrefix(c.auth, "Bearer")) != "" {
+		req.Header.Set("Authorization", c.auth)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := strings.TrimSpace(string(data))
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, msg)
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	return nil
+}
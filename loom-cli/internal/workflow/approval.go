@@ -19,8 +19,34 @@ const (
 	ActionQuit
 )
 
+// FileStore reads and writes the files under approval. A derivation
+// transaction implements it to stage files until the run commits.
+type FileStore interface {
+	ReadFile(path string) ([]byte, error)
+	WriteFile(path string, data []byte) error
+	Discard(path string) error
+}
+
+// osFiles writes files directly
+type osFiles struct{}
+
+func (osFiles) ReadFile(path string) ([]byte, error) { return os.ReadFile(path) }
+
+func (osFiles) WriteFile(path string, data []byte) error { return os.WriteFile(path, data, 0644) }
+
+func (osFiles) Discard(path string) error { return os.Remove(path) }
+
+// filesOrOS returns files, or a store writing directly if it is nil
+func filesOrOS(files FileStore) FileStore {
+	if files == nil {
+		return osFiles{}
+	}
+	return files
+}
+
 // WriteConfig configures a file write with optional approval
 type WriteConfig struct {
+	Files     FileStore // nil writes directly
 	Path      string
 	Content   string
 	PhaseName string
@@ -40,8 +66,10 @@ type WriteResult struct {
 // WriteWithApproval writes a file with optional interactive approval
 // Returns: result, needsRegenerate, error
 func WriteWithApproval(cfg WriteConfig, interactive bool) (*WriteResult, bool, error) {
+	files := filesOrOS(cfg.Files)
+
 	// Write the file first
-	if err := os.WriteFile(cfg.Path, []byte(cfg.Content), 0644); err != nil {
+	if err := files.WriteFile(cfg.Path, []byte(cfg.Content)); err != nil {
 		return nil, false, fmt.Errorf("failed to write %s: %w", cfg.Path, err)
 	}
 
@@ -72,7 +100,7 @@ func WriteWithApproval(cfg WriteConfig, interactive bool) (*WriteResult, bool, e
 			fmt.Fprintf(os.Stderr, "  Edit error: %v\n", err)
 			result.Written = true
 		} else {
-			if err := os.WriteFile(cfg.Path, []byte(edited), 0644); err != nil {
+			if err := files.WriteFile(cfg.Path, []byte(edited)); err != nil {
 				return nil, false, fmt.Errorf("failed to write edited content: %w", err)
 			}
 			result.Written = true
@@ -81,12 +109,12 @@ func WriteWithApproval(cfg WriteConfig, interactive bool) (*WriteResult, bool, e
 		}
 
 	case ActionSkip:
-		os.Remove(cfg.Path)
+		files.Discard(cfg.Path)
 		result.Skipped = true
 		fmt.Fprintf(os.Stderr, "  Skipped: %s\n", cfg.Path)
 
 	case ActionRegenerate:
-		os.Remove(cfg.Path)
+		files.Discard(cfg.Path)
 		return result, true, nil
 
 	case ActionQuit:
@@ -263,7 +291,7 @@ func confirmQuit() bool {
 
 // HandleFileApproval handles approval for an already written file
 // Returns: result, needsRegenerate, error
-func HandleFileApproval(path, phaseName string, itemCount int, itemType, summary string, interactive bool) (*WriteResult, bool, error) {
+func HandleFileApproval(files FileStore, path, phaseName string, itemCount int, itemType, summary string, interactive bool) (*WriteResult, bool, error) {
 	result := &WriteResult{
 		Path: path,
 	}
@@ -274,8 +302,10 @@ func HandleFileApproval(path, phaseName string, itemCount int, itemType, summary
 		return result, false, nil
 	}
 
+	files = filesOrOS(files)
+
 	// Read file content for preview
-	content, err := os.ReadFile(path)
+	content, err := files.ReadFile(path)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to read %s: %w", path, err)
 	}
@@ -305,7 +335,7 @@ func HandleFileApproval(path, phaseName string, itemCount int, itemType, summary
 			fmt.Fprintf(os.Stderr, "  Edit error: %v\n", err)
 			result.Written = true
 		} else {
-			if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+			if err := files.WriteFile(path, []byte(edited)); err != nil {
 				return nil, false, fmt.Errorf("failed to write edited content: %w", err)
 			}
 			result.Written = true
@@ -314,12 +344,12 @@ func HandleFileApproval(path, phaseName string, itemCount int, itemType, summary
 		}
 
 	case ActionSkip:
-		os.Remove(path)
+		files.Discard(path)
 		result.Skipped = true
 		fmt.Fprintf(os.Stderr, "  Skipped: %s\n", path)
 
 	case ActionRegenerate:
-		os.Remove(path)
+		files.Discard(path)
 		return result, true, nil
 
 	case ActionQuit:
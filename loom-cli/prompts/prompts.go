@@ -69,3 +69,6 @@ var DeriveEventDesign string
 
 //go:embed derive-dependency-graph.md
 var DeriveDependencyGraph string
+
+//go:embed interview-followup.md
+var InterviewFollowUp string